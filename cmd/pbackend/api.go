@@ -72,11 +72,6 @@ func api() {
 		}
 	}
 
-	// initialize background goroutines
-	bgCtx, bgCancel := context.WithCancel(ctx)
-	background.InitializeApi(bgCtx)
-	defer bgCancel()
-
 	// initialize job queue
 	err = jq.Initialize(ctx, &logger)
 	if err != nil {
@@ -87,6 +82,11 @@ func api() {
 		jq.StartDequeueLoop(ctx)
 	}
 
+	// initialize background goroutines (the job outbox relay needs the job queue)
+	bgCtx, bgCancel := context.WithCancel(ctx)
+	background.InitializeApi(bgCtx)
+	defer bgCancel()
+
 	// Setup routes
 	rootRouter := chi.NewRouter()
 	apiRouter := chi.NewRouter()
@@ -114,6 +114,9 @@ func api() {
 		Handler: rootRouter,
 	}
 
+	// stop background goroutines when shutting down, this also ends all event streams
+	apiServer.RegisterOnShutdown(bgCancel)
+
 	metricsServer := http.Server{
 		Addr:    fmt.Sprintf(":%d", config.Prometheus.Port),
 		Handler: metricsRouter,
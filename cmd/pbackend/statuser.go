@@ -110,6 +110,7 @@ func processMessage(origCtx context.Context, message *kafka.GenericMessage) {
 	case models.ProviderTypeGCP:
 		chGcp <- s
 	case models.ProviderTypeNoop:
+	case models.ProviderTypeComposite:
 	case models.ProviderTypeUnknown:
 		logger.Warn().Err(err).Msg("Authentication provider type is unknown")
 	}
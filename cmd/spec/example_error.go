@@ -23,6 +23,22 @@ var ResponseBadRequestErrorExample = payloads.ResponseError{
 	BuildTime: "2023-04-14_17:15:02",
 }
 
+var ResponseQuotaExceededErrorExample = payloads.ResponseError{
+	Message:   "quota exceeded",
+	TraceId:   "b57f7b78c",
+	Error:     "maximum of instances launched in the last 24 hours reached: 200",
+	Version:   "df8a489",
+	BuildTime: "2023-04-14_17:15:02",
+}
+
+var ResponseQuotaForbiddenErrorExample = payloads.ResponseError{
+	Message:   "quota exceeded",
+	TraceId:   "b57f7b78c",
+	Error:     "amount of instances is over the maximum per reservation: 50",
+	Version:   "df8a489",
+	BuildTime: "2023-04-14_17:15:02",
+}
+
 var ResponseErrorUserFriendlyExample = payloads.ResponseError{
 	Message:   "vCPU limit reached, contact AWS support",
 	TraceId:   "b57f7b78c",
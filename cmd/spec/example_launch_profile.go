@@ -0,0 +1,36 @@
+package main
+
+import "github.com/RHEnVision/provisioning-backend/internal/payloads"
+
+var LaunchProfileRequest = payloads.LaunchProfileRequest{
+	Name:         "small-us-east",
+	Provider:     "aws",
+	SourceID:     "654321",
+	Region:       "us-east-1",
+	InstanceType: "t3.small",
+	ImageID:      "ami-7846387643232",
+	PubkeyID:     42,
+}
+
+var LaunchProfileResponse = payloads.LaunchProfileResponse{
+	ID:           1,
+	Name:         "small-us-east",
+	Provider:     "aws",
+	SourceID:     "654321",
+	Region:       "us-east-1",
+	InstanceType: "t3.small",
+	ImageID:      "ami-7846387643232",
+	PubkeyID:     42,
+	CreatedAt:    ReservationTime,
+}
+
+var LaunchProfileListResponse = payloads.LaunchProfileListResponse{
+	Data:     []*payloads.LaunchProfileResponse{&LaunchProfileResponse},
+	Metadata: payloads.ListMetadata{Total: 1},
+}
+
+var LaunchProfileReservationRequest = payloads.LaunchProfileReservationRequest{
+	Name:         "my-instance",
+	Amount:       2,
+	InstanceType: "t3.medium",
+}
@@ -17,12 +17,33 @@ var PubkeyResponse = payloads.PubkeyResponse{
 	FingerprintLegacy: "ee:f1:d4:62:99:ab:17:d9:3b:00:66:62:32:b2:55:9e",
 }
 
-var PubkeyListResponse = []payloads.PubkeyResponse{{
-	ID:                1,
-	AccountID:         1,
-	Name:              "My key",
-	Body:              "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIEhnn80ZywmjeBFFOGm+cm+5HUwm62qTVnjKlOdYFLHN lzap",
-	Type:              "ssh-ed25519",
-	Fingerprint:       "gL/y6MvNmJ8jDXtsL/oMmK8jUuIefN39BBuvYw/Rndk=",
-	FingerprintLegacy: "ee:f1:d4:62:99:ab:17:d9:3b:00:66:62:32:b2:55:9e",
-}}
+var PubkeyListResponse = payloads.PubkeyListResponse{
+	Data: []*payloads.PubkeyResponse{{
+		ID:                1,
+		AccountID:         1,
+		Name:              "My key",
+		Body:              "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIEhnn80ZywmjeBFFOGm+cm+5HUwm62qTVnjKlOdYFLHN lzap",
+		Type:              "ssh-ed25519",
+		Fingerprint:       "gL/y6MvNmJ8jDXtsL/oMmK8jUuIefN39BBuvYw/Rndk=",
+		FingerprintLegacy: "ee:f1:d4:62:99:ab:17:d9:3b:00:66:62:32:b2:55:9e",
+	}},
+	Metadata: payloads.ListMetadata{Total: 1},
+}
+
+var PubkeyUpdateRequest = payloads.PubkeyUpdateRequest{
+	Name: "My renamed key",
+}
+
+var PubkeyResourcesResponse = payloads.PubkeyResourcesResponse{
+	Resources: []payloads.PubkeyResourceResponse{{
+		ID:       1,
+		Provider: "aws",
+		SourceID: "1",
+		Region:   "us-east-1",
+		Handle:   "key-0c4e4b4b4b4b4b4b4",
+	}},
+	Reservations: []*payloads.GenericReservationResponsePayload{
+		&GenericReservationResponsePayloadSuccessExample,
+	},
+	Metadata: payloads.ListMetadata{Total: 1},
+}
@@ -32,16 +32,17 @@ var GenericReservationResponsePayloadPendingExample = payloads.GenericReservatio
 }
 
 var GenericReservationResponsePayloadSuccessExample = payloads.GenericReservationResponsePayload{
-	ID:         1305,
-	Provider:   1,
-	CreatedAt:  ReservationTime.Add(-10 * time.Second),
-	Steps:      3,
-	StepTitles: []string{"Ensure public key", "Launch instance(s)", "Fetch instance(s) description"},
-	Step:       3,
-	Status:     "Finished Fetch instance(s) description",
-	Error:      "",
-	FinishedAt: ptr.To(ReservationTime),
-	Success:    ptr.To(true),
+	ID:           1305,
+	Provider:     1,
+	CreatedAt:    ReservationTime.Add(-10 * time.Second),
+	Steps:        3,
+	StepTitles:   []string{"Ensure public key", "Launch instance(s)", "Fetch instance(s) description"},
+	Step:         3,
+	Status:       "Finished Fetch instance(s) description",
+	Error:        "",
+	FinishedAt:   ptr.To(ReservationTime),
+	Success:      ptr.To(true),
+	UserDataHash: "128d46f8539ffd4e363d14e4d0e1e66bae881a389898d3054435231fd65b9e7d",
 }
 
 var GenericReservationResponsePayloadFailureExample = payloads.GenericReservationResponsePayload{
@@ -57,10 +58,49 @@ var GenericReservationResponsePayloadFailureExample = payloads.GenericReservatio
 	Success:    ptr.To(false),
 }
 
-var GenericReservationResponsePayloadListExample = []payloads.GenericReservationResponsePayload{
-	GenericReservationResponsePayloadPendingExample,
-	GenericReservationResponsePayloadSuccessExample,
-	GenericReservationResponsePayloadFailureExample,
+var ReservationTimelineResponseExample = payloads.ReservationTimelineResponse{
+	ReservationID: 1305,
+	CreatedAt:     ReservationTime.Add(-10 * time.Second),
+	FinishedAt:    ptr.To(ReservationTime),
+	Events: []payloads.ReservationEventResponse{
+		{
+			Status:     "Started Ensure public key",
+			Step:       0,
+			CreatedAt:  ReservationTime.Add(-9 * time.Second),
+			DurationMs: ptr.To(int64(1200)),
+		},
+		{
+			Status:     "Finished Ensure public key",
+			Step:       1,
+			CreatedAt:  ReservationTime.Add(-7800 * time.Millisecond),
+			DurationMs: ptr.To(int64(5300)),
+		},
+		{
+			Status:     "Finished Launch instance(s)",
+			Step:       2,
+			CreatedAt:  ReservationTime.Add(-2500 * time.Millisecond),
+			DurationMs: ptr.To(int64(2400)),
+		},
+		{
+			Status:     "Finished Fetch instance(s) description",
+			Step:       3,
+			CreatedAt:  ReservationTime.Add(-100 * time.Millisecond),
+			DurationMs: ptr.To(int64(100)),
+		},
+	},
+}
+
+var GenericReservationResponsePayloadListExample = payloads.ReservationListResponse{
+	Data: []*payloads.GenericReservationResponsePayload{
+		&GenericReservationResponsePayloadSuccessExample,
+		&GenericReservationResponsePayloadPendingExample,
+		&GenericReservationResponsePayloadFailureExample,
+	},
+	Metadata: payloads.ListMetadata{Total: 4},
+	Links: payloads.ListLinks{
+		Next:     "/api/provisioning/v1/reservations?cursor=bmV4dDoxMzEz&limit=3",
+		Previous: "/api/provisioning/v1/reservations?cursor=cHJldjoxMzA1&limit=3",
+	},
 }
 
 var AwsReservationRequestPayloadExample = payloads.AWSReservationRequestPayload{
@@ -150,6 +190,41 @@ var AzureReservationResponsePayloadDoneExample = payloads.AzureReservationRespon
 	}},
 }
 
+var CompositeReservationRequestPayloadExample = payloads.CompositeReservationRequestPayload{
+	Reservations: []payloads.CompositeChildRequestPayload{
+		{AWS: &AwsReservationRequestPayloadExample},
+		{Azure: &AzureReservationRequestPayloadExample},
+	},
+}
+
+var CompositeReservationResponsePayloadExample = payloads.CompositeReservationResponsePayload{
+	ID: 1320,
+	Reservations: []*payloads.GenericReservationResponsePayload{
+		{
+			ID:         1321,
+			Provider:   2,
+			CreatedAt:  ReservationTime.Add(-10 * time.Second),
+			Steps:      3,
+			StepTitles: []string{"Ensure public key", "Launch instance(s)", "Fetch instance(s) description"},
+			Status:     "Created",
+			ParentID:   ptr.To(int64(1320)),
+		},
+		{
+			ID:         1322,
+			Provider:   3,
+			CreatedAt:  ReservationTime.Add(-10 * time.Second),
+			Steps:      2,
+			StepTitles: []string{"Prepare resource group", "Launch instance(s)"},
+			Status:     "Created",
+			ParentID:   ptr.To(int64(1320)),
+		},
+	},
+}
+
 var NoopReservationResponsePayloadExample = payloads.NoopReservationResponsePayload{
 	ID: 1310,
 }
+
+var InstancePowerRequestPayloadExample = payloads.InstancePowerRequestPayload{
+	Action: "reboot",
+}
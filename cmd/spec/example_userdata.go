@@ -0,0 +1,9 @@
+package main
+
+import "github.com/RHEnVision/provisioning-backend/internal/payloads"
+
+var UserDataPreviewResponse = payloads.UserDataPreviewResponse{
+	Provider: "aws",
+	Hash:     "8a0c1f3a9d7be48f9e1e2b6b3fd2d8e4a1f7c9b5a2f1d0e3c4b5a6978877665a",
+	UserData: "#cloud-config\nruncmd:\n- [ \"rhc\", \"connect\", \"--activation-key\", \"lab-key\", \"--organization\", \"5318290\" ]\n",
+}
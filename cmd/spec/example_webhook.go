@@ -0,0 +1,39 @@
+package main
+
+import "github.com/RHEnVision/provisioning-backend/internal/payloads"
+
+var WebhookRequest = payloads.WebhookRequest{
+	URL: "https://example.com/provisioning/hook",
+}
+
+var WebhookResponse = payloads.WebhookResponse{
+	ID:        1,
+	URL:       "https://example.com/provisioning/hook",
+	Enabled:   true,
+	CreatedAt: ReservationTime,
+	Secret:    "9f3c0a8e5d2b47f1a6c4e8d0b3f5a7c9e1d3b5f7a9c1e3d5b7f9a1c3e5d7b9f1",
+}
+
+var WebhookListResponse = payloads.WebhookListResponse{
+	Data: []*payloads.WebhookResponse{{
+		ID:        1,
+		URL:       "https://example.com/provisioning/hook",
+		Enabled:   true,
+		CreatedAt: ReservationTime,
+	}},
+	Metadata: payloads.ListMetadata{Total: 1},
+}
+
+var WebhookDeliveryListResponse = payloads.WebhookDeliveryListResponse{
+	Data: []*payloads.WebhookDeliveryResponse{{
+		ID:            1,
+		ReservationID: 1310,
+		Event:         "finished",
+		Attempt:       1,
+		StatusCode:    503,
+		Success:       false,
+		Error:         "webhook responded with unexpected status code: 503",
+		CreatedAt:     ReservationTime,
+	}},
+	Metadata: payloads.ListMetadata{Total: 1},
+}
@@ -17,24 +17,43 @@ import (
 func addPayloads(gen *APISchemaGen) {
 	gen.addSchema("v1.PubkeyRequest", &payloads.PubkeyRequest{})
 	gen.addSchema("v1.PubkeyResponse", &payloads.PubkeyResponse{})
+	gen.addSchema("v1.PubkeyListResponse", &payloads.PubkeyListResponse{})
+	gen.addSchema("v1.PubkeyUpdateRequest", &payloads.PubkeyUpdateRequest{})
+	gen.addSchema("v1.PubkeyResourcesResponse", &payloads.PubkeyResourcesResponse{})
 	gen.addSchema("v1.SourceResponse", &payloads.SourceResponse{})
 	gen.addSchema("v1.InstanceTypeResponse", &payloads.InstanceTypeResponse{})
 	gen.addSchema("v1.GenericReservationResponsePayload", &payloads.GenericReservationResponsePayload{})
+	gen.addSchema("v1.ReservationListResponse", &payloads.ReservationListResponse{})
+	gen.addSchema("v1.ReservationTimelineResponse", &payloads.ReservationTimelineResponse{})
+	gen.addSchema("v1.InstancePowerRequest", &payloads.InstancePowerRequestPayload{})
 	gen.addSchema("v1.NoopReservationResponse", &payloads.NoopReservationResponsePayload{})
 	gen.addSchema("v1.AWSReservationRequest", &payloads.AWSReservationRequestPayload{})
 	gen.addSchema("v1.AWSReservationResponse", &payloads.AWSReservationResponsePayload{})
 	gen.addSchema("v1.AzureReservationRequest", &payloads.AzureReservationRequestPayload{})
 	gen.addSchema("v1.AzureReservationResponse", &payloads.AzureReservationResponsePayload{})
+	gen.addSchema("v1.CompositeReservationRequest", &payloads.CompositeReservationRequestPayload{})
+	gen.addSchema("v1.CompositeReservationResponse", &payloads.CompositeReservationResponsePayload{})
 	gen.addSchema("v1.AvailabilityStatusRequest", &payloads.AvailabilityStatusRequest{})
 	gen.addSchema("v1.AccountIDTypeResponse", &payloads.AccountIdentityResponse{})
 	gen.addSchema("v1.SourceUploadInfoResponse", &payloads.SourceUploadInfoResponse{})
 	gen.addSchema("v1.LaunchTemplatesResponse", &payloads.LaunchTemplateResponse{})
+	gen.addSchema("v1.WebhookRequest", &payloads.WebhookRequest{})
+	gen.addSchema("v1.WebhookResponse", &payloads.WebhookResponse{})
+	gen.addSchema("v1.WebhookListResponse", &payloads.WebhookListResponse{})
+	gen.addSchema("v1.WebhookDeliveryListResponse", &payloads.WebhookDeliveryListResponse{})
+	gen.addSchema("v1.LaunchProfileRequest", &payloads.LaunchProfileRequest{})
+	gen.addSchema("v1.LaunchProfileResponse", &payloads.LaunchProfileResponse{})
+	gen.addSchema("v1.LaunchProfileListResponse", &payloads.LaunchProfileListResponse{})
+	gen.addSchema("v1.LaunchProfileReservationRequest", &payloads.LaunchProfileReservationRequest{})
+	gen.addSchema("v1.UserDataPreviewResponse", &payloads.UserDataPreviewResponse{})
 }
 
 func addExamples(gen *APISchemaGen) {
 	gen.addExample("v1.PubkeyRequestExample", PubkeyRequest)
 	gen.addExample("v1.PubkeyResponseExample", PubkeyResponse)
 	gen.addExample("v1.PubkeyListResponseExample", PubkeyListResponse)
+	gen.addExample("v1.PubkeyUpdateRequestExample", PubkeyUpdateRequest)
+	gen.addExample("v1.PubkeyResourcesResponseExample", PubkeyResourcesResponse)
 	gen.addExample("v1.SourceListResponseExample", SourceListResponse)
 	gen.addExample("v1.SourceUploadInfoAWSResponse", SourceUploadInfoAWSResponse)
 	gen.addExample("v1.SourceUploadInfoAzureResponse", SourceUploadInfoAzureResponse)
@@ -44,13 +63,26 @@ func addExamples(gen *APISchemaGen) {
 	gen.addExample("v1.GenericReservationResponsePayloadPendingExample", GenericReservationResponsePayloadPendingExample)
 	gen.addExample("v1.GenericReservationResponsePayloadFailureExample", GenericReservationResponsePayloadFailureExample)
 	gen.addExample("v1.GenericReservationResponsePayloadListExample", GenericReservationResponsePayloadListExample)
+	gen.addExample("v1.ReservationTimelineResponseExample", ReservationTimelineResponseExample)
 	gen.addExample("v1.AwsReservationRequestPayloadExample", AwsReservationRequestPayloadExample)
 	gen.addExample("v1.AwsReservationResponsePayloadPendingExample", AwsReservationResponsePayloadPendingExample)
 	gen.addExample("v1.AwsReservationResponsePayloadDoneExample", AwsReservationResponsePayloadDoneExample)
 	gen.addExample("v1.AzureReservationRequestPayloadExample", AzureReservationRequestPayloadExample)
 	gen.addExample("v1.AzureReservationResponsePayloadPendingExample", AzureReservationResponsePayloadPendingExample)
 	gen.addExample("v1.AzureReservationResponsePayloadDoneExample", AzureReservationResponsePayloadDoneExample)
+	gen.addExample("v1.CompositeReservationRequestPayloadExample", CompositeReservationRequestPayloadExample)
+	gen.addExample("v1.CompositeReservationResponsePayloadExample", CompositeReservationResponsePayloadExample)
 	gen.addExample("v1.NoopReservationResponsePayloadExample", NoopReservationResponsePayloadExample)
+	gen.addExample("v1.InstancePowerRequestPayloadExample", InstancePowerRequestPayloadExample)
+	gen.addExample("v1.WebhookRequestExample", WebhookRequest)
+	gen.addExample("v1.WebhookResponseExample", WebhookResponse)
+	gen.addExample("v1.WebhookListResponseExample", WebhookListResponse)
+	gen.addExample("v1.WebhookDeliveryListResponseExample", WebhookDeliveryListResponse)
+	gen.addExample("v1.LaunchProfileRequestExample", LaunchProfileRequest)
+	gen.addExample("v1.LaunchProfileResponseExample", LaunchProfileResponse)
+	gen.addExample("v1.LaunchProfileListResponseExample", LaunchProfileListResponse)
+	gen.addExample("v1.LaunchProfileReservationRequestExample", LaunchProfileReservationRequest)
+	gen.addExample("v1.UserDataPreviewResponseExample", UserDataPreviewResponse)
 
 	gen.addExample("v1.InstanceTypesAWSResponse", InstanceTypesAWSResponse)
 	gen.addExample("v1.InstanceTypesAzureResponse", InstanceTypesAzureResponse)
@@ -65,6 +97,8 @@ func addErrorSchemas(gen *APISchemaGen) {
 	gen.addResponse("NotFound", "The requested resource was not found", "#/components/schemas/v1.ResponseError", ResponseNotFoundErrorExample)
 	gen.addResponse("InternalError", "The server encountered an internal error", "#/components/schemas/v1.ResponseError", ResponseErrorGenericExample)
 	gen.addResponse("BadRequest", "The request's parameters are not valid", "#/components/schemas/v1.ResponseError", ResponseBadRequestErrorExample)
+	gen.addResponse("QuotaForbidden", "The request is over a launch limit of the account", "#/components/schemas/v1.ResponseError", ResponseQuotaForbiddenErrorExample)
+	gen.addResponse("QuotaExceeded", "A launch limit of the account was reached, try again later", "#/components/schemas/v1.ResponseError", ResponseQuotaExceededErrorExample)
 }
 
 type APISchemaGen struct {
@@ -18,37 +18,43 @@ func EnqueueAvailabilityStatusRequest(msg *kafka.GenericMessage) {
 	kafkaAvailabilityRequest <- msg
 }
 
-// send a message to the background worker for availability check
-func send(ctx context.Context, messages ...*kafka.GenericMessage) {
+// send messages to the kafka, errors are only logged
+func send(ctx context.Context, name string, messages ...*kafka.GenericMessage) {
 	err := kafka.Send(ctx, messages...)
 	if err != nil {
-		zerolog.Ctx(ctx).Warn().Err(err).Msg("Unable to send availability check messages")
+		zerolog.Ctx(ctx).Warn().Err(err).Msgf("Unable to send %s messages", name)
 	}
 }
 
 // main sending loop: takes messages enqueued via EnqueueAvailabilityStatusRequest and sends them to the kafka
 func sendAvailabilityRequestMessages(ctx context.Context, batchSize int, tickDuration time.Duration) {
+	sendMessageBatches(ctx, kafkaAvailabilityRequest, "availability request", batchSize, tickDuration)
+}
+
+// sendMessageBatches takes messages from the queue and sends them to the kafka in batches,
+// all messages in the queue must be of the same topic
+func sendMessageBatches(ctx context.Context, queue <-chan *kafka.GenericMessage, name string, batchSize int, tickDuration time.Duration) {
 	logger := zerolog.Ctx(ctx)
 	ticker := time.NewTicker(tickDuration)
 	messageBuffer := make([]*kafka.GenericMessage, 0, batchSize)
 
 	for {
 		select {
-		case msg := <-kafkaAvailabilityRequest:
+		case msg := <-queue:
 			messageBuffer = append(messageBuffer, msg)
 			length := len(messageBuffer)
 
 			if length >= batchSize {
-				logger.Trace().Int("messages", length).Msgf("Sending %d availability request messages (full buffer)", length)
-				send(ctx, messageBuffer...)
+				logger.Trace().Int("messages", length).Msgf("Sending %d %s messages (full buffer)", length, name)
+				send(ctx, name, messageBuffer...)
 				messageBuffer = messageBuffer[:0]
 			}
 		case <-ticker.C:
 			length := len(messageBuffer)
 
 			if length > 0 {
-				logger.Trace().Int("messages", length).Msgf("Sending %d availability request messages (tick)", length)
-				send(ctx, messageBuffer...)
+				logger.Trace().Int("messages", length).Msgf("Sending %d %s messages (tick)", length, name)
+				send(ctx, name, messageBuffer...)
 				messageBuffer = messageBuffer[:0]
 			}
 		case <-ctx.Done():
@@ -56,8 +62,8 @@ func sendAvailabilityRequestMessages(ctx context.Context, batchSize int, tickDur
 			length := len(messageBuffer)
 
 			if length > 0 {
-				logger.Trace().Int("messages", length).Msgf("Sending %d availability request messages (cancel)", length)
-				send(ctx, messageBuffer...)
+				logger.Trace().Int("messages", length).Msgf("Sending %d %s messages (cancel)", length, name)
+				send(ctx, name, messageBuffer...)
 			}
 
 			return
@@ -14,6 +14,22 @@ import (
 // incoming requests to overload the sender.
 const availabilityStatusBatchSize = 1024
 
+// Maximum batch size of reservation status messages.
+const reservationStatusBatchSize = 64
+
+// Maximum number of stale reservations marked as failed in a single reaper run.
+const staleReservationBatchSize = 100
+
+// Maximum number of jobs published from the job outbox in a single transaction.
+const outboxBatchSize = 100
+
+// Maximum number of attempts to publish a job from the job outbox before it is marked dead.
+const outboxMaxAttempts = 10
+
+// Maximum time to enqueue a single job from the job outbox, the outbox transaction is open
+// while jobs are being enqueued.
+const outboxEnqueueTimeout = 10 * time.Second
+
 // InitializeApi starts background goroutines for REST API processes.
 // Use context cancellation to stop them.
 func InitializeApi(ctx context.Context) {
@@ -22,6 +38,15 @@ func InitializeApi(ctx context.Context) {
 
 	// start availability request batch sender
 	go sendAvailabilityRequestMessages(ctx, availabilityStatusBatchSize, 5*time.Second)
+
+	// start reservation status batch sender (jobs can be processed in API processes)
+	go sendReservationStatusMessages(ctx, reservationStatusBatchSize, time.Second)
+
+	// start job outbox relay
+	go outboxRelayLoop(ctx, time.Second, outboxBatchSize, outboxMaxAttempts)
+
+	// start reservation change listener for server-sent events
+	go reservationChangesLoop(ctx, 5*time.Second)
 }
 
 // InitializeWorker starts background goroutines for worker processes.
@@ -30,6 +55,17 @@ func InitializeWorker(ctx context.Context) {
 	logger := zerolog.Ctx(ctx).With().Bool("background", true).Logger()
 	ctx = logger.WithContext(ctx)
 
+	// start reservation status batch sender
+	go sendReservationStatusMessages(ctx, reservationStatusBatchSize, time.Second)
+
 	// start job queue telemetry
 	go jobQueueMetricLoop(ctx, 30*time.Second, config.Hostname())
+
+	// start stale reservation reaper
+	go staleReaperLoop(ctx, 10*time.Minute, config.Worker.StaleThreshold, staleReservationBatchSize)
+
+	// start reservation retention cleanup scheduler
+	if config.Retention.Days > 0 {
+		go retentionScheduleLoop(ctx, config.Retention.Interval)
+	}
 }
@@ -0,0 +1,76 @@
+package background
+
+import (
+	"context"
+	"time"
+
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
+	"github.com/RHEnVision/provisioning-backend/internal/metrics"
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/RHEnVision/provisioning-backend/internal/queue"
+	"github.com/RHEnVision/provisioning-backend/pkg/worker"
+	"github.com/rs/zerolog"
+)
+
+// outboxRelayLoop is a background function that publishes jobs stored in the job outbox to the
+// job queue. It runs in all API processes, jobs are locked so each job is published by one relay.
+func outboxRelayLoop(ctx context.Context, sleep time.Duration, batchSize int64, maxAttempts int) {
+	logger := zerolog.Ctx(ctx)
+	ticker := time.NewTicker(sleep)
+
+	for {
+		select {
+		case <-ticker.C:
+			// publish until the outbox is drained
+			published := batchSize
+			for published == batchSize {
+				published = relayOutbox(ctx, batchSize, maxAttempts)
+			}
+
+			size, dead, lag, err := dao.GetOutboxDao(ctx).Stats(ctx)
+			if err != nil {
+				logger.Warn().Err(err).Msg("Unable to get job outbox stats")
+				continue
+			}
+			metrics.SetOutboxStats(size, dead, lag)
+
+		case <-ctx.Done():
+			ticker.Stop()
+			logger.Debug().Msg("Stopping job outbox relay loop")
+			return
+		}
+	}
+}
+
+// relayOutbox publishes one batch of jobs and returns the number of published jobs.
+func relayOutbox(ctx context.Context, batchSize int64, maxAttempts int) int64 {
+	logger := zerolog.Ctx(ctx)
+
+	published, err := dao.GetOutboxDao(ctx).Publish(ctx, batchSize, maxAttempts, func(outboxJob *models.OutboxJob) error {
+		job, err := worker.DecodeJob(outboxJob.Payload)
+		if err != nil {
+			metrics.IncOutboxPublished("failure")
+			return err
+		}
+
+		eCtx, cancel := context.WithTimeout(ctx, outboxEnqueueTimeout)
+		defer cancel()
+		err = queue.GetEnqueuer(eCtx).Enqueue(eCtx, job)
+		if err != nil {
+			metrics.IncOutboxPublished("failure")
+			return err
+		}
+
+		metrics.IncOutboxPublished("success")
+		return nil
+	})
+	if err != nil {
+		logger.Warn().Err(err).Msg("Unable to publish jobs from job outbox")
+		return 0
+	}
+
+	if published > 0 {
+		logger.Debug().Msgf("Published %d job(s) from job outbox", published)
+	}
+	return int64(published)
+}
@@ -31,6 +31,9 @@ func jobQueueMetricLoop(ctx context.Context, sleep time.Duration, name string) {
 			stats := jq.Stats(ctx)
 			metrics.SetJobQueueSize(stats.EnqueuedJobs)
 			metrics.SetJobQueueInFlight(name, stats.InFlight)
+			metrics.SetJobQueueRetrySize(stats.RetryingJobs)
+			metrics.SetJobQueueScheduledSize(stats.ScheduledJobs)
+			metrics.SetJobQueueDeadLetterSize(stats.DeadLetterJobs)
 
 		case <-ctx.Done():
 			ticker.Stop()
@@ -0,0 +1,115 @@
+package background
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/RHEnVision/provisioning-backend/internal/db"
+	"github.com/rs/zerolog"
+)
+
+// Database notification channel, see migration 025.
+const reservationChangesChannel = "reservation_changes"
+
+type changeSubscribers struct {
+	mu       sync.Mutex
+	channels map[int64]map[chan struct{}]struct{}
+	closed   bool
+}
+
+var reservationSubscribers = newChangeSubscribers()
+
+func newChangeSubscribers() *changeSubscribers {
+	return &changeSubscribers{channels: make(map[int64]map[chan struct{}]struct{})}
+}
+
+// SubscribeReservationChanges returns a channel which receives a value when the reservation
+// or its instances change in any process. Multiple changes can be merged into one value. The
+// channel is closed when the process is shutting down. Call the returned function to unsubscribe.
+func SubscribeReservationChanges(id int64) (<-chan struct{}, func()) {
+	return reservationSubscribers.subscribe(id)
+}
+
+func (s *changeSubscribers) subscribe(id int64) (<-chan struct{}, func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch := make(chan struct{}, 1)
+	if s.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	if s.channels[id] == nil {
+		s.channels[id] = make(map[chan struct{}]struct{})
+	}
+	s.channels[id][ch] = struct{}{}
+
+	return ch, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if _, ok := s.channels[id][ch]; !ok {
+			return
+		}
+		delete(s.channels[id], ch)
+		if len(s.channels[id]) == 0 {
+			delete(s.channels, id)
+		}
+	}
+}
+
+func (s *changeSubscribers) notify(id int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for ch := range s.channels[id] {
+		// a change is already pending when the channel is full
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (s *changeSubscribers) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, chans := range s.channels {
+		for ch := range chans {
+			close(ch)
+		}
+	}
+	s.channels = make(map[int64]map[chan struct{}]struct{})
+	s.closed = true
+}
+
+// reservationChangesLoop is a background function that listens for reservation change
+// notifications from the database and forwards them to subscribers in this process.
+func reservationChangesLoop(ctx context.Context, retryDelay time.Duration) {
+	logger := zerolog.Ctx(ctx)
+	defer reservationSubscribers.close()
+
+	for {
+		err := db.Listen(ctx, reservationChangesChannel, func(payload string) {
+			id, parseErr := strconv.ParseInt(payload, 10, 64)
+			if parseErr != nil {
+				logger.Warn().Err(parseErr).Msgf("Invalid reservation change notification: %s", payload)
+				return
+			}
+			reservationSubscribers.notify(id)
+		})
+		if err != nil {
+			logger.Warn().Err(err).Msgf("Unable to listen for reservation changes, retrying in %s", retryDelay)
+		}
+
+		select {
+		case <-time.After(retryDelay):
+		case <-ctx.Done():
+			logger.Debug().Msg("Stopping reservation changes loop")
+			return
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package background
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangeSubscribers(t *testing.T) {
+	subscribers := newChangeSubscribers()
+	ch, unsubscribe := subscribers.subscribe(1)
+	other, unsubscribeOther := subscribers.subscribe(2)
+	defer unsubscribeOther()
+
+	// multiple changes are merged
+	subscribers.notify(1)
+	subscribers.notify(1)
+	require.Len(t, ch, 1)
+	assert.Empty(t, other)
+	<-ch
+
+	unsubscribe()
+	subscribers.notify(1)
+	assert.Empty(t, ch)
+
+	subscribers.close()
+	_, ok := <-other
+	assert.False(t, ok, "channel must be closed")
+
+	closed, _ := subscribers.subscribe(3)
+	_, ok = <-closed
+	assert.False(t, ok, "channel must be closed")
+}
@@ -0,0 +1,14 @@
+package background
+
+import (
+	"context"
+	"time"
+
+	"github.com/RHEnVision/provisioning-backend/internal/kafka"
+)
+
+// sendReservationStatusMessages takes messages enqueued via kafka.EnqueueReservationStatus and
+// sends them to the kafka
+func sendReservationStatusMessages(ctx context.Context, batchSize int, tickDuration time.Duration) {
+	sendMessageBatches(ctx, kafka.ReservationStatusQueue(), "reservation status", batchSize, tickDuration)
+}
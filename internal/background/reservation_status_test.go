@@ -0,0 +1,34 @@
+package background
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RHEnVision/provisioning-backend/internal/kafka"
+	"github.com/RHEnVision/provisioning-backend/internal/testing/identity"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReservationStatusSend(t *testing.T) {
+	ctx := context.Background()
+	ctx = identity.WithIdentity(t, ctx)
+	_ = kafka.InitializeStubBroker(16)
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	cct, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go sendReservationStatusMessages(cct, 8, 10*time.Millisecond)
+	go kafka.Consume(cct, kafka.ReservationStatusTopic, time.Now(), func(ctx context.Context, msg *kafka.GenericMessage) {
+		rsm, _ := kafka.NewReservationStatusMessage(msg)
+		require.EqualValues(t, 42, rsm.ReservationID)
+		require.Equal(t, "42", string(msg.Key))
+		require.Equal(t, "reservation_status", msg.Header("event_type"))
+		wg.Done()
+	})
+
+	kafka.EnqueueReservationStatus(ctx, kafka.ReservationStatusMessage{ReservationID: 42, Event: kafka.ReservationFinished})
+	wg.Wait()
+}
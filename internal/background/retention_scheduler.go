@@ -0,0 +1,48 @@
+package background
+
+import (
+	"context"
+	"time"
+
+	"github.com/RHEnVision/provisioning-backend/internal/config"
+	"github.com/RHEnVision/provisioning-backend/internal/jobs"
+	"github.com/RHEnVision/provisioning-backend/internal/queue"
+	"github.com/RHEnVision/provisioning-backend/pkg/worker"
+	"github.com/rs/zerolog"
+)
+
+// retentionScheduleLoop is a background function that periodically enqueues the reservation
+// cleanup job according to the retention policy. The job is idempotent, when it is enqueued by
+// multiple workers, reservations are deleted only once.
+func retentionScheduleLoop(ctx context.Context, sleep time.Duration) {
+	logger := zerolog.Ctx(ctx)
+	ticker := time.NewTicker(sleep)
+
+	for {
+		select {
+		case <-ticker.C:
+			job := newCleanupJob()
+			err := queue.GetEnqueuer(ctx).Enqueue(ctx, job)
+			if err != nil {
+				logger.Warn().Err(err).Msg("Unable to enqueue reservation cleanup job")
+			}
+
+		case <-ctx.Done():
+			ticker.Stop()
+			logger.Debug().Msg("Stopping reservation retention schedule loop")
+			return
+		}
+	}
+}
+
+func newCleanupJob() *worker.Job {
+	return &worker.Job{
+		Type: jobs.TypeCleanupReservations,
+		Args: jobs.CleanupReservationsTaskArgs{
+			Retention: time.Duration(config.Retention.Days) * 24 * time.Hour,
+			BatchSize: config.Retention.BatchSize,
+			Archive:   config.Retention.Archive,
+			DryRun:    config.Retention.DryRun,
+		},
+	}
+}
@@ -0,0 +1,92 @@
+package background
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
+	"github.com/RHEnVision/provisioning-backend/internal/jobs"
+	"github.com/RHEnVision/provisioning-backend/internal/metrics"
+	"github.com/rs/zerolog"
+)
+
+// staleReaperLoop is a background function that runs for all workers. It periodically marks
+// reservations which were not finished within the threshold as failed. Those are reservations
+// which job was lost, for example because the worker process was killed. Instances teardowns
+// which were not finished within the threshold are marked as failed too.
+//
+//nolint:gosec
+func staleReaperLoop(ctx context.Context, sleep, threshold time.Duration, batchSize int64) {
+	logger := zerolog.Ctx(ctx)
+
+	// spread polling intervals
+	randSleep := rand.Int63() % sleep.Milliseconds()
+	logger.Debug().Msgf("Stale reservation reaper delay %dms", randSleep)
+	time.Sleep(time.Duration(randSleep) * time.Millisecond)
+	ticker := time.NewTicker(sleep)
+
+	for {
+		select {
+		case <-ticker.C:
+			reapStaleReservations(ctx, threshold, batchSize)
+			reapStaleTeardowns(ctx, threshold)
+
+		case <-ctx.Done():
+			ticker.Stop()
+			logger.Debug().Msg("Stopping stale reservation reaper loop")
+			return
+		}
+	}
+}
+
+// reapStaleReservations marks up to batchSize stale reservations as failed and returns the count.
+// Reservations are finished the same way as failed jobs, so the failure is published.
+func reapStaleReservations(ctx context.Context, threshold time.Duration, batchSize int64) int {
+	logger := zerolog.Ctx(ctx)
+	rDao := dao.GetReservationDao(ctx)
+
+	reservations, err := rDao.UnscopedListStale(ctx, threshold, batchSize)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Unable to list stale reservations")
+		return 0
+	}
+
+	reaped := 0
+	staleErr := fmt.Errorf("%w: reservation was not finished within %s", jobs.ErrReservationLost, threshold)
+	for _, reservation := range reservations {
+		rLogger := logger.With().Int64("reservation_id", reservation.ID).Logger()
+		finished, fErr := jobs.FinishStaleReservation(rLogger.WithContext(ctx), reservation, staleErr)
+		if fErr != nil {
+			rLogger.Warn().Err(fErr).Msg("Unable to finish stale reservation")
+			continue
+		}
+		if !finished {
+			continue
+		}
+
+		rLogger.Warn().Msgf("Marked stale reservation %d as failed", reservation.ID)
+		metrics.IncStaleReservationCount(reservation.Provider.String())
+		reaped++
+	}
+
+	return reaped
+}
+
+// reapStaleTeardowns marks teardowns which job was lost as failed and returns the count. The
+// launch outcome of those reservations is not changed, so nothing is published.
+func reapStaleTeardowns(ctx context.Context, threshold time.Duration) int64 {
+	logger := zerolog.Ctx(ctx)
+
+	staleErr := fmt.Errorf("%w: instances termination was not finished within %s", jobs.ErrReservationLost, threshold)
+	count, err := dao.GetReservationDao(ctx).UnscopedFinishStaleTeardowns(ctx, threshold, jobs.TeardownFailedStatus, staleErr.Error())
+	if err != nil {
+		logger.Warn().Err(err).Msg("Unable to finish stale teardowns")
+		return 0
+	}
+	if count > 0 {
+		logger.Warn().Msgf("Marked %d stale teardown(s) as failed", count)
+	}
+	return count
+}
@@ -0,0 +1,105 @@
+package background
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
+	"github.com/RHEnVision/provisioning-backend/internal/dao/stubs"
+	"github.com/RHEnVision/provisioning-backend/internal/jobs"
+	"github.com/RHEnVision/provisioning-backend/internal/kafka"
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/RHEnVision/provisioning-backend/internal/queue/stub"
+	"github.com/RHEnVision/provisioning-backend/internal/testing/identity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReapStaleReservations(t *testing.T) {
+	// the reaper runs without identity
+	ctx := stubs.WithAccountDaoOne(context.Background())
+	ctx = stubs.WithReservationDao(ctx)
+	ctx = stubs.WithWebhookDao(ctx)
+	ctx = stub.WithEnqueuer(ctx)
+	tenantCtx := identity.WithTenant(t, ctx)
+
+	// drain messages of other tests
+	for len(kafka.ReservationStatusQueue()) > 0 {
+		<-kafka.ReservationStatusQueue()
+	}
+
+	stale := &models.AWSReservation{Detail: &models.AWSDetail{}}
+	stale.AccountID = 1
+	stale.Provider = models.ProviderTypeAWS
+	stale.CreatedAt = time.Now().Add(-3 * time.Hour)
+	require.NoError(t, stubs.AddAWSReservation(tenantCtx, stale))
+
+	fresh := &models.AWSReservation{Detail: &models.AWSDetail{}}
+	fresh.AccountID = 1
+	fresh.Provider = models.ProviderTypeAWS
+	fresh.CreatedAt = time.Now()
+	require.NoError(t, stubs.AddAWSReservation(tenantCtx, fresh))
+
+	reaped := reapStaleReservations(ctx, 2*time.Hour, 100)
+	require.Equal(t, 1, reaped)
+	require.True(t, stale.FinishedAt.Valid)
+	require.False(t, stale.Success.Bool)
+	require.Contains(t, stale.Error, "was not finished within 2h0m0s")
+	require.False(t, fresh.FinishedAt.Valid)
+
+	events, err := dao.GetReservationDao(ctx).ListEvents(ctx, stale.ID)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "Lost", events[0].Status)
+
+	require.Len(t, kafka.ReservationStatusQueue(), 1)
+	msg, err := kafka.NewReservationStatusMessage(<-kafka.ReservationStatusQueue())
+	require.NoError(t, err)
+	assert.Equal(t, kafka.ReservationFailed, msg.Event)
+	assert.Equal(t, stale.ID, msg.ReservationID)
+	assert.Equal(t, identity.DefaultOrgId, msg.OrgID)
+
+	reaped = reapStaleReservations(ctx, 2*time.Hour, 100)
+	require.Equal(t, 0, reaped)
+
+	t.Run("Finished meanwhile", func(t *testing.T) {
+		finished, err := jobs.FinishStaleReservation(ctx, &stale.Reservation, errors.New("late"))
+		require.NoError(t, err)
+		assert.False(t, finished)
+		assert.Contains(t, stale.Error, "was not finished within 2h0m0s")
+		assert.Empty(t, kafka.ReservationStatusQueue())
+	})
+}
+
+func TestReapStaleTeardowns(t *testing.T) {
+	ctx := stubs.WithAccountDaoOne(context.Background())
+	ctx = stubs.WithReservationDao(ctx)
+	tenantCtx := identity.WithTenant(t, ctx)
+
+	stale := &models.AWSReservation{Detail: &models.AWSDetail{}}
+	stale.AccountID = 1
+	stale.Provider = models.ProviderTypeAWS
+	stale.Success = sql.NullBool{Bool: true, Valid: true}
+	stale.FinishedAt = sql.NullTime{Time: time.Now().Add(-4 * time.Hour), Valid: true}
+	stale.TeardownQueuedAt = sql.NullTime{Time: time.Now().Add(-3 * time.Hour), Valid: true}
+	require.NoError(t, stubs.AddAWSReservation(tenantCtx, stale))
+
+	fresh := &models.AWSReservation{Detail: &models.AWSDetail{}}
+	fresh.AccountID = 1
+	fresh.Provider = models.ProviderTypeAWS
+	fresh.FinishedAt = sql.NullTime{Time: time.Now().Add(-4 * time.Hour), Valid: true}
+	fresh.TeardownQueuedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	require.NoError(t, stubs.AddAWSReservation(tenantCtx, fresh))
+
+	require.Equal(t, int64(1), reapStaleTeardowns(ctx, 2*time.Hour))
+	assert.False(t, stale.TeardownQueuedAt.Valid)
+	assert.Equal(t, jobs.TeardownFailedStatus, stale.Status)
+	assert.Contains(t, stale.TeardownError, "was not finished within 2h0m0s")
+	assert.True(t, stale.Success.Bool, "launch outcome must not change")
+	assert.True(t, fresh.TeardownQueuedAt.Valid)
+
+	require.Zero(t, reapStaleTeardowns(ctx, 2*time.Hour))
+}
@@ -6,7 +6,9 @@ package tests
 import (
 	"context"
 	"database/sql"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/RHEnVision/provisioning-backend/internal/cache"
 	"github.com/RHEnVision/provisioning-backend/internal/clients"
@@ -58,3 +60,19 @@ func TestPrefix(t *testing.T) {
 
 	require.Equal(t, value1, result)
 }
+
+func TestTakeToken(t *testing.T) {
+	ctx := context.Background()
+	key := "bucket-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+
+	for i := 0; i < 3; i++ {
+		wait, err := cache.TakeToken(ctx, key, 1, 3)
+		require.NoError(t, err)
+		require.Zero(t, wait, "token %d must be available in the burst", i)
+	}
+
+	wait, err := cache.TakeToken(ctx, key, 1, 3)
+	require.NoError(t, err)
+	require.Greater(t, wait, time.Duration(0))
+	require.LessOrEqual(t, wait, time.Second)
+}
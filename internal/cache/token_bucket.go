@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const tokenBucketPrefix = "token-bucket-"
+
+// tokenBucketScript refills the bucket by the elapsed time, takes one token when available
+// and returns amount of milliseconds the caller must wait for the next token (0 when a token
+// was taken). The bucket is stored as a hash with token count and last refill time.
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil or ts == nil then
+  tokens = burst
+  ts = now
+end
+
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000)
+local wait = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+else
+  wait = math.ceil((1 - tokens) * 1000 / rate)
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", tostring(now))
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return wait
+`)
+
+// TakeToken takes a single token from a token bucket with the given key. The bucket holds up
+// to burst tokens and it is refilled by rate tokens per second. Returns zero when a token was
+// taken, or duration until the next token is available. When Redis is disabled, a token is
+// always taken.
+func TakeToken(ctx context.Context, key string, rate float64, burst int) (time.Duration, error) {
+	if !redisEnabled {
+		return 0, nil
+	}
+
+	now := time.Now().UnixMilli()
+	args := []any{strconv.FormatFloat(rate, 'f', -1, 64), burst, now}
+	wait, err := tokenBucketScript.Run(ctx, client, []string{tokenBucketPrefix + key}, args...).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("redis token bucket error: %w", err)
+	}
+
+	return time.Duration(wait) * time.Millisecond, nil
+}
@@ -0,0 +1,66 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+)
+
+func (c *client) DeleteVMs(ctx context.Context, vmIds []string) error {
+	ctx, span := otel.Tracer(TraceName).Start(ctx, "DeleteVMs")
+	defer span.End()
+
+	logger := logger(ctx)
+	logger.Debug().Msgf("Started deleting %d Azure VM instances", len(vmIds))
+
+	vmClient, err := c.newVirtualMachinesClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	resumeTokens := make([]string, len(vmIds))
+	for i, vmId := range vmIds {
+		resourceID, err := arm.ParseResourceID(vmId)
+		if err != nil {
+			span.SetStatus(codes.Error, "cannot parse Azure instance id")
+			return fmt.Errorf("cannot parse Azure instance id %s: %w", vmId, err)
+		}
+
+		poller, err := vmClient.BeginDelete(ctx, resourceID.ResourceGroupName, resourceID.Name, nil)
+		if err != nil {
+			span.SetStatus(codes.Error, "cannot delete virtual machine")
+			return fmt.Errorf("delete of virtual machine failed to start: %w", err)
+		}
+
+		resumeTokens[i], err = poller.ResumeToken()
+		if err != nil {
+			span.SetStatus(codes.Error, "cannot generate resume token")
+			return fmt.Errorf("cannot generate Azure resume token: %w", err)
+		}
+	}
+
+	for i, token := range resumeTokens {
+		poller, err := vmClient.BeginDelete(ctx, "", "", &armcompute.VirtualMachinesClientBeginDeleteOptions{
+			ResumeToken: token,
+		})
+		if err != nil {
+			span.SetStatus(codes.Error, "polling of virtual machine delete status failed to start")
+			return fmt.Errorf("polling of virtual machine delete status failed to start: %w", err)
+		}
+		_, err = poller.PollUntilDone(ctx, &runtime.PollUntilDoneOptions{
+			Frequency: vmPollFrequency,
+		})
+		if err != nil {
+			span.SetStatus(codes.Error, "failed to poll for delete virtual machine status")
+			return fmt.Errorf("failed to poll for delete virtual machine status: %w", err)
+		}
+		logger.Debug().Msgf("Deleted virtual machine id=%s", vmIds[i])
+	}
+
+	return nil
+}
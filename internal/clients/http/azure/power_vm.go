@@ -0,0 +1,82 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+)
+
+func (c *client) StartVM(ctx context.Context, vmId string) error {
+	ctx, span := otel.Tracer(TraceName).Start(ctx, "StartVM")
+	defer span.End()
+
+	vmClient, err := c.newVirtualMachinesClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	resourceID, err := arm.ParseResourceID(vmId)
+	if err != nil {
+		span.SetStatus(codes.Error, "cannot parse Azure instance id")
+		return fmt.Errorf("cannot parse Azure instance id %s: %w", vmId, err)
+	}
+
+	_, err = vmClient.BeginStart(ctx, resourceID.ResourceGroupName, resourceID.Name, nil)
+	if err != nil {
+		span.SetStatus(codes.Error, "cannot start virtual machine")
+		return fmt.Errorf("start of virtual machine failed: %w", err)
+	}
+
+	return nil
+}
+
+func (c *client) DeallocateVM(ctx context.Context, vmId string) error {
+	ctx, span := otel.Tracer(TraceName).Start(ctx, "DeallocateVM")
+	defer span.End()
+
+	vmClient, err := c.newVirtualMachinesClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	resourceID, err := arm.ParseResourceID(vmId)
+	if err != nil {
+		span.SetStatus(codes.Error, "cannot parse Azure instance id")
+		return fmt.Errorf("cannot parse Azure instance id %s: %w", vmId, err)
+	}
+
+	_, err = vmClient.BeginDeallocate(ctx, resourceID.ResourceGroupName, resourceID.Name, nil)
+	if err != nil {
+		span.SetStatus(codes.Error, "cannot deallocate virtual machine")
+		return fmt.Errorf("deallocation of virtual machine failed: %w", err)
+	}
+
+	return nil
+}
+
+func (c *client) RestartVM(ctx context.Context, vmId string) error {
+	ctx, span := otel.Tracer(TraceName).Start(ctx, "RestartVM")
+	defer span.End()
+
+	vmClient, err := c.newVirtualMachinesClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	resourceID, err := arm.ParseResourceID(vmId)
+	if err != nil {
+		span.SetStatus(codes.Error, "cannot parse Azure instance id")
+		return fmt.Errorf("cannot parse Azure instance id %s: %w", vmId, err)
+	}
+
+	_, err = vmClient.BeginRestart(ctx, resourceID.ResourceGroupName, resourceID.Name, nil)
+	if err != nil {
+		span.SetStatus(codes.Error, "cannot restart virtual machine")
+		return fmt.Errorf("restart of virtual machine failed: %w", err)
+	}
+
+	return nil
+}
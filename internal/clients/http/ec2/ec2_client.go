@@ -426,6 +426,100 @@ func (c *ec2Client) RunInstances(ctx context.Context, params *clients.AWSInstanc
 	return instances, resp.ReservationId, nil
 }
 
+func (c *ec2Client) TerminateInstances(ctx context.Context, instanceIds []string) error {
+	ctx, span := otel.Tracer(TraceName).Start(ctx, "TerminateInstances")
+	defer span.End()
+
+	if !c.assumed {
+		return http.ServiceAccountUnsupportedOperationErr
+	}
+	logger := logger(ctx)
+	logger.Trace().Msgf("Terminating %d AWS EC2 instance(s)", len(instanceIds))
+
+	input := &ec2.TerminateInstancesInput{
+		InstanceIds: instanceIds,
+	}
+	_, err := c.ec2.TerminateInstances(ctx, input)
+	if err != nil {
+		if isAWSUnauthorizedError(err) {
+			err = clients.UnauthorizedErr
+		}
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("cannot terminate instances: %w", err)
+	}
+
+	return nil
+}
+
+func (c *ec2Client) StartInstances(ctx context.Context, instanceIds []string) error {
+	ctx, span := otel.Tracer(TraceName).Start(ctx, "StartInstances")
+	defer span.End()
+
+	if !c.assumed {
+		return http.ServiceAccountUnsupportedOperationErr
+	}
+
+	input := &ec2.StartInstancesInput{
+		InstanceIds: instanceIds,
+	}
+	_, err := c.ec2.StartInstances(ctx, input)
+	if err != nil {
+		if isAWSUnauthorizedError(err) {
+			err = clients.UnauthorizedErr
+		}
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("cannot start instances: %w", err)
+	}
+
+	return nil
+}
+
+func (c *ec2Client) StopInstances(ctx context.Context, instanceIds []string) error {
+	ctx, span := otel.Tracer(TraceName).Start(ctx, "StopInstances")
+	defer span.End()
+
+	if !c.assumed {
+		return http.ServiceAccountUnsupportedOperationErr
+	}
+
+	input := &ec2.StopInstancesInput{
+		InstanceIds: instanceIds,
+	}
+	_, err := c.ec2.StopInstances(ctx, input)
+	if err != nil {
+		if isAWSUnauthorizedError(err) {
+			err = clients.UnauthorizedErr
+		}
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("cannot stop instances: %w", err)
+	}
+
+	return nil
+}
+
+func (c *ec2Client) RebootInstances(ctx context.Context, instanceIds []string) error {
+	ctx, span := otel.Tracer(TraceName).Start(ctx, "RebootInstances")
+	defer span.End()
+
+	if !c.assumed {
+		return http.ServiceAccountUnsupportedOperationErr
+	}
+
+	input := &ec2.RebootInstancesInput{
+		InstanceIds: instanceIds,
+	}
+	_, err := c.ec2.RebootInstances(ctx, input)
+	if err != nil {
+		if isAWSUnauthorizedError(err) {
+			err = clients.UnauthorizedErr
+		}
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("cannot reboot instances: %w", err)
+	}
+
+	return nil
+}
+
 func (c *ec2Client) parseRunInstancesResponse(respAWS *ec2.RunInstancesOutput) []*string {
 	instances := respAWS.Instances
 	list := make([]*string, len(instances))
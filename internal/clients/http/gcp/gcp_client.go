@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/RHEnVision/provisioning-backend/internal/logging"
 	"github.com/RHEnVision/provisioning-backend/internal/telemetry"
@@ -112,7 +113,7 @@ func (c *gcpClient) InsertInstances(ctx context.Context, params *clients.GCPInst
 	metadata := []*computepb.Items{
 		{
 			Key:   ptr.To("ssh-keys"),
-			Value: ptr.To(params.KeyBody),
+			Value: ptr.To(strings.Join(append([]string{params.KeyBody}, params.AdditionalKeyBodies...), "\n")),
 		},
 	}
 	if params.StartupScript != "" {
@@ -121,6 +122,12 @@ func (c *gcpClient) InsertInstances(ctx context.Context, params *clients.GCPInst
 			Value: ptr.To(params.StartupScript),
 		})
 	}
+	if params.UserData != "" {
+		metadata = append(metadata, &computepb.Items{
+			Key:   ptr.To("user-data"),
+			Value: ptr.To(params.UserData),
+		})
+	}
 
 	req := &computepb.BulkInsertInstanceRequest{
 		Project: c.auth.Payload,
@@ -248,3 +255,124 @@ func (c *gcpClient) GetInstanceDescriptionByID(ctx context.Context, id string) (
 	}
 	return &instanceDesc, nil
 }
+
+func (c *gcpClient) DeleteInstances(ctx context.Context, zone string, ids []string) error {
+	ctx, span := otel.Tracer(TraceName).Start(ctx, "DeleteInstances")
+	defer span.End()
+
+	logger := logger(ctx)
+	logger.Trace().Msgf("Deleting %d instance(s) in zone %s", len(ids), zone)
+
+	client, err := c.newInstancesClient(ctx)
+	if err != nil {
+		logger.Error().Err(err).Msg("Could not get instances client")
+		return fmt.Errorf("unable to get instances client: %w", err)
+	}
+	defer client.Close()
+
+	if zone == "" {
+		zone = config.GCP.DefaultZone
+	}
+
+	for _, id := range ids {
+		req := &computepb.DeleteInstanceRequest{
+			Project:  c.auth.Payload,
+			Zone:     zone,
+			Instance: id,
+		}
+
+		op, err := client.Delete(ctx, req)
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+			logger.Error().Err(err).Msgf("Delete operation for instance %s failed", id)
+			return fmt.Errorf("cannot delete instance %s: %w", id, err)
+		}
+		if err = op.Wait(ctx); err != nil {
+			span.SetStatus(codes.Error, err.Error())
+			logger.Error().Err(err).Msgf("Delete wait operation for instance %s failed", id)
+			return fmt.Errorf("cannot delete instance %s: %w", id, err)
+		}
+		if !op.Done() {
+			return fmt.Errorf("an error occured on operation %s: %w", op.Name(), ErrOperationFailed)
+		}
+	}
+	return nil
+}
+
+func (c *gcpClient) StartInstance(ctx context.Context, zone string, id string) error {
+	ctx, span := otel.Tracer(TraceName).Start(ctx, "StartInstance")
+	defer span.End()
+
+	logger := logger(ctx)
+
+	client, err := c.newInstancesClient(ctx)
+	if err != nil {
+		logger.Error().Err(err).Msg("Could not get instances client")
+		return fmt.Errorf("unable to get instances client: %w", err)
+	}
+	defer client.Close()
+
+	req := &computepb.StartInstanceRequest{
+		Project:  c.auth.Payload,
+		Zone:     zone,
+		Instance: id,
+	}
+	_, err = client.Start(ctx, req)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("cannot start instance %s: %w", id, err)
+	}
+	return nil
+}
+
+func (c *gcpClient) StopInstance(ctx context.Context, zone string, id string) error {
+	ctx, span := otel.Tracer(TraceName).Start(ctx, "StopInstance")
+	defer span.End()
+
+	logger := logger(ctx)
+
+	client, err := c.newInstancesClient(ctx)
+	if err != nil {
+		logger.Error().Err(err).Msg("Could not get instances client")
+		return fmt.Errorf("unable to get instances client: %w", err)
+	}
+	defer client.Close()
+
+	req := &computepb.StopInstanceRequest{
+		Project:  c.auth.Payload,
+		Zone:     zone,
+		Instance: id,
+	}
+	_, err = client.Stop(ctx, req)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("cannot stop instance %s: %w", id, err)
+	}
+	return nil
+}
+
+func (c *gcpClient) ResetInstance(ctx context.Context, zone string, id string) error {
+	ctx, span := otel.Tracer(TraceName).Start(ctx, "ResetInstance")
+	defer span.End()
+
+	logger := logger(ctx)
+
+	client, err := c.newInstancesClient(ctx)
+	if err != nil {
+		logger.Error().Err(err).Msg("Could not get instances client")
+		return fmt.Errorf("unable to get instances client: %w", err)
+	}
+	defer client.Close()
+
+	req := &computepb.ResetInstanceRequest{
+		Project:  c.auth.Payload,
+		Zone:     zone,
+		Instance: id,
+	}
+	_, err = client.Reset(ctx, req)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("cannot reset instance %s: %w", id, err)
+	}
+	return nil
+}
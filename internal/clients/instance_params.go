@@ -24,8 +24,14 @@ type GCPInstanceParams struct {
 	// Pubkey to use for the instance access
 	KeyBody string
 
+	// Additional pubkeys authorized for the instance access
+	AdditionalKeyBodies []string
+
 	// StartupScript contains metadata startup script (GCP tools must be installed on the image)
 	StartupScript string
+
+	// UserData contains metadata cloud-init user data (cloud-init must be installed on the image)
+	UserData string
 }
 
 type AWSInstanceParams struct {
@@ -98,6 +98,18 @@ type EC2 interface {
 	CheckPermission(ctx context.Context, auth *Authentication) ([]string, error)
 
 	DescribeInstanceDetails(ctx context.Context, InstanceIds []string) ([]*InstanceDescription, error)
+
+	// TerminateInstances terminates one or more instances found by AWS IDs.
+	TerminateInstances(ctx context.Context, instanceIds []string) error
+
+	// StartInstances starts one or more stopped instances found by AWS IDs.
+	StartInstances(ctx context.Context, instanceIds []string) error
+
+	// StopInstances stops one or more running instances found by AWS IDs.
+	StopInstances(ctx context.Context, instanceIds []string) error
+
+	// RebootInstances reboots one or more running instances found by AWS IDs.
+	RebootInstances(ctx context.Context, instanceIds []string) error
 }
 
 // GetAzureClient returns an Azure client with customer's subscription ID.
@@ -120,6 +132,19 @@ type Azure interface {
 	CreateVMs(ctx context.Context, instanceParams AzureInstanceParams, amount int64, vmNamePrefix string) (vmIds []InstanceDescription, err error)
 
 	ListResourceGroups(ctx context.Context) ([]string, error)
+
+	// DeleteVMs deletes multiple Azure virtual machines found by their full resource IDs
+	DeleteVMs(ctx context.Context, vmIds []string) error
+
+	// StartVM starts a virtual machine found by its full resource ID, it does not wait for the operation
+	StartVM(ctx context.Context, vmId string) error
+
+	// DeallocateVM stops and deallocates a virtual machine found by its full resource ID, it does not
+	// wait for the operation
+	DeallocateVM(ctx context.Context, vmId string) error
+
+	// RestartVM restarts a virtual machine found by its full resource ID, it does not wait for the operation
+	RestartVM(ctx context.Context, vmId string) error
 }
 
 type ServiceAzure interface {
@@ -154,4 +179,16 @@ type GCP interface {
 	ListInstancesIDsByTag(ctx context.Context, uuid string) ([]*string, error)
 
 	GetInstanceDescriptionByID(ctx context.Context, id string) (*InstanceDescription, error)
+
+	// DeleteInstances deletes one or more instances in a zone and waits until the operations are done
+	DeleteInstances(ctx context.Context, zone string, ids []string) error
+
+	// StartInstance starts a stopped instance in a zone, it does not wait for the operation
+	StartInstance(ctx context.Context, zone string, id string) error
+
+	// StopInstance stops a running instance in a zone, it does not wait for the operation
+	StopInstance(ctx context.Context, zone string, id string) error
+
+	// ResetInstance performs a hard reset of a running instance in a zone, it does not wait for the operation
+	ResetInstance(ctx context.Context, zone string, id string) error
 }
@@ -11,7 +11,10 @@ import (
 	"github.com/RHEnVision/provisioning-backend/internal/clients"
 )
 
-var ErrNotStartedVM = errors.New("the VM under given resumeToken not started")
+var (
+	ErrNotStartedVM = errors.New("the VM under given resumeToken not started")
+	ErrVMNotFound   = errors.New("the VM with given ID was not created")
+)
 
 type AzureClientStub struct {
 	startedVms []*armcompute.VirtualMachine
@@ -92,6 +95,47 @@ func (stub *AzureClientStub) WaitForVM(ctx context.Context, resumeToken string)
 	return "", ErrNotStartedVM
 }
 
+func (stub *AzureClientStub) DeleteVMs(ctx context.Context, vmIds []string) error {
+	for _, vmId := range vmIds {
+		found := false
+		for i, vm := range stub.createdVms {
+			if *vm.ID == vmId {
+				stub.createdVms = append(stub.createdVms[:i], stub.createdVms[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w: %s", ErrVMNotFound, vmId)
+		}
+	}
+	return nil
+}
+
+func (stub *AzureClientStub) findCreatedVM(vmId string) (*armcompute.VirtualMachine, error) {
+	for _, vm := range stub.createdVms {
+		if *vm.ID == vmId {
+			return vm, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrVMNotFound, vmId)
+}
+
+func (stub *AzureClientStub) StartVM(ctx context.Context, vmId string) error {
+	_, err := stub.findCreatedVM(vmId)
+	return err
+}
+
+func (stub *AzureClientStub) DeallocateVM(ctx context.Context, vmId string) error {
+	_, err := stub.findCreatedVM(vmId)
+	return err
+}
+
+func (stub *AzureClientStub) RestartVM(ctx context.Context, vmId string) error {
+	_, err := stub.findCreatedVM(vmId)
+	return err
+}
+
 func (stub *AzureClientStub) EnsureResourceGroup(ctx context.Context, name string, location string) (*string, error) {
 	id := strconv.Itoa(len(stub.createdRgs) + 1)
 
@@ -179,3 +179,19 @@ func (mock *EC2ClientStub) DescribeInstanceDetails(ctx context.Context, Instance
 		},
 	}, nil
 }
+
+func (mock *EC2ClientStub) TerminateInstances(ctx context.Context, instanceIds []string) error {
+	return nil
+}
+
+func (mock *EC2ClientStub) StartInstances(ctx context.Context, instanceIds []string) error {
+	return nil
+}
+
+func (mock *EC2ClientStub) StopInstances(ctx context.Context, instanceIds []string) error {
+	return nil
+}
+
+func (mock *EC2ClientStub) RebootInstances(ctx context.Context, instanceIds []string) error {
+	return nil
+}
@@ -71,6 +71,22 @@ func (mock *GCPClientStub) ListInstancesIDsByTag(ctx context.Context, uuid strin
 	return nil, nil
 }
 
+func (mock *GCPClientStub) DeleteInstances(ctx context.Context, zone string, ids []string) error {
+	return nil
+}
+
+func (mock *GCPClientStub) StartInstance(ctx context.Context, zone string, id string) error {
+	return nil
+}
+
+func (mock *GCPClientStub) StopInstance(ctx context.Context, zone string, id string) error {
+	return nil
+}
+
+func (mock *GCPClientStub) ResetInstance(ctx context.Context, zone string, id string) error {
+	return nil
+}
+
 func (mock *GCPServiceClientStub) ListMachineTypes(ctx context.Context, zone string) ([]*clients.InstanceType, error) {
 	return nil, nil
 }
@@ -67,7 +67,7 @@ func (stub *SourcesClientStub) addSource(ctx context.Context, provider models.Pr
 		stub.auths[id] = clients.NewAuthentication("4b9d213f-712f-4d17-a483-8a10bbe9df3a", provider)
 	case models.ProviderTypeGCP:
 		stub.auths[id] = clients.NewAuthentication("test@org.com", provider)
-	case models.ProviderTypeUnknown, models.ProviderTypeNoop:
+	case models.ProviderTypeUnknown, models.ProviderTypeNoop, models.ProviderTypeComposite:
 		// not implemented
 		return nil, NotImplementedErr
 	}
@@ -20,6 +20,7 @@ var config struct {
 	App struct {
 		Port           int    `env:"PORT" env-default:"8000" env-description:"HTTP port of the API service"`
 		InstancePrefix string `env:"INSTANCE_PREFIX" env-default:"" env-description:"prefix for all VMs names"`
+		SecretsKey     string `env:"SECRETS_KEY" env-default:"" env-description:"base64 encoded 32 byte key encrypting secrets stored until launch, rhc registration is refused when blank"`
 		Cache          struct {
 			Type       string        `env:"TYPE" env-default:"none" env-description:"application cache (none, redis)"`
 			Expiration time.Duration `env:"EXPIRATION" env-default:"1h" env-description:"expiration for both memory and Redis (time interval syntax)"`
@@ -114,11 +115,33 @@ var config struct {
 		TraceData bool `env:"TRACE_DATA" env-default:"true" env-description:"open telemetry HTTP context pass and trace"`
 	} `env-prefix:"REST_ENDPOINTS_"`
 	Worker struct {
-		Queue        string        `env:"QUEUE" env-default:"memory" env-description:"job worker implementation (memory, redis, sqs, postgres)"`
-		PollInterval time.Duration `env:"POLL_INTERVAL" env-default:"5s" env-description:"polling interval (network timeout)"`
-		Concurrency  int           `env:"CONCURRENCY" env-default:"33" env-description:"amount of worker polling goroutines (effective concurrency)"`
-		Timeout      time.Duration `env:"TIMEOUT" env-default:"30m" env-description:"total timeout for a single job to complete (duration)"`
+		Queue             string        `env:"QUEUE" env-default:"memory" env-description:"job worker implementation (memory, redis, sqs, postgres)"`
+		PollInterval      time.Duration `env:"POLL_INTERVAL" env-default:"5s" env-description:"polling interval (network timeout)"`
+		HeartbeatInterval time.Duration `env:"HEARTBEAT_INTERVAL" env-default:"10s" env-description:"worker heartbeat interval, jobs of workers without heartbeat for three intervals are re-queued"`
+		Concurrency       int           `env:"CONCURRENCY" env-default:"33" env-description:"amount of worker polling goroutines (effective concurrency)"`
+		Timeout           time.Duration `env:"TIMEOUT" env-default:"30m" env-description:"total timeout for a single job to complete (duration)"`
+		StaleThreshold    time.Duration `env:"STALE_THRESHOLD" env-default:"2h" env-description:"unfinished reservations older than this are marked as failed (duration, should be longer than timeout)"`
 	} `env-prefix:"WORKER_"`
+	Retention struct {
+		Days      int           `env:"DAYS" env-default:"0" env-description:"finished reservations older than this are deleted by a cleanup job (days, 0 disables the job)"`
+		Interval  time.Duration `env:"INTERVAL" env-default:"1h" env-description:"how often the cleanup job is scheduled (duration)"`
+		BatchSize int64         `env:"BATCH_SIZE" env-default:"500" env-description:"amount of reservations deleted in a single transaction"`
+		Archive   bool          `env:"ARCHIVE" env-default:"false" env-description:"copy deleted reservations into reservations_archive table"`
+		DryRun    bool          `env:"DRY_RUN" env-default:"false" env-description:"only log amount of reservations which would be deleted"`
+	} `env-prefix:"RETENTION_"`
+	Quota struct {
+		MaxInflightReservations    int32 `env:"MAX_INFLIGHT_RESERVATIONS" env-default:"20" env-description:"default maximum of reservations in progress per account (0 means unlimited)"`
+		MaxInstancesPerReservation int32 `env:"MAX_INSTANCES_PER_RESERVATION" env-default:"50" env-description:"default maximum of instances launched by a reservation (0 means unlimited)"`
+		MaxInstancesPerDay         int32 `env:"MAX_INSTANCES_PER_DAY" env-default:"200" env-description:"default maximum of instances launched per account in the last 24 hours (0 means unlimited)"`
+	} `env-prefix:"QUOTA_"`
+	RateLimit struct {
+		Enabled bool    `env:"ENABLED" env-default:"true" env-description:"per-account API rate limiting (requires redis cache)"`
+		Rate    float64 `env:"RATE" env-default:"10" env-description:"requests per second refilled into the per-account token bucket"`
+		Burst   int     `env:"BURST" env-default:"100" env-description:"maximum of requests per account in a burst (token bucket size)"`
+	} `env-prefix:"RATE_LIMIT_"`
+	Webhook struct {
+		AllowPrivate bool `env:"ALLOW_PRIVATE" env-default:"false" env-description:"allow webhook URLs on loopback, private and link-local addresses (dev only)"`
+	} `env-prefix:"WEBHOOK_"`
 	Unleash struct {
 		Enabled     bool   `env:"ENABLED" env-default:"false" env-description:"unleash service (feature flags)"`
 		Environment string `env:"ENVIRONMENT" env-default:"" env-description:"unleash environment"`
@@ -158,6 +181,10 @@ var (
 	ImageBuilder  = &config.RestEndpoints.ImageBuilder
 	Sources       = &config.RestEndpoints.Sources
 	Worker        = &config.Worker
+	Retention     = &config.Retention
+	Quota         = &config.Quota
+	RateLimit     = &config.RateLimit
+	Webhook       = &config.Webhook
 	Unleash       = &config.Unleash
 	Sentry        = &config.Sentry
 	Kafka         = &config.Kafka
@@ -284,6 +311,9 @@ func Initialize(configFiles ...string) {
 		config.RestEndpoints.Sources.Proxy.URL = ""
 		config.RestEndpoints.ImageBuilder.Proxy.URL = ""
 
+		// webhooks into internal network are not allowed in clowder environment
+		config.Webhook.AllowPrivate = false
+
 		// endpoints configuration
 		if endpoint, ok := clowder.DependencyEndpoints["sources-api"]["svc"]; ok {
 			config.RestEndpoints.Sources.URL = fmt.Sprintf("http://%s:%d/api/sources/v3.1", endpoint.Hostname, endpoint.Port)
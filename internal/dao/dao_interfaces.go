@@ -12,9 +12,11 @@ package dao
 
 import (
 	"context"
+	"time"
 
 	"github.com/RHEnVision/provisioning-backend/internal/clients"
 	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/jackc/pgx/v5"
 )
 
 var GetAccountDao func(ctx context.Context) AccountDao
@@ -27,6 +29,9 @@ type AccountDao interface {
 	GetOrCreateByIdentity(ctx context.Context, orgId string, accountNumber string) (*models.Account, error)
 	GetByOrgId(ctx context.Context, orgId string) (*models.Account, error)
 	List(ctx context.Context, limit, offset int64) ([]*models.Account, error)
+
+	// UpdateQuota sets launch limits of an account.
+	UpdateQuota(ctx context.Context, id int64, quota *models.AccountQuota) error
 }
 
 var GetPubkeyDao func(ctx context.Context) PubkeyDao
@@ -37,7 +42,12 @@ type PubkeyDao interface {
 	Create(ctx context.Context, pk *models.Pubkey) error
 	Update(ctx context.Context, pk *models.Pubkey) error
 	GetById(ctx context.Context, id int64) (*models.Pubkey, error)
-	List(ctx context.Context, limit, offset int64) ([]*models.Pubkey, error)
+	// List returns a page of pubkeys for a particular account.
+	List(ctx context.Context, params ListParams) ([]*models.Pubkey, error)
+
+	// Count returns the total number of pubkeys for a particular account.
+	Count(ctx context.Context) (int64, error)
+
 	Delete(ctx context.Context, id int64) error
 
 	UnscopedCreateResource(ctx context.Context, pkr *models.PubkeyResource) error
@@ -52,17 +62,25 @@ var GetReservationDao func(ctx context.Context) ReservationDao
 // associated detail information different for different cloud providers (like number of vCPUs,
 // instance IDs created etc).
 type ReservationDao interface {
-	// CreateNoop creates no operation reservation with details in a single transaction.
-	CreateNoop(ctx context.Context, reservation *models.NoopReservation) error
+	// CreateNoop creates no operation reservation with details in a single transaction. The optional
+	// function is called within the transaction after the reservation is inserted.
+	CreateNoop(ctx context.Context, reservation *models.NoopReservation, fn TxFn) error
 
-	// CreateAWS creates AWS reservation with details in a single transaction.
-	CreateAWS(ctx context.Context, reservation *models.AWSReservation) error
+	// CreateAWS creates AWS reservation with details in a single transaction. The optional
+	// function is called within the transaction after the reservation is inserted.
+	CreateAWS(ctx context.Context, reservation *models.AWSReservation, fn TxFn) error
 
-	// CreateAzure creates Azure reservation with details in a single transaction.
-	CreateAzure(ctx context.Context, reservation *models.AzureReservation) error
+	// CreateAzure creates Azure reservation with details in a single transaction. The optional
+	// function is called within the transaction after the reservation is inserted.
+	CreateAzure(ctx context.Context, reservation *models.AzureReservation, fn TxFn) error
 
-	// CreateGCP creates GCP reservation with details in a single transaction.
-	CreateGCP(ctx context.Context, reservation *models.GCPReservation) error
+	// CreateGCP creates GCP reservation with details in a single transaction. The optional
+	// function is called within the transaction after the reservation is inserted.
+	CreateGCP(ctx context.Context, reservation *models.GCPReservation, fn TxFn) error
+
+	// CreateComposite creates composite reservation without details. Child reservations are
+	// created separately with ParentID set to the composite reservation.
+	CreateComposite(ctx context.Context, reservation *models.CompositeReservation) error
 
 	// CreateInstance inserts instance associated to a reservation.
 	CreateInstance(ctx context.Context, reservation *models.ReservationInstance) error
@@ -70,6 +88,9 @@ type ReservationDao interface {
 	// GetById returns reservation for a particular account.
 	GetById(ctx context.Context, id int64) (*models.Reservation, error)
 
+	// GetByIdempotencyKey returns reservation created with the idempotency key for a particular account.
+	GetByIdempotencyKey(ctx context.Context, key string) (*models.Reservation, error)
+
 	// GetAWSById returns reservation for a particular account.
 	GetAWSById(ctx context.Context, id int64) (*models.AWSReservation, error)
 
@@ -79,16 +100,55 @@ type ReservationDao interface {
 	// GetGCPById returns reservation for a particular account.
 	GetGCPById(ctx context.Context, id int64) (*models.GCPReservation, error)
 
-	// List returns reservation for a particular account.
-	List(ctx context.Context, limit, offset int64) ([]*models.Reservation, error)
+	// List returns a page of reservations matching the filter for a particular account.
+	List(ctx context.Context, params ListParams, filter ReservationFilter) ([]*models.Reservation, error)
+
+	// Count returns the total number of reservations matching the filter for a particular account.
+	Count(ctx context.Context, filter ReservationFilter) (int64, error)
+
+	// UnscopedListStale returns up to limit reservations which were created (or scheduled to
+	// launch) before the given duration and were not finished yet. Composite reservations are
+	// finished with their children and they are not listed. UNSCOPED.
+	UnscopedListStale(ctx context.Context, olderThan time.Duration, limit int64) ([]*models.Reservation, error)
+
+	// CountInflight returns the number of unfinished reservations for a particular account. All
+	// provider types are counted when provider is ProviderTypeUnknown, composite reservations
+	// are not counted (their children are).
+	CountInflight(ctx context.Context, provider models.ProviderType) (int64, error)
+
+	// SumInstancesSince returns the amount of instances requested by reservations created after
+	// since for a particular account, failed reservations are not counted. All provider types are
+	// counted when provider is ProviderTypeUnknown.
+	SumInstancesSince(ctx context.Context, provider models.ProviderType, since time.Time) (int64, error)
+
+	// QuotaUsageTx locks quota of the current account until the end of the transaction and
+	// returns its usage since the given time including reservations created in the transaction.
+	// Quota checks of reservations created in transactions calling it are serialized per account.
+	// All provider types are counted when provider is ProviderTypeUnknown.
+	QuotaUsageTx(ctx context.Context, tx pgx.Tx, provider models.ProviderType, since time.Time) (*QuotaUsage, error)
+
+	// ListChildren returns reservations launched by a composite reservation for a particular account.
+	ListChildren(ctx context.Context, parentId int64) ([]*models.Reservation, error)
 
 	// ListInstances returns instances associated to a reservation. UNSCOPED.
 	// It currently lists all instances and not instances for a reservation, this is a TODO.
 	ListInstances(ctx context.Context, reservationId int64) ([]*models.ReservationInstance, error)
 
-	// UpdateStatus sets status field and increment step counter by addSteps. UNSCOPED.
+	// DeleteInstances deletes all instances associated to a reservation. UNSCOPED.
+	DeleteInstances(ctx context.Context, reservationId int64) error
+
+	// ListEvents returns status changes of a reservation ordered by time.
+	ListEvents(ctx context.Context, reservationId int64) ([]*models.ReservationEvent, error)
+
+	// UpdateStatus sets status field and increment step counter by addSteps. The change is
+	// recorded as a reservation event. UNSCOPED.
 	UpdateStatus(ctx context.Context, id int64, status string, addSteps int32) error
 
+	// UpdateCompositeProgress sets step and status of a composite reservation from its children
+	// and finishes it when all children are finished. The change is recorded as a reservation
+	// event. Returns ErrNoRows when the composite reservation is already finished. UNSCOPED.
+	UpdateCompositeProgress(ctx context.Context, id int64) (*models.Reservation, error)
+
 	// UnscopedUpdateAWSDetail updates details of the AWS reservation. UNSCOPED.
 	UnscopedUpdateAWSDetail(ctx context.Context, id int64, awsDetail *models.AWSDetail) error
 
@@ -101,12 +161,114 @@ type ReservationDao interface {
 	// UpdateReservationInstance updates an instance with its description
 	UpdateReservationInstance(ctx context.Context, reservationID int64, instance *clients.InstanceDescription) error
 
-	// FinishWithSuccess sets Success flag. UNSCOPED.
+	// FinishWithSuccess sets Success flag and clears launch input of the details. Returns
+	// ErrAffectedMismatch when the reservation was already finished. UNSCOPED.
 	FinishWithSuccess(ctx context.Context, id int64) error
 
-	// FinishWithError sets Success flag and Error flag. UNSCOPED.
+	// FinishWithError sets Success flag and Error flag and clears launch input of the details.
+	// Returns ErrAffectedMismatch when the reservation was already finished, teardown of
+	// finished reservations is recorded via FinishTeardown instead. UNSCOPED.
 	FinishWithError(ctx context.Context, id int64, errorString string) error
 
+	// QueueTeardown marks a finished reservation as having an instances teardown job queued.
+	// Returns ErrAffectedMismatch when the reservation is not finished or a teardown is already
+	// queued.
+	QueueTeardown(ctx context.Context, id int64) error
+
+	// FinishTeardown sets status and teardown error (empty on success) and clears the teardown
+	// flag. The launch outcome is not changed, the status change is recorded as a reservation
+	// event. Returns ErrAffectedMismatch when no teardown was queued. UNSCOPED.
+	FinishTeardown(ctx context.Context, id int64, status string, errorString string) error
+
+	// UnscopedFinishStaleTeardowns finishes teardowns queued before the given duration the same
+	// way as FinishTeardown and returns their count. UNSCOPED.
+	UnscopedFinishStaleTeardowns(ctx context.Context, olderThan time.Duration, status string, errorString string) (int64, error)
+
+	// Cancel sets Cancelled flag of a reservation which has not been finished yet. Returns
+	// ErrAffectedMismatch when the reservation is already finished.
+	Cancel(ctx context.Context, id int64) error
+
 	// Delete deletes a reservation. Only used in tests and background cleanup job. UNSCOPED.
 	Delete(ctx context.Context, id int64) error
+
+	// UnscopedCountExpired returns count of reservations finished before the given duration.
+	// Composite reservations with children which are not expired are not counted. UNSCOPED.
+	UnscopedCountExpired(ctx context.Context, olderThan time.Duration) (int64, error)
+
+	// UnscopedDeleteExpired deletes up to limit reservations finished before the given duration
+	// including details and instances. Composite reservations are only deleted when all their
+	// children are expired, children are deleted with them even over the limit. When archive is
+	// set, deleted reservations are copied into the archive table in the same transaction.
+	// Returns count of deleted reservations. UNSCOPED.
+	UnscopedDeleteExpired(ctx context.Context, olderThan time.Duration, limit int64, archive bool) (int64, error)
+}
+
+var GetWebhookDao func(ctx context.Context) WebhookDao
+
+// WebhookDao represents webhook subscriptions of accounts and the log of their deliveries.
+type WebhookDao interface {
+	Create(ctx context.Context, webhook *models.Webhook) error
+	Update(ctx context.Context, webhook *models.Webhook) error
+	GetById(ctx context.Context, id int64) (*models.Webhook, error)
+
+	// List returns a page of webhooks for a particular account.
+	List(ctx context.Context, params ListParams) ([]*models.Webhook, error)
+
+	// Count returns the total number of webhooks for a particular account.
+	Count(ctx context.Context) (int64, error)
+
+	// ListEnabled returns all enabled webhooks for a particular account.
+	ListEnabled(ctx context.Context) ([]*models.Webhook, error)
+
+	Delete(ctx context.Context, id int64) error
+
+	// UnscopedCreateDelivery logs a delivery attempt. UNSCOPED.
+	UnscopedCreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+
+	// ListDeliveries returns a page of deliveries of a webhook for a particular account.
+	ListDeliveries(ctx context.Context, webhookId int64, params ListParams, filter WebhookDeliveryFilter) ([]*models.WebhookDelivery, error)
+
+	// CountDeliveries returns the total number of deliveries of a webhook matching the filter for
+	// a particular account.
+	CountDeliveries(ctx context.Context, webhookId int64, filter WebhookDeliveryFilter) (int64, error)
+}
+
+var GetLaunchProfileDao func(ctx context.Context) LaunchProfileDao
+
+// LaunchProfileDao represents saved reservation parameters of accounts.
+type LaunchProfileDao interface {
+	Create(ctx context.Context, profile *models.LaunchProfile) error
+	Update(ctx context.Context, profile *models.LaunchProfile) error
+	GetById(ctx context.Context, id int64) (*models.LaunchProfile, error)
+
+	// List returns a page of launch profiles for a particular account.
+	List(ctx context.Context, params ListParams) ([]*models.LaunchProfile, error)
+
+	// Count returns the total number of launch profiles for a particular account.
+	Count(ctx context.Context) (int64, error)
+
+	// CountByPubkeyId returns the number of launch profiles using the pubkey for a particular account.
+	CountByPubkeyId(ctx context.Context, pubkeyId int64) (int64, error)
+
+	Delete(ctx context.Context, id int64) error
+}
+
+var GetOutboxDao func(ctx context.Context) OutboxDao
+
+// OutboxDao represents jobs waiting to be published to the job queue. All operations are UNSCOPED.
+type OutboxDao interface {
+	// CreateTx stores a job within the transaction.
+	CreateTx(ctx context.Context, tx pgx.Tx, job *models.OutboxJob) error
+
+	// Publish locks up to limit oldest jobs which are due, calls the function for each of them
+	// and deletes jobs the function returned no error for. Jobs locked by other relays are skipped.
+	// Jobs are published at least once, when the transaction fails they are published again.
+	// Failed jobs are retried with exponential backoff and marked dead after maxAttempts failed
+	// attempts. The function is called within the transaction, so it must not block. Returns
+	// the number of published jobs.
+	Publish(ctx context.Context, limit int64, maxAttempts int, fn func(job *models.OutboxJob) error) (int, error)
+
+	// Stats returns the number of pending jobs, the number of dead jobs and age of the oldest
+	// pending job (relay lag). The lag is zero when there are no pending jobs.
+	Stats(ctx context.Context) (int64, int64, time.Duration, error)
 }
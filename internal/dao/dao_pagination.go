@@ -0,0 +1,63 @@
+package dao
+
+import (
+	"time"
+
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+)
+
+// ListParams are cursor-based pagination parameters of list operations. Records are
+// always ordered by ID which is monotonic with the creation time.
+type ListParams struct {
+	// Limit is the maximum amount of records returned.
+	Limit int64
+
+	// After returns records following the record with this ID in the sort order (next page).
+	// Zero value means no cursor.
+	After int64
+
+	// Before returns records preceding the record with this ID in the sort order (previous
+	// page). Records are still returned in the sort order. Zero value means no cursor.
+	Before int64
+
+	// Descending sorts records from the newest to the oldest.
+	Descending bool
+}
+
+// Backward returns true when the previous page is requested.
+func (p ListParams) Backward() bool {
+	return p.Before != 0
+}
+
+// ReservationFilter restricts reservations returned by list operations, zero values
+// are not applied.
+type ReservationFilter struct {
+	// Provider type of the reservation.
+	Provider models.ProviderType
+
+	// Status is the textual status of the reservation, exact match.
+	Status string
+
+	// Success flag, reservations still in progress only match when not set.
+	Success *bool
+
+	// SourceID of the reservation, only applies to AWS, Azure and GCP reservations.
+	SourceID string
+
+	// PubkeyID authorized by the reservation, either as the reservation pubkey or as an
+	// additional pubkey. Only applies to AWS, Azure and GCP reservations.
+	PubkeyID int64
+
+	// CreatedAfter returns reservations created at or after the time.
+	CreatedAfter time.Time
+
+	// CreatedBefore returns reservations created before the time.
+	CreatedBefore time.Time
+}
+
+// WebhookDeliveryFilter restricts webhook deliveries returned by list operations, zero values
+// are not applied.
+type WebhookDeliveryFilter struct {
+	// Success flag of the delivery attempt.
+	Success *bool
+}
@@ -0,0 +1,11 @@
+package dao
+
+// QuotaUsage is the usage of an account counted against its launch limits.
+type QuotaUsage struct {
+	// Inflight is the number of unfinished reservations, composite reservations are not counted.
+	Inflight int64
+
+	// Instances is the amount of instances requested by reservations created after the given
+	// time which did not fail.
+	Instances int64
+}
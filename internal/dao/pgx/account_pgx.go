@@ -105,3 +105,16 @@ func (x *accountDao) List(ctx context.Context, limit, offset int64) ([]*models.A
 	}
 	return result, nil
 }
+
+func (x *accountDao) UpdateQuota(ctx context.Context, id int64, quota *models.AccountQuota) error {
+	query := `UPDATE accounts SET quota = $2 WHERE id = $1`
+
+	tag, err := db.Pool.Exec(ctx, query, id, quota)
+	if err != nil {
+		return fmt.Errorf("pgx error: %w", err)
+	}
+	if tag.RowsAffected() != 1 {
+		return fmt.Errorf("expected 1 row: %w", dao.ErrAffectedMismatch)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package pgx
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
+	"github.com/RHEnVision/provisioning-backend/internal/db"
+	"github.com/RHEnVision/provisioning-backend/internal/identity"
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/georgysavva/scany/v2/pgxscan"
+)
+
+func init() {
+	dao.GetLaunchProfileDao = getLaunchProfileDao
+}
+
+type launchProfileDao struct{}
+
+func getLaunchProfileDao(ctx context.Context) dao.LaunchProfileDao {
+	return &launchProfileDao{}
+}
+
+func (x *launchProfileDao) Create(ctx context.Context, profile *models.LaunchProfile) error {
+	query := `
+		INSERT INTO launch_profiles (account_id, name, provider, source_id, region, instance_type, image_id, pubkey_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at`
+
+	profile.AccountID = identity.AccountId(ctx)
+
+	if vError := models.Validate(ctx, profile); vError != nil {
+		return fmt.Errorf("launch profile validation: %w", vError)
+	}
+
+	err := db.Pool.QueryRow(ctx, query,
+		profile.AccountID,
+		profile.Name,
+		profile.Provider,
+		profile.SourceID,
+		profile.Region,
+		profile.InstanceType,
+		profile.ImageID,
+		profile.PubkeyID).Scan(&profile.ID, &profile.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("pgx error: %w", err)
+	}
+
+	return nil
+}
+
+func (x *launchProfileDao) Update(ctx context.Context, profile *models.LaunchProfile) error {
+	query := `
+		UPDATE launch_profiles SET name = $3, provider = $4, source_id = $5, region = $6, instance_type = $7,
+			image_id = $8, pubkey_id = $9
+		WHERE account_id = $1 AND id = $2`
+	accountId := identity.AccountId(ctx)
+
+	if vError := models.Validate(ctx, profile); vError != nil {
+		return fmt.Errorf("launch profile validation: %w", vError)
+	}
+
+	tag, err := db.Pool.Exec(ctx, query,
+		accountId,
+		profile.ID,
+		profile.Name,
+		profile.Provider,
+		profile.SourceID,
+		profile.Region,
+		profile.InstanceType,
+		profile.ImageID,
+		profile.PubkeyID)
+	if err != nil {
+		return fmt.Errorf("pgx error: %w", err)
+	}
+	if tag.RowsAffected() != 1 {
+		return fmt.Errorf("expected 1 row: %w", dao.ErrAffectedMismatch)
+	}
+	return nil
+}
+
+func (x *launchProfileDao) GetById(ctx context.Context, id int64) (*models.LaunchProfile, error) {
+	query := `SELECT * FROM launch_profiles WHERE account_id = $1 AND id = $2 LIMIT 1`
+	accountId := identity.AccountId(ctx)
+	result := &models.LaunchProfile{}
+
+	err := pgxscan.Get(ctx, db.Pool, result, query, accountId, id)
+	if err != nil {
+		return nil, fmt.Errorf("pgx error: %w", err)
+	}
+	return result, nil
+}
+
+func (x *launchProfileDao) List(ctx context.Context, params dao.ListParams) ([]*models.LaunchProfile, error) {
+	q := &listQuery{}
+	q.where("account_id = $%[1]d", identity.AccountId(ctx))
+	query := q.page("launch_profiles", params)
+	var result []*models.LaunchProfile
+
+	rows, err := db.Pool.Query(ctx, query, q.args...)
+	if err != nil {
+		return nil, fmt.Errorf("pgx error: %w", err)
+	}
+
+	err = pgxscan.ScanAll(&result, rows)
+	if err != nil {
+		return nil, fmt.Errorf("pgx error: %w", err)
+	}
+	reversePage(params, result)
+	return result, nil
+}
+
+func (x *launchProfileDao) Count(ctx context.Context) (int64, error) {
+	query := `SELECT count(*) FROM launch_profiles WHERE account_id = $1`
+	accountId := identity.AccountId(ctx)
+	var result int64
+
+	err := db.Pool.QueryRow(ctx, query, accountId).Scan(&result)
+	if err != nil {
+		return 0, fmt.Errorf("pgx error: %w", err)
+	}
+	return result, nil
+}
+
+func (x *launchProfileDao) CountByPubkeyId(ctx context.Context, pubkeyId int64) (int64, error) {
+	query := `SELECT count(*) FROM launch_profiles WHERE account_id = $1 AND pubkey_id = $2`
+	accountId := identity.AccountId(ctx)
+	var result int64
+
+	err := db.Pool.QueryRow(ctx, query, accountId, pubkeyId).Scan(&result)
+	if err != nil {
+		return 0, fmt.Errorf("pgx error: %w", err)
+	}
+	return result, nil
+}
+
+func (x *launchProfileDao) Delete(ctx context.Context, id int64) error {
+	query := `DELETE FROM launch_profiles WHERE account_id = $1 AND id = $2`
+	accountId := identity.AccountId(ctx)
+
+	tag, err := db.Pool.Exec(ctx, query, accountId, id)
+	if err != nil {
+		return fmt.Errorf("pgx error: %w", err)
+	}
+	if tag.RowsAffected() != 1 {
+		return fmt.Errorf("expected 1 row: %w", dao.ErrAffectedMismatch)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package pgx
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
+	"github.com/RHEnVision/provisioning-backend/internal/db"
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+)
+
+func init() {
+	dao.GetOutboxDao = getOutboxDao
+}
+
+type outboxDao struct{}
+
+func getOutboxDao(_ context.Context) dao.OutboxDao {
+	return &outboxDao{}
+}
+
+func (x *outboxDao) CreateTx(ctx context.Context, tx pgx.Tx, job *models.OutboxJob) error {
+	query := `INSERT INTO job_outbox (job_id, job_type, payload) VALUES ($1, $2, $3) RETURNING id, created_at`
+
+	err := tx.QueryRow(ctx, query, job.JobID, job.JobType, job.Payload).Scan(&job.ID, &job.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("pgx error: %w", err)
+	}
+	return nil
+}
+
+func (x *outboxDao) Publish(ctx context.Context, limit int64, maxAttempts int, fn func(job *models.OutboxJob) error) (int, error) {
+	logger := zerolog.Ctx(ctx)
+	published := 0
+
+	txErr := dao.WithTransaction(ctx, func(tx pgx.Tx) error {
+		query := `SELECT * FROM job_outbox WHERE NOT dead AND next_attempt_at <= now()
+			ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED`
+		var result []*models.OutboxJob
+		err := pgxscan.Select(ctx, tx, &result, query, limit)
+		if err != nil {
+			return fmt.Errorf("pgx error: %w", err)
+		}
+
+		ids := make([]int64, 0, len(result))
+		for _, job := range result {
+			if fnErr := fn(job); fnErr != nil {
+				dead := job.Attempts+1 >= maxAttempts
+				if dead {
+					logger.Error().Err(fnErr).Str("job_id", job.JobID.String()).Msgf("Unable to publish outbox job %d times, marking dead", job.Attempts+1)
+				} else {
+					logger.Warn().Err(fnErr).Str("job_id", job.JobID.String()).Msg("Unable to publish outbox job, will retry")
+				}
+
+				failQuery := `UPDATE job_outbox SET attempts = attempts + 1, error = $2, dead = $3,
+					next_attempt_at = now() + power(2, attempts) * interval '1 second'
+					WHERE id = $1`
+				_, err = tx.Exec(ctx, failQuery, job.ID, fnErr.Error(), dead)
+				if err != nil {
+					return fmt.Errorf("pgx error: %w", err)
+				}
+				continue
+			}
+			ids = append(ids, job.ID)
+		}
+
+		deleteQuery := `DELETE FROM job_outbox WHERE id = ANY($1)`
+		tag, err := tx.Exec(ctx, deleteQuery, ids)
+		if err != nil {
+			return fmt.Errorf("pgx error: %w", err)
+		}
+		if tag.RowsAffected() != int64(len(ids)) {
+			return fmt.Errorf("expected %d rows: %w", len(ids), dao.ErrAffectedMismatch)
+		}
+		published = len(ids)
+
+		return nil
+	})
+	if txErr != nil {
+		return 0, fmt.Errorf("pgx tx error: %w", txErr)
+	}
+	return published, nil
+}
+
+func (x *outboxDao) Stats(ctx context.Context) (int64, int64, time.Duration, error) {
+	query := `SELECT count(*) FILTER (WHERE NOT dead), count(*) FILTER (WHERE dead),
+		coalesce(extract(epoch FROM now() - min(created_at) FILTER (WHERE NOT dead)), 0)::float8
+		FROM job_outbox`
+
+	var count, dead int64
+	var lagSeconds float64
+	err := db.Pool.QueryRow(ctx, query).Scan(&count, &dead, &lagSeconds)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("pgx error: %w", err)
+	}
+	return count, dead, time.Duration(lagSeconds * float64(time.Second)), nil
+}
@@ -0,0 +1,71 @@
+package pgx
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
+)
+
+// listQuery builds WHERE clauses of list queries with positional arguments.
+type listQuery struct {
+	conditions []string
+	args       []any
+}
+
+// where adds a condition with a single argument. The condition must reference the argument
+// via %[1]d verb, it can be referenced multiple times (e.g. "a = $%[1]d OR b = $%[1]d").
+func (q *listQuery) where(condition string, arg any) {
+	q.args = append(q.args, arg)
+	q.conditions = append(q.conditions, fmt.Sprintf(condition, len(q.args)))
+}
+
+func (q *listQuery) whereClause() string {
+	if len(q.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(q.conditions, " AND ")
+}
+
+// count returns a query counting all records matching conditions.
+func (q *listQuery) count(table string) string {
+	return fmt.Sprintf("SELECT count(*) FROM %s%s", table, q.whereClause())
+}
+
+// page returns a query selecting a page of records with the cursor condition, order and limit
+// applied. Backward queries return records in the reverse order, see reversePage.
+func (q *listQuery) page(table string, params dao.ListParams) string {
+	descending := params.Descending
+	if params.After != 0 {
+		if descending {
+			q.where("id < $%[1]d", params.After)
+		} else {
+			q.where("id > $%[1]d", params.After)
+		}
+	}
+	if params.Before != 0 {
+		if descending {
+			q.where("id > $%[1]d", params.Before)
+		} else {
+			q.where("id < $%[1]d", params.Before)
+		}
+		descending = !descending
+	}
+
+	order := "ASC"
+	if descending {
+		order = "DESC"
+	}
+	q.args = append(q.args, params.Limit)
+	return fmt.Sprintf("SELECT * FROM %s%s ORDER BY id %s LIMIT $%d", table, q.whereClause(), order, len(q.args))
+}
+
+// reversePage restores the sort order of records returned by a backward page query.
+func reversePage[T any](params dao.ListParams, records []T) {
+	if !params.Backward() {
+		return
+	}
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+}
@@ -93,12 +93,13 @@ func (x *pubkeyDao) Update(ctx context.Context, pubkey *models.Pubkey) error {
 	return nil
 }
 
-func (x *pubkeyDao) List(ctx context.Context, limit, offset int64) ([]*models.Pubkey, error) {
-	query := `SELECT * FROM pubkeys WHERE account_id = $1 ORDER BY id LIMIT $2 OFFSET $3`
-	accountId := identity.AccountId(ctx)
+func (x *pubkeyDao) List(ctx context.Context, params dao.ListParams) ([]*models.Pubkey, error) {
+	q := &listQuery{}
+	q.where("account_id = $%[1]d", identity.AccountId(ctx))
+	query := q.page("pubkeys", params)
 	var result []*models.Pubkey
 
-	rows, err := db.Pool.Query(ctx, query, accountId, limit, offset)
+	rows, err := db.Pool.Query(ctx, query, q.args...)
 	if err != nil {
 		return nil, fmt.Errorf("pgx error: %w", err)
 	}
@@ -107,6 +108,19 @@ func (x *pubkeyDao) List(ctx context.Context, limit, offset int64) ([]*models.Pu
 	if err != nil {
 		return nil, fmt.Errorf("pgx error: %w", err)
 	}
+	reversePage(params, result)
+	return result, nil
+}
+
+func (x *pubkeyDao) Count(ctx context.Context) (int64, error) {
+	query := `SELECT count(*) FROM pubkeys WHERE account_id = $1`
+	accountId := identity.AccountId(ctx)
+	var result int64
+
+	err := db.Pool.QueryRow(ctx, query, accountId).Scan(&result)
+	if err != nil {
+		return 0, fmt.Errorf("pgx error: %w", err)
+	}
 	return result, nil
 }
 
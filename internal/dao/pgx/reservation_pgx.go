@@ -3,6 +3,7 @@ package pgx
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/RHEnVision/provisioning-backend/internal/clients"
 	"github.com/RHEnVision/provisioning-backend/internal/dao"
@@ -23,24 +24,32 @@ func getReservationDao(ctx context.Context) dao.ReservationDao {
 	return &reservationDao{}
 }
 
-func (x *reservationDao) CreateNoop(ctx context.Context, reservation *models.NoopReservation) error {
-	reservation.Provider = models.ProviderTypeNoop
-	if err := x.createGenericReservation(ctx, &reservation.Reservation); err != nil {
-		return fmt.Errorf("failed to create reservation record: %w", err)
+func (x *reservationDao) CreateNoop(ctx context.Context, reservation *models.NoopReservation, fn dao.TxFn) error {
+	txErr := dao.WithTransaction(ctx, func(tx pgx.Tx) error {
+		reservation.Provider = models.ProviderTypeNoop
+		if err := x.createGenericReservation(ctx, tx, &reservation.Reservation); err != nil {
+			return err
+		}
+
+		return callTxFn(tx, fn)
+	})
+
+	if txErr != nil {
+		return fmt.Errorf("pgx tx error: %w", txErr)
 	}
 	return nil
 }
 
-func (x *reservationDao) CreateAWS(ctx context.Context, reservation *models.AWSReservation) error {
+func (x *reservationDao) CreateAWS(ctx context.Context, reservation *models.AWSReservation, fn dao.TxFn) error {
 	txErr := dao.WithTransaction(ctx, func(tx pgx.Tx) error {
 		reservation.Provider = models.ProviderTypeAWS
-		if err := x.createGenericReservation(ctx, &reservation.Reservation); err != nil {
+		if err := x.createGenericReservation(ctx, tx, &reservation.Reservation); err != nil {
 			return err
 		}
 
 		awsQuery := `INSERT INTO aws_reservation_details (reservation_id, pubkey_id, source_id, image_id, detail)
-		VALUES ($1, $2, $3, $4, $5)`
-		tag, err := db.Pool.Exec(ctx, awsQuery,
+			VALUES ($1, $2, $3, $4, $5)`
+		tag, err := tx.Exec(ctx, awsQuery,
 			reservation.ID,
 			reservation.PubkeyID,
 			reservation.SourceID,
@@ -53,7 +62,7 @@ func (x *reservationDao) CreateAWS(ctx context.Context, reservation *models.AWSR
 			return fmt.Errorf("expected 1 row: %w", dao.ErrAffectedMismatch)
 		}
 
-		return nil
+		return callTxFn(tx, fn)
 	})
 
 	if txErr != nil {
@@ -62,16 +71,16 @@ func (x *reservationDao) CreateAWS(ctx context.Context, reservation *models.AWSR
 	return nil
 }
 
-func (x *reservationDao) CreateAzure(ctx context.Context, reservation *models.AzureReservation) error {
+func (x *reservationDao) CreateAzure(ctx context.Context, reservation *models.AzureReservation, fn dao.TxFn) error {
 	txErr := dao.WithTransaction(ctx, func(tx pgx.Tx) error {
 		reservation.Provider = models.ProviderTypeAzure
-		if err := x.createGenericReservation(ctx, &reservation.Reservation); err != nil {
+		if err := x.createGenericReservation(ctx, tx, &reservation.Reservation); err != nil {
 			return err
 		}
 
 		azureQuery := `INSERT INTO azure_reservation_details (reservation_id, pubkey_id, source_id, image_id, detail)
 			VALUES ($1, $2, $3, $4, $5)`
-		tag, err := db.Pool.Exec(ctx, azureQuery,
+		tag, err := tx.Exec(ctx, azureQuery,
 			reservation.ID,
 			reservation.PubkeyID,
 			reservation.SourceID,
@@ -84,7 +93,7 @@ func (x *reservationDao) CreateAzure(ctx context.Context, reservation *models.Az
 			return fmt.Errorf("expected 1 row: %w", dao.ErrAffectedMismatch)
 		}
 
-		return nil
+		return callTxFn(tx, fn)
 	})
 
 	if txErr != nil {
@@ -93,16 +102,16 @@ func (x *reservationDao) CreateAzure(ctx context.Context, reservation *models.Az
 	return nil
 }
 
-func (x *reservationDao) CreateGCP(ctx context.Context, reservation *models.GCPReservation) error {
+func (x *reservationDao) CreateGCP(ctx context.Context, reservation *models.GCPReservation, fn dao.TxFn) error {
 	txErr := dao.WithTransaction(ctx, func(tx pgx.Tx) error {
 		reservation.Provider = models.ProviderTypeGCP
-		if err := x.createGenericReservation(ctx, &reservation.Reservation); err != nil {
+		if err := x.createGenericReservation(ctx, tx, &reservation.Reservation); err != nil {
 			return err
 		}
 
 		gcpQuery := `INSERT INTO gcp_reservation_details (reservation_id, pubkey_id, source_id, image_id, detail)
 			VALUES ($1, $2, $3, $4, $5)`
-		tag, err := db.Pool.Exec(ctx, gcpQuery,
+		tag, err := tx.Exec(ctx, gcpQuery,
 			reservation.ID,
 			reservation.PubkeyID,
 			reservation.SourceID,
@@ -115,7 +124,19 @@ func (x *reservationDao) CreateGCP(ctx context.Context, reservation *models.GCPR
 			return fmt.Errorf("expected 1 row: %w", dao.ErrAffectedMismatch)
 		}
 
-		return nil
+		return callTxFn(tx, fn)
+	})
+
+	if txErr != nil {
+		return fmt.Errorf("pgx tx error: %w", txErr)
+	}
+	return nil
+}
+
+func (x *reservationDao) CreateComposite(ctx context.Context, reservation *models.CompositeReservation) error {
+	txErr := dao.WithTransaction(ctx, func(tx pgx.Tx) error {
+		reservation.Provider = models.ProviderTypeComposite
+		return x.createGenericReservation(ctx, tx, &reservation.Reservation)
 	})
 
 	if txErr != nil {
@@ -124,18 +145,34 @@ func (x *reservationDao) CreateGCP(ctx context.Context, reservation *models.GCPR
 	return nil
 }
 
-func (x *reservationDao) createGenericReservation(ctx context.Context, reservation *models.Reservation) error {
+// callTxFn calls the optional function passed to Create methods within the same transaction.
+func callTxFn(tx pgx.Tx, fn dao.TxFn) error {
+	if fn == nil {
+		return nil
+	}
+	return fn(tx)
+}
+
+func (x *reservationDao) createGenericReservation(ctx context.Context, tx pgx.Tx, reservation *models.Reservation) error {
 	reservation.AccountID = identity.AccountId(ctx)
 	reservation.Status = "Created"
+	if reservation.LaunchAt.Valid {
+		reservation.Status = "Scheduled"
+	}
 
-	reservationQuery := `INSERT INTO reservations (provider, account_id, steps, step_titles, status)
-		VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at`
-	err := db.Pool.QueryRow(ctx, reservationQuery,
+	reservationQuery := `INSERT INTO reservations (provider, account_id, steps, step_titles, status, job_id, launch_at, idempotency_key, parent_id, user_data_hash)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id, created_at`
+	err := tx.QueryRow(ctx, reservationQuery,
 		reservation.Provider,
 		reservation.AccountID,
 		reservation.Steps,
 		reservation.StepTitles,
-		reservation.Status).Scan(&reservation.ID, &reservation.CreatedAt)
+		reservation.Status,
+		reservation.JobID,
+		reservation.LaunchAt,
+		reservation.IdempotencyKey,
+		reservation.ParentID,
+		reservation.UserDataHash).Scan(&reservation.ID, &reservation.CreatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to create reservation record: %w", err)
 	}
@@ -188,6 +225,18 @@ func (x *reservationDao) GetById(ctx context.Context, id int64) (*models.Reserva
 	return result, nil
 }
 
+func (x *reservationDao) GetByIdempotencyKey(ctx context.Context, key string) (*models.Reservation, error) {
+	query := `SELECT * FROM reservations WHERE account_id = $1 AND idempotency_key = $2 LIMIT 1`
+	accountId := identity.AccountId(ctx)
+	result := &models.Reservation{}
+
+	err := pgxscan.Get(ctx, db.Pool, result, query, accountId, key)
+	if err != nil {
+		return nil, fmt.Errorf("pgx error: %w", err)
+	}
+	return result, nil
+}
+
 func (x *reservationDao) GetAWSById(ctx context.Context, id int64) (*models.AWSReservation, error) {
 	query := `SELECT id, provider, account_id, created_at, steps, step, status, error, finished_at, success,
     	pubkey_id, source_id, image_id, aws_reservation_id, detail
@@ -233,13 +282,12 @@ func (x *reservationDao) GetGCPById(ctx context.Context, id int64) (*models.GCPR
 	return result, nil
 }
 
-func (x *reservationDao) List(ctx context.Context, limit, offset int64) ([]*models.Reservation, error) {
-	query := `SELECT * FROM reservations WHERE account_id = $1 ORDER BY id LIMIT $2 OFFSET $3`
-
-	accountId := identity.AccountId(ctx)
+func (x *reservationDao) List(ctx context.Context, params dao.ListParams, filter dao.ReservationFilter) ([]*models.Reservation, error) {
+	q := reservationFilterQuery(ctx, filter)
+	query := q.page("reservations", params)
 	var result []*models.Reservation
 
-	rows, err := db.Pool.Query(ctx, query, accountId, limit, offset)
+	rows, err := db.Pool.Query(ctx, query, q.args...)
 	if err != nil {
 		return nil, fmt.Errorf("pgx error: %w", err)
 	}
@@ -248,6 +296,140 @@ func (x *reservationDao) List(ctx context.Context, limit, offset int64) ([]*mode
 	if err != nil {
 		return nil, fmt.Errorf("pgx error: %w", err)
 	}
+	reversePage(params, result)
+	return result, nil
+}
+
+func (x *reservationDao) Count(ctx context.Context, filter dao.ReservationFilter) (int64, error) {
+	q := reservationFilterQuery(ctx, filter)
+	var result int64
+
+	err := db.Pool.QueryRow(ctx, q.count("reservations"), q.args...).Scan(&result)
+	if err != nil {
+		return 0, fmt.Errorf("pgx error: %w", err)
+	}
+	return result, nil
+}
+
+func reservationFilterQuery(ctx context.Context, filter dao.ReservationFilter) *listQuery {
+	q := &listQuery{}
+	q.where("account_id = $%[1]d", identity.AccountId(ctx))
+	if filter.Provider != models.ProviderTypeUnknown {
+		q.where("provider = $%[1]d", filter.Provider)
+	}
+	if filter.Status != "" {
+		q.where("status = $%[1]d", filter.Status)
+	}
+	if filter.Success != nil {
+		q.where("success = $%[1]d", *filter.Success)
+	}
+	if filter.SourceID != "" {
+		q.where(`id IN (
+			SELECT reservation_id FROM aws_reservation_details WHERE source_id = $%[1]d
+			UNION ALL SELECT reservation_id FROM azure_reservation_details WHERE source_id = $%[1]d
+			UNION ALL SELECT reservation_id FROM gcp_reservation_details WHERE source_id = $%[1]d)`, filter.SourceID)
+	}
+	if filter.PubkeyID != 0 {
+		q.where(`id IN (
+			SELECT reservation_id FROM aws_reservation_details
+				WHERE pubkey_id = $%[1]d OR detail->'pubkey_ids' @> to_jsonb($%[1]d::BIGINT)
+			UNION ALL SELECT reservation_id FROM azure_reservation_details
+				WHERE pubkey_id = $%[1]d OR detail->'pubkey_ids' @> to_jsonb($%[1]d::BIGINT)
+			UNION ALL SELECT reservation_id FROM gcp_reservation_details
+				WHERE pubkey_id = $%[1]d OR detail->'pubkey_ids' @> to_jsonb($%[1]d::BIGINT))`, filter.PubkeyID)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		q.where("created_at >= $%[1]d", filter.CreatedAfter)
+	}
+	if !filter.CreatedBefore.IsZero() {
+		q.where("created_at < $%[1]d", filter.CreatedBefore)
+	}
+	return q
+}
+
+func (x *reservationDao) UnscopedListStale(ctx context.Context, olderThan time.Duration, limit int64) ([]*models.Reservation, error) {
+	query := `SELECT * FROM reservations
+		WHERE finished_at IS NULL AND coalesce(launch_at, created_at) < now() - $1 * interval '1 second'
+			AND provider <> provider_type_composite()
+		ORDER BY id LIMIT $2`
+	var result []*models.Reservation
+
+	err := pgxscan.Select(ctx, db.Pool, &result, query, int64(olderThan.Seconds()), limit)
+	if err != nil {
+		return nil, fmt.Errorf("pgx error: %w", err)
+	}
+	return result, nil
+}
+
+const countInflightQuery = `SELECT count(*) FROM reservations
+	WHERE account_id = $1 AND ($2 = 0 OR provider = $2) AND finished_at IS NULL
+		AND provider <> provider_type_composite()`
+
+const sumInstancesSinceQuery = `SELECT coalesce(sum((d.detail->>'amount')::bigint), 0) FROM reservations r, (
+		SELECT reservation_id, detail FROM aws_reservation_details
+		UNION ALL SELECT reservation_id, detail FROM azure_reservation_details
+		UNION ALL SELECT reservation_id, detail FROM gcp_reservation_details) d
+	WHERE d.reservation_id = r.id AND r.account_id = $1 AND ($2 = 0 OR r.provider = $2)
+		AND r.created_at >= $3 AND r.success IS DISTINCT FROM false`
+
+func (x *reservationDao) CountInflight(ctx context.Context, provider models.ProviderType) (int64, error) {
+	accountId := identity.AccountId(ctx)
+	var result int64
+
+	err := db.Pool.QueryRow(ctx, countInflightQuery, accountId, provider).Scan(&result)
+	if err != nil {
+		return 0, fmt.Errorf("pgx error: %w", err)
+	}
+	return result, nil
+}
+
+func (x *reservationDao) SumInstancesSince(ctx context.Context, provider models.ProviderType, since time.Time) (int64, error) {
+	accountId := identity.AccountId(ctx)
+	var result int64
+
+	err := db.Pool.QueryRow(ctx, sumInstancesSinceQuery, accountId, provider, since).Scan(&result)
+	if err != nil {
+		return 0, fmt.Errorf("pgx error: %w", err)
+	}
+	return result, nil
+}
+
+func (x *reservationDao) QuotaUsageTx(ctx context.Context, tx pgx.Tx, provider models.ProviderType, since time.Time) (*dao.QuotaUsage, error) {
+	// the key is not locked, reservations created in the transaction already hold a key share
+	// lock of the account row through the foreign key and other transactions can insert them too
+	lockQuery := `SELECT id FROM accounts WHERE id = $1 FOR NO KEY UPDATE`
+	accountId := identity.AccountId(ctx)
+	result := &dao.QuotaUsage{}
+
+	tag, err := tx.Exec(ctx, lockQuery, accountId)
+	if err != nil {
+		return nil, fmt.Errorf("pgx error: %w", err)
+	}
+	if tag.RowsAffected() != 1 {
+		return nil, fmt.Errorf("expected 1 row: %w", dao.ErrAffectedMismatch)
+	}
+
+	// counted after the lock was acquired, statements see reservations committed meanwhile
+	err = tx.QueryRow(ctx, countInflightQuery, accountId, provider).Scan(&result.Inflight)
+	if err != nil {
+		return nil, fmt.Errorf("pgx error: %w", err)
+	}
+	err = tx.QueryRow(ctx, sumInstancesSinceQuery, accountId, provider, since).Scan(&result.Instances)
+	if err != nil {
+		return nil, fmt.Errorf("pgx error: %w", err)
+	}
+	return result, nil
+}
+
+func (x *reservationDao) ListChildren(ctx context.Context, parentId int64) ([]*models.Reservation, error) {
+	query := `SELECT * FROM reservations WHERE account_id = $1 AND parent_id = $2 ORDER BY id`
+	accountId := identity.AccountId(ctx)
+	var result []*models.Reservation
+
+	err := pgxscan.Select(ctx, db.Pool, &result, query, accountId, parentId)
+	if err != nil {
+		return nil, fmt.Errorf("pgx error: %w", err)
+	}
 	return result, nil
 }
 
@@ -270,8 +452,40 @@ func (x *reservationDao) ListInstances(ctx context.Context, reservationId int64)
 	return result, nil
 }
 
+func (x *reservationDao) ListEvents(ctx context.Context, reservationId int64) ([]*models.ReservationEvent, error) {
+	query := `SELECT e.* FROM reservation_events e, reservations r
+		WHERE e.reservation_id = r.id AND r.account_id = $1 AND e.reservation_id = $2
+		ORDER BY e.id`
+
+	accountId := identity.AccountId(ctx)
+	var result []*models.ReservationEvent
+
+	rows, err := db.Pool.Query(ctx, query, accountId, reservationId)
+	if err != nil {
+		return nil, fmt.Errorf("pgx error: %w", err)
+	}
+
+	err = pgxscan.ScanAll(&result, rows)
+	if err != nil {
+		return nil, fmt.Errorf("pgx error: %w", err)
+	}
+	return result, nil
+}
+
+func (x *reservationDao) DeleteInstances(ctx context.Context, reservationId int64) error {
+	query := `DELETE FROM reservation_instances WHERE reservation_id = $1`
+
+	_, err := db.Pool.Exec(ctx, query, reservationId)
+	if err != nil {
+		return fmt.Errorf("pgx error: %w", err)
+	}
+	return nil
+}
+
 func (x *reservationDao) UpdateStatus(ctx context.Context, id int64, status string, addSteps int32) error {
-	query := `UPDATE reservations SET status = $2, step = step + $3 WHERE id = $1`
+	query := `WITH updated AS (
+			UPDATE reservations SET status = $2, step = step + $3 WHERE id = $1 RETURNING id, status, step)
+		INSERT INTO reservation_events (reservation_id, status, step) SELECT id, status, step FROM updated`
 
 	tag, err := db.Pool.Exec(ctx, query, id, status, addSteps)
 	if err != nil {
@@ -283,6 +497,42 @@ func (x *reservationDao) UpdateStatus(ctx context.Context, id int64, status stri
 	return nil
 }
 
+func (x *reservationDao) UpdateCompositeProgress(ctx context.Context, id int64) (*models.Reservation, error) {
+	result := &models.Reservation{}
+
+	txErr := dao.WithTransaction(ctx, func(tx pgx.Tx) error {
+		// children finishing concurrently must see each other
+		lockQuery := `SELECT id FROM reservations WHERE id = $1 FOR UPDATE`
+		if _, err := tx.Exec(ctx, lockQuery, id); err != nil {
+			return fmt.Errorf("pgx error: %w", err)
+		}
+
+		query := `WITH children AS (
+				SELECT count(*) AS total, count(finished_at) AS finished, count(*) FILTER (WHERE success = false) AS failed
+				FROM reservations WHERE parent_id = $1),
+			updated AS (
+				UPDATE reservations r SET
+					step = c.finished,
+					status = format('Finished %s of %s reservations', c.finished, c.total),
+					finished_at = CASE WHEN c.finished = c.total THEN now() END,
+					success = CASE WHEN c.finished = c.total THEN c.failed = 0 END,
+					error = CASE WHEN c.failed > 0 THEN format('%s of %s reservations failed', c.failed, c.total) ELSE '' END
+				FROM children c WHERE r.id = $1 AND r.finished_at IS NULL RETURNING r.*),
+			events AS (
+				INSERT INTO reservation_events (reservation_id, status, step) SELECT id, status, step FROM updated)
+			SELECT * FROM updated`
+		if err := pgxscan.Get(ctx, tx, result, query, id); err != nil {
+			return fmt.Errorf("pgx error: %w", err)
+		}
+		return nil
+	})
+
+	if txErr != nil {
+		return nil, fmt.Errorf("pgx tx error: %w", txErr)
+	}
+	return result, nil
+}
+
 func (x *reservationDao) UnscopedUpdateAWSDetail(ctx context.Context, id int64, awsDetail *models.AWSDetail) error {
 	query := `UPDATE aws_reservation_details SET detail = $2 WHERE reservation_id = $1`
 
@@ -323,9 +573,51 @@ func (x *reservationDao) UpdateOperationNameForGCP(ctx context.Context, id int64
 }
 
 func (x *reservationDao) FinishWithSuccess(ctx context.Context, id int64) error {
-	query := `UPDATE reservations SET success = true, finished_at = now() WHERE id = $1`
+	query := `UPDATE reservations SET success = true, finished_at = now() WHERE id = $1 AND finished_at IS NULL`
+	return finishReservation(ctx, query, id)
+}
 
-	tag, err := db.Pool.Exec(ctx, query, id)
+func (x *reservationDao) FinishWithError(ctx context.Context, id int64, errorString string) error {
+	query := `UPDATE reservations SET success = false, error = $2, finished_at = now() WHERE id = $1 AND finished_at IS NULL`
+	return finishReservation(ctx, query, id, errorString)
+}
+
+// clearLaunchInputQuery removes launch input (models.LaunchInput) from provider details of a
+// reservation, user data secrets are not kept after the launch nor archived.
+const clearLaunchInputQuery = `WITH
+	aws AS (UPDATE aws_reservation_details SET detail = detail - 'user_data' - 'rhc_activation_key' - 'rhc_org_id' WHERE reservation_id = $1),
+	azure AS (UPDATE azure_reservation_details SET detail = detail - 'user_data' - 'rhc_activation_key' - 'rhc_org_id' WHERE reservation_id = $1)
+	UPDATE gcp_reservation_details SET detail = detail - 'user_data' - 'rhc_activation_key' - 'rhc_org_id' WHERE reservation_id = $1`
+
+// finishReservation executes a query finishing reservation with the ID as the first argument
+// and clears its launch input in the same transaction.
+func finishReservation(ctx context.Context, query string, id int64, args ...any) error {
+	txErr := dao.WithTransaction(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, query, append([]any{id}, args...)...)
+		if err != nil {
+			return fmt.Errorf("pgx error: %w", err)
+		}
+		if tag.RowsAffected() != 1 {
+			return fmt.Errorf("expected 1 row: %w", dao.ErrAffectedMismatch)
+		}
+
+		if _, err = tx.Exec(ctx, clearLaunchInputQuery, id); err != nil {
+			return fmt.Errorf("pgx error: %w", err)
+		}
+		return nil
+	})
+	if txErr != nil {
+		return fmt.Errorf("pgx tx error: %w", txErr)
+	}
+	return nil
+}
+
+func (x *reservationDao) QueueTeardown(ctx context.Context, id int64) error {
+	query := `UPDATE reservations SET teardown_queued_at = now(), teardown_error = ''
+		WHERE account_id = $1 AND id = $2 AND finished_at IS NOT NULL AND teardown_queued_at IS NULL`
+	accountId := identity.AccountId(ctx)
+
+	tag, err := db.Pool.Exec(ctx, query, accountId, id)
 	if err != nil {
 		return fmt.Errorf("pgx error: %w", err)
 	}
@@ -335,10 +627,40 @@ func (x *reservationDao) FinishWithSuccess(ctx context.Context, id int64) error
 	return nil
 }
 
-func (x *reservationDao) FinishWithError(ctx context.Context, id int64, errorString string) error {
-	query := `UPDATE reservations SET success = false, error = $2, finished_at = now() WHERE id = $1`
+func (x *reservationDao) FinishTeardown(ctx context.Context, id int64, status string, errorString string) error {
+	query := `WITH updated AS (
+			UPDATE reservations SET status = $2, teardown_error = $3, teardown_queued_at = NULL
+			WHERE id = $1 AND teardown_queued_at IS NOT NULL RETURNING id, status, step)
+		INSERT INTO reservation_events (reservation_id, status, step) SELECT id, status, step FROM updated`
 
-	tag, err := db.Pool.Exec(ctx, query, id, errorString)
+	tag, err := db.Pool.Exec(ctx, query, id, status, errorString)
+	if err != nil {
+		return fmt.Errorf("pgx error: %w", err)
+	}
+	if tag.RowsAffected() != 1 {
+		return fmt.Errorf("expected 1 row: %w", dao.ErrAffectedMismatch)
+	}
+	return nil
+}
+
+func (x *reservationDao) UnscopedFinishStaleTeardowns(ctx context.Context, olderThan time.Duration, status string, errorString string) (int64, error) {
+	query := `WITH updated AS (
+			UPDATE reservations SET status = $2, teardown_error = $3, teardown_queued_at = NULL
+			WHERE teardown_queued_at < now() - $1 * interval '1 second' RETURNING id, status, step)
+		INSERT INTO reservation_events (reservation_id, status, step) SELECT id, status, step FROM updated`
+
+	tag, err := db.Pool.Exec(ctx, query, int64(olderThan.Seconds()), status, errorString)
+	if err != nil {
+		return 0, fmt.Errorf("pgx error: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (x *reservationDao) Cancel(ctx context.Context, id int64) error {
+	query := `UPDATE reservations SET cancelled = true WHERE account_id = $1 AND id = $2 AND finished_at IS NULL`
+	accountId := identity.AccountId(ctx)
+
+	tag, err := db.Pool.Exec(ctx, query, accountId, id)
 	if err != nil {
 		return fmt.Errorf("pgx error: %w", err)
 	}
@@ -360,3 +682,86 @@ func (x *reservationDao) Delete(ctx context.Context, id int64) error {
 	}
 	return nil
 }
+
+// expiredReservationsCondition selects reservations finished before $1 seconds. Composite
+// reservations are only expired when all their children are, because children are deleted
+// together with their parent.
+const expiredReservationsCondition = `finished_at < now() - $1 * interval '1 second'
+	AND NOT EXISTS (SELECT 1 FROM reservations c WHERE c.parent_id = reservations.id
+		AND (c.finished_at IS NULL OR c.finished_at >= now() - $1 * interval '1 second'))`
+
+func (x *reservationDao) UnscopedCountExpired(ctx context.Context, olderThan time.Duration) (int64, error) {
+	query := `SELECT count(*) FROM reservations WHERE ` + expiredReservationsCondition
+
+	var count int64
+	err := db.Pool.QueryRow(ctx, query, int64(olderThan.Seconds())).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("pgx error: %w", err)
+	}
+	return count, nil
+}
+
+func (x *reservationDao) UnscopedDeleteExpired(ctx context.Context, olderThan time.Duration, limit int64, archive bool) (int64, error) {
+	var deleted int64
+
+	txErr := dao.WithTransaction(ctx, func(tx pgx.Tx) error {
+		selectQuery := `SELECT id FROM reservations WHERE ` + expiredReservationsCondition + `
+			ORDER BY id LIMIT $2 FOR UPDATE SKIP LOCKED`
+		rows, err := tx.Query(ctx, selectQuery, int64(olderThan.Seconds()), limit)
+		if err != nil {
+			return fmt.Errorf("pgx error: %w", err)
+		}
+		ids, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+		if err != nil {
+			return fmt.Errorf("pgx error: %w", err)
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		// children of composite reservations are deleted via cascade, they must be archived too
+		childrenQuery := `SELECT id FROM reservations WHERE parent_id = ANY($1) AND id <> ALL($1) FOR UPDATE`
+		rows, err = tx.Query(ctx, childrenQuery, ids)
+		if err != nil {
+			return fmt.Errorf("pgx error: %w", err)
+		}
+		childIds, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+		if err != nil {
+			return fmt.Errorf("pgx error: %w", err)
+		}
+		ids = append(ids, childIds...)
+
+		if archive {
+			archiveQuery := `INSERT INTO reservations_archive (id, account_id, provider, created_at, finished_at, data)
+				SELECT r.id, r.account_id, r.provider, r.created_at, r.finished_at, jsonb_build_object(
+					'reservation', to_jsonb(r),
+					'aws', (SELECT to_jsonb(d) FROM aws_reservation_details d WHERE d.reservation_id = r.id),
+					'azure', (SELECT to_jsonb(d) FROM azure_reservation_details d WHERE d.reservation_id = r.id),
+					'gcp', (SELECT to_jsonb(d) FROM gcp_reservation_details d WHERE d.reservation_id = r.id),
+					'instances', (SELECT coalesce(jsonb_agg(to_jsonb(i)), '[]') FROM reservation_instances i WHERE i.reservation_id = r.id),
+					'events', (SELECT coalesce(jsonb_agg(to_jsonb(e) ORDER BY e.id), '[]') FROM reservation_events e WHERE e.reservation_id = r.id))
+				FROM reservations r WHERE r.id = ANY($1)`
+			tag, err := tx.Exec(ctx, archiveQuery, ids)
+			if err != nil {
+				return fmt.Errorf("pgx error: %w", err)
+			}
+			if tag.RowsAffected() != int64(len(ids)) {
+				return fmt.Errorf("expected %d rows: %w", len(ids), dao.ErrAffectedMismatch)
+			}
+		}
+
+		// details, instances and events are deleted via cascade
+		deleteQuery := `DELETE FROM reservations WHERE id = ANY($1)`
+		tag, err := tx.Exec(ctx, deleteQuery, ids)
+		if err != nil {
+			return fmt.Errorf("pgx error: %w", err)
+		}
+		deleted = tag.RowsAffected()
+
+		return nil
+	})
+	if txErr != nil {
+		return 0, fmt.Errorf("pgx tx error: %w", txErr)
+	}
+	return deleted, nil
+}
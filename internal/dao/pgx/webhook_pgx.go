@@ -0,0 +1,193 @@
+package pgx
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
+	"github.com/RHEnVision/provisioning-backend/internal/db"
+	"github.com/RHEnVision/provisioning-backend/internal/identity"
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/georgysavva/scany/v2/pgxscan"
+)
+
+func init() {
+	dao.GetWebhookDao = getWebhookDao
+}
+
+type webhookDao struct{}
+
+func getWebhookDao(ctx context.Context) dao.WebhookDao {
+	return &webhookDao{}
+}
+
+func (x *webhookDao) Create(ctx context.Context, webhook *models.Webhook) error {
+	query := `
+		INSERT INTO webhooks (account_id, url, secret, enabled)
+		VALUES ($1, $2, $3, $4) RETURNING id, created_at`
+
+	webhook.AccountID = identity.AccountId(ctx)
+
+	if vError := models.Validate(ctx, webhook); vError != nil {
+		return fmt.Errorf("webhook validation: %w", vError)
+	}
+
+	err := db.Pool.QueryRow(ctx, query, webhook.AccountID, webhook.URL, webhook.Secret, webhook.Enabled).Scan(&webhook.ID, &webhook.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("pgx error: %w", err)
+	}
+
+	return nil
+}
+
+func (x *webhookDao) Update(ctx context.Context, webhook *models.Webhook) error {
+	query := `UPDATE webhooks SET url = $3, secret = $4, enabled = $5 WHERE account_id = $1 AND id = $2`
+	accountId := identity.AccountId(ctx)
+
+	if vError := models.Validate(ctx, webhook); vError != nil {
+		return fmt.Errorf("webhook validation: %w", vError)
+	}
+
+	tag, err := db.Pool.Exec(ctx, query, accountId, webhook.ID, webhook.URL, webhook.Secret, webhook.Enabled)
+	if err != nil {
+		return fmt.Errorf("pgx error: %w", err)
+	}
+	if tag.RowsAffected() != 1 {
+		return fmt.Errorf("expected 1 row: %w", dao.ErrAffectedMismatch)
+	}
+	return nil
+}
+
+func (x *webhookDao) GetById(ctx context.Context, id int64) (*models.Webhook, error) {
+	query := `SELECT * FROM webhooks WHERE account_id = $1 AND id = $2 LIMIT 1`
+	accountId := identity.AccountId(ctx)
+	result := &models.Webhook{}
+
+	err := pgxscan.Get(ctx, db.Pool, result, query, accountId, id)
+	if err != nil {
+		return nil, fmt.Errorf("pgx error: %w", err)
+	}
+	return result, nil
+}
+
+func (x *webhookDao) List(ctx context.Context, params dao.ListParams) ([]*models.Webhook, error) {
+	q := &listQuery{}
+	q.where("account_id = $%[1]d", identity.AccountId(ctx))
+	query := q.page("webhooks", params)
+	var result []*models.Webhook
+
+	rows, err := db.Pool.Query(ctx, query, q.args...)
+	if err != nil {
+		return nil, fmt.Errorf("pgx error: %w", err)
+	}
+
+	err = pgxscan.ScanAll(&result, rows)
+	if err != nil {
+		return nil, fmt.Errorf("pgx error: %w", err)
+	}
+	reversePage(params, result)
+	return result, nil
+}
+
+func (x *webhookDao) Count(ctx context.Context) (int64, error) {
+	query := `SELECT count(*) FROM webhooks WHERE account_id = $1`
+	accountId := identity.AccountId(ctx)
+	var result int64
+
+	err := db.Pool.QueryRow(ctx, query, accountId).Scan(&result)
+	if err != nil {
+		return 0, fmt.Errorf("pgx error: %w", err)
+	}
+	return result, nil
+}
+
+func (x *webhookDao) ListEnabled(ctx context.Context) ([]*models.Webhook, error) {
+	query := `SELECT * FROM webhooks WHERE account_id = $1 AND enabled ORDER BY id`
+	accountId := identity.AccountId(ctx)
+	var result []*models.Webhook
+
+	rows, err := db.Pool.Query(ctx, query, accountId)
+	if err != nil {
+		return nil, fmt.Errorf("pgx error: %w", err)
+	}
+
+	err = pgxscan.ScanAll(&result, rows)
+	if err != nil {
+		return nil, fmt.Errorf("pgx error: %w", err)
+	}
+	return result, nil
+}
+
+func (x *webhookDao) Delete(ctx context.Context, id int64) error {
+	query := `DELETE FROM webhooks WHERE account_id = $1 AND id = $2`
+	accountId := identity.AccountId(ctx)
+
+	tag, err := db.Pool.Exec(ctx, query, accountId, id)
+	if err != nil {
+		return fmt.Errorf("pgx error: %w", err)
+	}
+	if tag.RowsAffected() != 1 {
+		return fmt.Errorf("expected 1 row: %w", dao.ErrAffectedMismatch)
+	}
+	return nil
+}
+
+func (x *webhookDao) UnscopedCreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries (webhook_id, reservation_id, event, attempt, status_code, success, error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at`
+
+	err := db.Pool.QueryRow(ctx, query,
+		delivery.WebhookID,
+		delivery.ReservationID,
+		delivery.Event,
+		delivery.Attempt,
+		delivery.StatusCode,
+		delivery.Success,
+		delivery.Error).Scan(&delivery.ID, &delivery.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("pgx error: %w", err)
+	}
+
+	return nil
+}
+
+// deliveryFilterQuery returns conditions of deliveries of an account webhook matching the filter.
+func deliveryFilterQuery(ctx context.Context, webhookId int64, filter dao.WebhookDeliveryFilter) *listQuery {
+	q := &listQuery{}
+	q.where("webhook_id = $%[1]d", webhookId)
+	q.where("webhook_id IN (SELECT id FROM webhooks WHERE account_id = $%[1]d)", identity.AccountId(ctx))
+	if filter.Success != nil {
+		q.where("success = $%[1]d", *filter.Success)
+	}
+	return q
+}
+
+func (x *webhookDao) ListDeliveries(ctx context.Context, webhookId int64, params dao.ListParams, filter dao.WebhookDeliveryFilter) ([]*models.WebhookDelivery, error) {
+	q := deliveryFilterQuery(ctx, webhookId, filter)
+	query := q.page("webhook_deliveries", params)
+	var result []*models.WebhookDelivery
+
+	rows, err := db.Pool.Query(ctx, query, q.args...)
+	if err != nil {
+		return nil, fmt.Errorf("pgx error: %w", err)
+	}
+
+	err = pgxscan.ScanAll(&result, rows)
+	if err != nil {
+		return nil, fmt.Errorf("pgx error: %w", err)
+	}
+	reversePage(params, result)
+	return result, nil
+}
+
+func (x *webhookDao) CountDeliveries(ctx context.Context, webhookId int64, filter dao.WebhookDeliveryFilter) (int64, error) {
+	q := deliveryFilterQuery(ctx, webhookId, filter)
+	var result int64
+
+	err := db.Pool.QueryRow(ctx, q.count("webhook_deliveries"), q.args...).Scan(&result)
+	if err != nil {
+		return 0, fmt.Errorf("pgx error: %w", err)
+	}
+	return result, nil
+}
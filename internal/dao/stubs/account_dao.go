@@ -102,3 +102,12 @@ func (stub *accountDaoStub) getByAccountNumber(ctx context.Context, number strin
 func (stub *accountDaoStub) List(ctx context.Context, limit, offset int64) ([]*models.Account, error) {
 	return stub.store, nil
 }
+
+func (stub *accountDaoStub) UpdateQuota(ctx context.Context, id int64, quota *models.AccountQuota) error {
+	acc, err := stub.GetById(ctx, id)
+	if err != nil {
+		return dao.ErrAffectedMismatch
+	}
+	acc.Quota = *quota
+	return nil
+}
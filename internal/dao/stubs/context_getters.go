@@ -14,6 +14,8 @@ const (
 	accountCtxKey     daoStubCtxKeyType = iota
 	pubkeyCtxKey      daoStubCtxKeyType = iota
 	reservationCtxKey daoStubCtxKeyType = iota
+	webhookCtxKey     daoStubCtxKeyType = iota
+	profileCtxKey     daoStubCtxKeyType = iota
 )
 
 func ctxAccountId(ctx context.Context) int64 {
@@ -45,6 +47,7 @@ func WithReservationDao(parent context.Context) context.Context {
 
 	ctx := context.WithValue(parent, reservationCtxKey, &reservationDaoStub{
 		instances: make(map[int64][]*models.ReservationInstance),
+		events:    make(map[int64][]*models.ReservationEvent),
 	})
 	return ctx
 }
@@ -58,6 +61,42 @@ func getReservationDaoStub(ctx context.Context) *reservationDaoStub {
 	return resDao
 }
 
+func WithWebhookDao(parent context.Context) context.Context {
+	if parent.Value(webhookCtxKey) != nil {
+		panic(dao.ErrStubContextAlreadySet)
+	}
+
+	ctx := context.WithValue(parent, webhookCtxKey, &webhookDaoStub{})
+	return ctx
+}
+
+func getWebhookDaoStub(ctx context.Context) *webhookDaoStub {
+	var ok bool
+	var whDao *webhookDaoStub
+	if whDao, ok = ctx.Value(webhookCtxKey).(*webhookDaoStub); !ok {
+		panic(dao.ErrStubMissingContext)
+	}
+	return whDao
+}
+
+func WithLaunchProfileDao(parent context.Context) context.Context {
+	if parent.Value(profileCtxKey) != nil {
+		panic(dao.ErrStubContextAlreadySet)
+	}
+
+	ctx := context.WithValue(parent, profileCtxKey, &launchProfileDaoStub{})
+	return ctx
+}
+
+func getLaunchProfileDaoStub(ctx context.Context) *launchProfileDaoStub {
+	var ok bool
+	var lpDao *launchProfileDaoStub
+	if lpDao, ok = ctx.Value(profileCtxKey).(*launchProfileDaoStub); !ok {
+		panic(dao.ErrStubMissingContext)
+	}
+	return lpDao
+}
+
 func WithAccountDaoOne(parent context.Context) context.Context {
 	if parent.Value(accountCtxKey) != nil {
 		panic(dao.ErrStubContextAlreadySet)
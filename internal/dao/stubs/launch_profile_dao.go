@@ -0,0 +1,96 @@
+package stubs
+
+import (
+	"context"
+	"time"
+
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+)
+
+type launchProfileDaoStub struct {
+	lastId int64
+	store  []*models.LaunchProfile
+}
+
+func init() {
+	dao.GetLaunchProfileDao = getLaunchProfileDao
+}
+
+func getLaunchProfileDao(ctx context.Context) dao.LaunchProfileDao {
+	return getLaunchProfileDaoStub(ctx)
+}
+
+func (stub *launchProfileDaoStub) Create(ctx context.Context, profile *models.LaunchProfile) error {
+	profile.AccountID = ctxAccountId(ctx)
+	if err := models.Validate(ctx, profile); err != nil {
+		return dao.ErrValidation
+	}
+
+	profile.ID = stub.lastId + 1
+	profile.CreatedAt = time.Now()
+	stub.store = append(stub.store, profile)
+	stub.lastId++
+	return nil
+}
+
+func (stub *launchProfileDaoStub) Update(ctx context.Context, profile *models.LaunchProfile) error {
+	if err := models.Validate(ctx, profile); err != nil {
+		return dao.ErrValidation
+	}
+
+	for idx, lp := range stub.store {
+		if lp.AccountID == ctxAccountId(ctx) && lp.ID == profile.ID {
+			stub.store[idx] = profile
+			return nil
+		}
+	}
+	return dao.ErrAffectedMismatch
+}
+
+func (stub *launchProfileDaoStub) GetById(ctx context.Context, id int64) (*models.LaunchProfile, error) {
+	for _, lp := range stub.store {
+		if lp.AccountID == ctxAccountId(ctx) && lp.ID == id {
+			return lp, nil
+		}
+	}
+	return nil, dao.ErrNoRows
+}
+
+func (stub *launchProfileDaoStub) List(ctx context.Context, params dao.ListParams) ([]*models.LaunchProfile, error) {
+	return page(params, stub.filter(ctx), func(lp *models.LaunchProfile) int64 { return lp.ID }), nil
+}
+
+func (stub *launchProfileDaoStub) Count(ctx context.Context) (int64, error) {
+	return int64(len(stub.filter(ctx))), nil
+}
+
+func (stub *launchProfileDaoStub) CountByPubkeyId(ctx context.Context, pubkeyId int64) (int64, error) {
+	var result int64
+	for _, lp := range stub.filter(ctx) {
+		if lp.PubkeyID == pubkeyId {
+			result++
+		}
+	}
+	return result, nil
+}
+
+func (stub *launchProfileDaoStub) filter(ctx context.Context) []*models.LaunchProfile {
+	var filtered []*models.LaunchProfile
+	for _, lp := range stub.store {
+		if lp.AccountID == ctxAccountId(ctx) {
+			filtered = append(filtered, lp)
+		}
+	}
+	return filtered
+}
+
+func (stub *launchProfileDaoStub) Delete(ctx context.Context, id int64) error {
+	for idx, lp := range stub.store {
+		if lp.AccountID == ctxAccountId(ctx) && lp.ID == id {
+			stub.store = append(stub.store[:idx], stub.store[idx+1:]...)
+			return nil
+		}
+	}
+	return dao.ErrAffectedMismatch
+}
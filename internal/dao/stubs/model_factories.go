@@ -13,5 +13,5 @@ func AddPubkey(ctx context.Context, pubkey *models.Pubkey) error {
 
 func AddAWSReservation(ctx context.Context, reservation *models.AWSReservation) error {
 	reservationDao := getReservationDaoStub(ctx)
-	return reservationDao.CreateAWS(ctx, reservation)
+	return reservationDao.CreateAWS(ctx, reservation, nil)
 }
@@ -0,0 +1,38 @@
+package stubs
+
+import (
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
+)
+
+// page applies pagination parameters to records ordered by ascending ID.
+func page[T any](params dao.ListParams, records []T, id func(T) int64) []T {
+	follows := func(a, b int64) bool {
+		if params.Descending {
+			return a < b
+		}
+		return a > b
+	}
+
+	ordered := make([]T, 0, len(records))
+	for i := range records {
+		record := records[i]
+		if params.Descending {
+			record = records[len(records)-1-i]
+		}
+		if params.After != 0 && !follows(id(record), params.After) {
+			continue
+		}
+		if params.Before != 0 && !follows(params.Before, id(record)) {
+			continue
+		}
+		ordered = append(ordered, record)
+	}
+
+	if int64(len(ordered)) <= params.Limit {
+		return ordered
+	}
+	if params.Backward() {
+		return ordered[int64(len(ordered))-params.Limit:]
+	}
+	return ordered[:params.Limit]
+}
@@ -72,14 +72,22 @@ func (stub *pubkeyDaoStub) GetById(ctx context.Context, id int64) (*models.Pubke
 	return nil, dao.ErrNoRows
 }
 
-func (stub *pubkeyDaoStub) List(ctx context.Context, limit, offset int64) ([]*models.Pubkey, error) {
+func (stub *pubkeyDaoStub) List(ctx context.Context, params dao.ListParams) ([]*models.Pubkey, error) {
+	return page(params, stub.filter(ctx), func(pk *models.Pubkey) int64 { return pk.ID }), nil
+}
+
+func (stub *pubkeyDaoStub) Count(ctx context.Context) (int64, error) {
+	return int64(len(stub.filter(ctx))), nil
+}
+
+func (stub *pubkeyDaoStub) filter(ctx context.Context) []*models.Pubkey {
 	var filtered []*models.Pubkey
 	for _, pk := range stub.store {
 		if pk.AccountID == ctxAccountId(ctx) {
 			filtered = append(filtered, pk)
 		}
 	}
-	return filtered, nil
+	return filtered
 }
 
 func (stub *pubkeyDaoStub) Delete(ctx context.Context, id int64) error {
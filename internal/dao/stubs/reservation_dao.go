@@ -2,20 +2,30 @@ package stubs
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/RHEnVision/provisioning-backend/internal/clients"
 	"github.com/RHEnVision/provisioning-backend/internal/dao"
 	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/exp/slices"
 )
 
 type reservationDaoStub struct {
-	storeAWS   []*models.AWSReservation
-	storeAzure []*models.AzureReservation
-	storeGCP   []*models.GCPReservation
-	instances  map[int64][]*models.ReservationInstance
+	storeAWS       []*models.AWSReservation
+	storeAzure     []*models.AzureReservation
+	storeGCP       []*models.GCPReservation
+	storeComposite []*models.CompositeReservation
+	instances      map[int64][]*models.ReservationInstance
+	events         map[int64][]*models.ReservationEvent
 }
 
+// compositeIdOffset separates IDs of composite reservations from IDs of their children, the
+// stub stores have independent ID sequences.
+const compositeIdOffset = 1000
+
 func init() {
 	dao.GetReservationDao = getReservationDao
 }
@@ -39,28 +49,68 @@ func getReservationDao(ctx context.Context) dao.ReservationDao {
 	return getReservationDaoStub(ctx)
 }
 
-func (stub *reservationDaoStub) CreateAWS(ctx context.Context, reservation *models.AWSReservation) error {
+func (stub *reservationDaoStub) CreateAWS(ctx context.Context, reservation *models.AWSReservation, fn dao.TxFn) error {
 	reservation.ID = int64(len(stub.storeAWS)) + 1
+	if reservation.CreatedAt.IsZero() {
+		reservation.CreatedAt = time.Now()
+	}
 	stub.storeAWS = append(stub.storeAWS, reservation)
+	if err := callTxFn(fn); err != nil {
+		stub.storeAWS = stub.storeAWS[:len(stub.storeAWS)-1]
+		return err
+	}
 	return nil
 }
 
-func (stub *reservationDaoStub) CreateAzure(ctx context.Context, reservation *models.AzureReservation) error {
+func (stub *reservationDaoStub) CreateAzure(ctx context.Context, reservation *models.AzureReservation, fn dao.TxFn) error {
 	reservation.ID = int64(len(stub.storeAzure)) + 1
+	if reservation.CreatedAt.IsZero() {
+		reservation.CreatedAt = time.Now()
+	}
 	stub.storeAzure = append(stub.storeAzure, reservation)
+	if err := callTxFn(fn); err != nil {
+		stub.storeAzure = stub.storeAzure[:len(stub.storeAzure)-1]
+		return err
+	}
 	return nil
 }
 
-func (stub *reservationDaoStub) CreateGCP(ctx context.Context, reservation *models.GCPReservation) error {
+func (stub *reservationDaoStub) CreateGCP(ctx context.Context, reservation *models.GCPReservation, fn dao.TxFn) error {
 	reservation.ID = int64(len(stub.storeGCP)) + 1
+	if reservation.CreatedAt.IsZero() {
+		reservation.CreatedAt = time.Now()
+	}
 	stub.storeGCP = append(stub.storeGCP, reservation)
+	if err := callTxFn(fn); err != nil {
+		stub.storeGCP = stub.storeGCP[:len(stub.storeGCP)-1]
+		return err
+	}
 	return nil
 }
 
-func (stub *reservationDaoStub) CreateNoop(ctx context.Context, reservation *models.NoopReservation) error {
+func (stub *reservationDaoStub) CreateNoop(ctx context.Context, reservation *models.NoopReservation, fn dao.TxFn) error {
+	return callTxFn(fn)
+}
+
+func (stub *reservationDaoStub) CreateComposite(ctx context.Context, reservation *models.CompositeReservation) error {
+	reservation.ID = compositeIdOffset + int64(len(stub.storeComposite)) + 1
+	reservation.Provider = models.ProviderTypeComposite
+	reservation.AccountID = ctxAccountId(ctx)
+	if reservation.CreatedAt.IsZero() {
+		reservation.CreatedAt = time.Now()
+	}
+	stub.storeComposite = append(stub.storeComposite, reservation)
 	return nil
 }
 
+// callTxFn calls the optional function passed to Create methods, stubs have no transaction.
+func callTxFn(fn dao.TxFn) error {
+	if fn == nil {
+		return nil
+	}
+	return fn(nil)
+}
+
 func (stub *reservationDaoStub) CreateInstance(ctx context.Context, resInstance *models.ReservationInstance) error {
 	resId := resInstance.ReservationID
 	stub.instances[resId] = append(stub.instances[resId], resInstance)
@@ -73,6 +123,33 @@ func (stub *reservationDaoStub) GetById(ctx context.Context, id int64) (*models.
 			return &awsReservation.Reservation, nil
 		}
 	}
+	for _, compositeReservation := range stub.storeComposite {
+		if compositeReservation.AccountID == ctxAccountId(ctx) && compositeReservation.ID == id {
+			return &compositeReservation.Reservation, nil
+		}
+	}
+	return nil, dao.ErrNoRows
+}
+
+func (stub *reservationDaoStub) GetByIdempotencyKey(ctx context.Context, key string) (*models.Reservation, error) {
+	var found []*models.Reservation
+	for _, res := range stub.storeAWS {
+		found = append(found, &res.Reservation)
+	}
+	for _, res := range stub.storeAzure {
+		found = append(found, &res.Reservation)
+	}
+	for _, res := range stub.storeGCP {
+		found = append(found, &res.Reservation)
+	}
+	for _, res := range stub.storeComposite {
+		found = append(found, &res.Reservation)
+	}
+	for _, res := range found {
+		if res.AccountID == ctxAccountId(ctx) && res.IdempotencyKey.Valid && res.IdempotencyKey.String == key {
+			return res, nil
+		}
+	}
 	return nil, dao.ErrNoRows
 }
 
@@ -103,18 +180,201 @@ func (stub *reservationDaoStub) GetGCPById(ctx context.Context, id int64) (*mode
 	return nil, dao.ErrNoRows
 }
 
-func (stub *reservationDaoStub) List(ctx context.Context, limit, offset int64) ([]*models.Reservation, error) {
-	return nil, nil
+func (stub *reservationDaoStub) List(ctx context.Context, params dao.ListParams, filter dao.ReservationFilter) ([]*models.Reservation, error) {
+	return page(params, stub.filter(ctx, filter), func(r *models.Reservation) int64 { return r.ID }), nil
+}
+
+func (stub *reservationDaoStub) Count(ctx context.Context, filter dao.ReservationFilter) (int64, error) {
+	return int64(len(stub.filter(ctx, filter))), nil
+}
+
+// filter returns AWS reservations matching the filter, other types are not supported
+func (stub *reservationDaoStub) filter(ctx context.Context, filter dao.ReservationFilter) []*models.Reservation {
+	var result []*models.Reservation
+	for _, awsReservation := range stub.storeAWS {
+		r := awsReservation.Reservation
+		if r.AccountID != ctxAccountId(ctx) ||
+			(filter.Provider != models.ProviderTypeUnknown && r.Provider != filter.Provider) ||
+			(filter.Status != "" && r.Status != filter.Status) ||
+			(filter.Success != nil && (!r.Success.Valid || r.Success.Bool != *filter.Success)) ||
+			(filter.SourceID != "" && awsReservation.SourceID != filter.SourceID) ||
+			(filter.PubkeyID != 0 && !authorizesPubkey(awsReservation, filter.PubkeyID)) ||
+			(!filter.CreatedAfter.IsZero() && r.CreatedAt.Before(filter.CreatedAfter)) ||
+			(!filter.CreatedBefore.IsZero() && !r.CreatedAt.Before(filter.CreatedBefore)) {
+			continue
+		}
+		result = append(result, &awsReservation.Reservation)
+	}
+	return result
+}
+
+func authorizesPubkey(reservation *models.AWSReservation, pubkeyId int64) bool {
+	if reservation.PubkeyID == pubkeyId {
+		return true
+	}
+	return reservation.Detail != nil && slices.Contains(reservation.Detail.PubkeyIDs, pubkeyId)
+}
+
+func (stub *reservationDaoStub) UnscopedListStale(ctx context.Context, olderThan time.Duration, limit int64) ([]*models.Reservation, error) {
+	var result []*models.Reservation
+	for _, awsReservation := range stub.storeAWS {
+		if int64(len(result)) >= limit {
+			break
+		}
+		since := awsReservation.CreatedAt
+		if awsReservation.LaunchAt.Valid {
+			since = awsReservation.LaunchAt.Time
+		}
+		if !awsReservation.FinishedAt.Valid && since.Before(time.Now().Add(-olderThan)) {
+			result = append(result, &awsReservation.Reservation)
+		}
+	}
+	return result, nil
+}
+
+// matchesProvider returns true for reservations of the account and provider type.
+func matchesProvider(ctx context.Context, res *models.Reservation, provider models.ProviderType) bool {
+	return res.AccountID == ctxAccountId(ctx) && (provider == models.ProviderTypeUnknown || res.Provider == provider)
+}
+
+func (stub *reservationDaoStub) CountInflight(ctx context.Context, provider models.ProviderType) (int64, error) {
+	var result int64
+	inflight := func(res *models.Reservation) {
+		if matchesProvider(ctx, res, provider) && !res.FinishedAt.Valid {
+			result++
+		}
+	}
+	for _, res := range stub.storeAWS {
+		inflight(&res.Reservation)
+	}
+	for _, res := range stub.storeAzure {
+		inflight(&res.Reservation)
+	}
+	for _, res := range stub.storeGCP {
+		inflight(&res.Reservation)
+	}
+	return result, nil
+}
+
+func (stub *reservationDaoStub) SumInstancesSince(ctx context.Context, provider models.ProviderType, since time.Time) (int64, error) {
+	var result int64
+	add := func(res *models.Reservation, amount int64) {
+		failed := res.Success.Valid && !res.Success.Bool
+		if matchesProvider(ctx, res, provider) && !res.CreatedAt.Before(since) && !failed {
+			result += amount
+		}
+	}
+	for _, res := range stub.storeAWS {
+		if res.Detail != nil {
+			add(&res.Reservation, int64(res.Detail.Amount))
+		}
+	}
+	for _, res := range stub.storeAzure {
+		if res.Detail != nil {
+			add(&res.Reservation, res.Detail.Amount)
+		}
+	}
+	for _, res := range stub.storeGCP {
+		if res.Detail != nil {
+			add(&res.Reservation, res.Detail.Amount)
+		}
+	}
+	return result, nil
+}
+
+func (stub *reservationDaoStub) QuotaUsageTx(ctx context.Context, _ pgx.Tx, provider models.ProviderType, since time.Time) (*dao.QuotaUsage, error) {
+	inflight, err := stub.CountInflight(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+	instances, err := stub.SumInstancesSince(ctx, provider, since)
+	if err != nil {
+		return nil, err
+	}
+	return &dao.QuotaUsage{Inflight: inflight, Instances: instances}, nil
+}
+
+func (stub *reservationDaoStub) ListChildren(ctx context.Context, parentId int64) ([]*models.Reservation, error) {
+	var result []*models.Reservation
+	add := func(res *models.Reservation) {
+		if res.AccountID == ctxAccountId(ctx) && res.ParentID.Valid && res.ParentID.Int64 == parentId {
+			result = append(result, res)
+		}
+	}
+	for _, res := range stub.storeAWS {
+		add(&res.Reservation)
+	}
+	for _, res := range stub.storeAzure {
+		add(&res.Reservation)
+	}
+	for _, res := range stub.storeGCP {
+		add(&res.Reservation)
+	}
+	return result, nil
 }
 
 func (stub *reservationDaoStub) ListInstances(ctx context.Context, reservationId int64) ([]*models.ReservationInstance, error) {
 	return stub.instances[reservationId], nil
 }
 
+func (stub *reservationDaoStub) DeleteInstances(ctx context.Context, reservationId int64) error {
+	delete(stub.instances, reservationId)
+	return nil
+}
+
+// UpdateStatus only records the event, the reservation is not updated.
 func (stub *reservationDaoStub) UpdateStatus(ctx context.Context, id int64, status string, addSteps int32) error {
+	step := addSteps
+	if events := stub.events[id]; len(events) > 0 {
+		step += events[len(events)-1].Step
+	}
+	stub.events[id] = append(stub.events[id], &models.ReservationEvent{
+		ID:            int64(len(stub.events[id])) + 1,
+		ReservationID: id,
+		Status:        status,
+		Step:          step,
+		CreatedAt:     time.Now(),
+	})
 	return nil
 }
 
+func (stub *reservationDaoStub) ListEvents(ctx context.Context, reservationId int64) ([]*models.ReservationEvent, error) {
+	return stub.events[reservationId], nil
+}
+
+// UpdateCompositeProgress updates the composite reservation, the event is not recorded.
+func (stub *reservationDaoStub) UpdateCompositeProgress(ctx context.Context, id int64) (*models.Reservation, error) {
+	parent, err := stub.GetById(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("stubbed lookup of reservation failed: %w", err)
+	}
+	if parent.FinishedAt.Valid {
+		return nil, dao.ErrNoRows
+	}
+
+	children, _ := stub.ListChildren(ctx, id)
+	var finished, failed int
+	for _, child := range children {
+		if child.FinishedAt.Valid {
+			finished++
+		}
+		if child.Success.Valid && !child.Success.Bool {
+			failed++
+		}
+	}
+
+	parent.Step = int32(finished)
+	parent.Status = fmt.Sprintf("Finished %d of %d reservations", finished, len(children))
+	if failed > 0 {
+		parent.Error = fmt.Sprintf("%d of %d reservations failed", failed, len(children))
+	}
+	if finished == len(children) {
+		parent.FinishedAt = sql.NullTime{Time: time.Now(), Valid: true}
+		parent.Success = sql.NullBool{Bool: failed == 0, Valid: true}
+	}
+	return parent, nil
+}
+
 func (stub *reservationDaoStub) UnscopedUpdateAWSDetail(ctx context.Context, id int64, awsDetail *models.AWSDetail) error {
 	res, err := stub.GetAWSById(ctx, id)
 	if err != nil {
@@ -133,10 +393,101 @@ func (stub *reservationDaoStub) UpdateOperationNameForGCP(ctx context.Context, i
 }
 
 func (stub *reservationDaoStub) FinishWithSuccess(ctx context.Context, id int64) error {
+	for _, awsReservation := range stub.storeAWS {
+		if awsReservation.ID == id {
+			if awsReservation.FinishedAt.Valid {
+				return fmt.Errorf("expected 1 row: %w", dao.ErrAffectedMismatch)
+			}
+			awsReservation.Success = sql.NullBool{Bool: true, Valid: true}
+			if awsReservation.Detail != nil {
+				awsReservation.Detail.LaunchInput = models.LaunchInput{}
+			}
+			awsReservation.FinishedAt = sql.NullTime{Time: time.Now(), Valid: true}
+		}
+	}
 	return nil
 }
 
 func (stub *reservationDaoStub) FinishWithError(ctx context.Context, id int64, errorString string) error {
+	for _, awsReservation := range stub.storeAWS {
+		if awsReservation.ID == id {
+			if awsReservation.FinishedAt.Valid {
+				return fmt.Errorf("expected 1 row: %w", dao.ErrAffectedMismatch)
+			}
+			awsReservation.Success = sql.NullBool{Bool: false, Valid: true}
+			if awsReservation.Detail != nil {
+				awsReservation.Detail.LaunchInput = models.LaunchInput{}
+			}
+			awsReservation.Error = errorString
+			awsReservation.FinishedAt = sql.NullTime{Time: time.Now(), Valid: true}
+		}
+	}
+	for _, compositeReservation := range stub.storeComposite {
+		if compositeReservation.ID == id {
+			if compositeReservation.FinishedAt.Valid {
+				return fmt.Errorf("expected 1 row: %w", dao.ErrAffectedMismatch)
+			}
+			compositeReservation.Success = sql.NullBool{Bool: false, Valid: true}
+			compositeReservation.Error = errorString
+			compositeReservation.FinishedAt = sql.NullTime{Time: time.Now(), Valid: true}
+		}
+	}
+	return nil
+}
+
+func (stub *reservationDaoStub) QueueTeardown(ctx context.Context, id int64) error {
+	res, err := stub.GetById(ctx, id)
+	if err != nil {
+		return fmt.Errorf("stubbed lookup of reservation failed: %w", err)
+	}
+	if !res.FinishedAt.Valid || res.TeardownQueuedAt.Valid {
+		return fmt.Errorf("expected 1 row: %w", dao.ErrAffectedMismatch)
+	}
+	res.TeardownQueuedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	res.TeardownError = ""
+	return nil
+}
+
+func (stub *reservationDaoStub) FinishTeardown(ctx context.Context, id int64, status string, errorString string) error {
+	res, err := stub.GetById(ctx, id)
+	if err != nil {
+		return fmt.Errorf("stubbed lookup of reservation failed: %w", err)
+	}
+	if !res.TeardownQueuedAt.Valid {
+		return fmt.Errorf("expected 1 row: %w", dao.ErrAffectedMismatch)
+	}
+	res.Status = status
+	res.TeardownQueuedAt = sql.NullTime{}
+	res.TeardownError = errorString
+	return stub.UpdateStatus(ctx, id, status, 0)
+}
+
+func (stub *reservationDaoStub) UnscopedFinishStaleTeardowns(ctx context.Context, olderThan time.Duration, status string, errorString string) (int64, error) {
+	var count int64
+	for _, awsReservation := range stub.storeAWS {
+		res := &awsReservation.Reservation
+		if res.TeardownQueuedAt.Valid && res.TeardownQueuedAt.Time.Before(time.Now().Add(-olderThan)) {
+			res.Status = status
+			res.TeardownQueuedAt = sql.NullTime{}
+			res.TeardownError = errorString
+			if err := stub.UpdateStatus(ctx, res.ID, status, 0); err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (stub *reservationDaoStub) Cancel(ctx context.Context, id int64) error {
+	res, err := stub.GetById(ctx, id)
+	if err != nil {
+		return fmt.Errorf("stubbed lookup of reservation failed: %w", err)
+	}
+	if res.FinishedAt.Valid {
+		return fmt.Errorf("expected 1 row: %w", dao.ErrAffectedMismatch)
+	}
+	res.Cancelled = true
 	return nil
 }
 
@@ -144,6 +495,35 @@ func (stub *reservationDaoStub) Delete(ctx context.Context, id int64) error {
 	return nil
 }
 
+func (stub *reservationDaoStub) UnscopedCountExpired(ctx context.Context, olderThan time.Duration) (int64, error) {
+	var count int64
+	for _, awsReservation := range stub.storeAWS {
+		if stub.expired(&awsReservation.Reservation, olderThan) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (stub *reservationDaoStub) UnscopedDeleteExpired(ctx context.Context, olderThan time.Duration, limit int64, _ bool) (int64, error) {
+	var deleted int64
+	kept := make([]*models.AWSReservation, 0, len(stub.storeAWS))
+	for _, awsReservation := range stub.storeAWS {
+		if deleted < limit && stub.expired(&awsReservation.Reservation, olderThan) {
+			delete(stub.instances, awsReservation.ID)
+			deleted++
+			continue
+		}
+		kept = append(kept, awsReservation)
+	}
+	stub.storeAWS = kept
+	return deleted, nil
+}
+
+func (stub *reservationDaoStub) expired(reservation *models.Reservation, olderThan time.Duration) bool {
+	return reservation.FinishedAt.Valid && reservation.FinishedAt.Time.Before(time.Now().Add(-olderThan))
+}
+
 func (stub *reservationDaoStub) UpdateReservationInstance(ctx context.Context, reservationID int64, instance *clients.InstanceDescription) error {
 	return nil
 }
@@ -0,0 +1,135 @@
+package stubs
+
+import (
+	"context"
+	"time"
+
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+)
+
+type webhookDaoStub struct {
+	lastId     int64
+	store      []*models.Webhook
+	deliveries []*models.WebhookDelivery
+}
+
+func init() {
+	dao.GetWebhookDao = getWebhookDao
+}
+
+// WebhookDeliveries returns all deliveries logged via the stub
+func WebhookDeliveries(ctx context.Context) []*models.WebhookDelivery {
+	return getWebhookDaoStub(ctx).deliveries
+}
+
+func getWebhookDao(ctx context.Context) dao.WebhookDao {
+	return getWebhookDaoStub(ctx)
+}
+
+func (stub *webhookDaoStub) Create(ctx context.Context, webhook *models.Webhook) error {
+	webhook.AccountID = ctxAccountId(ctx)
+	if err := models.Validate(ctx, webhook); err != nil {
+		return dao.ErrValidation
+	}
+
+	webhook.ID = stub.lastId + 1
+	webhook.CreatedAt = time.Now()
+	stub.store = append(stub.store, webhook)
+	stub.lastId++
+	return nil
+}
+
+func (stub *webhookDaoStub) Update(ctx context.Context, webhook *models.Webhook) error {
+	if err := models.Validate(ctx, webhook); err != nil {
+		return dao.ErrValidation
+	}
+
+	for idx, wh := range stub.store {
+		if wh.AccountID == ctxAccountId(ctx) && wh.ID == webhook.ID {
+			stub.store[idx] = webhook
+			return nil
+		}
+	}
+	return dao.ErrAffectedMismatch
+}
+
+func (stub *webhookDaoStub) GetById(ctx context.Context, id int64) (*models.Webhook, error) {
+	for _, wh := range stub.store {
+		if wh.AccountID == ctxAccountId(ctx) && wh.ID == id {
+			return wh, nil
+		}
+	}
+	return nil, dao.ErrNoRows
+}
+
+func (stub *webhookDaoStub) List(ctx context.Context, params dao.ListParams) ([]*models.Webhook, error) {
+	return page(params, stub.filter(ctx), func(wh *models.Webhook) int64 { return wh.ID }), nil
+}
+
+func (stub *webhookDaoStub) Count(ctx context.Context) (int64, error) {
+	return int64(len(stub.filter(ctx))), nil
+}
+
+func (stub *webhookDaoStub) ListEnabled(ctx context.Context) ([]*models.Webhook, error) {
+	var result []*models.Webhook
+	for _, wh := range stub.filter(ctx) {
+		if wh.Enabled {
+			result = append(result, wh)
+		}
+	}
+	return result, nil
+}
+
+func (stub *webhookDaoStub) filter(ctx context.Context) []*models.Webhook {
+	var filtered []*models.Webhook
+	for _, wh := range stub.store {
+		if wh.AccountID == ctxAccountId(ctx) {
+			filtered = append(filtered, wh)
+		}
+	}
+	return filtered
+}
+
+func (stub *webhookDaoStub) Delete(ctx context.Context, id int64) error {
+	for idx, wh := range stub.store {
+		if wh.AccountID == ctxAccountId(ctx) && wh.ID == id {
+			stub.store = append(stub.store[:idx], stub.store[idx+1:]...)
+			return nil
+		}
+	}
+	return dao.ErrAffectedMismatch
+}
+
+func (stub *webhookDaoStub) UnscopedCreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	delivery.ID = int64(len(stub.deliveries)) + 1
+	delivery.CreatedAt = time.Now()
+	stub.deliveries = append(stub.deliveries, delivery)
+	return nil
+}
+
+func (stub *webhookDaoStub) ListDeliveries(ctx context.Context, webhookId int64, params dao.ListParams, filter dao.WebhookDeliveryFilter) ([]*models.WebhookDelivery, error) {
+	return page(params, stub.filterDeliveries(ctx, webhookId, filter), func(d *models.WebhookDelivery) int64 { return d.ID }), nil
+}
+
+func (stub *webhookDaoStub) CountDeliveries(ctx context.Context, webhookId int64, filter dao.WebhookDeliveryFilter) (int64, error) {
+	return int64(len(stub.filterDeliveries(ctx, webhookId, filter))), nil
+}
+
+func (stub *webhookDaoStub) filterDeliveries(ctx context.Context, webhookId int64, filter dao.WebhookDeliveryFilter) []*models.WebhookDelivery {
+	if _, err := stub.GetById(ctx, webhookId); err != nil {
+		return nil
+	}
+
+	var filtered []*models.WebhookDelivery
+	for _, d := range stub.deliveries {
+		if d.WebhookID != webhookId {
+			continue
+		}
+		if filter.Success != nil && d.Success != *filter.Success {
+			continue
+		}
+		filtered = append(filtered, d)
+	}
+	return filtered
+}
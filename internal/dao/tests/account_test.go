@@ -11,6 +11,7 @@ import (
 
 	"github.com/RHEnVision/provisioning-backend/internal/dao"
 	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/RHEnVision/provisioning-backend/internal/ptr"
 	"github.com/RHEnVision/provisioning-backend/internal/testing/identity"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -155,3 +156,32 @@ func TestAccountGetOrCreateByIdentity(t *testing.T) {
 		assert.Equal(t, "1", account.AccountNumber.String)
 	})
 }
+
+func TestAccountUpdateQuota(t *testing.T) {
+	accDao, ctx := setupAccount(t)
+	defer reset()
+
+	t.Run("success", func(t *testing.T) {
+		quota := &models.AccountQuota{
+			QuotaLimits: models.QuotaLimits{MaxInstancesPerDay: ptr.To(int32(10))},
+			Providers: map[string]models.QuotaLimits{
+				"aws": {MaxInflightReservations: ptr.To(int32(2))},
+			},
+		}
+		err := accDao.UpdateQuota(ctx, 1, quota)
+		require.NoError(t, err)
+
+		acc, err := accDao.GetById(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, *quota, acc.Quota)
+
+		acc, err = accDao.GetOrCreateByIdentity(ctx, acc.OrgID, acc.AccountNumber.String)
+		require.NoError(t, err)
+		assert.Equal(t, *quota, acc.Quota)
+	})
+
+	t.Run("no rows", func(t *testing.T) {
+		err := accDao.UpdateQuota(ctx, math.MaxInt64, &models.AccountQuota{})
+		require.ErrorIs(t, err, dao.ErrAffectedMismatch)
+	})
+}
@@ -0,0 +1,104 @@
+//go:build integration
+// +build integration
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
+	"github.com/RHEnVision/provisioning-backend/internal/db"
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/RHEnVision/provisioning-backend/internal/testing/identity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLaunchProfile(name string) *models.LaunchProfile {
+	return &models.LaunchProfile{
+		Name:         name,
+		Provider:     models.ProviderTypeAWS,
+		SourceID:     "1",
+		Region:       "us-east-1",
+		InstanceType: "t3.small",
+		ImageID:      "ami-7846387643232",
+		PubkeyID:     1,
+	}
+}
+
+func setupLaunchProfile(t *testing.T) (dao.LaunchProfileDao, context.Context) {
+	ctx := identity.WithTenant(t, context.Background())
+	profileDao := dao.GetLaunchProfileDao(ctx)
+	return profileDao, ctx
+}
+
+func TestLaunchProfileCreate(t *testing.T) {
+	profileDao, ctx := setupLaunchProfile(t)
+	defer reset()
+
+	t.Run("success", func(t *testing.T) {
+		profile := newLaunchProfile("small")
+		err := profileDao.Create(ctx, profile)
+		require.NoError(t, err)
+
+		profile2, err := profileDao.GetById(ctx, profile.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.ProviderTypeAWS, profile2.Provider)
+		assert.Equal(t, "us-east-1", profile2.Region)
+		assert.Equal(t, int64(1), profile2.PubkeyID)
+	})
+
+	t.Run("duplicate name", func(t *testing.T) {
+		err := profileDao.Create(ctx, newLaunchProfile("small"))
+		require.Error(t, db.IsPostgresError(err, db.UniqueConstraintErrorCode))
+	})
+
+	t.Run("unsupported provider", func(t *testing.T) {
+		profile := newLaunchProfile("noop")
+		profile.Provider = models.ProviderTypeNoop
+		err := profileDao.Create(ctx, profile)
+		require.Error(t, err)
+	})
+}
+
+func TestLaunchProfileUpdateListDelete(t *testing.T) {
+	profileDao, ctx := setupLaunchProfile(t)
+	defer reset()
+
+	small := newLaunchProfile("small")
+	require.NoError(t, profileDao.Create(ctx, small))
+	large := newLaunchProfile("large")
+	require.NoError(t, profileDao.Create(ctx, large))
+
+	large.InstanceType = "t3.large"
+	require.NoError(t, profileDao.Update(ctx, large))
+
+	profiles, err := profileDao.List(ctx, dao.ListParams{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, profiles, 2)
+	assert.Equal(t, "t3.large", profiles[1].InstanceType)
+
+	require.NoError(t, profileDao.Delete(ctx, small.ID))
+
+	count, err := profileDao.Count(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+
+	err = profileDao.Delete(ctx, small.ID)
+	require.ErrorIs(t, err, dao.ErrAffectedMismatch)
+}
+
+func TestLaunchProfilePubkeyInUse(t *testing.T) {
+	profileDao, ctx := setupLaunchProfile(t)
+	defer reset()
+
+	require.NoError(t, profileDao.Create(ctx, newLaunchProfile("small")))
+
+	count, err := profileDao.CountByPubkeyId(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	err = dao.GetPubkeyDao(ctx).Delete(ctx, 1)
+	require.Error(t, db.IsPostgresError(err, db.ForeignKeyConstraintErrorCode))
+}
@@ -0,0 +1,125 @@
+//go:build integration
+// +build integration
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/RHEnVision/provisioning-backend/internal/testing/identity"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newOutboxJob() *models.OutboxJob {
+	return &models.OutboxJob{
+		JobID:   uuid.New(),
+		JobType: "test",
+		Payload: []byte("payload"),
+	}
+}
+
+func setupOutbox(t *testing.T) (dao.OutboxDao, context.Context) {
+	ctx := identity.WithTenant(t, context.Background())
+	outboxDao := dao.GetOutboxDao(ctx)
+	return outboxDao, ctx
+}
+
+func createOutboxJob(t *testing.T, ctx context.Context, outboxDao dao.OutboxDao, job *models.OutboxJob) {
+	err := dao.WithTransaction(ctx, func(tx pgx.Tx) error {
+		return outboxDao.CreateTx(ctx, tx, job)
+	})
+	require.NoError(t, err)
+}
+
+func TestOutboxCreateWithReservation(t *testing.T) {
+	outboxDao, ctx := setupOutbox(t)
+	reservationDao := dao.GetReservationDao(ctx)
+	defer reset()
+
+	t.Run("success", func(t *testing.T) {
+		err := reservationDao.CreateNoop(ctx, newNoopReservation(), func(tx pgx.Tx) error {
+			return outboxDao.CreateTx(ctx, tx, newOutboxJob())
+		})
+		require.NoError(t, err)
+
+		size, _, _, err := outboxDao.Stats(ctx)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, size)
+	})
+}
+
+func TestOutboxPublish(t *testing.T) {
+	outboxDao, ctx := setupOutbox(t)
+	defer reset()
+
+	t.Run("success", func(t *testing.T) {
+		first, second := newOutboxJob(), newOutboxJob()
+		createOutboxJob(t, ctx, outboxDao, first)
+		createOutboxJob(t, ctx, outboxDao, second)
+
+		var published []uuid.UUID
+		count, err := outboxDao.Publish(ctx, 10, 10, func(job *models.OutboxJob) error {
+			published = append(published, job.JobID)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+		assert.Equal(t, []uuid.UUID{first.JobID, second.JobID}, published)
+
+		size, dead, lag, err := outboxDao.Stats(ctx)
+		require.NoError(t, err)
+		assert.EqualValues(t, 0, size)
+		assert.EqualValues(t, 0, dead)
+		assert.EqualValues(t, 0, lag)
+	})
+
+	t.Run("failure keeps job", func(t *testing.T) {
+		createOutboxJob(t, ctx, outboxDao, newOutboxJob())
+
+		count, err := outboxDao.Publish(ctx, 10, 10, func(job *models.OutboxJob) error {
+			return errors.New("enqueue error")
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 0, count)
+
+		size, dead, _, err := outboxDao.Stats(ctx)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, size)
+		assert.EqualValues(t, 0, dead)
+
+		// the job is postponed after the failure
+		called := false
+		_, err = outboxDao.Publish(ctx, 10, 10, func(job *models.OutboxJob) error {
+			called = true
+			return nil
+		})
+		require.NoError(t, err)
+		assert.False(t, called)
+	})
+}
+
+func TestOutboxPublishDead(t *testing.T) {
+	outboxDao, ctx := setupOutbox(t)
+	defer reset()
+
+	createOutboxJob(t, ctx, outboxDao, newOutboxJob())
+
+	count, err := outboxDao.Publish(ctx, 10, 1, func(job *models.OutboxJob) error {
+		return errors.New("enqueue error")
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	size, dead, lag, err := outboxDao.Stats(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, size)
+	assert.EqualValues(t, 1, dead)
+	assert.EqualValues(t, 0, lag)
+}
@@ -62,24 +62,39 @@ func TestPubkeyList(t *testing.T) {
 	defer reset()
 
 	t.Run("success", func(t *testing.T) {
-		pubkeys, err := pkDao.List(ctx, 1, 0)
+		pubkeys, err := pkDao.List(ctx, dao.ListParams{Limit: 1})
 		require.NoError(t, err)
 		assert.Equal(t, 1, len(pubkeys))
 	})
 
-	t.Run("with offset", func(t *testing.T) {
+	t.Run("with cursor", func(t *testing.T) {
 		newKey := factories.NewPubkeyRSA()
 		err := pkDao.Create(ctx, newKey)
 		require.NoError(t, err)
 
-		pubkeys, err := pkDao.List(ctx, 1, 0)
+		pubkeys, err := pkDao.List(ctx, dao.ListParams{Limit: 1})
 		require.NoError(t, err)
-		assert.Equal(t, 1, len(pubkeys))
+		require.Equal(t, 1, len(pubkeys))
 
-		pubkeys, err = pkDao.List(ctx, 1, 1)
+		pubkeys, err = pkDao.List(ctx, dao.ListParams{Limit: 1, After: pubkeys[0].ID})
 		require.NoError(t, err)
 		assert.Equal(t, 1, len(pubkeys))
 		require.Contains(t, pubkeys, newKey)
+
+		count, err := pkDao.Count(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+	})
+
+	t.Run("descending", func(t *testing.T) {
+		all, err := pkDao.List(ctx, dao.ListParams{Limit: 10})
+		require.NoError(t, err)
+		require.NotEmpty(t, all)
+
+		pubkeys, err := pkDao.List(ctx, dao.ListParams{Limit: 1, Descending: true})
+		require.NoError(t, err)
+		require.Equal(t, 1, len(pubkeys))
+		assert.Equal(t, all[len(all)-1].ID, pubkeys[0].ID)
 	})
 }
 
@@ -5,14 +5,19 @@ package tests
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"math"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/RHEnVision/provisioning-backend/internal/dao"
 	"github.com/RHEnVision/provisioning-backend/internal/db"
 	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/RHEnVision/provisioning-backend/internal/testing/factories"
 	"github.com/RHEnVision/provisioning-backend/internal/testing/identity"
+	"github.com/jackc/pgx/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -74,7 +79,7 @@ func TestReservationCreateNoop(t *testing.T) {
 
 	t.Run("success", func(t *testing.T) {
 		res := newNoopReservation()
-		err := reservationDao.CreateNoop(ctx, res)
+		err := reservationDao.CreateNoop(ctx, res, nil)
 		require.NoError(t, err)
 
 		newRes, err := reservationDao.GetById(ctx, res.ID)
@@ -94,7 +99,7 @@ func TestReservationGetById(t *testing.T) {
 
 	t.Run("success", func(t *testing.T) {
 		res := newNoopReservation()
-		err := reservationDao.CreateNoop(ctx, res)
+		err := reservationDao.CreateNoop(ctx, res, nil)
 		require.NoError(t, err)
 
 		newRes, err := reservationDao.GetById(ctx, res.ID)
@@ -113,13 +118,57 @@ func TestReservationGetById(t *testing.T) {
 	})
 }
 
+func TestReservationGetByIdempotencyKey(t *testing.T) {
+	reservationDao, ctx := setupReservation(t)
+	defer reset()
+
+	t.Run("success", func(t *testing.T) {
+		res := newNoopReservation()
+		res.IdempotencyKey = sql.NullString{String: "key-1", Valid: true}
+		err := reservationDao.CreateNoop(ctx, res, nil)
+		require.NoError(t, err)
+
+		newRes, err := reservationDao.GetByIdempotencyKey(ctx, "key-1")
+		require.NoError(t, err)
+		assert.Equal(t, res.ID, newRes.ID)
+		assert.Equal(t, "key-1", newRes.IdempotencyKey.String)
+	})
+
+	t.Run("duplicate key", func(t *testing.T) {
+		res := newNoopReservation()
+		res.IdempotencyKey = sql.NullString{String: "key-2", Valid: true}
+		err := reservationDao.CreateNoop(ctx, res, nil)
+		require.NoError(t, err)
+
+		dup := newNoopReservation()
+		dup.IdempotencyKey = sql.NullString{String: "key-2", Valid: true}
+		err = reservationDao.CreateNoop(ctx, dup, nil)
+		require.Error(t, err)
+		assert.NotNil(t, db.IsPostgresError(err, db.UniqueConstraintErrorCode))
+	})
+
+	t.Run("no key", func(t *testing.T) {
+		first := newNoopReservation()
+		err := reservationDao.CreateNoop(ctx, first, nil)
+		require.NoError(t, err)
+		second := newNoopReservation()
+		err = reservationDao.CreateNoop(ctx, second, nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("no rows", func(t *testing.T) {
+		_, err := reservationDao.GetByIdempotencyKey(ctx, "unknown")
+		require.ErrorIs(t, err, dao.ErrNoRows)
+	})
+}
+
 func TestReservationCreateAWS(t *testing.T) {
 	reservationDao, ctx := setupReservation(t)
 	defer reset()
 
 	t.Run("success", func(t *testing.T) {
 		res := newAWSReservation()
-		err := reservationDao.CreateAWS(ctx, res)
+		err := reservationDao.CreateAWS(ctx, res, nil)
 		require.NoError(t, err)
 
 		newRes, err := reservationDao.GetById(ctx, res.ID)
@@ -133,13 +182,30 @@ func TestReservationCreateAWS(t *testing.T) {
 	})
 }
 
+func TestReservationCreateAWSRollback(t *testing.T) {
+	reservationDao, ctx := setupReservation(t)
+	defer reset()
+
+	t.Run("callback error", func(t *testing.T) {
+		callbackErr := errors.New("callback error")
+		res := newAWSReservation()
+		err := reservationDao.CreateAWS(ctx, res, func(tx pgx.Tx) error {
+			return callbackErr
+		})
+		require.ErrorIs(t, err, callbackErr)
+
+		_, err = reservationDao.GetById(ctx, res.ID)
+		require.ErrorIs(t, err, dao.ErrNoRows)
+	})
+}
+
 func TestReservationCreateGCP(t *testing.T) {
 	reservationDao, ctx := setupReservation(t)
 	defer reset()
 
 	t.Run("success", func(t *testing.T) {
 		res := newGCPReservation()
-		err := reservationDao.CreateGCP(ctx, res)
+		err := reservationDao.CreateGCP(ctx, res, nil)
 		require.NoError(t, err)
 
 		newRes, err := reservationDao.GetById(ctx, res.ID)
@@ -159,7 +225,7 @@ func TestReservationCreateAWSInstance(t *testing.T) {
 
 	t.Run("success", func(t *testing.T) {
 		reservation := newAWSReservation()
-		err := reservationDao.CreateAWS(ctx, reservation)
+		err := reservationDao.CreateAWS(ctx, reservation, nil)
 		require.NoError(t, err)
 
 		instance := newReservationInstance(reservation.ID)
@@ -174,29 +240,351 @@ func TestReservationCreateAWSInstance(t *testing.T) {
 	})
 }
 
+func TestReservationDeleteInstances(t *testing.T) {
+	reservationDao, ctx := setupReservation(t)
+	defer reset()
+
+	t.Run("success", func(t *testing.T) {
+		reservation := newAWSReservation()
+		err := reservationDao.CreateAWS(ctx, reservation, nil)
+		require.NoError(t, err)
+
+		err = reservationDao.CreateInstance(ctx, newReservationInstance(reservation.ID))
+		require.NoError(t, err)
+
+		err = reservationDao.DeleteInstances(ctx, reservation.ID)
+		require.NoError(t, err)
+
+		instancesList, err := reservationDao.ListInstances(ctx, reservation.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 0, len(instancesList))
+	})
+}
+
+func TestReservationCancel(t *testing.T) {
+	reservationDao, ctx := setupReservation(t)
+	defer reset()
+
+	t.Run("success", func(t *testing.T) {
+		reservation := newNoopReservation()
+		err := reservationDao.CreateNoop(ctx, reservation, nil)
+		require.NoError(t, err)
+
+		err = reservationDao.Cancel(ctx, reservation.ID)
+		require.NoError(t, err)
+
+		updated, err := reservationDao.GetById(ctx, reservation.ID)
+		require.NoError(t, err)
+		assert.True(t, updated.Cancelled)
+	})
+
+	t.Run("finished", func(t *testing.T) {
+		reservation := newNoopReservation()
+		err := reservationDao.CreateNoop(ctx, reservation, nil)
+		require.NoError(t, err)
+
+		err = reservationDao.FinishWithSuccess(ctx, reservation.ID)
+		require.NoError(t, err)
+
+		err = reservationDao.Cancel(ctx, reservation.ID)
+		require.ErrorIs(t, err, dao.ErrAffectedMismatch)
+	})
+}
+
+func TestReservationTeardown(t *testing.T) {
+	reservationDao, ctx := setupReservation(t)
+	defer reset()
+
+	t.Run("success", func(t *testing.T) {
+		reservation := newNoopReservation()
+		err := reservationDao.CreateNoop(ctx, reservation, nil)
+		require.NoError(t, err)
+		err = reservationDao.FinishWithSuccess(ctx, reservation.ID)
+		require.NoError(t, err)
+
+		err = reservationDao.QueueTeardown(ctx, reservation.ID)
+		require.NoError(t, err)
+		err = reservationDao.QueueTeardown(ctx, reservation.ID)
+		require.ErrorIs(t, err, dao.ErrAffectedMismatch)
+
+		err = reservationDao.FinishTeardown(ctx, reservation.ID, "Instance termination failed", "teardown error")
+		require.NoError(t, err)
+
+		updated, err := reservationDao.GetById(ctx, reservation.ID)
+		require.NoError(t, err)
+		assert.False(t, updated.TeardownQueuedAt.Valid)
+		assert.Equal(t, "teardown error", updated.TeardownError)
+		assert.Equal(t, "Instance termination failed", updated.Status)
+		assert.True(t, updated.Success.Bool)
+		assert.Empty(t, updated.Error)
+
+		err = reservationDao.FinishTeardown(ctx, reservation.ID, "Terminated instance(s)", "")
+		require.ErrorIs(t, err, dao.ErrAffectedMismatch)
+	})
+
+	t.Run("in progress", func(t *testing.T) {
+		reservation := newNoopReservation()
+		err := reservationDao.CreateNoop(ctx, reservation, nil)
+		require.NoError(t, err)
+
+		err = reservationDao.QueueTeardown(ctx, reservation.ID)
+		require.ErrorIs(t, err, dao.ErrAffectedMismatch)
+	})
+
+	t.Run("stale", func(t *testing.T) {
+		stale := newNoopReservation()
+		err := reservationDao.CreateNoop(ctx, stale, nil)
+		require.NoError(t, err)
+		err = reservationDao.FinishWithSuccess(ctx, stale.ID)
+		require.NoError(t, err)
+		err = reservationDao.QueueTeardown(ctx, stale.ID)
+		require.NoError(t, err)
+		_, err = db.Pool.Exec(ctx, `UPDATE reservations SET teardown_queued_at = now() - interval '3 hours' WHERE id = $1`, stale.ID)
+		require.NoError(t, err)
+
+		fresh := newNoopReservation()
+		err = reservationDao.CreateNoop(ctx, fresh, nil)
+		require.NoError(t, err)
+		err = reservationDao.FinishWithSuccess(ctx, fresh.ID)
+		require.NoError(t, err)
+		err = reservationDao.QueueTeardown(ctx, fresh.ID)
+		require.NoError(t, err)
+
+		count, err := reservationDao.UnscopedFinishStaleTeardowns(ctx, 2*time.Hour, "Lost", "lost")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+
+		updated, err := reservationDao.GetById(ctx, stale.ID)
+		require.NoError(t, err)
+		assert.False(t, updated.TeardownQueuedAt.Valid)
+		assert.Equal(t, "lost", updated.TeardownError)
+		updated, err = reservationDao.GetById(ctx, fresh.ID)
+		require.NoError(t, err)
+		assert.True(t, updated.TeardownQueuedAt.Valid)
+	})
+}
+
+func TestReservationUnscopedListStale(t *testing.T) {
+	reservationDao, ctx := setupReservation(t)
+	defer reset()
+
+	t.Run("success", func(t *testing.T) {
+		stale := newNoopReservation()
+		err := reservationDao.CreateNoop(ctx, stale, nil)
+		require.NoError(t, err)
+		_, err = db.Pool.Exec(ctx, `UPDATE reservations SET created_at = now() - interval '3 hours' WHERE id = $1`, stale.ID)
+		require.NoError(t, err)
+
+		finished := newNoopReservation()
+		err = reservationDao.CreateNoop(ctx, finished, nil)
+		require.NoError(t, err)
+		_, err = db.Pool.Exec(ctx, `UPDATE reservations SET created_at = now() - interval '3 hours' WHERE id = $1`, finished.ID)
+		require.NoError(t, err)
+		err = reservationDao.FinishWithSuccess(ctx, finished.ID)
+		require.NoError(t, err)
+
+		fresh := newNoopReservation()
+		err = reservationDao.CreateNoop(ctx, fresh, nil)
+		require.NoError(t, err)
+
+		reservations, err := reservationDao.UnscopedListStale(ctx, 2*time.Hour, 100)
+		require.NoError(t, err)
+		require.Len(t, reservations, 1)
+		assert.Equal(t, stale.ID, reservations[0].ID)
+	})
+}
+
+func TestReservationUnscopedDeleteExpired(t *testing.T) {
+	reservationDao, ctx := setupReservation(t)
+	defer reset()
+
+	createExpired := func(t *testing.T) *models.AWSReservation {
+		reservation := newAWSReservation()
+		err := reservationDao.CreateAWS(ctx, reservation, nil)
+		require.NoError(t, err)
+		err = reservationDao.CreateInstance(ctx, newReservationInstance(reservation.ID))
+		require.NoError(t, err)
+		err = reservationDao.FinishWithSuccess(ctx, reservation.ID)
+		require.NoError(t, err)
+		_, err = db.Pool.Exec(ctx, `UPDATE reservations SET finished_at = now() - interval '100 days' WHERE id = $1`, reservation.ID)
+		require.NoError(t, err)
+		return reservation
+	}
+
+	t.Run("delete", func(t *testing.T) {
+		expired := createExpired(t)
+		finished := newNoopReservation()
+		err := reservationDao.CreateNoop(ctx, finished, nil)
+		require.NoError(t, err)
+		err = reservationDao.FinishWithSuccess(ctx, finished.ID)
+		require.NoError(t, err)
+
+		count, err := reservationDao.UnscopedCountExpired(ctx, 30*24*time.Hour)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, count)
+
+		deleted, err := reservationDao.UnscopedDeleteExpired(ctx, 30*24*time.Hour, 100, false)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, deleted)
+
+		_, err = reservationDao.GetById(ctx, expired.ID)
+		require.ErrorIs(t, err, dao.ErrNoRows)
+		_, err = reservationDao.GetById(ctx, finished.ID)
+		require.NoError(t, err)
+	})
+
+	t.Run("archive", func(t *testing.T) {
+		expired := createExpired(t)
+
+		deleted, err := reservationDao.UnscopedDeleteExpired(ctx, 30*24*time.Hour, 100, true)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, deleted)
+
+		var instanceCount int
+		query := `SELECT jsonb_array_length(data->'instances') FROM reservations_archive WHERE id = $1`
+		err = db.Pool.QueryRow(ctx, query, expired.ID).Scan(&instanceCount)
+		require.NoError(t, err)
+		assert.Equal(t, 1, instanceCount)
+	})
+
+	t.Run("composite", func(t *testing.T) {
+		parent := &models.CompositeReservation{
+			Reservation: models.Reservation{
+				Steps:      1,
+				StepTitles: []string{"Launch aws reservation"},
+			},
+		}
+		err := reservationDao.CreateComposite(ctx, parent)
+		require.NoError(t, err)
+		child := newAWSReservation()
+		child.ParentID = sql.NullInt64{Int64: parent.ID, Valid: true}
+		err = reservationDao.CreateAWS(ctx, child, nil)
+		require.NoError(t, err)
+		_, err = db.Pool.Exec(ctx, `UPDATE reservations SET finished_at = now() - interval '100 days' WHERE id = $1`, parent.ID)
+		require.NoError(t, err)
+
+		// the child is not finished yet
+		count, err := reservationDao.UnscopedCountExpired(ctx, 30*24*time.Hour)
+		require.NoError(t, err)
+		assert.EqualValues(t, 0, count)
+		deleted, err := reservationDao.UnscopedDeleteExpired(ctx, 30*24*time.Hour, 100, true)
+		require.NoError(t, err)
+		assert.EqualValues(t, 0, deleted)
+
+		_, err = db.Pool.Exec(ctx, `UPDATE reservations SET finished_at = now() - interval '100 days' WHERE id = $1`, child.ID)
+		require.NoError(t, err)
+
+		// the child is deleted with the parent over the limit
+		deleted, err = reservationDao.UnscopedDeleteExpired(ctx, 30*24*time.Hour, 1, true)
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, deleted)
+
+		var archived int
+		query := `SELECT count(*) FROM reservations_archive WHERE id = ANY($1)`
+		err = db.Pool.QueryRow(ctx, query, []int64{parent.ID, child.ID}).Scan(&archived)
+		require.NoError(t, err)
+		assert.Equal(t, 2, archived)
+	})
+}
+
 func TestReservationList(t *testing.T) {
 	reservationDao, ctx := setupReservation(t)
 	defer reset()
 
 	t.Run("empty", func(t *testing.T) {
-		reservations, err := reservationDao.List(ctx, 10, 0)
+		reservations, err := reservationDao.List(ctx, dao.ListParams{Limit: 10}, dao.ReservationFilter{})
 		require.NoError(t, err)
 		require.Empty(t, reservations)
 	})
 
+	awsReservation := newAWSReservation()
+	noopReservation := newNoopReservation()
+
 	t.Run("success", func(t *testing.T) {
-		awsReservation := newAWSReservation()
-		err := reservationDao.CreateAWS(ctx, awsReservation)
+		err := reservationDao.CreateAWS(ctx, awsReservation, nil)
 		require.NoError(t, err)
 
-		noopReservation := newNoopReservation()
-		err = reservationDao.CreateNoop(ctx, noopReservation)
+		err = reservationDao.CreateNoop(ctx, noopReservation, nil)
 		require.NoError(t, err)
 
-		reservations, err := reservationDao.List(ctx, 10, 0)
+		reservations, err := reservationDao.List(ctx, dao.ListParams{Limit: 10}, dao.ReservationFilter{})
 		require.NoError(t, err)
 		assert.Equal(t, 2, len(reservations))
 	})
+
+	t.Run("with cursor", func(t *testing.T) {
+		reservations, err := reservationDao.List(ctx, dao.ListParams{Limit: 10, After: awsReservation.ID}, dao.ReservationFilter{})
+		require.NoError(t, err)
+		require.Equal(t, 1, len(reservations))
+		assert.Equal(t, noopReservation.ID, reservations[0].ID)
+
+		reservations, err = reservationDao.List(ctx, dao.ListParams{Limit: 10, Before: noopReservation.ID}, dao.ReservationFilter{})
+		require.NoError(t, err)
+		require.Equal(t, 1, len(reservations))
+		assert.Equal(t, awsReservation.ID, reservations[0].ID)
+	})
+
+	t.Run("descending", func(t *testing.T) {
+		reservations, err := reservationDao.List(ctx, dao.ListParams{Limit: 1, Descending: true}, dao.ReservationFilter{})
+		require.NoError(t, err)
+		require.Equal(t, 1, len(reservations))
+		assert.Equal(t, noopReservation.ID, reservations[0].ID)
+
+		reservations, err = reservationDao.List(ctx, dao.ListParams{Limit: 10, Before: awsReservation.ID, Descending: true}, dao.ReservationFilter{})
+		require.NoError(t, err)
+		require.Equal(t, 1, len(reservations))
+		assert.Equal(t, noopReservation.ID, reservations[0].ID)
+	})
+
+	t.Run("with filter", func(t *testing.T) {
+		filter := dao.ReservationFilter{Provider: models.ProviderTypeAWS, SourceID: awsReservation.SourceID}
+		reservations, err := reservationDao.List(ctx, dao.ListParams{Limit: 10}, filter)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(reservations))
+		assert.Equal(t, awsReservation.ID, reservations[0].ID)
+
+		count, err := reservationDao.Count(ctx, filter)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+
+		success := true
+		filter = dao.ReservationFilter{Success: &success, CreatedAfter: time.Now().Add(-time.Hour).UTC()}
+		count, err = reservationDao.Count(ctx, filter)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+	})
+}
+
+func TestReservationChangeNotification(t *testing.T) {
+	reservationDao, ctx := setupReservation(t)
+	defer reset()
+
+	t.Run("status update", func(t *testing.T) {
+		reservation := newNoopReservation()
+		err := reservationDao.CreateNoop(ctx, reservation, nil)
+		require.NoError(t, err)
+
+		listenCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		payloads := make(chan string, 10)
+		listening := make(chan error, 1)
+		go func() {
+			listening <- db.Listen(listenCtx, "reservation_changes", func(payload string) {
+				payloads <- payload
+				cancel()
+			})
+		}()
+
+		// notifications are only delivered to sessions which are already listening
+		require.Eventually(t, func() bool {
+			updateErr := reservationDao.UpdateStatus(ctx, reservation.ID, "Testing", 0)
+			require.NoError(t, updateErr)
+			return len(payloads) > 0
+		}, 5*time.Second, 100*time.Millisecond)
+
+		require.NoError(t, <-listening)
+		assert.Equal(t, strconv.FormatInt(reservation.ID, 10), <-payloads)
+	})
 }
 
 func TestUnscopedUpdateAWSDetail(t *testing.T) {
@@ -209,7 +597,7 @@ func TestUnscopedUpdateAWSDetail(t *testing.T) {
 			Region: "us-east-1",
 			Amount: 1,
 		}
-		err := reservationDao.CreateAWS(ctx, reservation)
+		err := reservationDao.CreateAWS(ctx, reservation, nil)
 		require.NoError(t, err)
 		newDetail := &models.AWSDetail{
 			Region:     "us-east-1",
@@ -231,7 +619,7 @@ func TestReservationUpdateIDForAWS(t *testing.T) {
 
 	t.Run("success", func(t *testing.T) {
 		reservation := newAWSReservation()
-		err := reservationDao.CreateAWS(ctx, reservation)
+		err := reservationDao.CreateAWS(ctx, reservation, nil)
 		require.NoError(t, err)
 		var count int
 
@@ -257,7 +645,7 @@ func TestReservationUpdateStatus(t *testing.T) {
 
 	t.Run("status text", func(t *testing.T) {
 		res := newNoopReservation()
-		err := reservationDao.CreateNoop(ctx, res)
+		err := reservationDao.CreateNoop(ctx, res, nil)
 		require.NoError(t, err)
 
 		err = reservationDao.UpdateStatus(ctx, res.ID, "Edited", 0)
@@ -271,7 +659,7 @@ func TestReservationUpdateStatus(t *testing.T) {
 
 	t.Run("step", func(t *testing.T) {
 		res := newNoopReservation()
-		err := reservationDao.CreateNoop(ctx, res)
+		err := reservationDao.CreateNoop(ctx, res, nil)
 		require.NoError(t, err)
 
 		err = reservationDao.UpdateStatus(ctx, res.ID, "New step", 1)
@@ -281,6 +669,26 @@ func TestReservationUpdateStatus(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, res.Step+1, newRes.Step)
 	})
+
+	t.Run("events", func(t *testing.T) {
+		res := newNoopReservation()
+		err := reservationDao.CreateNoop(ctx, res, nil)
+		require.NoError(t, err)
+
+		err = reservationDao.UpdateStatus(ctx, res.ID, "First", 0)
+		require.NoError(t, err)
+		err = reservationDao.UpdateStatus(ctx, res.ID, "Second", 1)
+		require.NoError(t, err)
+
+		events, err := reservationDao.ListEvents(ctx, res.ID)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		assert.Equal(t, "First", events[0].Status)
+		assert.Equal(t, res.Step, events[0].Step)
+		assert.Equal(t, "Second", events[1].Status)
+		assert.Equal(t, res.Step+1, events[1].Step)
+		assert.False(t, events[1].CreatedAt.Before(events[0].CreatedAt))
+	})
 }
 
 func TestReservationDelete(t *testing.T) {
@@ -289,7 +697,7 @@ func TestReservationDelete(t *testing.T) {
 
 	t.Run("success", func(t *testing.T) {
 		res := newNoopReservation()
-		err := reservationDao.CreateNoop(ctx, res)
+		err := reservationDao.CreateNoop(ctx, res, nil)
 		require.NoError(t, err)
 
 		err = reservationDao.Delete(ctx, res.ID)
@@ -311,7 +719,7 @@ func TestReservationFinish(t *testing.T) {
 
 	t.Run("with success", func(t *testing.T) {
 		res := newNoopReservation()
-		err := reservationDao.CreateNoop(ctx, res)
+		err := reservationDao.CreateNoop(ctx, res, nil)
 		require.NoError(t, err)
 
 		err = reservationDao.FinishWithSuccess(ctx, res.ID)
@@ -325,7 +733,7 @@ func TestReservationFinish(t *testing.T) {
 
 	t.Run("with error", func(t *testing.T) {
 		res := newNoopReservation()
-		err := reservationDao.CreateNoop(ctx, res)
+		err := reservationDao.CreateNoop(ctx, res, nil)
 		require.NoError(t, err)
 
 		err = reservationDao.FinishWithError(ctx, res.ID, "error")
@@ -338,6 +746,25 @@ func TestReservationFinish(t *testing.T) {
 		assert.Equal(t, "error", newRes.Error)
 	})
 
+	t.Run("clears launch input", func(t *testing.T) {
+		res := newAWSReservation()
+		res.Detail = &models.AWSDetail{
+			Region:      "us-east-1",
+			Amount:      1,
+			LaunchInput: models.LaunchInput{UserData: []byte("#!/bin/sh\n"), RHCActivationKey: "lab-key", RHCOrgID: "1"},
+		}
+		err := reservationDao.CreateAWS(ctx, res, nil)
+		require.NoError(t, err)
+
+		err = reservationDao.FinishWithError(ctx, res.ID, "error")
+		require.NoError(t, err)
+
+		newRes, err := reservationDao.GetAWSById(ctx, res.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.LaunchInput{}, newRes.Detail.LaunchInput)
+		assert.Equal(t, "us-east-1", newRes.Detail.Region)
+	})
+
 	t.Run("mismatch success", func(t *testing.T) {
 		err := reservationDao.FinishWithSuccess(ctx, math.MaxInt64)
 		require.ErrorIs(t, err, dao.ErrAffectedMismatch)
@@ -347,4 +774,229 @@ func TestReservationFinish(t *testing.T) {
 		err := reservationDao.FinishWithError(ctx, math.MaxInt64, "")
 		require.ErrorIs(t, err, dao.ErrAffectedMismatch)
 	})
+
+	t.Run("already finished", func(t *testing.T) {
+		res := newNoopReservation()
+		err := reservationDao.CreateNoop(ctx, res, nil)
+		require.NoError(t, err)
+
+		err = reservationDao.FinishWithSuccess(ctx, res.ID)
+		require.NoError(t, err)
+
+		err = reservationDao.FinishWithError(ctx, res.ID, "stale")
+		require.ErrorIs(t, err, dao.ErrAffectedMismatch)
+		err = reservationDao.FinishWithSuccess(ctx, res.ID)
+		require.ErrorIs(t, err, dao.ErrAffectedMismatch)
+
+		newRes, err := reservationDao.GetById(ctx, res.ID)
+		require.NoError(t, err)
+		assert.True(t, newRes.Success.Bool)
+		assert.Empty(t, newRes.Error)
+	})
+}
+
+func TestReservationQuotaUsage(t *testing.T) {
+	reservationDao, ctx := setupReservation(t)
+	defer reset()
+
+	aws := newAWSReservation()
+	aws.Detail = &models.AWSDetail{Region: "us-east-1", Amount: 2}
+	err := reservationDao.CreateAWS(ctx, aws, nil)
+	require.NoError(t, err)
+	gcp := newGCPReservation()
+	gcp.Detail = &models.GCPDetail{Zone: "us-east4", Amount: 3}
+	err = reservationDao.CreateGCP(ctx, gcp, nil)
+	require.NoError(t, err)
+
+	t.Run("in progress", func(t *testing.T) {
+		count, countErr := reservationDao.CountInflight(ctx, models.ProviderTypeUnknown)
+		require.NoError(t, countErr)
+		assert.Equal(t, int64(2), count)
+
+		count, countErr = reservationDao.CountInflight(ctx, models.ProviderTypeAWS)
+		require.NoError(t, countErr)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("instances", func(t *testing.T) {
+		sum, sumErr := reservationDao.SumInstancesSince(ctx, models.ProviderTypeUnknown, time.Now().Add(-time.Hour))
+		require.NoError(t, sumErr)
+		assert.Equal(t, int64(5), sum)
+
+		sum, sumErr = reservationDao.SumInstancesSince(ctx, models.ProviderTypeGCP, time.Now().Add(-time.Hour))
+		require.NoError(t, sumErr)
+		assert.Equal(t, int64(3), sum)
+
+		sum, sumErr = reservationDao.SumInstancesSince(ctx, models.ProviderTypeUnknown, time.Now().Add(time.Hour))
+		require.NoError(t, sumErr)
+		assert.Equal(t, int64(0), sum)
+	})
+
+	t.Run("failed reservation", func(t *testing.T) {
+		finishErr := reservationDao.FinishWithError(ctx, aws.ID, "error")
+		require.NoError(t, finishErr)
+
+		count, countErr := reservationDao.CountInflight(ctx, models.ProviderTypeUnknown)
+		require.NoError(t, countErr)
+		assert.Equal(t, int64(1), count)
+
+		sum, sumErr := reservationDao.SumInstancesSince(ctx, models.ProviderTypeUnknown, time.Now().Add(-time.Hour))
+		require.NoError(t, sumErr)
+		assert.Equal(t, int64(3), sum)
+	})
+}
+
+func TestReservationQuotaUsageTx(t *testing.T) {
+	reservationDao, ctx := setupReservation(t)
+	defer reset()
+
+	gcp := newGCPReservation()
+	gcp.Detail = &models.GCPDetail{Zone: "us-east4", Amount: 2}
+	err := reservationDao.CreateGCP(ctx, gcp, nil)
+	require.NoError(t, err)
+
+	t.Run("includes reservation created in transaction", func(t *testing.T) {
+		var usage *dao.QuotaUsage
+		aws := newAWSReservation()
+		aws.Detail = &models.AWSDetail{Region: "us-east-1", Amount: 3}
+		createErr := reservationDao.CreateAWS(ctx, aws, func(tx pgx.Tx) error {
+			var usageErr error
+			usage, usageErr = reservationDao.QuotaUsageTx(ctx, tx, models.ProviderTypeUnknown, time.Now().Add(-time.Hour))
+			return usageErr
+		})
+		require.NoError(t, createErr)
+		assert.Equal(t, int64(2), usage.Inflight)
+		assert.Equal(t, int64(5), usage.Instances)
+	})
+
+	t.Run("provider", func(t *testing.T) {
+		var usage *dao.QuotaUsage
+		aws := newAWSReservation()
+		aws.Detail = &models.AWSDetail{Region: "us-east-1", Amount: 1}
+		createErr := reservationDao.CreateAWS(ctx, aws, func(tx pgx.Tx) error {
+			var usageErr error
+			usage, usageErr = reservationDao.QuotaUsageTx(ctx, tx, models.ProviderTypeAWS, time.Now().Add(-time.Hour))
+			return usageErr
+		})
+		require.NoError(t, createErr)
+		assert.Equal(t, int64(2), usage.Inflight)
+		assert.Equal(t, int64(4), usage.Instances)
+	})
+}
+
+func TestReservationListByPubkey(t *testing.T) {
+	reservationDao, ctx := setupReservation(t)
+	defer reset()
+
+	pk := factories.NewPubkeyED25519()
+	err := dao.GetPubkeyDao(ctx).Create(ctx, pk)
+	require.NoError(t, err)
+
+	aws := newAWSReservation()
+	aws.Detail = &models.AWSDetail{Region: "us-east-1", Amount: 1}
+	err = reservationDao.CreateAWS(ctx, aws, nil)
+	require.NoError(t, err)
+	gcp := newGCPReservation()
+	gcp.Detail = &models.GCPDetail{Zone: "us-east4", Amount: 1, PubkeyIDs: []int64{pk.ID}}
+	err = reservationDao.CreateGCP(ctx, gcp, nil)
+	require.NoError(t, err)
+
+	t.Run("reservation pubkey", func(t *testing.T) {
+		filter := dao.ReservationFilter{PubkeyID: 1}
+		reservations, listErr := reservationDao.List(ctx, dao.ListParams{Limit: 10, Descending: true}, filter)
+		require.NoError(t, listErr)
+		require.Len(t, reservations, 2)
+		assert.Equal(t, gcp.ID, reservations[0].ID)
+		assert.Equal(t, aws.ID, reservations[1].ID)
+
+		count, countErr := reservationDao.Count(ctx, filter)
+		require.NoError(t, countErr)
+		assert.Equal(t, int64(2), count)
+	})
+
+	t.Run("additional pubkey", func(t *testing.T) {
+		reservations, listErr := reservationDao.List(ctx, dao.ListParams{Limit: 10}, dao.ReservationFilter{PubkeyID: pk.ID})
+		require.NoError(t, listErr)
+		require.Len(t, reservations, 1)
+		assert.Equal(t, gcp.ID, reservations[0].ID)
+	})
+
+	t.Run("paged", func(t *testing.T) {
+		reservations, listErr := reservationDao.List(ctx, dao.ListParams{Limit: 1, After: aws.ID}, dao.ReservationFilter{PubkeyID: 1})
+		require.NoError(t, listErr)
+		require.Len(t, reservations, 1)
+		assert.Equal(t, gcp.ID, reservations[0].ID)
+	})
+}
+
+func TestReservationCompositeProgress(t *testing.T) {
+	reservationDao, ctx := setupReservation(t)
+	defer reset()
+
+	parent := &models.CompositeReservation{
+		Reservation: models.Reservation{
+			Steps:      2,
+			StepTitles: []string{"Launch aws reservation", "Launch gcp reservation"},
+		},
+	}
+	err := reservationDao.CreateComposite(ctx, parent)
+	require.NoError(t, err)
+
+	aws := newAWSReservation()
+	aws.ParentID = sql.NullInt64{Int64: parent.ID, Valid: true}
+	err = reservationDao.CreateAWS(ctx, aws, nil)
+	require.NoError(t, err)
+	gcp := newGCPReservation()
+	gcp.ParentID = sql.NullInt64{Int64: parent.ID, Valid: true}
+	err = reservationDao.CreateGCP(ctx, gcp, nil)
+	require.NoError(t, err)
+
+	t.Run("children", func(t *testing.T) {
+		children, listErr := reservationDao.ListChildren(ctx, parent.ID)
+		require.NoError(t, listErr)
+		require.Len(t, children, 2)
+		assert.Equal(t, aws.ID, children[0].ID)
+		assert.Equal(t, gcp.ID, children[1].ID)
+	})
+
+	t.Run("not counted in progress", func(t *testing.T) {
+		count, countErr := reservationDao.CountInflight(ctx, models.ProviderTypeUnknown)
+		require.NoError(t, countErr)
+		assert.Equal(t, int64(2), count)
+	})
+
+	t.Run("first child finished", func(t *testing.T) {
+		finishErr := reservationDao.FinishWithSuccess(ctx, aws.ID)
+		require.NoError(t, finishErr)
+
+		updated, updateErr := reservationDao.UpdateCompositeProgress(ctx, parent.ID)
+		require.NoError(t, updateErr)
+		assert.Equal(t, int32(1), updated.Step)
+		assert.Equal(t, "Finished 1 of 2 reservations", updated.Status)
+		assert.False(t, updated.FinishedAt.Valid)
+		assert.False(t, updated.Success.Valid)
+
+		events, listErr := reservationDao.ListEvents(ctx, parent.ID)
+		require.NoError(t, listErr)
+		require.Len(t, events, 1)
+		assert.Equal(t, int32(1), events[0].Step)
+	})
+
+	t.Run("last child finished", func(t *testing.T) {
+		finishErr := reservationDao.FinishWithError(ctx, gcp.ID, "error")
+		require.NoError(t, finishErr)
+
+		updated, updateErr := reservationDao.UpdateCompositeProgress(ctx, parent.ID)
+		require.NoError(t, updateErr)
+		assert.Equal(t, int32(2), updated.Step)
+		assert.True(t, updated.FinishedAt.Valid)
+		assert.True(t, updated.Success.Valid)
+		assert.False(t, updated.Success.Bool)
+		assert.Equal(t, "1 of 2 reservations failed", updated.Error)
+	})
+
+	t.Run("already finished", func(t *testing.T) {
+		_, updateErr := reservationDao.UpdateCompositeProgress(ctx, parent.ID)
+		require.ErrorIs(t, updateErr, dao.ErrNoRows)
+	})
 }
@@ -0,0 +1,117 @@
+//go:build integration
+// +build integration
+
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
+	"github.com/RHEnVision/provisioning-backend/internal/db"
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/RHEnVision/provisioning-backend/internal/ptr"
+	"github.com/RHEnVision/provisioning-backend/internal/testing/identity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newWebhook(url string) *models.Webhook {
+	return &models.Webhook{
+		URL:     url,
+		Secret:  "0123456789abcdef",
+		Enabled: true,
+	}
+}
+
+func setupWebhook(t *testing.T) (dao.WebhookDao, context.Context) {
+	ctx := identity.WithTenant(t, context.Background())
+	webhookDao := dao.GetWebhookDao(ctx)
+	return webhookDao, ctx
+}
+
+func TestWebhookCreate(t *testing.T) {
+	webhookDao, ctx := setupWebhook(t)
+	defer reset()
+
+	t.Run("success", func(t *testing.T) {
+		webhook := newWebhook("https://example.com/hook")
+		err := webhookDao.Create(ctx, webhook)
+		require.NoError(t, err)
+
+		webhook2, err := webhookDao.GetById(ctx, webhook.ID)
+		require.NoError(t, err)
+		assert.Equal(t, webhook.URL, webhook2.URL)
+		assert.Equal(t, webhook.Secret, webhook2.Secret)
+		assert.True(t, webhook2.Enabled)
+	})
+
+	t.Run("duplicate url", func(t *testing.T) {
+		err := webhookDao.Create(ctx, newWebhook("https://example.com/hook"))
+		require.Error(t, db.IsPostgresError(err, db.UniqueConstraintErrorCode))
+	})
+}
+
+func TestWebhookUpdateAndList(t *testing.T) {
+	webhookDao, ctx := setupWebhook(t)
+	defer reset()
+
+	enabled := newWebhook("https://example.com/enabled")
+	require.NoError(t, webhookDao.Create(ctx, enabled))
+	disabled := newWebhook("https://example.com/disabled")
+	require.NoError(t, webhookDao.Create(ctx, disabled))
+
+	disabled.Enabled = false
+	require.NoError(t, webhookDao.Update(ctx, disabled))
+
+	webhooks, err := webhookDao.List(ctx, dao.ListParams{Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, webhooks, 2)
+
+	count, err := webhookDao.Count(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+
+	webhooks, err = webhookDao.ListEnabled(ctx)
+	require.NoError(t, err)
+	require.Len(t, webhooks, 1)
+	assert.Equal(t, enabled.ID, webhooks[0].ID)
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	webhookDao, ctx := setupWebhook(t)
+	defer reset()
+
+	webhook := newWebhook("https://example.com/hook")
+	require.NoError(t, webhookDao.Create(ctx, webhook))
+	failed := &models.WebhookDelivery{WebhookID: webhook.ID, ReservationID: 1, Event: "finished", Attempt: 1, StatusCode: 500, Error: "unexpected status"}
+	require.NoError(t, webhookDao.UnscopedCreateDelivery(ctx, failed))
+	succeeded := &models.WebhookDelivery{WebhookID: webhook.ID, ReservationID: 1, Event: "finished", Attempt: 2, StatusCode: 200, Success: true}
+	require.NoError(t, webhookDao.UnscopedCreateDelivery(ctx, succeeded))
+
+	t.Run("all", func(t *testing.T) {
+		deliveries, err := webhookDao.ListDeliveries(ctx, webhook.ID, dao.ListParams{Limit: 10}, dao.WebhookDeliveryFilter{})
+		require.NoError(t, err)
+		assert.Len(t, deliveries, 2)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		filter := dao.WebhookDeliveryFilter{Success: ptr.To(false)}
+		deliveries, err := webhookDao.ListDeliveries(ctx, webhook.ID, dao.ListParams{Limit: 10}, filter)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, failed.ID, deliveries[0].ID)
+
+		count, err := webhookDao.CountDeliveries(ctx, webhook.ID, filter)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, count)
+	})
+
+	t.Run("deleted with webhook", func(t *testing.T) {
+		require.NoError(t, webhookDao.Delete(ctx, webhook.ID))
+
+		count, err := webhookDao.CountDeliveries(ctx, webhook.ID, dao.WebhookDeliveryFilter{})
+		require.NoError(t, err)
+		assert.EqualValues(t, 0, count)
+	})
+}
@@ -10,7 +10,8 @@ import (
 type PostgresErrorCode string
 
 const (
-	UniqueConstraintErrorCode PostgresErrorCode = "23505"
+	UniqueConstraintErrorCode     PostgresErrorCode = "23505"
+	ForeignKeyConstraintErrorCode PostgresErrorCode = "23503"
 )
 
 func IsPostgresError(err error, code PostgresErrorCode) error {
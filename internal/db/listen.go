@@ -0,0 +1,36 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Listen subscribes to a notification channel and calls fn with payload of every notification
+// received until the context is cancelled or the connection fails. A dedicated connection is
+// taken out of the pool for this purpose and it is closed afterwards.
+func Listen(ctx context.Context, channel string, fn func(payload string)) error {
+	poolConn, err := Pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to acquire connection: %w", err)
+	}
+	conn := poolConn.Hijack()
+	defer conn.Close(context.Background())
+
+	_, err = conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize())
+	if err != nil {
+		return fmt.Errorf("unable to listen on channel %s: %w", channel, err)
+	}
+
+	for {
+		notification, waitErr := conn.WaitForNotification(ctx)
+		if waitErr != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("unable to receive notification: %w", waitErr)
+		}
+		fn(notification.Payload)
+	}
+}
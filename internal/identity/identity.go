@@ -41,3 +41,16 @@ func WithIdentityFrom64(ctx context.Context, id string) (context.Context, error)
 
 	return context.WithValue(ctx, identity.Key, jsonData), nil
 }
+
+// WithAccount returns context copy with identity and account id of an account. It is meant for
+// background tasks acting on behalf of an account outside of a request or a job.
+func WithAccount(ctx context.Context, accountId int64, orgId, accountNumber string) context.Context {
+	id := Principal{
+		Identity: identity.Identity{
+			OrgID:         orgId,
+			AccountNumber: accountNumber,
+			Internal:      identity.Internal{OrgID: orgId},
+		},
+	}
+	return WithAccountId(WithIdentity(ctx, id), accountId)
+}
@@ -0,0 +1,84 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
+	"github.com/RHEnVision/provisioning-backend/pkg/worker"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+)
+
+type CleanupReservationsTaskArgs struct {
+	// Finished reservations older than this are deleted
+	Retention time.Duration
+
+	// Amount of reservations deleted in a single transaction
+	BatchSize int64
+
+	// Copy deleted reservations into the archive table
+	Archive bool
+
+	// Only count reservations which would be deleted
+	DryRun bool
+}
+
+// Unmarshall arguments and handle error
+func HandleCleanupReservations(ctx context.Context, job *worker.Job) error {
+	args, ok := job.Args.(CleanupReservationsTaskArgs)
+	if !ok {
+		err := fmt.Errorf("%w: job %s, reservation: %#v", ErrTypeAssertion, job.ID, job.Args)
+		zerolog.Ctx(ctx).Error().Err(err).Msg("Type assertion error for job")
+		return err
+	}
+
+	logger := zerolog.Ctx(ctx)
+	logger.Info().Msg("Started cleanup reservations job")
+	ctx, span := otel.Tracer(TraceName).Start(ctx, "CleanupReservationsJob")
+	defer span.End()
+
+	deleted, err := DoCleanupReservations(ctx, &args)
+	if err != nil {
+		logger.Error().Err(err).Msgf("Cleanup reservations job failed after %d deleted reservation(s)", deleted)
+		return err
+	}
+
+	logger.Info().Msgf("Finished cleanup reservations job, deleted %d reservation(s)", deleted)
+	return nil
+}
+
+// DoCleanupReservations deletes (or archives) finished reservations older than the retention
+// period in batches and returns count of deleted reservations. In dry-run mode, count of
+// reservations which would be deleted is returned instead.
+func DoCleanupReservations(ctx context.Context, args *CleanupReservationsTaskArgs) (int64, error) {
+	logger := zerolog.Ctx(ctx)
+	rDao := dao.GetReservationDao(ctx)
+
+	if args.DryRun {
+		count, err := rDao.UnscopedCountExpired(ctx, args.Retention)
+		if err != nil {
+			return 0, fmt.Errorf("cannot count expired reservations: %w", err)
+		}
+		logger.Info().Msgf("Dry run: %d reservation(s) older than %s would be deleted", count, args.Retention)
+		return count, nil
+	}
+
+	var total int64
+	for {
+		deleted, err := rDao.UnscopedDeleteExpired(ctx, args.Retention, args.BatchSize, args.Archive)
+		if err != nil {
+			return total, fmt.Errorf("cannot delete expired reservations: %w", err)
+		}
+		total += deleted
+		logger.Debug().Msgf("Deleted batch of %d reservation(s)", deleted)
+
+		if deleted == 0 || deleted < args.BatchSize {
+			return total, nil
+		}
+		if ctx.Err() != nil {
+			return total, fmt.Errorf("cleanup interrupted: %w", ctx.Err())
+		}
+	}
+}
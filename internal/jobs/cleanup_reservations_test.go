@@ -0,0 +1,60 @@
+package jobs_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	daoStubs "github.com/RHEnVision/provisioning-backend/internal/dao/stubs"
+	"github.com/RHEnVision/provisioning-backend/internal/jobs"
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/RHEnVision/provisioning-backend/internal/testing/identity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func prepareCleanupContext(t *testing.T) context.Context {
+	t.Helper()
+
+	ctx := daoStubs.WithAccountDaoOne(context.Background())
+	ctx = identity.WithTenant(t, ctx)
+	ctx = daoStubs.WithReservationDao(ctx)
+
+	finishedAt := []time.Time{time.Now().Add(-100 * 24 * time.Hour), time.Now().Add(-50 * 24 * time.Hour), time.Now()}
+	for _, finished := range finishedAt {
+		res := &models.AWSReservation{Detail: &models.AWSDetail{}}
+		res.FinishedAt = sql.NullTime{Time: finished, Valid: true}
+		require.NoError(t, daoStubs.AddAWSReservation(ctx, res))
+	}
+	require.NoError(t, daoStubs.AddAWSReservation(ctx, &models.AWSReservation{Detail: &models.AWSDetail{}}))
+
+	return ctx
+}
+
+func TestDoCleanupReservations(t *testing.T) {
+	ctx := prepareCleanupContext(t)
+
+	args := &jobs.CleanupReservationsTaskArgs{
+		Retention: 30 * 24 * time.Hour,
+		BatchSize: 1,
+	}
+	deleted, err := jobs.DoCleanupReservations(ctx, args)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, deleted)
+	assert.Equal(t, 2, daoStubs.AWSReservationStubCount(ctx))
+}
+
+func TestDoCleanupReservationsDryRun(t *testing.T) {
+	ctx := prepareCleanupContext(t)
+
+	args := &jobs.CleanupReservationsTaskArgs{
+		Retention: 30 * 24 * time.Hour,
+		BatchSize: 100,
+		DryRun:    true,
+	}
+	deleted, err := jobs.DoCleanupReservations(ctx, args)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, deleted)
+	assert.Equal(t, 4, daoStubs.AWSReservationStubCount(ctx))
+}
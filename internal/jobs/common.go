@@ -2,13 +2,21 @@ package jobs
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
 
 	"github.com/RHEnVision/provisioning-backend/internal/dao"
+	"github.com/RHEnVision/provisioning-backend/internal/identity"
+	"github.com/RHEnVision/provisioning-backend/internal/kafka"
 	"github.com/RHEnVision/provisioning-backend/internal/metrics"
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/RHEnVision/provisioning-backend/internal/queue"
+	"github.com/RHEnVision/provisioning-backend/internal/secrets"
 	"github.com/RHEnVision/provisioning-backend/internal/telemetry"
+	"github.com/RHEnVision/provisioning-backend/internal/userdata"
+	"github.com/RHEnVision/provisioning-backend/pkg/worker"
 	"github.com/rs/zerolog"
 )
 
@@ -16,6 +24,10 @@ const TraceName = telemetry.TracePrefix + "internal/jobs"
 
 var ErrTypeAssertion = errors.New("type assert error")
 
+var ErrReservationCancelled = errors.New("reservation was cancelled")
+
+var ErrReservationLost = errors.New("reservation job was likely lost")
+
 func finishJob(ctx context.Context, reservationId int64, jobErr error) {
 	if jobErr != nil {
 		finishWithError(ctx, reservationId, jobErr)
@@ -26,8 +38,8 @@ func finishJob(ctx context.Context, reservationId int64, jobErr error) {
 
 func finishWithSuccess(ctx context.Context, reservationId int64) {
 	logger := zerolog.Ctx(ctx)
-	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
-		// the original context is expired and unusable at this point
+	if ctx.Err() != nil {
+		// the original context is expired or cancelled and unusable at this point
 		ctx = copyContext(ctx)
 	}
 
@@ -46,18 +58,34 @@ func finishWithSuccess(ctx context.Context, reservationId int64) {
 	if reservation.Step >= reservation.Steps {
 		logger.Info().Msgf("All jobs executed, marking job as success")
 		err = rDao.FinishWithSuccess(ctx, reservationId)
-		if err != nil {
+		if errors.Is(err, dao.ErrAffectedMismatch) {
+			logger.Warn().Msgf("Reservation %d was already finished", reservationId)
+			return
+		} else if err != nil {
 			logger.Warn().Err(err).Msg("unable to update job status: finish")
+			return
+		}
+
+		instances, listErr := rDao.ListInstances(ctx, reservationId)
+		if listErr != nil {
+			logger.Warn().Err(listErr).Msg("unable to list instances for status event")
 		}
+		instanceIDs := make([]string, len(instances))
+		for i, instance := range instances {
+			instanceIDs[i] = instance.InstanceID
+		}
+		publishStatus(ctx, reservation, kafka.ReservationFinished, instanceIDs)
+		updateParent(ctx, reservation)
 	}
 }
 
 // finishWithError closes a reservation and sets it into error state. Error message is also
-// stored into the reservation.
+// stored into the reservation. When the reservation was cancelled, the outcome is recorded as
+// cancelled instead.
 func finishWithError(ctx context.Context, reservationId int64, jobError error) {
 	logger := zerolog.Ctx(ctx)
-	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
-		// the original context is expired and unusable at this point
+	if ctx.Err() != nil {
+		// the original context is expired or cancelled and unusable at this point
 		ctx = copyContext(ctx)
 	}
 
@@ -67,15 +95,126 @@ func finishWithError(ctx context.Context, reservationId int64, jobError error) {
 		logger.Warn().Err(err).Msg("unable to update job status: get by id")
 		return
 	}
+
+	if reservation.Cancelled {
+		finishWithCancel(ctx, reservation)
+		return
+	}
 	logger.Error().Err(jobError).Msgf("Reservation for %s returned an error", reservation.Provider.String())
 
-	// total count of reservations
-	metrics.IncReservationCount(reservation.Provider.String(), "failure")
+	failReservation(ctx, reservation, "", jobError, "failure")
+}
+
+// finishWithCancel closes a cancelled reservation, it sets it into error state with "Cancelled" status.
+func finishWithCancel(ctx context.Context, reservation *models.Reservation) bool {
+	logger := zerolog.Ctx(ctx)
+	logger.Warn().Msgf("Reservation for %s was cancelled", reservation.Provider.String())
 
-	err = rDao.FinishWithError(ctx, reservationId, jobError.Error())
+	return failReservation(ctx, reservation, "Cancelled", ErrReservationCancelled, "cancelled")
+}
+
+// FinishStaleReservation closes a reservation which job was lost and publishes the failure the
+// same way as when a job fails. The stale reservation reaper runs without identity, so identity
+// of the reservation account is used. Returns false when the reservation was finished meanwhile.
+func FinishStaleReservation(ctx context.Context, reservation *models.Reservation, jobError error) (bool, error) {
+	account, err := dao.GetAccountDao(ctx).GetById(ctx, reservation.AccountID)
 	if err != nil {
+		return false, fmt.Errorf("unable to get account: %w", err)
+	}
+	ctx = identity.WithAccount(ctx, account.ID, account.OrgID, account.AccountNumber.String)
+
+	if reservation.Cancelled {
+		return finishWithCancel(ctx, reservation), nil
+	}
+	return failReservation(ctx, reservation, "Lost", jobError, "failure"), nil
+}
+
+// failReservation sets the reservation into error state, updates its status when not empty and
+// publishes the failure. The reservation is only updated when it is not finished yet, so a job
+// and the stale reservation reaper cannot both finish it. Returns false when it was finished already.
+func failReservation(ctx context.Context, reservation *models.Reservation, status string, jobError error, result string) bool {
+	logger := zerolog.Ctx(ctx)
+	rDao := dao.GetReservationDao(ctx)
+
+	err := rDao.FinishWithError(ctx, reservation.ID, jobError.Error())
+	if errors.Is(err, dao.ErrAffectedMismatch) {
+		logger.Warn().Msgf("Reservation %d was already finished", reservation.ID)
+		return false
+	} else if err != nil {
 		logger.Warn().Err(err).Msg("unable to update job status: finish")
+		return false
+	}
+
+	// total count of reservations
+	metrics.IncReservationCount(reservation.Provider.String(), result)
+
+	if status != "" {
+		err = rDao.UpdateStatus(ctx, reservation.ID, status, 0)
+		if err != nil {
+			logger.Warn().Err(err).Msgf("unable to update job status: %s", result)
+		} else {
+			reservation.Status = status
+		}
+	}
+
+	reservation.Error = jobError.Error()
+	publishStatus(ctx, reservation, kafka.ReservationFailed, nil)
+	updateParent(ctx, reservation)
+	return true
+}
+
+// updateParent updates progress of the composite reservation which launched the finished
+// reservation. The composite reservation is finished and published with its last child.
+func updateParent(ctx context.Context, reservation *models.Reservation) {
+	if !reservation.ParentID.Valid {
+		return
+	}
+	logger := zerolog.Ctx(ctx)
+
+	parent, err := dao.GetReservationDao(ctx).UpdateCompositeProgress(ctx, reservation.ParentID.Int64)
+	if errors.Is(err, dao.ErrNoRows) {
+		logger.Debug().Msgf("Composite reservation %d is already finished", reservation.ParentID.Int64)
+		return
+	} else if err != nil {
+		logger.Warn().Err(err).Msg("unable to update composite reservation progress")
+		return
+	}
+
+	if !parent.FinishedAt.Valid {
+		publishStatus(ctx, parent, kafka.ReservationStep, nil)
+	} else if parent.Success.Bool {
+		metrics.IncReservationCount(parent.Provider.String(), "success")
+		publishStatus(ctx, parent, kafka.ReservationFinished, nil)
+	} else {
+		metrics.IncReservationCount(parent.Provider.String(), "failure")
+		publishStatus(ctx, parent, kafka.ReservationFailed, nil)
+	}
+}
+
+// retryLaunchOrFinishWithError closes a reservation and sets it into error state unless the launch
+// job can be retried according to its retry policy. It is only meant for errors of steps before any
+// instances were launched. Progress of the failed attempt is reset, because the retried job starts
+// from the first step again. The job error is always returned, so the worker either retries the job
+// or moves it into the dead-letter queue.
+func retryLaunchOrFinishWithError(ctx context.Context, job *worker.Job, reservationId int64, jobError error) error {
+	if ctx.Err() != nil || !RetryPolicies[job.Type].Retry(job.Attempt) {
+		finishWithError(ctx, reservationId, jobError)
+		return jobError
 	}
+
+	logger := zerolog.Ctx(ctx)
+	logger.Warn().Err(jobError).Msgf("Launch job attempt %d failed, it will be retried", job.Attempt)
+	rDao := dao.GetReservationDao(ctx)
+	reservation, err := rDao.GetById(ctx, reservationId)
+	if err != nil {
+		logger.Warn().Err(err).Msg("unable to reset job progress: get by id")
+		return jobError
+	}
+	err = rDao.UpdateStatus(ctx, reservationId, "Waiting for retry", -reservation.Step)
+	if err != nil {
+		logger.Warn().Err(err).Msg("unable to reset job progress: update")
+	}
+	return jobError
 }
 
 // updateStatusBefore is called after every step function within a job. It updates reservation status
@@ -90,7 +229,9 @@ func updateStatusAfter(ctx context.Context, id int64, status string, addSteps in
 	logger := zerolog.Ctx(ctx)
 	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
 		status = "Timeout"
-		// the original context is expired and unusable at this point
+	}
+	if ctx.Err() != nil {
+		// the original context is expired or cancelled and unusable at this point
 		ctx = copyContext(ctx)
 	}
 
@@ -104,6 +245,82 @@ func updateStatusAfter(ctx context.Context, id int64, status string, addSteps in
 	err := rDao.UpdateStatus(ctx, id, status, int32(addSteps))
 	if err != nil {
 		logger.Warn().Err(err).Msg("unable to update step number: update")
+		return
+	}
+
+	// only the first status change and step transitions are published
+	reservation, err := rDao.GetById(ctx, id)
+	if err != nil {
+		logger.Warn().Err(err).Msg("unable to get reservation for status event")
+		return
+	}
+	if addSteps > 0 {
+		publishStatus(ctx, reservation, kafka.ReservationStep, nil)
+	} else if reservation.Step == 0 {
+		publishStatus(ctx, reservation, kafka.ReservationStarted, nil)
+	}
+}
+
+// publishStatus enqueues a reservation lifecycle event for the platform kafka. Finished and
+// failed reservations are also delivered to webhooks of the account.
+func publishStatus(ctx context.Context, reservation *models.Reservation, event kafka.ReservationEventType, instanceIDs []string) {
+	id := identity.Identity(ctx)
+	msg := kafka.ReservationStatusMessage{
+		ReservationID: reservation.ID,
+		Event:         event,
+		Provider:      reservation.Provider.String(),
+		AccountID:     reservation.AccountID,
+		OrgID:         id.Identity.OrgID,
+		AccountNumber: id.Identity.AccountNumber,
+		Status:        reservation.Status,
+		Step:          reservation.Step,
+		Steps:         reservation.Steps,
+		InstanceIDs:   instanceIDs,
+		Error:         reservation.Error,
+		Timestamp:     time.Now(),
+	}
+	kafka.EnqueueReservationStatus(ctx, msg)
+
+	if event == kafka.ReservationFinished || event == kafka.ReservationFailed {
+		enqueueWebhooks(ctx, &msg)
+	}
+}
+
+// enqueueWebhooks enqueues delivery jobs of the message for all enabled webhooks of the account.
+func enqueueWebhooks(ctx context.Context, msg *kafka.ReservationStatusMessage) {
+	logger := zerolog.Ctx(ctx)
+
+	webhooks, err := dao.GetWebhookDao(ctx).ListEnabled(ctx)
+	if err != nil {
+		logger.Warn().Err(err).Msg("unable to list webhooks")
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		logger.Warn().Err(err).Msg("unable to marshal webhook body")
+		return
+	}
+
+	for _, webhook := range webhooks {
+		job := worker.Job{
+			Type:      TypeDeliverWebhook,
+			Identity:  identity.Identity(ctx),
+			AccountID: identity.AccountId(ctx),
+			Args: DeliverWebhookTaskArgs{
+				WebhookID:     webhook.ID,
+				ReservationID: msg.ReservationID,
+				Event:         string(msg.Event),
+				Body:          body,
+			},
+		}
+		err = queue.GetEnqueuer(ctx).Enqueue(ctx, &job)
+		if err != nil {
+			logger.Warn().Err(err).Msgf("unable to enqueue delivery of webhook %d", webhook.ID)
+		}
 	}
 }
 
@@ -111,6 +328,9 @@ func nilUnlessTimeout(ctx context.Context) error {
 	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
 		return fmt.Errorf("context timeout: %w", ctx.Err())
 	}
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return fmt.Errorf("context cancelled: %w", ctx.Err())
+	}
 	return nil
 }
 
@@ -126,3 +346,30 @@ func sleepCtx(ctx context.Context, d time.Duration) error {
 		return ctx.Err()
 	}
 }
+
+// renderUserData renders user data of a reservation from the launch input of its detail. The
+// input is the same as when the reservation was created, so user data matches its hash.
+func renderUserData(provider models.ProviderType, powerOff bool, sshKeys []string, input models.LaunchInput) ([]byte, error) {
+	var activationKey string
+	if input.RHCActivationKey != "" {
+		var err error
+		activationKey, err = secrets.Decrypt(input.RHCActivationKey)
+		if err != nil {
+			return nil, fmt.Errorf("cannot decrypt activation key: %w", err)
+		}
+	}
+
+	userData, err := userdata.GenerateUserData(&userdata.UserData{
+		Type:              provider,
+		PowerOff:          powerOff,
+		InsightsTags:      true,
+		Custom:            input.UserData,
+		RHCActivationKey:  activationKey,
+		RHCOrgID:          input.RHCOrgID,
+		SSHAuthorizedKeys: sshKeys,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot generate user data: %w", err)
+	}
+	return userData, nil
+}
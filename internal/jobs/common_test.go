@@ -2,9 +2,22 @@ package jobs
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/RHEnVision/provisioning-backend/internal/config"
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
+	daoStubs "github.com/RHEnVision/provisioning-backend/internal/dao/stubs"
+	"github.com/RHEnVision/provisioning-backend/internal/kafka"
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/RHEnVision/provisioning-backend/internal/queue/stub"
+	"github.com/RHEnVision/provisioning-backend/internal/secrets"
+	"github.com/RHEnVision/provisioning-backend/internal/testing/identity"
+	"github.com/RHEnVision/provisioning-backend/internal/userdata"
+	"github.com/RHEnVision/provisioning-backend/pkg/worker"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -19,3 +32,238 @@ func TestSleepCtxDeadline(t *testing.T) {
 	err := sleepCtx(ctx, 500*time.Microsecond)
 	require.ErrorIs(t, err, context.DeadlineExceeded)
 }
+
+func TestNilUnlessTimeoutCancelled(t *testing.T) {
+	ctx, c := context.WithCancel(context.Background())
+	require.NoError(t, nilUnlessTimeout(ctx))
+	c()
+	err := nilUnlessTimeout(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func nextReservationStatus(t *testing.T) *kafka.ReservationStatusMessage {
+	t.Helper()
+
+	select {
+	case msg := <-kafka.ReservationStatusQueue():
+		rsm, err := kafka.NewReservationStatusMessage(msg)
+		require.NoError(t, err)
+		return rsm
+	default:
+		require.FailNow(t, "no reservation status message enqueued")
+		return nil
+	}
+}
+
+func TestFinishJobPublishesStatus(t *testing.T) {
+	ctx := daoStubs.WithAccountDaoOne(context.Background())
+	ctx = identity.WithTenant(t, ctx)
+	ctx = daoStubs.WithReservationDao(ctx)
+	ctx = daoStubs.WithWebhookDao(ctx)
+	ctx = stub.WithEnqueuer(ctx)
+
+	// drain messages of other tests
+	for len(kafka.ReservationStatusQueue()) > 0 {
+		<-kafka.ReservationStatusQueue()
+	}
+
+	rDao := dao.GetReservationDao(ctx)
+	reservation := &models.AWSReservation{
+		SourceID: "irrelevant",
+		ImageID:  "irrelevant",
+		Detail:   &models.AWSDetail{Region: "us-east-1", Amount: 1, LaunchInput: models.LaunchInput{UserData: []byte("#!/bin/sh\n"), RHCActivationKey: "lab-key", RHCOrgID: "1"}},
+	}
+	reservation.AccountID = 1
+	reservation.Provider = models.ProviderTypeAWS
+	reservation.Status = "Launched instance(s)"
+	reservation.Step = 1
+	reservation.Steps = 1
+	require.NoError(t, rDao.CreateAWS(ctx, reservation, nil))
+	require.NoError(t, rDao.CreateInstance(ctx, &models.ReservationInstance{ReservationID: reservation.ID, InstanceID: "i-1"}))
+	webhook := &models.Webhook{URL: "https://example.com/hook", Secret: "0123456789abcdef", Enabled: true}
+	require.NoError(t, dao.GetWebhookDao(ctx).Create(ctx, webhook))
+
+	t.Run("Success", func(t *testing.T) {
+		finishJob(ctx, reservation.ID, nil)
+
+		msg := nextReservationStatus(t)
+		assert.Equal(t, kafka.ReservationFinished, msg.Event)
+		assert.Equal(t, reservation.ID, msg.ReservationID)
+		assert.Equal(t, "aws", msg.Provider)
+		assert.Equal(t, int64(1), msg.AccountID)
+		assert.Equal(t, identity.DefaultOrgId, msg.OrgID)
+		assert.Equal(t, []string{"i-1"}, msg.InstanceIDs)
+		assert.Empty(t, msg.Error)
+
+		require.Len(t, stub.EnqueuedJobs(ctx), 1)
+		job := stub.EnqueuedJobs(ctx)[0]
+		assert.Equal(t, TypeDeliverWebhook, job.Type)
+		assert.Equal(t, webhook.ID, job.Args.(DeliverWebhookTaskArgs).WebhookID)
+		assert.Equal(t, "finished", job.Args.(DeliverWebhookTaskArgs).Event)
+		assert.Equal(t, models.LaunchInput{}, reservation.Detail.LaunchInput, "launch input must be cleared")
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		failed := &models.AWSReservation{
+			SourceID: "irrelevant",
+			ImageID:  "irrelevant",
+			Detail:   &models.AWSDetail{Region: "us-east-1", Amount: 1, LaunchInput: models.LaunchInput{UserData: []byte("#!/bin/sh\n"), RHCActivationKey: "lab-key", RHCOrgID: "1"}},
+		}
+		failed.AccountID = 1
+		failed.Provider = models.ProviderTypeAWS
+		failed.Steps = 1
+		require.NoError(t, rDao.CreateAWS(ctx, failed, nil))
+
+		finishJob(ctx, failed.ID, errors.New("launch failed"))
+
+		msg := nextReservationStatus(t)
+		assert.Equal(t, kafka.ReservationFailed, msg.Event)
+		assert.Equal(t, failed.ID, msg.ReservationID)
+		assert.Equal(t, "launch failed", msg.Error)
+		assert.Empty(t, msg.InstanceIDs)
+		assert.Equal(t, models.LaunchInput{}, failed.Detail.LaunchInput, "launch input must be cleared")
+	})
+
+	t.Run("Step", func(t *testing.T) {
+		updateStatusAfter(ctx, reservation.ID, "Launched instance(s)", 1)
+
+		msg := nextReservationStatus(t)
+		assert.Equal(t, kafka.ReservationStep, msg.Event)
+		assert.Equal(t, "Launched instance(s)", msg.Status)
+		assert.Len(t, stub.EnqueuedJobs(ctx), 2, "steps must not be delivered to webhooks")
+	})
+
+	t.Run("Already finished", func(t *testing.T) {
+		finishJob(ctx, reservation.ID, errors.New("late failure"))
+
+		assert.Empty(t, kafka.ReservationStatusQueue(), "finished reservation must not be published again")
+		assert.Len(t, stub.EnqueuedJobs(ctx), 2, "finished reservation must not be delivered again")
+		assert.True(t, reservation.Success.Bool)
+		assert.Empty(t, reservation.Error)
+	})
+}
+
+func TestFinishJobUpdatesParent(t *testing.T) {
+	ctx := daoStubs.WithAccountDaoOne(context.Background())
+	ctx = identity.WithTenant(t, ctx)
+	ctx = daoStubs.WithReservationDao(ctx)
+	ctx = daoStubs.WithWebhookDao(ctx)
+	ctx = stub.WithEnqueuer(ctx)
+
+	// drain messages of other tests
+	for len(kafka.ReservationStatusQueue()) > 0 {
+		<-kafka.ReservationStatusQueue()
+	}
+
+	rDao := dao.GetReservationDao(ctx)
+	parent := &models.CompositeReservation{}
+	parent.Status = "Created"
+	parent.Steps = 2
+	require.NoError(t, rDao.CreateComposite(ctx, parent))
+
+	children := make([]*models.AWSReservation, 2)
+	for i := range children {
+		children[i] = &models.AWSReservation{Detail: &models.AWSDetail{Region: "us-east-1", Amount: 1}}
+		children[i].AccountID = 1
+		children[i].Provider = models.ProviderTypeAWS
+		children[i].Step = 1
+		children[i].Steps = 1
+		children[i].ParentID = sql.NullInt64{Int64: parent.ID, Valid: true}
+		require.NoError(t, rDao.CreateAWS(ctx, children[i], nil))
+	}
+
+	t.Run("FirstChild", func(t *testing.T) {
+		finishJob(ctx, children[0].ID, nil)
+
+		assert.Equal(t, children[0].ID, nextReservationStatus(t).ReservationID)
+		msg := nextReservationStatus(t)
+		assert.Equal(t, kafka.ReservationStep, msg.Event)
+		assert.Equal(t, parent.ID, msg.ReservationID)
+		assert.Equal(t, int32(1), msg.Step)
+		assert.False(t, parent.FinishedAt.Valid)
+	})
+
+	t.Run("LastChild", func(t *testing.T) {
+		finishJob(ctx, children[1].ID, errors.New("launch failed"))
+
+		assert.Equal(t, children[1].ID, nextReservationStatus(t).ReservationID)
+		msg := nextReservationStatus(t)
+		assert.Equal(t, kafka.ReservationFailed, msg.Event)
+		assert.Equal(t, parent.ID, msg.ReservationID)
+		assert.Equal(t, "composite", msg.Provider)
+		assert.Equal(t, "1 of 2 reservations failed", msg.Error)
+		assert.True(t, parent.FinishedAt.Valid)
+		assert.False(t, parent.Success.Bool)
+	})
+}
+
+func TestRetryLaunchOrFinishWithError(t *testing.T) {
+	ctx := daoStubs.WithAccountDaoOne(context.Background())
+	ctx = identity.WithTenant(t, ctx)
+	ctx = daoStubs.WithReservationDao(ctx)
+	ctx = daoStubs.WithWebhookDao(ctx)
+	ctx = stub.WithEnqueuer(ctx)
+
+	// drain messages of other tests
+	for len(kafka.ReservationStatusQueue()) > 0 {
+		<-kafka.ReservationStatusQueue()
+	}
+
+	rDao := dao.GetReservationDao(ctx)
+	reservation := &models.AWSReservation{Detail: &models.AWSDetail{Region: "us-east-1", Amount: 1}}
+	reservation.AccountID = 1
+	reservation.Provider = models.ProviderTypeAWS
+	reservation.Step = 1
+	reservation.Steps = 3
+	require.NoError(t, rDao.CreateAWS(ctx, reservation, nil))
+	updateStatusAfter(ctx, reservation.ID, "Uploaded public key", 1)
+	<-kafka.ReservationStatusQueue()
+	jobErr := errors.New("throttled")
+
+	t.Run("Retry", func(t *testing.T) {
+		job := &worker.Job{Type: TypeLaunchInstanceAws, Attempt: 1}
+		err := retryLaunchOrFinishWithError(ctx, job, reservation.ID, jobErr)
+		require.ErrorIs(t, err, jobErr)
+		assert.False(t, reservation.FinishedAt.Valid)
+		assert.Empty(t, kafka.ReservationStatusQueue())
+
+		events, err := rDao.ListEvents(ctx, reservation.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Waiting for retry", events[len(events)-1].Status)
+		assert.Equal(t, int32(0), events[len(events)-1].Step)
+	})
+
+	t.Run("Last attempt", func(t *testing.T) {
+		job := &worker.Job{Type: TypeLaunchInstanceAws, Attempt: RetryPolicies[TypeLaunchInstanceAws].MaxAttempts}
+		err := retryLaunchOrFinishWithError(ctx, job, reservation.ID, jobErr)
+		require.ErrorIs(t, err, jobErr)
+		assert.True(t, reservation.FinishedAt.Valid)
+		assert.Equal(t, kafka.ReservationFailed, nextReservationStatus(t).Event)
+	})
+}
+
+func TestRenderUserData(t *testing.T) {
+	secretsKey := config.Application.SecretsKey
+	config.Application.SecretsKey = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	defer func() { config.Application.SecretsKey = secretsKey }()
+	activationKey, err := secrets.Encrypt("lab-key")
+	require.NoError(t, err)
+	input := models.LaunchInput{UserData: []byte("#!/bin/sh\necho hello\n"), RHCActivationKey: activationKey, RHCOrgID: "1"}
+
+	t.Run("AWS", func(t *testing.T) {
+		userData, err := renderUserData(models.ProviderTypeAWS, true, []string{"ssh-ed25519 AAAA"}, input)
+		require.NoError(t, err)
+		assert.True(t, userdata.IsMultipart(userData))
+		assert.Contains(t, string(userData), "echo hello")
+		assert.Contains(t, string(userData), "ssh-ed25519 AAAA")
+		assert.Contains(t, string(userData), `"--activation-key", "lab-key"`)
+	})
+
+	t.Run("GCP", func(t *testing.T) {
+		script, err := renderUserData(models.ProviderTypeGCP, true, nil, input)
+		require.NoError(t, err)
+		assert.False(t, userdata.IsMultipart(script))
+		assert.NotContains(t, string(script), "echo hello", "custom user data is passed via cloud-init")
+		assert.Contains(t, string(script), `--activation-key "lab-key"`)
+	})
+}
@@ -15,6 +15,7 @@ func copyContext(ctx context.Context) context.Context {
 	nCtx = log.Logger.WithContext(nCtx)
 	nCtx = logging.WithTraceId(nCtx, logging.TraceId(ctx))
 	nCtx = logging.WithEdgeRequestId(nCtx, logging.EdgeRequestId(ctx))
+	nCtx = identity.WithIdentity(nCtx, identity.Identity(ctx))
 	nCtx = identity.WithAccountId(nCtx, identity.AccountId(ctx))
 	return nCtx
 }
@@ -0,0 +1,180 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/RHEnVision/provisioning-backend/internal/config"
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/RHEnVision/provisioning-backend/internal/publicnet"
+	"github.com/RHEnVision/provisioning-backend/pkg/worker"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+)
+
+// Headers of webhook requests, the signature is "sha256=" followed by hex encoded HMAC-SHA256
+// of the request body computed with the webhook secret.
+const (
+	WebhookSignatureHeader = "X-Provisioning-Signature"
+	WebhookEventHeader     = "X-Provisioning-Event"
+	WebhookDeliveryHeader  = "X-Provisioning-Delivery"
+)
+
+var WebhookResponseErr = errors.New("webhook responded with unexpected status code")
+
+// HTTP client for webhook requests, targets are outside of the platform.
+var webhookClient = newWebhookClient()
+
+// newWebhookClient returns a client which refuses connections to non-public addresses and does
+// not follow redirects, so user supplied URLs cannot reach internal services. Proxy is not used
+// because addresses are checked when connecting.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   5 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, c syscall.RawConn) error {
+			if config.Webhook.AllowPrivate {
+				return nil
+			}
+			return publicnet.Control(network, address, c)
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: transport,
+		// redirect response is returned and recorded as failed delivery
+		CheckRedirect: func(_ *http.Request, _ []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+type DeliverWebhookTaskArgs struct {
+	// Target webhook
+	WebhookID int64
+
+	// Associated reservation
+	ReservationID int64
+
+	// Reservation event type
+	Event string
+
+	// JSON body of the request
+	Body []byte
+}
+
+// Unmarshall arguments and handle error
+func HandleDeliverWebhook(ctx context.Context, job *worker.Job) error {
+	args, ok := job.Args.(DeliverWebhookTaskArgs)
+	if !ok {
+		err := fmt.Errorf("%w: job %s, reservation: %#v", ErrTypeAssertion, job.ID, job.Args)
+		zerolog.Ctx(ctx).Error().Err(err).Msg("Type assertion error for job")
+		return err
+	}
+
+	logger := zerolog.Ctx(ctx).With().
+		Int64("reservation_id", args.ReservationID).
+		Int64("webhook_id", args.WebhookID).Logger()
+	ctx = logger.WithContext(ctx)
+
+	logger.Info().Msg("Started deliver webhook job")
+	ctx, span := otel.Tracer(TraceName).Start(ctx, "DeliverWebhookJob")
+	defer span.End()
+
+	jobErr := DoDeliverWebhook(ctx, &args, job.ID.String(), job.Attempt)
+	if jobErr != nil {
+		logger.Warn().Err(jobErr).Msgf("Webhook delivery attempt %d failed", job.Attempt)
+		return jobErr
+	}
+
+	logger.Info().Msg("Finished deliver webhook job")
+	return nil
+}
+
+// SignWebhook returns signature of the body for the signature header.
+func SignWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// DoDeliverWebhook sends the signed request to the webhook and logs the delivery attempt. An error
+// is returned when the webhook did not respond with 2xx status code, so the job is retried.
+// Deleted or disabled webhooks are skipped.
+func DoDeliverWebhook(ctx context.Context, args *DeliverWebhookTaskArgs, deliveryId string, attempt int) error {
+	logger := zerolog.Ctx(ctx)
+	whDao := dao.GetWebhookDao(ctx)
+
+	webhook, err := whDao.GetById(ctx, args.WebhookID)
+	if err != nil {
+		if errors.Is(err, dao.ErrNoRows) {
+			logger.Warn().Msg("Webhook was deleted, skipping delivery")
+			return nil
+		}
+		return fmt.Errorf("cannot get webhook: %w", err)
+	}
+	if !webhook.Enabled {
+		logger.Warn().Msg("Webhook was disabled, skipping delivery")
+		return nil
+	}
+
+	delivery := &models.WebhookDelivery{
+		WebhookID:     webhook.ID,
+		ReservationID: args.ReservationID,
+		Event:         args.Event,
+		Attempt:       attempt,
+	}
+	deliveryErr := postWebhook(ctx, webhook, args, deliveryId, delivery)
+	if deliveryErr != nil {
+		delivery.Error = deliveryErr.Error()
+	} else {
+		delivery.Success = true
+	}
+
+	err = whDao.UnscopedCreateDelivery(ctx, delivery)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Unable to log webhook delivery")
+	}
+
+	return deliveryErr
+}
+
+// postWebhook sends the request and sets the response status code of the delivery.
+func postWebhook(ctx context.Context, webhook *models.Webhook, args *DeliverWebhookTaskArgs, deliveryId string, delivery *models.WebhookDelivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(args.Body))
+	if err != nil {
+		return fmt.Errorf("cannot create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(webhook.Secret, args.Body))
+	req.Header.Set(WebhookEventHeader, args.Event)
+	req.Header.Set(WebhookDeliveryHeader, deliveryId)
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot send webhook request: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	delivery.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%w: %d", WebhookResponseErr, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package jobs_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RHEnVision/provisioning-backend/internal/config"
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
+	daoStubs "github.com/RHEnVision/provisioning-backend/internal/dao/stubs"
+	"github.com/RHEnVision/provisioning-backend/internal/jobs"
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/RHEnVision/provisioning-backend/internal/publicnet"
+	"github.com/RHEnVision/provisioning-backend/internal/testing/identity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoDeliverWebhook(t *testing.T) {
+	ctx := daoStubs.WithAccountDaoOne(context.Background())
+	ctx = identity.WithTenant(t, ctx)
+	ctx = daoStubs.WithWebhookDao(ctx)
+	// test server listens on loopback
+	config.Webhook.AllowPrivate = true
+	defer func() { config.Webhook.AllowPrivate = false }()
+
+	status := http.StatusOK
+	var signature, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf, _ := io.ReadAll(r.Body)
+		body = string(buf)
+		signature = r.Header.Get(jobs.WebhookSignatureHeader)
+		if status == http.StatusFound {
+			w.Header().Set("Location", "http://169.254.169.254/")
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	webhook := &models.Webhook{URL: server.URL, Secret: "0123456789abcdef", Enabled: true}
+	require.NoError(t, dao.GetWebhookDao(ctx).Create(ctx, webhook))
+	args := &jobs.DeliverWebhookTaskArgs{
+		WebhookID:     webhook.ID,
+		ReservationID: 1,
+		Event:         "finished",
+		Body:          []byte(`{"reservation_id":1}`),
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		err := jobs.DoDeliverWebhook(ctx, args, "delivery", 1)
+		require.NoError(t, err)
+
+		assert.Equal(t, `{"reservation_id":1}`, body)
+		assert.Equal(t, jobs.SignWebhook("0123456789abcdef", args.Body), signature)
+		deliveries := daoStubs.WebhookDeliveries(ctx)
+		require.Len(t, deliveries, 1)
+		assert.True(t, deliveries[0].Success)
+		assert.Equal(t, http.StatusOK, deliveries[0].StatusCode)
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		status = http.StatusServiceUnavailable
+		err := jobs.DoDeliverWebhook(ctx, args, "delivery", 2)
+		require.ErrorIs(t, err, jobs.WebhookResponseErr)
+
+		deliveries := daoStubs.WebhookDeliveries(ctx)
+		require.Len(t, deliveries, 2)
+		assert.False(t, deliveries[1].Success)
+		assert.Equal(t, 2, deliveries[1].Attempt)
+		assert.Equal(t, http.StatusServiceUnavailable, deliveries[1].StatusCode)
+	})
+
+	t.Run("Redirect is not followed", func(t *testing.T) {
+		status = http.StatusFound
+		err := jobs.DoDeliverWebhook(ctx, args, "delivery", 3)
+		require.ErrorIs(t, err, jobs.WebhookResponseErr)
+
+		deliveries := daoStubs.WebhookDeliveries(ctx)
+		require.Len(t, deliveries, 3)
+		assert.Equal(t, http.StatusFound, deliveries[2].StatusCode)
+	})
+
+	t.Run("Disabled webhook", func(t *testing.T) {
+		webhook.Enabled = false
+		defer func() { webhook.Enabled = true }()
+
+		err := jobs.DoDeliverWebhook(ctx, args, "delivery", 1)
+		require.NoError(t, err)
+		assert.Len(t, daoStubs.WebhookDeliveries(ctx), 3)
+	})
+}
+
+func TestDoDeliverWebhookPrivateAddress(t *testing.T) {
+	ctx := daoStubs.WithAccountDaoOne(context.Background())
+	ctx = identity.WithTenant(t, ctx)
+	ctx = daoStubs.WithWebhookDao(ctx)
+
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	webhook := &models.Webhook{URL: server.URL, Secret: "0123456789abcdef", Enabled: true}
+	require.NoError(t, dao.GetWebhookDao(ctx).Create(ctx, webhook))
+	args := &jobs.DeliverWebhookTaskArgs{
+		WebhookID:     webhook.ID,
+		ReservationID: 1,
+		Event:         "finished",
+		Body:          []byte(`{"reservation_id":1}`),
+	}
+
+	err := jobs.DoDeliverWebhook(ctx, args, "delivery", 1)
+	require.ErrorIs(t, err, publicnet.ErrNonPublicAddress)
+	assert.False(t, called)
+
+	deliveries := daoStubs.WebhookDeliveries(ctx)
+	require.Len(t, deliveries, 1)
+	assert.False(t, deliveries[0].Success)
+	assert.Zero(t, deliveries[0].StatusCode)
+}
+
+func TestSignWebhook(t *testing.T) {
+	// echo -n 'body' | openssl dgst -sha256 -hmac 'secret'
+	assert.Equal(t, "sha256=dc46983557fea127b43af721467eb9b3fde2338fe3e14f51952aa8478c13d355", jobs.SignWebhook("secret", []byte("body")))
+}
@@ -1,10 +1,27 @@
 package jobs
 
-import "github.com/RHEnVision/provisioning-backend/pkg/worker"
+import (
+	"time"
+
+	"github.com/RHEnVision/provisioning-backend/pkg/worker"
+)
 
 const (
 	TypeNoop                worker.JobType = "no_operation"
 	TypeLaunchInstanceAws   worker.JobType = "launch_instances_aws"
 	TypeLaunchInstanceAzure worker.JobType = "launch_instances_azure"
 	TypeLaunchInstanceGcp   worker.JobType = "launch_instances_gcp"
+	TypeTerminateInstances  worker.JobType = "terminate_instances"
+	TypeCleanupReservations worker.JobType = "cleanup_reservations"
+	TypeDeliverWebhook      worker.JobType = "deliver_webhook"
 )
+
+// RetryPolicies configures retries of failed jobs per job type, job types which are not listed
+// are not retried. Launch jobs are only retried when they fail before any instances are launched,
+// for example because of throttling of the cloud provider. GCP launch jobs have no such steps.
+var RetryPolicies = map[worker.JobType]worker.RetryPolicy{
+	TypeLaunchInstanceAws:   {MaxAttempts: 4, Backoff: 15 * time.Second},
+	TypeLaunchInstanceAzure: {MaxAttempts: 4, Backoff: 15 * time.Second},
+	TypeTerminateInstances:  {MaxAttempts: 3, Backoff: 30 * time.Second},
+	TypeDeliverWebhook:      {MaxAttempts: 6, Backoff: time.Minute},
+}
@@ -10,7 +10,6 @@ import (
 	"github.com/RHEnVision/provisioning-backend/internal/clients/http"
 	"github.com/RHEnVision/provisioning-backend/internal/dao"
 	"github.com/RHEnVision/provisioning-backend/internal/models"
-	"github.com/RHEnVision/provisioning-backend/internal/userdata"
 	"github.com/RHEnVision/provisioning-backend/pkg/worker"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/rs/zerolog"
@@ -43,12 +42,12 @@ type LaunchInstanceAWSTaskArgs struct {
 }
 
 // Unmarshall arguments and handle error
-func HandleLaunchInstanceAWS(ctx context.Context, job *worker.Job) {
+func HandleLaunchInstanceAWS(ctx context.Context, job *worker.Job) error {
 	args, ok := job.Args.(LaunchInstanceAWSTaskArgs)
 	if !ok {
 		err := fmt.Errorf("%w: job %s, reservation: %#v", ErrTypeAssertion, job.ID, job.Args)
 		zerolog.Ctx(ctx).Error().Err(err).Msg("Type assertion error for job")
-		return
+		return err
 	}
 
 	logger := zerolog.Ctx(ctx).With().Int64("reservation_id", args.ReservationID).Logger()
@@ -56,18 +55,19 @@ func HandleLaunchInstanceAWS(ctx context.Context, job *worker.Job) {
 
 	jobErr := DoEnsurePubkeyOnAWS(ctx, &args)
 	if jobErr != nil {
-		finishWithError(ctx, args.ReservationID, jobErr)
-		return
+		return retryLaunchOrFinishWithError(ctx, job, args.ReservationID, jobErr)
 	}
 
+	// instances may have been launched from now on, the job must not be retried
 	jobErr = DoLaunchInstanceAWS(ctx, &args)
 	if jobErr != nil {
 		finishWithError(ctx, args.ReservationID, jobErr)
-		return
+		return worker.Permanent(jobErr)
 	}
 	jobErr = FetchInstancesDescriptionAWS(ctx, &args)
 
 	finishJob(ctx, args.ReservationID, jobErr)
+	return worker.Permanent(jobErr)
 }
 
 // Job logic, when error is returned the job status is updated accordingly
@@ -171,17 +171,10 @@ func DoLaunchInstanceAWS(ctx context.Context, args *LaunchInstanceAWSTaskArgs) e
 		return fmt.Errorf("cannot get aws reservation by id: %w", err)
 	}
 
-	// Generate user data
-	userDataInput := userdata.UserData{
-		Type:         models.ProviderTypeAWS,
-		PowerOff:     args.Detail.PowerOff,
-		InsightsTags: true,
-	}
-	userData, err := userdata.GenerateUserData(&userDataInput)
+	userData, err := renderUserData(models.ProviderTypeAWS, reservation.Detail.PowerOff, reservation.Detail.SSHKeys, reservation.Detail.LaunchInput)
 	if err != nil {
-		return fmt.Errorf("cannot generate user data: %w", err)
+		return err
 	}
-	logger.Trace().Bool("userdata", true).Msg(string(userData))
 
 	ec2Client, err := clients.GetEC2Client(ctx, args.ARN, args.Region)
 	if err != nil {
@@ -89,7 +89,7 @@ func TestHandleEnsurePubkeyOnAWS(t *testing.T) {
 
 			reservation := prepareAWSReservation(t, ctx, pk)
 			rDao := dao.GetReservationDao(ctx)
-			err = rDao.CreateAWS(ctx, reservation)
+			err = rDao.CreateAWS(ctx, reservation, nil)
 			require.NoError(t, err, "failed to add stubbed reservation")
 
 			args := &jobs.LaunchInstanceAWSTaskArgs{
@@ -127,7 +127,7 @@ func TestHandleEnsurePubkeyOnAWS(t *testing.T) {
 
 		reservation := prepareAWSReservation(t, ctx, pk)
 		rDao := dao.GetReservationDao(ctx)
-		err = rDao.CreateAWS(ctx, reservation)
+		err = rDao.CreateAWS(ctx, reservation, nil)
 		require.NoError(t, err, "failed to add stubbed reservation")
 
 		args := &jobs.LaunchInstanceAWSTaskArgs{
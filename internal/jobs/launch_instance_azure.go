@@ -7,7 +7,6 @@ import (
 	"github.com/RHEnVision/provisioning-backend/internal/clients"
 	"github.com/RHEnVision/provisioning-backend/internal/dao"
 	"github.com/RHEnVision/provisioning-backend/internal/models"
-	"github.com/RHEnVision/provisioning-backend/internal/userdata"
 	"github.com/RHEnVision/provisioning-backend/pkg/worker"
 	"github.com/rs/zerolog"
 	"go.opentelemetry.io/otel"
@@ -42,12 +41,12 @@ type LaunchInstanceAzureTaskArgs struct {
 	Subscription *clients.Authentication
 }
 
-func HandleLaunchInstanceAzure(ctx context.Context, job *worker.Job) {
+func HandleLaunchInstanceAzure(ctx context.Context, job *worker.Job) error {
 	args, ok := job.Args.(LaunchInstanceAzureTaskArgs)
 	if !ok {
 		err := fmt.Errorf("%w: job %s, reservation: %#v", ErrTypeAssertion, job.ID, job.Args)
 		zerolog.Ctx(ctx).Error().Err(err).Msg("Type assertion error for job")
-		return
+		return err
 	}
 
 	logger := zerolog.Ctx(ctx).With().Int64("reservation_id", args.ReservationID).Logger()
@@ -59,15 +58,16 @@ func HandleLaunchInstanceAzure(ctx context.Context, job *worker.Job) {
 
 	jobErr := DoEnsureAzureResourceGroup(ctx, &args)
 	if jobErr != nil {
-		finishWithError(ctx, args.ReservationID, jobErr)
-		return
+		return retryLaunchOrFinishWithError(ctx, job, args.ReservationID, jobErr)
 	}
 
+	// instances may have been launched from now on, the job must not be retried
 	jobErr = DoLaunchInstanceAzure(ctx, &args)
 
 	finishJob(ctx, args.ReservationID, jobErr)
 
 	logger.Info().Msg("Finished launch instance Azure job")
+	return worker.Permanent(jobErr)
 }
 
 func DoEnsureAzureResourceGroup(ctx context.Context, args *LaunchInstanceAzureTaskArgs) error {
@@ -99,8 +99,6 @@ func DoLaunchInstanceAzure(ctx context.Context, args *LaunchInstanceAzureTaskArg
 	ctx, span := otel.Tracer(TraceName).Start(ctx, "LaunchInstanceAzureStep")
 	defer span.End()
 
-	logger := zerolog.Ctx(ctx)
-
 	// status updates before and after the code logic
 	updateStatusBefore(ctx, args.ReservationID, "Launching instance(s)")
 	defer updateStatusAfter(ctx, args.ReservationID, "Launched instance(s)", 1)
@@ -125,17 +123,11 @@ func DoLaunchInstanceAzure(ctx context.Context, args *LaunchInstanceAzureTaskArg
 		span.SetStatus(codes.Error, "cannot instantiate Azure client")
 		return fmt.Errorf("failed to instantiate Azure client: %w", err)
 	}
-	// Generate user data
-	userDataInput := userdata.UserData{
-		Type:         models.ProviderTypeAzure,
-		PowerOff:     reservation.Detail.PowerOff,
-		InsightsTags: true,
-	}
-	userData, err := userdata.GenerateUserData(&userDataInput)
+	userData, err := renderUserData(models.ProviderTypeAzure, reservation.Detail.PowerOff, reservation.Detail.SSHKeys, reservation.Detail.LaunchInput)
 	if err != nil {
-		return fmt.Errorf("cannot generate user data: %w", err)
+		span.SetStatus(codes.Error, "cannot generate user data")
+		return err
 	}
-	logger.Trace().Bool("userdata", true).Msg(string(userData))
 
 	vmParams := clients.AzureInstanceParams{
 		Location:          location,
@@ -60,7 +60,7 @@ func TestDoEnsureAzureResourceGroup(t *testing.T) {
 	res := prepareAzureReservation(t, ctx, pk)
 
 	rDao := dao.GetReservationDao(ctx)
-	err = rDao.CreateAzure(ctx, res)
+	err = rDao.CreateAzure(ctx, res, nil)
 	require.NoError(t, err, "failed to add stubbed reservation")
 
 	args := &jobs.LaunchInstanceAzureTaskArgs{
@@ -89,7 +89,7 @@ func TestDoLaunchInstanceAzure(t *testing.T) {
 	res.Detail.Amount = 2
 
 	rDao := dao.GetReservationDao(ctx)
-	err = rDao.CreateAzure(ctx, res)
+	err = rDao.CreateAzure(ctx, res, nil)
 	require.NoError(t, err, "failed to add stubbed reservation")
 
 	args := &jobs.LaunchInstanceAzureTaskArgs{
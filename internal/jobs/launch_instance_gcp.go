@@ -9,7 +9,6 @@ import (
 	"github.com/RHEnVision/provisioning-backend/internal/dao"
 	"github.com/RHEnVision/provisioning-backend/internal/models"
 	"github.com/RHEnVision/provisioning-backend/internal/ptr"
-	"github.com/RHEnVision/provisioning-backend/internal/userdata"
 	"github.com/RHEnVision/provisioning-backend/pkg/worker"
 	"github.com/rs/zerolog"
 )
@@ -35,12 +34,12 @@ type LaunchInstanceGCPTaskArgs struct {
 }
 
 // Unmarshall arguments and handle error
-func HandleLaunchInstanceGCP(ctx context.Context, job *worker.Job) {
+func HandleLaunchInstanceGCP(ctx context.Context, job *worker.Job) error {
 	args, ok := job.Args.(LaunchInstanceGCPTaskArgs)
 	if !ok {
 		err := fmt.Errorf("%w: job %s, reservation: %#v", ErrTypeAssertion, job.ID, job.Args)
 		zerolog.Ctx(ctx).Error().Err(err).Msg("Type assertion error for job")
-		return
+		return err
 	}
 
 	logger := zerolog.Ctx(ctx).With().Int64("reservation_id", args.ReservationID).Logger()
@@ -49,12 +48,13 @@ func HandleLaunchInstanceGCP(ctx context.Context, job *worker.Job) {
 	jobErr := DoLaunchInstanceGCP(ctx, &args)
 	if jobErr != nil {
 		finishWithError(ctx, args.ReservationID, jobErr)
-		return
+		return jobErr
 	}
 
 	jobErr = FetchInstancesDescriptionGCP(ctx, &args)
 
 	finishJob(ctx, args.ReservationID, jobErr)
+	return jobErr
 }
 
 // Job logic, when error is returned the job status is updated accordingly
@@ -79,26 +79,27 @@ func DoLaunchInstanceGCP(ctx context.Context, args *LaunchInstanceGCPTaskArgs) e
 		return fmt.Errorf("cannot get gcp client: %w", err)
 	}
 
-	// Generate user data
-	userDataInput := userdata.UserData{
-		Type:         models.ProviderTypeGCP,
-		PowerOff:     args.Detail.PowerOff,
-		InsightsTags: true,
+	reservation, err := dao.GetReservationDao(ctx).GetGCPById(ctx, args.ReservationID)
+	if err != nil {
+		return fmt.Errorf("cannot get gcp reservation by id: %w", err)
 	}
-	userData, err := userdata.GenerateUserData(&userDataInput)
+
+	// Custom user data is processed by cloud-init, the startup script does not need it
+	startupScript, err := renderUserData(models.ProviderTypeGCP, args.Detail.PowerOff, args.Detail.SSHKeys, reservation.Detail.LaunchInput)
 	if err != nil {
-		return fmt.Errorf("cannot generate user data: %w", err)
+		return err
 	}
-	logger.Trace().Bool("userdata", true).Msg(string(userData))
 
 	params := &clients.GCPInstanceParams{
-		NamePattern:   ptr.To("inst-####"),
-		ImageName:     args.ImageName,
-		MachineType:   args.Detail.MachineType,
-		Zone:          args.Zone,
-		KeyBody:       pk.Body,
-		StartupScript: string(userData),
-		UUID:          args.Detail.UUID,
+		NamePattern:         ptr.To("inst-####"),
+		ImageName:           args.ImageName,
+		MachineType:         args.Detail.MachineType,
+		Zone:                args.Zone,
+		KeyBody:             pk.Body,
+		UUID:                args.Detail.UUID,
+		AdditionalKeyBodies: args.Detail.SSHKeys,
+		StartupScript:       string(startupScript),
+		UserData:            string(reservation.Detail.UserData),
 	}
 
 	instances, opName, err := gcpClient.InsertInstances(ctx, params, args.Detail.Amount)
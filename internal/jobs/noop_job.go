@@ -19,12 +19,12 @@ type NoopJobArgs struct {
 var NoOperationFailure = errors.New("job failed on request")
 
 // Unmarshall arguments and handle error
-func HandleNoop(ctx context.Context, job *worker.Job) {
+func HandleNoop(ctx context.Context, job *worker.Job) error {
 	args, ok := job.Args.(NoopJobArgs)
 	if !ok {
 		err := fmt.Errorf("%w: job %s, reservation: %#v", ErrTypeAssertion, job.ID, job.Args)
 		zerolog.Ctx(ctx).Error().Err(err).Msg("Type assertion error for job")
-		return
+		return err
 	}
 
 	logger := zerolog.Ctx(ctx).With().Int64("reservation_id", args.ReservationID).Logger()
@@ -33,6 +33,7 @@ func HandleNoop(ctx context.Context, job *worker.Job) {
 	jobErr := DoNoop(ctx, &args)
 
 	finishJob(ctx, args.ReservationID, jobErr)
+	return jobErr
 }
 
 // Job logic, when error is returned the job status is updated accordingly
@@ -0,0 +1,150 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/RHEnVision/provisioning-backend/internal/clients"
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/RHEnVision/provisioning-backend/pkg/worker"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+)
+
+var UnknownProviderErr = errors.New("unsupported provider for instance termination")
+
+// TeardownFailedStatus is the reservation status after instances termination failed.
+const TeardownFailedStatus = "Instance termination failed"
+
+type TerminateInstancesTaskArgs struct {
+	// Associated reservation
+	ReservationID int64
+
+	// Provider of the associated reservation
+	Provider models.ProviderType
+
+	// Region (AWS) or zone (GCP) the instances were launched into, not used for Azure
+	Region string
+
+	// The authentication fetched from Sources which is linked to a specific source
+	Authentication *clients.Authentication
+}
+
+// Unmarshall arguments and handle error
+func HandleTerminateInstances(ctx context.Context, job *worker.Job) error {
+	args, ok := job.Args.(TerminateInstancesTaskArgs)
+	if !ok {
+		err := fmt.Errorf("%w: job %s, reservation: %#v", ErrTypeAssertion, job.ID, job.Args)
+		zerolog.Ctx(ctx).Error().Err(err).Msg("Type assertion error for job")
+		return err
+	}
+
+	logger := zerolog.Ctx(ctx).With().Int64("reservation_id", args.ReservationID).Logger()
+	ctx = logger.WithContext(ctx)
+
+	logger.Info().Msg("Started terminate instances job")
+	ctx, span := otel.Tracer(TraceName).Start(ctx, "TerminateInstancesJob")
+	defer span.End()
+
+	jobErr := DoTerminateInstances(ctx, &args)
+	if jobErr != nil && ctx.Err() == nil && RetryPolicies[job.Type].Retry(job.Attempt) {
+		logger.Warn().Err(jobErr).Msgf("Job attempt %d failed, it will be retried", job.Attempt)
+		return jobErr
+	}
+
+	finishTeardown(ctx, args.ReservationID, jobErr)
+	logger.Info().Msg("Finished terminate instances job")
+	return jobErr
+}
+
+// finishTeardown records the outcome of instances termination. Teardown runs for finished
+// reservations, so the launch outcome of the reservation is kept untouched.
+func finishTeardown(ctx context.Context, reservationId int64, jobErr error) {
+	logger := zerolog.Ctx(ctx)
+	if ctx.Err() != nil {
+		// the original context is expired or cancelled and unusable at this point
+		ctx = copyContext(ctx)
+	}
+
+	status, errorString := "Terminated instance(s)", ""
+	if jobErr != nil {
+		logger.Error().Err(jobErr).Msg("Instances termination failed")
+		status, errorString = TeardownFailedStatus, jobErr.Error()
+	}
+
+	err := dao.GetReservationDao(ctx).FinishTeardown(ctx, reservationId, status, errorString)
+	if err != nil {
+		logger.Warn().Err(err).Msg("unable to update teardown status")
+	}
+}
+
+// DoTerminateInstances terminates all instances of a reservation and deletes them from the
+// database. The reservation step counter is not increased, the final status is set by the
+// job handler according to the outcome.
+func DoTerminateInstances(ctx context.Context, args *TerminateInstancesTaskArgs) error {
+	ctx, span := otel.Tracer(TraceName).Start(ctx, "TerminateInstancesStep")
+	defer span.End()
+
+	logger := zerolog.Ctx(ctx)
+
+	updateStatusBefore(ctx, args.ReservationID, "Terminating instance(s)")
+
+	rDao := dao.GetReservationDao(ctx)
+	instances, err := rDao.ListInstances(ctx, args.ReservationID)
+	if err != nil {
+		return fmt.Errorf("cannot get instances list: %w", err)
+	}
+	if len(instances) == 0 {
+		logger.Debug().Msg("No instances to terminate")
+		return nilUnlessTimeout(ctx)
+	}
+
+	ids := make([]string, len(instances))
+	for i, instance := range instances {
+		ids[i] = instance.InstanceID
+	}
+
+	switch args.Provider {
+	case models.ProviderTypeAWS:
+		ec2Client, clientErr := clients.GetEC2Client(ctx, args.Authentication, args.Region)
+		if clientErr != nil {
+			return fmt.Errorf("cannot create new ec2 client from config: %w", clientErr)
+		}
+		err = ec2Client.TerminateInstances(ctx, ids)
+		if err != nil {
+			return fmt.Errorf("cannot terminate instances: %w", err)
+		}
+	case models.ProviderTypeAzure:
+		azureClient, clientErr := clients.GetAzureClient(ctx, args.Authentication)
+		if clientErr != nil {
+			return fmt.Errorf("cannot obtain a azure client: %w", clientErr)
+		}
+		err = azureClient.DeleteVMs(ctx, ids)
+		if err != nil {
+			return fmt.Errorf("cannot delete Azure instances: %w", err)
+		}
+	case models.ProviderTypeGCP:
+		gcpClient, clientErr := clients.GetGCPClient(ctx, args.Authentication)
+		if clientErr != nil {
+			return fmt.Errorf("cannot get gcp client: %w", clientErr)
+		}
+		err = gcpClient.DeleteInstances(ctx, args.Region, ids)
+		if err != nil {
+			return fmt.Errorf("cannot delete GCP instances: %w", err)
+		}
+	case models.ProviderTypeNoop, models.ProviderTypeComposite, models.ProviderTypeUnknown:
+		return fmt.Errorf("%w: %s", UnknownProviderErr, args.Provider.String())
+	default:
+		return fmt.Errorf("%w: %s", UnknownProviderErr, args.Provider.String())
+	}
+	logger.Info().Msgf("Terminated %d instance(s)", len(ids))
+
+	err = rDao.DeleteInstances(ctx, args.ReservationID)
+	if err != nil {
+		return fmt.Errorf("cannot delete instances: %w", err)
+	}
+
+	return nilUnlessTimeout(ctx)
+}
@@ -0,0 +1,125 @@
+package jobs_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/RHEnVision/provisioning-backend/internal/clients"
+	clientStubs "github.com/RHEnVision/provisioning-backend/internal/clients/stubs"
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
+	daoStubs "github.com/RHEnVision/provisioning-backend/internal/dao/stubs"
+	"github.com/RHEnVision/provisioning-backend/internal/jobs"
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/RHEnVision/provisioning-backend/internal/testing/factories"
+	"github.com/RHEnVision/provisioning-backend/pkg/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoTerminateInstancesAzure(t *testing.T) {
+	ctx := prepareAzureContext(t)
+
+	pk := factories.NewPubkeyRSA()
+	err := daoStubs.AddPubkey(ctx, pk)
+	require.NoError(t, err, "failed to add stubbed key")
+
+	res := prepareAzureReservation(t, ctx, pk)
+	res.Detail.Amount = 2
+
+	rDao := dao.GetReservationDao(ctx)
+	err = rDao.CreateAzure(ctx, res, nil)
+	require.NoError(t, err, "failed to add stubbed reservation")
+
+	subscription := clients.NewAuthentication("subUUID", models.ProviderTypeAzure)
+	launchArgs := &jobs.LaunchInstanceAzureTaskArgs{
+		AzureImageID:  "/subscriptions/subUUID/rgName/images/uuid2",
+		Location:      "useast",
+		PubkeyID:      pk.ID,
+		ReservationID: res.ID,
+		SourceID:      "2",
+		Subscription:  subscription,
+	}
+	err = jobs.DoLaunchInstanceAzure(ctx, launchArgs)
+	require.NoError(t, err, "launch instances failed to run")
+	require.Equal(t, 2, clientStubs.CountStubAzureVMs(ctx))
+
+	args := &jobs.TerminateInstancesTaskArgs{
+		ReservationID:  res.ID,
+		Provider:       models.ProviderTypeAzure,
+		Authentication: subscription,
+	}
+	err = jobs.DoTerminateInstances(ctx, args)
+	require.NoError(t, err, "terminate instances failed to run")
+
+	assert.Equal(t, 0, clientStubs.CountStubAzureVMs(ctx))
+	resultInstances, err := rDao.ListInstances(ctx, res.ID)
+	require.NoError(t, err, "failed to fetch instances")
+	assert.Empty(t, resultInstances)
+}
+
+func TestDoTerminateInstancesUnknownProvider(t *testing.T) {
+	ctx := prepareEC2Context(t)
+
+	rDao := dao.GetReservationDao(ctx)
+	err := rDao.CreateInstance(ctx, &models.ReservationInstance{ReservationID: 1, InstanceID: "i-1"})
+	require.NoError(t, err, "failed to add stubbed instance")
+
+	args := &jobs.TerminateInstancesTaskArgs{
+		ReservationID: 1,
+		Provider:      models.ProviderTypeNoop,
+	}
+	err = jobs.DoTerminateInstances(ctx, args)
+	require.ErrorIs(t, err, jobs.UnknownProviderErr)
+}
+
+func TestHandleTerminateInstancesOutcome(t *testing.T) {
+	ctx := prepareEC2Context(t)
+	rDao := dao.GetReservationDao(ctx)
+
+	newTeardown := func(t *testing.T) *models.AWSReservation {
+		t.Helper()
+		res := &models.AWSReservation{Detail: &models.AWSDetail{Region: "us-east-1", Amount: 1}}
+		res.AccountID = 1
+		res.Provider = models.ProviderTypeAWS
+		res.Status = "Launched instance(s)"
+		res.Success = sql.NullBool{Bool: true, Valid: true}
+		res.FinishedAt = sql.NullTime{Time: time.Now(), Valid: true}
+		require.NoError(t, rDao.CreateAWS(ctx, res, nil))
+		require.NoError(t, rDao.QueueTeardown(ctx, res.ID))
+		require.ErrorIs(t, rDao.QueueTeardown(ctx, res.ID), dao.ErrAffectedMismatch, "second teardown must be refused")
+		return res
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		res := newTeardown(t)
+		job := &worker.Job{
+			Type:    jobs.TypeTerminateInstances,
+			Attempt: 1,
+			Args:    jobs.TerminateInstancesTaskArgs{ReservationID: res.ID, Provider: models.ProviderTypeAWS, Region: "us-east-1"},
+		}
+		require.NoError(t, jobs.HandleTerminateInstances(ctx, job))
+
+		assert.Equal(t, "Terminated instance(s)", res.Status)
+		assert.False(t, res.TeardownQueuedAt.Valid)
+		assert.Empty(t, res.TeardownError)
+		assert.True(t, res.Success.Bool)
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		res := newTeardown(t)
+		require.NoError(t, rDao.CreateInstance(ctx, &models.ReservationInstance{ReservationID: res.ID, InstanceID: "i-1"}))
+		job := &worker.Job{
+			Type:    jobs.TypeTerminateInstances,
+			Attempt: jobs.RetryPolicies[jobs.TypeTerminateInstances].MaxAttempts,
+			Args:    jobs.TerminateInstancesTaskArgs{ReservationID: res.ID, Provider: models.ProviderTypeNoop},
+		}
+		require.ErrorIs(t, jobs.HandleTerminateInstances(ctx, job), jobs.UnknownProviderErr)
+
+		assert.Equal(t, "Instance termination failed", res.Status)
+		assert.False(t, res.TeardownQueuedAt.Valid)
+		assert.Contains(t, res.TeardownError, jobs.UnknownProviderErr.Error())
+		assert.True(t, res.Success.Bool, "launch outcome must not change")
+		assert.Empty(t, res.Error)
+	})
+}
@@ -23,10 +23,10 @@ func NewAvailabilityStatusMessage(msg *GenericMessage) (*AvailabilityStatusMessa
 }
 
 func (m AvailabilityStatusMessage) GenericMessage(ctx context.Context) (GenericMessage, error) {
-	return genericMessage(ctx, m, m.SourceID, AvailabilityStatusRequestTopic)
+	return genericMessage(ctx, m, m.SourceID, AvailabilityStatusRequestTopic, "availability_status")
 }
 
-func genericMessage(ctx context.Context, m any, key string, topic string) (GenericMessage, error) {
+func genericMessage(ctx context.Context, m any, key string, topic string, eventType string) (GenericMessage, error) {
 	payload, err := json.Marshal(m)
 	if err != nil {
 		return GenericMessage{}, fmt.Errorf("unable to marshal message: %w", err)
@@ -44,7 +44,7 @@ func genericMessage(ctx context.Context, m any, key string, topic string) (Gener
 			"x-rh-identity", identity.IdentityHeader(ctx),
 			"x-rh-sources-org-id", id.Identity.OrgID,
 			"x-rh-sources-account-number", id.Identity.AccountNumber,
-			"event_type", "availability_status",
+			"event_type", eventType,
 		),
 	}, nil
 }
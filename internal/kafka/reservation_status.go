@@ -0,0 +1,92 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type ReservationEventType string
+
+const (
+	ReservationStarted  ReservationEventType = "started"
+	ReservationStep     ReservationEventType = "step"
+	ReservationFinished ReservationEventType = "finished"
+	ReservationFailed   ReservationEventType = "failed"
+)
+
+// Maximum amount of reservation status messages waiting to be sent, messages are dropped
+// when the queue is full.
+const ReservationStatusQueueSize = 1024
+
+// buffered channel for reservation status messages, sent in batches by a background goroutine
+var reservationStatusQueue = make(chan *GenericMessage, ReservationStatusQueueSize)
+
+// ReservationStatusMessage is a reservation lifecycle event published for other platform services.
+type ReservationStatusMessage struct {
+	ReservationID int64 `json:"reservation_id"`
+
+	Event ReservationEventType `json:"event"`
+
+	Provider string `json:"provider"`
+
+	AccountID int64 `json:"account_id"`
+
+	OrgID string `json:"org_id"`
+
+	AccountNumber string `json:"account_number,omitempty"`
+
+	Status string `json:"status"`
+
+	Step int32 `json:"step"`
+
+	Steps int32 `json:"steps"`
+
+	// Instance IDs of the finished reservation, empty for other events.
+	InstanceIDs []string `json:"instance_ids,omitempty"`
+
+	Error string `json:"error,omitempty"`
+
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func NewReservationStatusMessage(msg *GenericMessage) (*ReservationStatusMessage, error) {
+	rsm := ReservationStatusMessage{}
+	err := json.Unmarshal(msg.Value, &rsm)
+	if err != nil {
+		return nil, fmt.Errorf("unable to unmarshal message: %w", err)
+	}
+
+	return &rsm, nil
+}
+
+func (m ReservationStatusMessage) GenericMessage(ctx context.Context) (GenericMessage, error) {
+	return genericMessage(ctx, m, strconv.FormatInt(m.ReservationID, 10), ReservationStatusTopic, "reservation_status")
+}
+
+// EnqueueReservationStatus prepares a reservation status message to be sent in the next batch
+// to the platform kafka. The function never blocks, the message is dropped when the queue is full.
+func EnqueueReservationStatus(ctx context.Context, m NativeMessage) {
+	logger := zerolog.Ctx(ctx)
+
+	msg, err := m.GenericMessage(ctx)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Unable to create reservation status message")
+		return
+	}
+
+	select {
+	case reservationStatusQueue <- &msg:
+	default:
+		logger.Warn().Msg("Reservation status queue is full, dropping message")
+	}
+}
+
+// ReservationStatusQueue returns the channel of enqueued reservation status messages.
+func ReservationStatusQueue() <-chan *GenericMessage {
+	return reservationStatusQueue
+}
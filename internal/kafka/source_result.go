@@ -27,7 +27,7 @@ type SourceResult struct {
 }
 
 func (sr SourceResult) GenericMessage(ctx context.Context) (GenericMessage, error) {
-	return genericMessage(ctx, sr, sr.ResourceID, SourcesStatusTopic)
+	return genericMessage(ctx, sr, sr.ResourceID, SourcesStatusTopic, "availability_status")
 }
 
 func (st StatusType) String() string {
@@ -10,16 +10,19 @@ import (
 var (
 	availabilityStatusRequestTopicReq = "platform.provisioning.internal.availability-check"
 	sendStatusToSourcesTopicReq       = "platform.sources.status"
+	reservationStatusTopicReq         = "platform.provisioning.reservation-status"
 )
 
 // topics after clowder mapping
 var (
 	AvailabilityStatusRequestTopic string
 	SourcesStatusTopic             string
+	ReservationStatusTopic         string
 )
 
 // InitializeTopicRequests performs clowder mapping of topics.
 func InitializeTopicRequests(ctx context.Context) {
 	AvailabilityStatusRequestTopic = config.TopicName(ctx, availabilityStatusRequestTopicReq)
 	SourcesStatusTopic = config.TopicName(ctx, sendStatusToSourcesTopicReq)
+	ReservationStatusTopic = config.TopicName(ctx, reservationStatusTopicReq)
 }
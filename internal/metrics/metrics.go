@@ -30,18 +30,66 @@ var CacheHits = prometheus.NewCounterVec(prometheus.CounterOpts{
 	ConstLabels: prometheus.Labels{"service": version.PrometheusLabelName},
 }, []string{"type", "result"})
 
+var RateLimitedRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name:        "provisioning_rate_limited_requests_total",
+	Help:        "The total number of API requests rejected by the per-account rate limiter by HTTP method",
+	ConstLabels: prometheus.Labels{"service": version.PrometheusLabelName},
+}, []string{"method"})
+
 var JobQueueSize = prometheus.NewGauge(prometheus.GaugeOpts{
 	Name:        "provisioning_job_queue_size",
 	Help:        "background job queue size (total pending jobs)",
 	ConstLabels: prometheus.Labels{"service": "provisioning"},
 })
 
+var JobQueueRetrySize = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name:        "provisioning_job_queue_retry_size",
+	Help:        "background job retry queue size (total failed jobs waiting for a retry)",
+	ConstLabels: prometheus.Labels{"service": "provisioning"},
+})
+
+var JobQueueScheduledSize = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name:        "provisioning_job_queue_scheduled_size",
+	Help:        "background job scheduled queue size (total delayed jobs which are not due yet)",
+	ConstLabels: prometheus.Labels{"service": "provisioning"},
+})
+
+var JobQueueDeadLetterSize = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name:        "provisioning_job_queue_dead_letter_size",
+	Help:        "background job dead-letter queue size (total jobs which failed all attempts)",
+	ConstLabels: prometheus.Labels{"service": "provisioning"},
+})
+
 var JobQueueInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 	Name:        "provisioning_job_queue_inflight",
 	Help:        "number of in-flight jobs (total jobs which are currently processing)",
 	ConstLabels: prometheus.Labels{"service": "provisioning"},
 }, []string{"worker"})
 
+var OutboxSize = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name:        "provisioning_outbox_size",
+	Help:        "job outbox size (total jobs waiting to be published to the job queue)",
+	ConstLabels: prometheus.Labels{"service": "provisioning"},
+})
+
+var OutboxRelayLag = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name:        "provisioning_outbox_relay_lag_seconds",
+	Help:        "age of the oldest job waiting in the job outbox (in seconds)",
+	ConstLabels: prometheus.Labels{"service": "provisioning"},
+})
+
+var OutboxDead = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name:        "provisioning_outbox_dead",
+	Help:        "jobs in the job outbox which failed all publish attempts (not counted in size and lag)",
+	ConstLabels: prometheus.Labels{"service": "provisioning"},
+})
+
+var OutboxPublished = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name:        "provisioning_outbox_published_total",
+	Help:        "jobs published from the job outbox to the job queue by result (success/failure)",
+	ConstLabels: prometheus.Labels{"service": "provisioning"},
+}, []string{"result"})
+
 var AvailabilityCheckReqsDuration = prometheus.NewHistogramVec(
 	prometheus.HistogramOpts{
 		Name:        "provisioning_source_availability_check_request_duration_ms",
@@ -65,12 +113,21 @@ var BackgroundJobDuration = prometheus.NewHistogramVec(
 var ReservationCount = prometheus.NewCounterVec(
 	prometheus.CounterOpts{
 		Name:        "provisioning_reservation_count",
-		Help:        "reservation count by result (success/failure) by type (aws/gcp/azure)",
+		Help:        "reservation count by result (success/failure/cancelled) by type (aws/gcp/azure)",
 		ConstLabels: prometheus.Labels{"service": version.PrometheusLabelName, "component": "worker"},
 	},
 	[]string{"type", "result"},
 )
 
+var StaleReservationCount = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name:        "provisioning_reservation_stale_count",
+		Help:        "stale reservation count (not finished within threshold and marked as failed) by type (aws/gcp/azure)",
+		ConstLabels: prometheus.Labels{"service": version.PrometheusLabelName, "component": "worker"},
+	},
+	[]string{"type"},
+)
+
 func ObserveAvailabilityCheckReqsDuration(provider string, observedFunc func() error) {
 	errString := "false"
 	start := time.Now()
@@ -109,14 +166,44 @@ func IncCacheHit(model, result string) {
 	CacheHits.WithLabelValues(model, result).Inc()
 }
 
+func IncRateLimitedRequests(method string) {
+	RateLimitedRequests.WithLabelValues(method).Inc()
+}
+
 func SetJobQueueSize(size uint64) {
 	JobQueueSize.Set(float64(size))
 }
 
+func SetJobQueueRetrySize(size uint64) {
+	JobQueueRetrySize.Set(float64(size))
+}
+
+func SetJobQueueScheduledSize(size uint64) {
+	JobQueueScheduledSize.Set(float64(size))
+}
+
+func SetJobQueueDeadLetterSize(size uint64) {
+	JobQueueDeadLetterSize.Set(float64(size))
+}
+
 func SetJobQueueInFlight(workerName string, inflight int64) {
 	JobQueueInFlight.WithLabelValues(workerName).Set(float64(inflight))
 }
 
+func SetOutboxStats(size, dead int64, lag time.Duration) {
+	OutboxSize.Set(float64(size))
+	OutboxDead.Set(float64(dead))
+	OutboxRelayLag.Set(lag.Seconds())
+}
+
+func IncOutboxPublished(result string) {
+	OutboxPublished.WithLabelValues(result).Inc()
+}
+
 func IncReservationCount(rtype, result string) {
 	ReservationCount.WithLabelValues(rtype, result).Inc()
 }
+
+func IncStaleReservationCount(rtype string) {
+	StaleReservationCount.WithLabelValues(rtype).Inc()
+}
@@ -7,9 +7,9 @@ func RegisterStatuserMetrics() {
 }
 
 func RegisterApiMetrics() {
-	prometheus.MustRegister(CacheHits)
+	prometheus.MustRegister(CacheHits, RateLimitedRequests, OutboxSize, OutboxRelayLag, OutboxDead, OutboxPublished)
 }
 
 func RegisterWorkerMetrics() {
-	prometheus.MustRegister(JobQueueSize, JobQueueInFlight, BackgroundJobDuration, ReservationCount, CacheHits)
+	prometheus.MustRegister(JobQueueSize, JobQueueInFlight, JobQueueRetrySize, JobQueueScheduledSize, JobQueueDeadLetterSize, BackgroundJobDuration, ReservationCount, StaleReservationCount, CacheHits)
 }
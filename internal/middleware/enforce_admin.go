@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/redhatinsights/platform-go-middlewares/identity"
+)
+
+// AdminIdentityType is the identity type of Red Hat associates.
+const AdminIdentityType = "Associate"
+
+// EnforceAdmin aborts requests which are not made by Red Hat associates. It must be used after
+// EnforceIdentity.
+func EnforceAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := identity.Get(r.Context())
+		if id.Identity.Type != AdminIdentityType {
+			doError(r.Context(), w, http.StatusForbidden, "X-Rh-Identity header is not an associate identity")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/RHEnVision/provisioning-backend/internal/config"
+	"github.com/RHEnVision/provisioning-backend/internal/identity"
+	"github.com/RHEnVision/provisioning-backend/internal/metrics"
+	"github.com/rs/zerolog"
+)
+
+// TakeTokenFunc takes a token from a token bucket with the given key and returns zero, or
+// duration until the next token is available. See cache.TakeToken.
+type TakeTokenFunc func(ctx context.Context, key string, rate float64, burst int) (time.Duration, error)
+
+// RateLimitMiddleware limits amount of requests per organization using token buckets. When
+// the bucket is empty, the request is aborted with 429 and Retry-After header is set. It must
+// be used after EnforceIdentity. Errors of the token bucket storage do not block requests.
+func RateLimitMiddleware(take TakeTokenFunc) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			orgID := identity.Identity(r.Context()).Identity.OrgID
+			if !config.RateLimit.Enabled || orgID == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			logger := zerolog.Ctx(r.Context())
+			wait, err := take(r.Context(), orgID, config.RateLimit.Rate, config.RateLimit.Burst)
+			if err != nil {
+				logger.Warn().Err(err).Msg("Unable to take rate limit token, allowing request")
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if wait > 0 {
+				retryAfter := int64(math.Ceil(wait.Seconds()))
+				logger.Warn().Str("org_id", orgID).Int64("retry_after", retryAfter).Msg("Request rate limit exceeded")
+				metrics.IncRateLimitedRequests(r.Method)
+				w.Header().Set("Retry-After", strconv.FormatInt(retryAfter, 10))
+				http.Error(w, "request rate limit exceeded", http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/RHEnVision/provisioning-backend/internal/config"
+	"github.com/RHEnVision/provisioning-backend/internal/middleware"
+	"github.com/RHEnVision/provisioning-backend/internal/testing/identity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	config.RateLimit.Enabled = true
+	defer func() { config.RateLimit.Enabled = false }()
+
+	okHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	serve := func(t *testing.T, take middleware.TakeTokenFunc) *httptest.ResponseRecorder {
+		t.Helper()
+		ctx := identity.WithIdentity(t, context.Background())
+		req, err := http.NewRequestWithContext(ctx, "GET", "/sources/1/instance_types", nil)
+		require.NoError(t, err, "failed to create request")
+
+		rr := httptest.NewRecorder()
+		middleware.RateLimitMiddleware(take)(okHandler).ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("allowed", func(t *testing.T) {
+		var key string
+		rr := serve(t, func(_ context.Context, k string, _ float64, _ int) (time.Duration, error) {
+			key = k
+			return 0, nil
+		})
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, identity.DefaultOrgId, key)
+		assert.Empty(t, rr.Header().Get("Retry-After"))
+	})
+
+	t.Run("throttled", func(t *testing.T) {
+		rr := serve(t, func(_ context.Context, _ string, _ float64, _ int) (time.Duration, error) {
+			return 1500 * time.Millisecond, nil
+		})
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "2", rr.Header().Get("Retry-After"))
+	})
+
+	t.Run("storage error", func(t *testing.T) {
+		rr := serve(t, func(_ context.Context, _ string, _ float64, _ int) (time.Duration, error) {
+			return 0, errors.New("connection refused")
+		})
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		config.RateLimit.Enabled = false
+		defer func() { config.RateLimit.Enabled = true }()
+
+		rr := serve(t, func(_ context.Context, _ string, _ float64, _ int) (time.Duration, error) {
+			return time.Second, nil
+		})
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+}
@@ -51,7 +51,7 @@ func TestMigrationUpdateFingerprint(t *testing.T) {
 	})
 
 	t.Run("migrate ed key", func(t *testing.T) {
-		pks, err := pkDao.List(ctx, 1, 0) // the key from seed
+		pks, err := pkDao.List(ctx, dao.ListParams{Limit: 1}) // the key from seed
 		require.NoError(t, err)
 		pks[0].Type = "test"
 		err = pkDao.Update(ctx, pks[0])
@@ -68,7 +68,7 @@ func TestMigrationUpdateFingerprint(t *testing.T) {
 	})
 
 	t.Run("migrate both rsa and ed keys", func(t *testing.T) {
-		pks, err := pkDao.List(ctx, 2, 0)
+		pks, err := pkDao.List(ctx, dao.ListParams{Limit: 2})
 		require.NoError(t, err)
 		for _, pk := range pks {
 			pk.Type = "test"
@@ -0,0 +1,5 @@
+ALTER TABLE reservations ADD COLUMN
+  job_id UUID;
+
+ALTER TABLE reservations ADD COLUMN
+  cancelled BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,18 @@
+-- Job queue table for the postgres worker (WORKER_QUEUE=postgres). Jobs are claimed
+-- via SELECT FOR UPDATE SKIP LOCKED and locked for the job timeout, jobs of workers
+-- which died are claimed again once the lock expires.
+CREATE TABLE jobs
+(
+  id UUID PRIMARY KEY,
+  type TEXT NOT NULL CHECK (NOT empty(type)),
+  payload BYTEA NOT NULL,
+  attempt INTEGER NOT NULL DEFAULT 0,
+  run_at TIMESTAMP NOT NULL DEFAULT current_timestamp,
+  locked_until TIMESTAMP,
+  cancelled BOOLEAN NOT NULL DEFAULT FALSE,
+  dead BOOLEAN NOT NULL DEFAULT FALSE,
+  error TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX jobs_run_at ON jobs(run_at) WHERE NOT dead;
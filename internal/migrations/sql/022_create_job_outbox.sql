@@ -0,0 +1,18 @@
+-- Transactional outbox for non-transactional job queues (memory, redis). Jobs are inserted
+-- in the same transaction as reservations and published to the job queue by a relay.
+-- Jobs which failed to publish are retried with exponential backoff and marked dead after
+-- too many attempts, dead jobs are kept for inspection and not counted in the relay lag.
+CREATE TABLE job_outbox
+(
+  id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  job_id UUID NOT NULL,
+  job_type TEXT NOT NULL CHECK (NOT empty(job_type)),
+  payload BYTEA NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT current_timestamp,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  next_attempt_at TIMESTAMP NOT NULL DEFAULT current_timestamp,
+  dead BOOLEAN NOT NULL DEFAULT FALSE,
+  error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX job_outbox_pending ON job_outbox(id) WHERE NOT dead;
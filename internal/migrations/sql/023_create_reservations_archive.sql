@@ -0,0 +1,12 @@
+-- Archive of reservations deleted by the retention cleanup job (RETENTION_ARCHIVE=true). The
+-- reservation is stored together with provider details and instances as a single document.
+CREATE TABLE reservations_archive
+(
+  id BIGINT PRIMARY KEY,
+  account_id BIGINT NOT NULL,
+  provider INTEGER NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  finished_at TIMESTAMP NOT NULL,
+  archived_at TIMESTAMP NOT NULL DEFAULT current_timestamp,
+  data JSONB NOT NULL
+);
@@ -0,0 +1,3 @@
+-- Optional time when the reservation job is due, reservations are "Scheduled" until then.
+ALTER TABLE reservations ADD COLUMN
+  launch_at TIMESTAMP;
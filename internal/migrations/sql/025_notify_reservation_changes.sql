@@ -0,0 +1,29 @@
+-- Notify "reservation_changes" channel listeners with reservation ID when a reservation
+-- (status, step, result) or one of its instances changes. Used for server-sent events.
+CREATE OR REPLACE FUNCTION notify_reservation_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('reservation_changes', NEW.id::text);
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE OR REPLACE FUNCTION notify_reservation_instance_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('reservation_changes', NEW.reservation_id::text);
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER notify_reservation_changes
+  AFTER UPDATE
+  ON reservations
+  FOR EACH ROW
+EXECUTE PROCEDURE notify_reservation_change();
+
+CREATE TRIGGER notify_reservation_instance_changes
+  AFTER INSERT OR UPDATE
+  ON reservation_instances
+  FOR EACH ROW
+EXECUTE PROCEDURE notify_reservation_instance_change();
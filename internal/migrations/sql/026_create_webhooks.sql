@@ -0,0 +1,28 @@
+-- Webhook subscriptions receive a signed POST request when a reservation of the account is
+-- finished. Every delivery attempt is logged.
+CREATE TABLE webhooks
+(
+  id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  account_id BIGINT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+  url TEXT NOT NULL CHECK (NOT empty(url)),
+  secret TEXT NOT NULL CHECK (NOT empty(secret)),
+  enabled BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at TIMESTAMP NOT NULL DEFAULT current_timestamp,
+
+  UNIQUE(url, account_id)
+);
+
+CREATE TABLE webhook_deliveries
+(
+  id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  webhook_id BIGINT NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+  reservation_id BIGINT NOT NULL,
+  event TEXT NOT NULL CHECK (NOT empty(event)),
+  attempt INTEGER NOT NULL,
+  status_code INTEGER NOT NULL DEFAULT 0,
+  success BOOLEAN NOT NULL,
+  error TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX webhook_deliveries_webhook_id ON webhook_deliveries(webhook_id);
@@ -0,0 +1,12 @@
+-- Timeline of reservation status changes, every status update of a reservation job is stored
+-- together with the step number after the update.
+CREATE TABLE reservation_events
+(
+  id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  reservation_id BIGINT NOT NULL REFERENCES reservations(id) ON DELETE CASCADE,
+  status TEXT NOT NULL,
+  step INTEGER NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX reservation_events_reservation_id ON reservation_events(reservation_id);
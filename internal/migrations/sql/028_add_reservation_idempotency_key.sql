@@ -0,0 +1,6 @@
+-- Optional client-provided key of the create request, repeated requests with the same key
+-- return the original reservation instead of launching again.
+ALTER TABLE reservations ADD COLUMN idempotency_key TEXT;
+
+CREATE UNIQUE INDEX reservations_account_id_idempotency_key
+  ON reservations(account_id, idempotency_key) WHERE idempotency_key IS NOT NULL;
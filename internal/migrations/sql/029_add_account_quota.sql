@@ -0,0 +1,3 @@
+-- Launch limits of an account overriding application defaults, optionally per provider type.
+-- Empty object means defaults apply.
+ALTER TABLE accounts ADD COLUMN quota JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,21 @@
+-- Composite reservations launch child reservations of other provider types and report their
+-- aggregate progress. Children reference the composite reservation via parent_id.
+CREATE OR REPLACE FUNCTION valid_provider(i INTEGER)
+  RETURNS BOOLEAN AS
+$valid_provider$
+BEGIN
+  RETURN i BETWEEN 1 AND 5;
+END;
+$valid_provider$ LANGUAGE 'plpgsql';
+
+CREATE OR REPLACE FUNCTION provider_type_composite()
+  RETURNS INTEGER AS
+$provider_type_composite$
+BEGIN
+  RETURN(SELECT 5);
+END;
+$provider_type_composite$ LANGUAGE 'plpgsql' IMMUTABLE PARALLEL SAFE;
+
+ALTER TABLE reservations ADD COLUMN parent_id BIGINT REFERENCES reservations(id) ON DELETE CASCADE;
+
+CREATE INDEX reservations_parent_id ON reservations(parent_id) WHERE parent_id IS NOT NULL;
@@ -0,0 +1,17 @@
+-- Launch profiles are saved reservation parameters of an account. A reservation can be created
+-- from a profile with optional overrides.
+CREATE TABLE launch_profiles
+(
+  id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+  account_id BIGINT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+  name TEXT NOT NULL CHECK (NOT empty(name)),
+  provider INTEGER NOT NULL CHECK (provider IN (provider_type_aws(), provider_type_azure(), provider_type_gcp())),
+  source_id TEXT NOT NULL CHECK (NOT empty(source_id)),
+  region TEXT NOT NULL DEFAULT '',
+  instance_type TEXT NOT NULL DEFAULT '',
+  image_id TEXT NOT NULL DEFAULT '',
+  pubkey_id BIGINT NOT NULL REFERENCES pubkeys(id) ON DELETE RESTRICT,
+  created_at TIMESTAMP NOT NULL DEFAULT current_timestamp,
+
+  UNIQUE(name, account_id)
+);
@@ -0,0 +1,3 @@
+-- Checksum of user data rendered for the reservation, NULL when the reservation has no
+-- user data (noop and composite reservations or reservations created before).
+ALTER TABLE reservations ADD COLUMN user_data_hash TEXT;
@@ -0,0 +1,5 @@
+-- Teardown of reservation instances. Only one teardown job can be queued at a time, its error
+-- is kept apart from the launch error of the reservation.
+ALTER TABLE reservations
+  ADD COLUMN teardown_queued_at TIMESTAMP,
+  ADD COLUMN teardown_error TEXT NOT NULL DEFAULT '';
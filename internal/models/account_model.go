@@ -12,8 +12,55 @@ type Account struct {
 
 	// EBS account number. Can be NULL but not blank.
 	AccountNumber sql.NullString `db:"account_number"`
+
+	// Launch limits of the account, stored as JSON in DB.
+	Quota AccountQuota `db:"quota"`
 }
 
 func (a Account) CacheKeyName() string {
 	return "account"
 }
+
+// QuotaLimits represents launch limits. Nil limit is not set, zero limit means unlimited.
+type QuotaLimits struct {
+	// Maximum amount of reservations in progress.
+	MaxInflightReservations *int32 `json:"max_inflight_reservations,omitempty"`
+
+	// Maximum amount of instances launched by a single reservation.
+	MaxInstancesPerReservation *int32 `json:"max_instances_per_reservation,omitempty"`
+
+	// Maximum amount of instances launched in the last 24 hours.
+	MaxInstancesPerDay *int32 `json:"max_instances_per_day,omitempty"`
+}
+
+// merge overwrites limits which are set in other.
+func (l *QuotaLimits) merge(other QuotaLimits) {
+	if other.MaxInflightReservations != nil {
+		l.MaxInflightReservations = other.MaxInflightReservations
+	}
+	if other.MaxInstancesPerReservation != nil {
+		l.MaxInstancesPerReservation = other.MaxInstancesPerReservation
+	}
+	if other.MaxInstancesPerDay != nil {
+		l.MaxInstancesPerDay = other.MaxInstancesPerDay
+	}
+}
+
+// AccountQuota represents launch limits of an account. Limits not set for a provider type are
+// taken from the account limits, limits not set for the account are application defaults.
+type AccountQuota struct {
+	QuotaLimits
+
+	// Provider type specific limits, keys are provider type names (e.g. "aws").
+	Providers map[string]QuotaLimits `json:"providers,omitempty"`
+}
+
+// Effective returns limits in effect for the provider type.
+func (q AccountQuota) Effective(provider ProviderType, defaults QuotaLimits) QuotaLimits {
+	result := defaults
+	result.merge(q.QuotaLimits)
+	if limits, ok := q.Providers[provider.String()]; ok {
+		result.merge(limits)
+	}
+	return result
+}
@@ -0,0 +1,32 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/RHEnVision/provisioning-backend/internal/ptr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountQuotaEffective(t *testing.T) {
+	defaults := models.QuotaLimits{
+		MaxInflightReservations:    ptr.To(int32(20)),
+		MaxInstancesPerReservation: ptr.To(int32(50)),
+		MaxInstancesPerDay:         ptr.To(int32(200)),
+	}
+	quota := models.AccountQuota{
+		QuotaLimits: models.QuotaLimits{MaxInstancesPerDay: ptr.To(int32(10))},
+		Providers: map[string]models.QuotaLimits{
+			"aws": {MaxInstancesPerReservation: ptr.To(int32(0))},
+		},
+	}
+
+	aws := quota.Effective(models.ProviderTypeAWS, defaults)
+	assert.Equal(t, int32(20), *aws.MaxInflightReservations)
+	assert.Equal(t, int32(0), *aws.MaxInstancesPerReservation)
+	assert.Equal(t, int32(10), *aws.MaxInstancesPerDay)
+
+	gcp := quota.Effective(models.ProviderTypeGCP, defaults)
+	assert.Equal(t, int32(50), *gcp.MaxInstancesPerReservation)
+	assert.Equal(t, int32(10), *gcp.MaxInstancesPerDay)
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// LaunchProfile represents saved reservation parameters of an account. Reservations can be
+// created from a profile, all parameters can be overridden.
+type LaunchProfile struct {
+	// Required auto-generated PK.
+	ID int64 `db:"id"`
+
+	// Associated Account model. Required.
+	AccountID int64 `db:"account_id"`
+
+	// User-facing name. Required.
+	Name string `db:"name" validate:"required"`
+
+	// Provider type, one of AWS, Azure or GCP. Required.
+	Provider ProviderType `db:"provider" validate:"oneof=2 3 4"`
+
+	// Source ID. Required.
+	SourceID string `db:"source_id" validate:"required"`
+
+	// AWS region, Azure location or GCP zone.
+	Region string `db:"region"`
+
+	// AWS instance type, Azure instance size or GCP machine type.
+	InstanceType string `db:"instance_type"`
+
+	// Image Builder UUID or provider image ID.
+	ImageID string `db:"image_id"`
+
+	// Associated Pubkey model. Required.
+	PubkeyID int64 `db:"pubkey_id" validate:"required"`
+
+	// Time when profile was created.
+	CreatedAt time.Time `db:"created_at"`
+}
@@ -19,6 +19,9 @@ const (
 
 	// Google Compute Engine provider
 	ProviderTypeGCP
+
+	// Composite reservation of other provider types
+	ProviderTypeComposite
 )
 
 func ProviderTypeFromString(str string) ProviderType {
@@ -31,6 +34,8 @@ func ProviderTypeFromString(str string) ProviderType {
 		return ProviderTypeAzure
 	case "gcp":
 		return ProviderTypeGCP
+	case "composite":
+		return ProviderTypeComposite
 	default:
 		return ProviderTypeUnknown
 	}
@@ -45,6 +50,7 @@ func (pt ProviderType) SourcesProviderName() string {
 	case ProviderTypeAzure:
 		return "azure"
 	case ProviderTypeNoop:
+	case ProviderTypeComposite:
 	case ProviderTypeUnknown:
 	default:
 		return ""
@@ -62,6 +68,8 @@ func (pt ProviderType) String() string {
 		return "azure"
 	case ProviderTypeGCP:
 		return "gcp"
+	case ProviderTypeComposite:
+		return "composite"
 	case ProviderTypeUnknown:
 	default:
 		return ""
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboxJob represents an encoded background job waiting to be published to the job queue.
+type OutboxJob struct {
+	// Required auto-generated PK.
+	ID int64 `db:"id"`
+
+	// ID of the encoded job.
+	JobID uuid.UUID `db:"job_id"`
+
+	// Type of the encoded job.
+	JobType string `db:"job_type"`
+
+	// Job encoded via worker.EncodeJob.
+	Payload []byte `db:"payload"`
+
+	// Time when the job was stored, used to calculate relay lag.
+	CreatedAt time.Time `db:"created_at"`
+
+	// Number of failed publish attempts.
+	Attempts int `db:"attempts"`
+
+	// Time of the next publish attempt, it is postponed after each failed attempt.
+	NextAttemptAt time.Time `db:"next_attempt_at"`
+
+	// Flag indicating the job failed all publish attempts and it is not published anymore.
+	Dead bool `db:"dead"`
+
+	// Error message of the last failed attempt.
+	Error string `db:"error"`
+}
@@ -3,6 +3,8 @@ package models
 import (
 	"database/sql"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 // Reservation represents an instance launch reservation. They are associated with a background
@@ -44,12 +46,59 @@ type Reservation struct {
 
 	// Flag indicating success, error or unknown state (NULL). See Status for the actual error.
 	Success sql.NullBool `db:"success" json:"success"`
+
+	// ID of the background job processing the reservation or NULL for older reservations.
+	JobID uuid.NullUUID `db:"job_id" json:"-"`
+
+	// Flag indicating the reservation was cancelled by the user. Cancelled reservations are
+	// finished with error.
+	Cancelled bool `db:"cancelled" json:"cancelled"`
+
+	// Optional time when the reservation job is due (UTC). Reservation is in "Scheduled" status
+	// until then, NULL means the job was enqueued immediately.
+	LaunchAt sql.NullTime `db:"launch_at" json:"launch_at"`
+
+	// Optional key from the Idempotency-Key header of the create request, unique per account.
+	IdempotencyKey sql.NullString `db:"idempotency_key" json:"-"`
+
+	// ID of the composite reservation this reservation was launched by or NULL.
+	ParentID sql.NullInt64 `db:"parent_id" json:"parent_id"`
+
+	// SHA-256 checksum of user data sent to the provider or NULL when there was none.
+	UserDataHash sql.NullString `db:"user_data_hash" json:"user_data_hash"`
+
+	// Time when a teardown job of reservation instances was queued or NULL when no teardown is
+	// queued or running.
+	TeardownQueuedAt sql.NullTime `db:"teardown_queued_at" json:"teardown_queued_at"`
+
+	// Error of the last teardown of reservation instances or empty string when it succeeded.
+	// Teardown does not change the launch outcome (Success and Error fields).
+	TeardownError string `db:"teardown_error" json:"teardown_error,omitempty"`
 }
 
 type NoopReservation struct {
 	Reservation
 }
 
+// CompositeReservation launches child reservations of other provider types. It has no job on
+// its own, its step is the number of finished children and it is finished with the last child.
+type CompositeReservation struct {
+	Reservation
+}
+
+// LaunchInput is user data input of a reservation only needed to launch it. It is cleared when
+// the reservation finishes, user data and the activation key are secrets of the user.
+type LaunchInput struct {
+	// Optional user supplied user data, see userdata.GenerateUserData.
+	UserData []byte `json:"user_data,omitempty"`
+
+	// Optional activation key of rhc registration at first boot encrypted by secrets.Encrypt.
+	RHCActivationKey string `json:"rhc_activation_key,omitempty"`
+
+	// Organization ID of rhc registration at first boot.
+	RHCOrgID string `json:"rhc_org_id,omitempty"`
+}
+
 type AWSDetail struct {
 	Region string `json:"region"`
 
@@ -70,6 +119,16 @@ type AWSDetail struct {
 
 	// PubkeyName on AWS in given region. Found by the EnsurePubkey job.
 	PubkeyName string `json:"pubkey_name"`
+
+	// User data input, user data is rendered from it when the reservation is launched.
+	LaunchInput
+
+	// Additional pubkeys authorized on the instance(s) via user data.
+	PubkeyIDs []int64 `json:"pubkey_ids,omitempty"`
+
+	// Bodies of additional pubkeys including a generated one rendered into user data, the pubkeys
+	// can be deleted before the reservation is launched.
+	SSHKeys []string `json:"ssh_keys,omitempty"`
 }
 
 type AWSReservation struct {
@@ -109,6 +168,16 @@ type GCPDetail struct {
 
 	// Immediately power off the system after initialization
 	PowerOff bool `json:"poweroff"`
+
+	// User data input, the startup script is rendered from it when the reservation is launched.
+	LaunchInput
+
+	// Additional pubkeys authorized on the instance(s) via ssh-keys metadata.
+	PubkeyIDs []int64 `json:"pubkey_ids,omitempty"`
+
+	// Bodies of additional pubkeys including a generated one, the pubkeys can be deleted before
+	// the reservation is launched.
+	SSHKeys []string `json:"ssh_keys,omitempty"`
 }
 
 type GCPReservation struct {
@@ -146,6 +215,16 @@ type AzureDetail struct {
 
 	// Immediately power off the system after initialization
 	PowerOff bool `json:"poweroff"`
+
+	// User data input, user data is rendered from it when the reservation is launched.
+	LaunchInput
+
+	// Additional pubkeys authorized on the instance(s) via user data.
+	PubkeyIDs []int64 `json:"pubkey_ids,omitempty"`
+
+	// Bodies of additional pubkeys including a generated one rendered into user data, the pubkeys
+	// can be deleted before the reservation is launched.
+	SSHKeys []string `json:"ssh_keys,omitempty"`
 }
 
 type AzureReservation struct {
@@ -169,6 +248,24 @@ type ReservationInstanceDetail struct {
 	PublicIPv4 string `json:"public_ipv4"`
 }
 
+// ReservationEvent represents a status change of a reservation.
+type ReservationEvent struct {
+	// Required auto-generated PK.
+	ID int64 `db:"id" json:"id"`
+
+	// Reservation ID.
+	ReservationID int64 `db:"reservation_id" json:"reservation_id"`
+
+	// Status of the reservation after the change.
+	Status string `db:"status" json:"status"`
+
+	// Step of the reservation after the change.
+	Step int32 `db:"step" json:"step"`
+
+	// Time of the change.
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
 type ReservationInstance struct {
 	// Reservation ID.
 	ReservationID int64 `db:"reservation_id" json:"reservation_id"`
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Webhook represents a subscription of an account to reservation notifications. A signed
+// JSON POST request is sent to the URL when a reservation of the account is finished.
+type Webhook struct {
+	// Required auto-generated PK.
+	ID int64 `db:"id"`
+
+	// Associated Account model. Required.
+	AccountID int64 `db:"account_id"`
+
+	// Target HTTP or HTTPS URL. Required.
+	URL string `db:"url" validate:"required,url,startswith=http"`
+
+	// Shared secret used to compute HMAC-SHA256 signature of the request body. Required.
+	Secret string `db:"secret" validate:"required,min=16"`
+
+	// Disabled webhooks are kept but no notifications are sent.
+	Enabled bool `db:"enabled"`
+
+	// Time when webhook was created.
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// WebhookDelivery represents a single delivery attempt of a webhook notification.
+type WebhookDelivery struct {
+	// Required auto-generated PK.
+	ID int64 `db:"id"`
+
+	// Associated Webhook model. Required.
+	WebhookID int64 `db:"webhook_id"`
+
+	// Reservation the notification was sent for.
+	ReservationID int64 `db:"reservation_id"`
+
+	// Reservation event type, e.g. "finished" or "failed".
+	Event string `db:"event"`
+
+	// Attempt number starting from 1.
+	Attempt int `db:"attempt"`
+
+	// HTTP status code of the response or 0 when no response was received.
+	StatusCode int `db:"status_code"`
+
+	// Flag indicating the target responded with 2xx status code.
+	Success bool `db:"success"`
+
+	// Error message of a failed delivery.
+	Error string `db:"error"`
+
+	// Time of the delivery attempt.
+	CreatedAt time.Time `db:"created_at"`
+}
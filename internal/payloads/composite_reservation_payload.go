@@ -0,0 +1,108 @@
+package payloads
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/go-chi/render"
+)
+
+// MaxCompositeReservations is the maximum amount of child reservations of a composite reservation.
+const MaxCompositeReservations = 10
+
+var (
+	CompositeReservationCountError    = fmt.Errorf("composite reservation must have 1 to %d reservations", MaxCompositeReservations)
+	CompositeReservationProviderError = errors.New("exactly one of aws, azure or gcp must be set for each reservation")
+)
+
+// CompositeChildRequestPayload is a provider specific reservation request, exactly one of
+// the fields must be set.
+type CompositeChildRequestPayload struct {
+	AWS *AWSReservationRequestPayload `json:"aws,omitempty" nullable:"true" yaml:"aws,omitempty"`
+
+	Azure *AzureReservationRequestPayload `json:"azure,omitempty" nullable:"true" yaml:"azure,omitempty"`
+
+	GCP *GCPReservationRequestPayload `json:"gcp,omitempty" nullable:"true" yaml:"gcp,omitempty"`
+}
+
+type CompositeReservationRequestPayload struct {
+	// Provider specific reservation requests, each creates a child reservation.
+	Reservations []CompositeChildRequestPayload `json:"reservations" yaml:"reservations"`
+}
+
+type CompositeReservationResponsePayload struct {
+	ID int64 `json:"reservation_id" yaml:"reservation_id"`
+
+	// Child reservations in the order of the request. Progress and result of the composite
+	// reservation is available as a generic reservation.
+	Reservations []*GenericReservationResponsePayload `json:"reservations" yaml:"reservations"`
+}
+
+// Provider returns provider type of the child reservation request or ProviderTypeUnknown
+// when none or more than one request is set.
+func (p *CompositeChildRequestPayload) Provider() models.ProviderType {
+	result := models.ProviderTypeUnknown
+	set := 0
+	if p.AWS != nil {
+		result = models.ProviderTypeAWS
+		set++
+	}
+	if p.Azure != nil {
+		result = models.ProviderTypeAzure
+		set++
+	}
+	if p.GCP != nil {
+		result = models.ProviderTypeGCP
+		set++
+	}
+	if set != 1 {
+		return models.ProviderTypeUnknown
+	}
+	return result
+}
+
+// Payload returns the provider specific reservation request.
+func (p *CompositeChildRequestPayload) Payload() any {
+	switch p.Provider() {
+	case models.ProviderTypeAWS:
+		return p.AWS
+	case models.ProviderTypeAzure:
+		return p.Azure
+	case models.ProviderTypeGCP:
+		return p.GCP
+	case models.ProviderTypeNoop, models.ProviderTypeComposite, models.ProviderTypeUnknown:
+		return nil
+	default:
+		return nil
+	}
+}
+
+func (p *CompositeReservationRequestPayload) Bind(_ *http.Request) error {
+	if len(p.Reservations) == 0 || len(p.Reservations) > MaxCompositeReservations {
+		return CompositeReservationCountError
+	}
+	for i := range p.Reservations {
+		if p.Reservations[i].Provider() == models.ProviderTypeUnknown {
+			return fmt.Errorf("reservation %d: %w", i, CompositeReservationProviderError)
+		}
+	}
+	return nil
+}
+
+func (p *CompositeReservationResponsePayload) Render(_ http.ResponseWriter, _ *http.Request) error {
+	return nil
+}
+
+func NewCompositeReservationResponse(reservation *models.Reservation, children []*models.Reservation) render.Renderer {
+	list := make([]*GenericReservationResponsePayload, len(children))
+	for i, child := range children {
+		list[i] = reservationResponseMapper(child)
+	}
+
+	return &CompositeReservationResponsePayload{
+		ID:           reservation.ID,
+		Reservations: list,
+	}
+}
@@ -94,6 +94,29 @@ func PubkeyDuplicateError(ctx context.Context, message string, err error) *Respo
 	return NewResponseError(ctx, http.StatusUnprocessableEntity, message, err)
 }
 
+func WebhookDuplicateError(ctx context.Context, message string, err error) *ResponseError {
+	return NewResponseError(ctx, http.StatusUnprocessableEntity, message, err)
+}
+
+func LaunchProfileDuplicateError(ctx context.Context, message string, err error) *ResponseError {
+	return NewResponseError(ctx, http.StatusUnprocessableEntity, message, err)
+}
+
+func PubkeyInUseError(ctx context.Context, message string, err error) *ResponseError {
+	return NewResponseError(ctx, http.StatusUnprocessableEntity, message, err)
+}
+
+// QuotaExceededError is returned when a launch limit over time is reached, the request can be
+// repeated later.
+func QuotaExceededError(ctx context.Context, message string, err error) *ResponseError {
+	return NewResponseError(ctx, http.StatusTooManyRequests, message, err)
+}
+
+// QuotaForbiddenError is returned when a request is over a launch limit regardless of time.
+func QuotaForbiddenError(ctx context.Context, message string, err error) *ResponseError {
+	return NewResponseError(ctx, http.StatusForbidden, message, err)
+}
+
 func ClientErrorHelper(err error) (int, string) {
 	if errors.Is(err, clients.NotFoundErr) {
 		return 404, "service returned not found or no data"
@@ -161,6 +184,11 @@ func NewEnqueueTaskError(ctx context.Context, message string, err error) *Respon
 	return NewResponseError(ctx, http.StatusInternalServerError, message, err)
 }
 
+func NewCancelTaskError(ctx context.Context, message string, err error) *ResponseError {
+	message = fmt.Sprintf("Task cancel error: %s", message)
+	return NewResponseError(ctx, http.StatusInternalServerError, message, err)
+}
+
 func NewDAOError(ctx context.Context, message string, err error) *ResponseError {
 	message = fmt.Sprintf("DAO error: %s", message)
 	return NewResponseError(ctx, http.StatusInternalServerError, message, err)
@@ -0,0 +1,253 @@
+package payloads
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/go-chi/render"
+)
+
+var LaunchProfileProviderError = errors.New("provider must be one of aws, azure or gcp")
+
+// See models.LaunchProfile
+type LaunchProfileRequest struct {
+	// User-facing name, required on create.
+	Name string `json:"name" yaml:"name"`
+
+	// Provider type: "aws", "azure" or "gcp", required on create.
+	Provider string `json:"provider" yaml:"provider"`
+
+	// Source ID, required on create.
+	SourceID string `json:"source_id" yaml:"source_id"`
+
+	// AWS region, Azure location or GCP zone.
+	Region string `json:"region" yaml:"region"`
+
+	// AWS instance type, Azure instance size or GCP machine type.
+	InstanceType string `json:"instance_type" yaml:"instance_type"`
+
+	// Image Builder UUID of the image or provider image ID.
+	ImageID string `json:"image_id" yaml:"image_id"`
+
+	// Pubkey ID, required on create.
+	PubkeyID int64 `json:"pubkey_id" yaml:"pubkey_id"`
+}
+
+// See models.LaunchProfile
+type LaunchProfileResponse struct {
+	ID           int64     `json:"id" yaml:"id"`
+	Name         string    `json:"name" yaml:"name"`
+	Provider     string    `json:"provider" yaml:"provider"`
+	SourceID     string    `json:"source_id" yaml:"source_id"`
+	Region       string    `json:"region" yaml:"region"`
+	InstanceType string    `json:"instance_type" yaml:"instance_type"`
+	ImageID      string    `json:"image_id" yaml:"image_id"`
+	PubkeyID     int64     `json:"pubkey_id" yaml:"pubkey_id"`
+	CreatedAt    time.Time `json:"created_at" yaml:"created_at"`
+}
+
+// LaunchProfileReservationRequest creates a reservation from a launch profile. Profile
+// parameters are used unless they are overridden.
+type LaunchProfileReservationRequest struct {
+	// Optional name of the instance(s), ignored for GCP.
+	Name string `json:"name" yaml:"name"`
+
+	// Amount of instances to provision, defaults to 1.
+	Amount int64 `json:"amount" yaml:"amount"`
+
+	// Immediately power off the system after initialization.
+	PowerOff bool `json:"poweroff" yaml:"poweroff"`
+
+	// Optional time to launch the instance(s) at, must be in the future. Launches immediately when not set.
+	LaunchAt *time.Time `json:"launch_at,omitempty" nullable:"true" yaml:"launch_at"`
+
+	// Overrides source ID of the profile.
+	SourceID string `json:"source_id,omitempty" yaml:"source_id,omitempty"`
+
+	// Overrides AWS region, Azure location or GCP zone of the profile.
+	Region string `json:"region,omitempty" yaml:"region,omitempty"`
+
+	// Overrides AWS instance type, Azure instance size or GCP machine type of the profile.
+	InstanceType string `json:"instance_type,omitempty" yaml:"instance_type,omitempty"`
+
+	// Overrides image of the profile.
+	ImageID string `json:"image_id,omitempty" yaml:"image_id,omitempty"`
+
+	// Overrides pubkey of the profile.
+	PubkeyID int64 `json:"pubkey_id,omitempty" yaml:"pubkey_id,omitempty"`
+
+	// Optional user data, see the provider specific reservation request.
+	UserData string `json:"user_data,omitempty" yaml:"user_data,omitempty"`
+
+	// Optional rhc registration, see the provider specific reservation request.
+	RHCActivationKey string `json:"rhc_activation_key,omitempty" yaml:"rhc_activation_key,omitempty"`
+
+	// Organization ID of the activation key, defaults to the organization of the requester.
+	RHCOrgID string `json:"rhc_org_id,omitempty" yaml:"rhc_org_id,omitempty"`
+
+	// Optional additional pubkeys, see the provider specific reservation request.
+	PubkeyIDs []int64 `json:"pubkey_ids,omitempty" yaml:"pubkey_ids,omitempty"`
+
+	// Generate a new key pair, see the provider specific reservation request.
+	GenerateKeyPair bool `json:"generate_key_pair,omitempty" yaml:"generate_key_pair,omitempty"`
+}
+
+func (p *LaunchProfileRequest) Bind(_ *http.Request) error {
+	if p.Provider != "" && !launchProfileProvider(models.ProviderTypeFromString(p.Provider)) {
+		return LaunchProfileProviderError
+	}
+	return nil
+}
+
+func (p *LaunchProfileResponse) Render(_ http.ResponseWriter, _ *http.Request) error {
+	return nil
+}
+
+func (p *LaunchProfileReservationRequest) Bind(_ *http.Request) error {
+	return nil
+}
+
+// launchProfileProvider returns true for provider types which can be saved in launch profiles.
+func launchProfileProvider(provider models.ProviderType) bool {
+	switch provider {
+	case models.ProviderTypeAWS, models.ProviderTypeAzure, models.ProviderTypeGCP:
+		return true
+	case models.ProviderTypeNoop, models.ProviderTypeComposite, models.ProviderTypeUnknown:
+		return false
+	default:
+		return false
+	}
+}
+
+// NewModel returns a new launch profile.
+func (p *LaunchProfileRequest) NewModel() *models.LaunchProfile {
+	return &models.LaunchProfile{
+		Name:         p.Name,
+		Provider:     models.ProviderTypeFromString(p.Provider),
+		SourceID:     p.SourceID,
+		Region:       p.Region,
+		InstanceType: p.InstanceType,
+		ImageID:      p.ImageID,
+		PubkeyID:     p.PubkeyID,
+	}
+}
+
+// Apply updates the launch profile with the provided fields.
+func (p *LaunchProfileRequest) Apply(profile *models.LaunchProfile) {
+	if p.Name != "" {
+		profile.Name = p.Name
+	}
+	if p.Provider != "" {
+		profile.Provider = models.ProviderTypeFromString(p.Provider)
+	}
+	if p.SourceID != "" {
+		profile.SourceID = p.SourceID
+	}
+	if p.Region != "" {
+		profile.Region = p.Region
+	}
+	if p.InstanceType != "" {
+		profile.InstanceType = p.InstanceType
+	}
+	if p.ImageID != "" {
+		profile.ImageID = p.ImageID
+	}
+	if p.PubkeyID != 0 {
+		profile.PubkeyID = p.PubkeyID
+	}
+}
+
+// ReservationPayload returns the provider specific reservation request of the profile with
+// overrides applied, or nil for unsupported provider types.
+func (p *LaunchProfileReservationRequest) ReservationPayload(profile *models.LaunchProfile) any {
+	expanded := *profile
+	(&LaunchProfileRequest{
+		SourceID:     p.SourceID,
+		Region:       p.Region,
+		InstanceType: p.InstanceType,
+		ImageID:      p.ImageID,
+		PubkeyID:     p.PubkeyID,
+	}).Apply(&expanded)
+
+	amount := p.Amount
+	if amount == 0 {
+		amount = 1
+	}
+
+	switch expanded.Provider {
+	case models.ProviderTypeAWS:
+		return &AWSReservationRequestPayload{
+			PubkeyID:         expanded.PubkeyID,
+			SourceID:         expanded.SourceID,
+			Region:           expanded.Region,
+			Name:             p.Name,
+			InstanceType:     expanded.InstanceType,
+			Amount:           int32(amount),
+			ImageID:          expanded.ImageID,
+			PowerOff:         p.PowerOff,
+			LaunchAt:         p.LaunchAt,
+			UserData:         p.UserData,
+			RHCActivationKey: p.RHCActivationKey,
+			RHCOrgID:         p.RHCOrgID,
+			PubkeyIDs:        p.PubkeyIDs,
+			GenerateKeyPair:  p.GenerateKeyPair,
+		}
+	case models.ProviderTypeAzure:
+		return &AzureReservationRequestPayload{
+			PubkeyID:         expanded.PubkeyID,
+			SourceID:         expanded.SourceID,
+			ImageID:          expanded.ImageID,
+			Location:         expanded.Region,
+			InstanceSize:     expanded.InstanceType,
+			Amount:           amount,
+			Name:             p.Name,
+			PowerOff:         p.PowerOff,
+			LaunchAt:         p.LaunchAt,
+			UserData:         p.UserData,
+			RHCActivationKey: p.RHCActivationKey,
+			RHCOrgID:         p.RHCOrgID,
+			PubkeyIDs:        p.PubkeyIDs,
+			GenerateKeyPair:  p.GenerateKeyPair,
+		}
+	case models.ProviderTypeGCP:
+		return &GCPReservationRequestPayload{
+			PubkeyID:         expanded.PubkeyID,
+			SourceID:         expanded.SourceID,
+			Zone:             expanded.Region,
+			MachineType:      expanded.InstanceType,
+			Amount:           amount,
+			ImageID:          expanded.ImageID,
+			PowerOff:         p.PowerOff,
+			LaunchAt:         p.LaunchAt,
+			UserData:         p.UserData,
+			RHCActivationKey: p.RHCActivationKey,
+			RHCOrgID:         p.RHCOrgID,
+			PubkeyIDs:        p.PubkeyIDs,
+			GenerateKeyPair:  p.GenerateKeyPair,
+		}
+	case models.ProviderTypeNoop, models.ProviderTypeComposite, models.ProviderTypeUnknown:
+		return nil
+	default:
+		return nil
+	}
+}
+
+func NewLaunchProfileResponse(profile *models.LaunchProfile) render.Renderer {
+	return launchProfileResponseMapper(profile)
+}
+
+func launchProfileResponseMapper(profile *models.LaunchProfile) *LaunchProfileResponse {
+	return &LaunchProfileResponse{
+		ID:           profile.ID,
+		Name:         profile.Name,
+		Provider:     profile.Provider.String(),
+		SourceID:     profile.SourceID,
+		Region:       profile.Region,
+		InstanceType: profile.InstanceType,
+		ImageID:      profile.ImageID,
+		PubkeyID:     profile.PubkeyID,
+		CreatedAt:    profile.CreatedAt,
+	}
+}
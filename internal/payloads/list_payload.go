@@ -0,0 +1,131 @@
+package payloads
+
+import (
+	"net/http"
+
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/go-chi/render"
+)
+
+type ListMetadata struct {
+	// Total number of records matching the filter, not only records on the page.
+	Total int64 `json:"total" yaml:"total"`
+}
+
+type ListLinks struct {
+	// Link to the next page or empty when there are no more records.
+	Next string `json:"next,omitempty" yaml:"next"`
+
+	// Link to the previous page or empty when on the first page.
+	Previous string `json:"previous,omitempty" yaml:"previous"`
+}
+
+type ReservationListResponse struct {
+	Data     []*GenericReservationResponsePayload `json:"data" yaml:"data"`
+	Metadata ListMetadata                         `json:"metadata" yaml:"metadata"`
+	Links    ListLinks                            `json:"links" yaml:"links"`
+}
+
+type PubkeyListResponse struct {
+	Data     []*PubkeyResponse `json:"data" yaml:"data"`
+	Metadata ListMetadata      `json:"metadata" yaml:"metadata"`
+	Links    ListLinks         `json:"links" yaml:"links"`
+}
+
+type WebhookListResponse struct {
+	Data     []*WebhookResponse `json:"data" yaml:"data"`
+	Metadata ListMetadata       `json:"metadata" yaml:"metadata"`
+	Links    ListLinks          `json:"links" yaml:"links"`
+}
+
+type WebhookDeliveryListResponse struct {
+	Data     []*WebhookDeliveryResponse `json:"data" yaml:"data"`
+	Metadata ListMetadata               `json:"metadata" yaml:"metadata"`
+	Links    ListLinks                  `json:"links" yaml:"links"`
+}
+
+type LaunchProfileListResponse struct {
+	Data     []*LaunchProfileResponse `json:"data" yaml:"data"`
+	Metadata ListMetadata             `json:"metadata" yaml:"metadata"`
+	Links    ListLinks                `json:"links" yaml:"links"`
+}
+
+func (p *ReservationListResponse) Render(_ http.ResponseWriter, _ *http.Request) error {
+	return nil
+}
+
+func (p *PubkeyListResponse) Render(_ http.ResponseWriter, _ *http.Request) error {
+	return nil
+}
+
+func (p *WebhookListResponse) Render(_ http.ResponseWriter, _ *http.Request) error {
+	return nil
+}
+
+func (p *WebhookDeliveryListResponse) Render(_ http.ResponseWriter, _ *http.Request) error {
+	return nil
+}
+
+func (p *LaunchProfileListResponse) Render(_ http.ResponseWriter, _ *http.Request) error {
+	return nil
+}
+
+func NewReservationListResponse(reservations []*models.Reservation, total int64, links ListLinks) render.Renderer {
+	list := make([]*GenericReservationResponsePayload, len(reservations))
+	for i, reservation := range reservations {
+		list[i] = reservationResponseMapper(reservation)
+	}
+	return &ReservationListResponse{
+		Data:     list,
+		Metadata: ListMetadata{Total: total},
+		Links:    links,
+	}
+}
+
+func NewPubkeyListResponse(pubkeys []*models.Pubkey, total int64, links ListLinks) render.Renderer {
+	list := make([]*PubkeyResponse, len(pubkeys))
+	for i, pubkey := range pubkeys {
+		list[i] = pubkeyResponseMapper(pubkey)
+	}
+	return &PubkeyListResponse{
+		Data:     list,
+		Metadata: ListMetadata{Total: total},
+		Links:    links,
+	}
+}
+
+func NewWebhookListResponse(webhooks []*models.Webhook, total int64, links ListLinks) render.Renderer {
+	list := make([]*WebhookResponse, len(webhooks))
+	for i, webhook := range webhooks {
+		list[i] = webhookResponseMapper(webhook)
+	}
+	return &WebhookListResponse{
+		Data:     list,
+		Metadata: ListMetadata{Total: total},
+		Links:    links,
+	}
+}
+
+func NewWebhookDeliveryListResponse(deliveries []*models.WebhookDelivery, total int64, links ListLinks) render.Renderer {
+	list := make([]*WebhookDeliveryResponse, len(deliveries))
+	for i, delivery := range deliveries {
+		list[i] = webhookDeliveryResponseMapper(delivery)
+	}
+	return &WebhookDeliveryListResponse{
+		Data:     list,
+		Metadata: ListMetadata{Total: total},
+		Links:    links,
+	}
+}
+
+func NewLaunchProfileListResponse(profiles []*models.LaunchProfile, total int64, links ListLinks) render.Renderer {
+	list := make([]*LaunchProfileResponse, len(profiles))
+	for i, profile := range profiles {
+		list[i] = launchProfileResponseMapper(profile)
+	}
+	return &LaunchProfileListResponse{
+		Data:     list,
+		Metadata: ListMetadata{Total: total},
+		Links:    links,
+	}
+}
@@ -25,10 +25,46 @@ type PubkeyResponse struct {
 	FingerprintLegacy string `json:"fingerprint_legacy,omitempty" yaml:"fingerprint_legacy,omitempty"`
 }
 
+// PubkeyUpdateRequest renames a pubkey, body of a pubkey cannot be changed.
+type PubkeyUpdateRequest struct {
+	Name string `json:"name" yaml:"name"`
+}
+
+// See models.PubkeyResource
+type PubkeyResourceResponse struct {
+	ID       int64  `json:"id" yaml:"id"`
+	Provider string `json:"provider" yaml:"provider"`
+	SourceID string `json:"source_id" yaml:"source_id"`
+	Region   string `json:"region" yaml:"region"`
+	Handle   string `json:"handle" yaml:"handle"`
+}
+
+type PubkeyResourcesResponse struct {
+	// Pubkey uploads to cloud providers.
+	Resources []PubkeyResourceResponse `json:"resources" yaml:"resources"`
+
+	// A page of reservations which authorized the pubkey.
+	Reservations []*GenericReservationResponsePayload `json:"reservations" yaml:"reservations"`
+
+	// Metadata of the reservations page.
+	Metadata ListMetadata `json:"metadata" yaml:"metadata"`
+
+	// Links to the neighbouring reservations pages.
+	Links ListLinks `json:"links" yaml:"links"`
+}
+
 func (p *PubkeyRequest) Bind(_ *http.Request) error {
 	return nil
 }
 
+func (p *PubkeyUpdateRequest) Bind(_ *http.Request) error {
+	return nil
+}
+
+func (p *PubkeyResourcesResponse) Render(_ http.ResponseWriter, _ *http.Request) error {
+	return nil
+}
+
 func (p *PubkeyResponse) Render(_ http.ResponseWriter, _ *http.Request) error {
 	return nil
 }
@@ -41,6 +77,10 @@ func (p *PubkeyRequest) NewModel() *models.Pubkey {
 }
 
 func NewPubkeyResponse(pubkey *models.Pubkey) render.Renderer {
+	return pubkeyResponseMapper(pubkey)
+}
+
+func pubkeyResponseMapper(pubkey *models.Pubkey) *PubkeyResponse {
 	return &PubkeyResponse{
 		ID:                pubkey.ID,
 		AccountID:         pubkey.AccountID,
@@ -52,10 +92,29 @@ func NewPubkeyResponse(pubkey *models.Pubkey) render.Renderer {
 	}
 }
 
-func NewPubkeyListResponse(pubkeys []*models.Pubkey) []render.Renderer {
-	list := make([]render.Renderer, len(pubkeys))
-	for i, pubkey := range pubkeys {
-		list[i] = NewPubkeyResponse(pubkey)
+// Apply renames the pubkey.
+func (p *PubkeyUpdateRequest) Apply(pubkey *models.Pubkey) {
+	pubkey.Name = p.Name
+}
+
+func NewPubkeyResourcesResponse(resources []*models.PubkeyResource, reservations []*models.Reservation, total int64, links ListLinks) render.Renderer {
+	response := &PubkeyResourcesResponse{
+		Resources:    make([]PubkeyResourceResponse, len(resources)),
+		Reservations: make([]*GenericReservationResponsePayload, len(reservations)),
+		Metadata:     ListMetadata{Total: total},
+		Links:        links,
+	}
+	for i, res := range resources {
+		response.Resources[i] = PubkeyResourceResponse{
+			ID:       res.ID,
+			Provider: res.Provider.String(),
+			SourceID: res.SourceID,
+			Region:   res.Region,
+			Handle:   res.Handle,
+		}
+	}
+	for i, reservation := range reservations {
+		response.Reservations[i] = reservationResponseMapper(reservation)
 	}
-	return list
+	return response
 }
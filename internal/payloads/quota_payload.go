@@ -0,0 +1,121 @@
+package payloads
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+)
+
+var (
+	UnknownQuotaProviderError = errors.New("unknown provider type in quota")
+	NegativeQuotaLimitError   = errors.New("quota limit must not be negative")
+)
+
+// Provider types with limits in effect returned in quota responses.
+var quotaProviders = []models.ProviderType{models.ProviderTypeAWS, models.ProviderTypeAzure, models.ProviderTypeGCP}
+
+// See models.QuotaLimits. Limits which are not set are inherited, zero means unlimited.
+type QuotaLimitsPayload struct {
+	MaxInflightReservations    *int32 `json:"max_inflight_reservations,omitempty" yaml:"max_inflight_reservations,omitempty" nullable:"true"`
+	MaxInstancesPerReservation *int32 `json:"max_instances_per_reservation,omitempty" yaml:"max_instances_per_reservation,omitempty" nullable:"true"`
+	MaxInstancesPerDay         *int32 `json:"max_instances_per_day,omitempty" yaml:"max_instances_per_day,omitempty" nullable:"true"`
+}
+
+// See models.AccountQuota. The request replaces all limits of the account.
+type AccountQuotaRequest struct {
+	QuotaLimitsPayload `yaml:",inline"`
+
+	// Provider type specific limits, keys are provider type names (aws, azure, gcp).
+	Providers map[string]QuotaLimitsPayload `json:"providers,omitempty" yaml:"providers,omitempty"`
+}
+
+type AccountQuotaResponse struct {
+	AccountID int64  `json:"account_id" yaml:"account_id"`
+	OrgID     string `json:"org_id" yaml:"org_id"`
+
+	// Limits set for the account, limits which are not set are application defaults.
+	QuotaLimitsPayload `yaml:",inline"`
+
+	// Provider type specific limits set for the account.
+	Providers map[string]QuotaLimitsPayload `json:"providers,omitempty" yaml:"providers,omitempty"`
+
+	// Limits in effect for each provider type.
+	Effective map[string]QuotaLimitsPayload `json:"effective" yaml:"effective"`
+}
+
+func (p *QuotaLimitsPayload) validate() error {
+	for _, limit := range []*int32{p.MaxInflightReservations, p.MaxInstancesPerReservation, p.MaxInstancesPerDay} {
+		if limit != nil && *limit < 0 {
+			return NegativeQuotaLimitError
+		}
+	}
+	return nil
+}
+
+func (p *AccountQuotaRequest) Bind(_ *http.Request) error {
+	if err := p.QuotaLimitsPayload.validate(); err != nil {
+		return err
+	}
+	for name, limits := range p.Providers {
+		if models.ProviderTypeFromString(name) == models.ProviderTypeUnknown {
+			return fmt.Errorf("%w: %s", UnknownQuotaProviderError, name)
+		}
+		if err := limits.validate(); err != nil {
+			return fmt.Errorf("%w: %s", err, name)
+		}
+	}
+	return nil
+}
+
+func (p *AccountQuotaResponse) Render(_ http.ResponseWriter, _ *http.Request) error {
+	return nil
+}
+
+func (p *QuotaLimitsPayload) model() models.QuotaLimits {
+	return models.QuotaLimits{
+		MaxInflightReservations:    p.MaxInflightReservations,
+		MaxInstancesPerReservation: p.MaxInstancesPerReservation,
+		MaxInstancesPerDay:         p.MaxInstancesPerDay,
+	}
+}
+
+func quotaLimitsMapper(limits models.QuotaLimits) QuotaLimitsPayload {
+	return QuotaLimitsPayload{
+		MaxInflightReservations:    limits.MaxInflightReservations,
+		MaxInstancesPerReservation: limits.MaxInstancesPerReservation,
+		MaxInstancesPerDay:         limits.MaxInstancesPerDay,
+	}
+}
+
+// NewModel returns account quota with provider type names in lower case.
+func (p *AccountQuotaRequest) NewModel() *models.AccountQuota {
+	quota := &models.AccountQuota{QuotaLimits: p.QuotaLimitsPayload.model()}
+	if len(p.Providers) > 0 {
+		quota.Providers = make(map[string]models.QuotaLimits, len(p.Providers))
+		for name, limits := range p.Providers {
+			quota.Providers[models.ProviderTypeFromString(name).String()] = limits.model()
+		}
+	}
+	return quota
+}
+
+func NewAccountQuotaResponse(account *models.Account, defaults models.QuotaLimits) *AccountQuotaResponse {
+	response := &AccountQuotaResponse{
+		AccountID:          account.ID,
+		OrgID:              account.OrgID,
+		QuotaLimitsPayload: quotaLimitsMapper(account.Quota.QuotaLimits),
+		Effective:          make(map[string]QuotaLimitsPayload, len(quotaProviders)),
+	}
+	if len(account.Quota.Providers) > 0 {
+		response.Providers = make(map[string]QuotaLimitsPayload, len(account.Quota.Providers))
+		for name, limits := range account.Quota.Providers {
+			response.Providers[name] = quotaLimitsMapper(limits)
+		}
+	}
+	for _, provider := range quotaProviders {
+		response.Effective[provider.String()] = quotaLimitsMapper(account.Quota.Effective(provider, defaults))
+	}
+	return response
+}
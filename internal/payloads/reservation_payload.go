@@ -40,6 +40,24 @@ type GenericReservationResponsePayload struct {
 
 	// Flag indicating success, error or unknown state (NULL). See Status for the actual error.
 	Success *bool `json:"success" nullable:"true" yaml:"success"`
+
+	// Flag indicating the reservation was cancelled.
+	Cancelled bool `json:"cancelled" yaml:"cancelled"`
+
+	// Time when the reservation is scheduled to launch or nil when it was launched immediately.
+	LaunchAt *time.Time `json:"launch_at" nullable:"true" yaml:"launch_at"`
+
+	// ID of the composite reservation which launched this reservation or nil.
+	ParentID *int64 `json:"parent_id" nullable:"true" yaml:"parent_id"`
+
+	// SHA-256 checksum of user data sent to the provider, missing when there was none.
+	UserDataHash string `json:"user_data_hash,omitempty" yaml:"user_data_hash"`
+
+	// Flag indicating termination of reservation instances is queued or in progress.
+	TeardownQueued bool `json:"teardown_queued" yaml:"teardown_queued"`
+
+	// Error message when the last termination of reservation instances failed.
+	TeardownError string `json:"teardown_error,omitempty" yaml:"teardown_error"`
 }
 
 type InstanceResponse struct {
@@ -85,6 +103,13 @@ type AWSReservationResponsePayload struct {
 
 	// Instances array, only present for finished reservations
 	Instances []InstanceResponse `json:"instances,omitempty" yaml:"instances"`
+
+	// IDs of additional pubkeys authorized on the instance(s).
+	PubkeyIDs []int64 `json:"pubkey_ids,omitempty" yaml:"pubkey_ids"`
+
+	// Private key of the generated key pair in the OpenSSH format, only present in the response
+	// of the create request.
+	PrivateKey string `json:"private_key,omitempty" yaml:"private_key"`
 }
 
 type AzureReservationResponsePayload struct {
@@ -114,6 +139,13 @@ type AzureReservationResponsePayload struct {
 
 	// Instances IDs, only present for finished reservations.
 	Instances []InstanceResponse `json:"instances,omitempty" yaml:"instances"`
+
+	// IDs of additional pubkeys authorized on the instance(s).
+	PubkeyIDs []int64 `json:"pubkey_ids,omitempty" yaml:"pubkey_ids"`
+
+	// Private key of the generated key pair in the OpenSSH format, only present in the response
+	// of the create request.
+	PrivateKey string `json:"private_key,omitempty" yaml:"private_key"`
 }
 
 type GCPReservationResponsePayload struct {
@@ -145,6 +177,13 @@ type GCPReservationResponsePayload struct {
 
 	// Instances IDs, only present for finished reservations.
 	Instances []InstanceResponse `json:"instances,omitempty" yaml:"instances"`
+
+	// IDs of additional pubkeys authorized on the instance(s).
+	PubkeyIDs []int64 `json:"pubkey_ids,omitempty" yaml:"pubkey_ids"`
+
+	// Private key of the generated key pair in the OpenSSH format, only present in the response
+	// of the create request.
+	PrivateKey string `json:"private_key,omitempty" yaml:"private_key"`
 }
 
 type NoopReservationResponsePayload struct {
@@ -178,6 +217,27 @@ type AWSReservationRequestPayload struct {
 
 	// Immediately power off the system after initialization
 	PowerOff bool `json:"poweroff" yaml:"poweroff"`
+
+	// Optional time to launch the instance(s) at, must be in the future. Launches immediately when not set.
+	LaunchAt *time.Time `json:"launch_at,omitempty" nullable:"true" yaml:"launch_at"`
+
+	// Optional cloud-config YAML starting with #cloud-config or shell script starting with #!,
+	// merged with the generated user data via cloud-init multipart archive. Up to 16 KB.
+	UserData string `json:"user_data,omitempty" yaml:"user_data,omitempty"`
+
+	// Optional activation key to register the instance(s) with Red Hat Subscription Management
+	// and Insights via rhc at first boot. The key is stored encrypted and only until the launch.
+	RHCActivationKey string `json:"rhc_activation_key,omitempty" yaml:"rhc_activation_key,omitempty"`
+
+	// Organization ID of the activation key, defaults to the organization of the requester.
+	RHCOrgID string `json:"rhc_org_id,omitempty" yaml:"rhc_org_id,omitempty"`
+
+	// Optional IDs of additional pubkeys authorized on the instance(s).
+	PubkeyIDs []int64 `json:"pubkey_ids,omitempty" yaml:"pubkey_ids,omitempty"`
+
+	// Generate a new ed25519 key pair authorized on the instance(s). The private key is only
+	// returned in the response of this request, it cannot be used with an idempotency key.
+	GenerateKeyPair bool `json:"generate_key_pair,omitempty" yaml:"generate_key_pair,omitempty"`
 }
 
 type AzureReservationRequestPayload struct {
@@ -202,6 +262,27 @@ type AzureReservationRequestPayload struct {
 
 	// Immediately power off the system after initialization.
 	PowerOff bool `json:"poweroff" yaml:"poweroff"`
+
+	// Optional time to launch the instance(s) at, must be in the future. Launches immediately when not set.
+	LaunchAt *time.Time `json:"launch_at,omitempty" nullable:"true" yaml:"launch_at"`
+
+	// Optional cloud-config YAML starting with #cloud-config or shell script starting with #!,
+	// merged with the generated user data via cloud-init multipart archive. Up to 48 KB.
+	UserData string `json:"user_data,omitempty" yaml:"user_data,omitempty"`
+
+	// Optional activation key to register the instance(s) with Red Hat Subscription Management
+	// and Insights via rhc at first boot. The key is stored encrypted and only until the launch.
+	RHCActivationKey string `json:"rhc_activation_key,omitempty" yaml:"rhc_activation_key,omitempty"`
+
+	// Organization ID of the activation key, defaults to the organization of the requester.
+	RHCOrgID string `json:"rhc_org_id,omitempty" yaml:"rhc_org_id,omitempty"`
+
+	// Optional IDs of additional pubkeys authorized on the instance(s).
+	PubkeyIDs []int64 `json:"pubkey_ids,omitempty" yaml:"pubkey_ids,omitempty"`
+
+	// Generate a new ed25519 key pair authorized on the instance(s). The private key is only
+	// returned in the response of this request, it cannot be used with an idempotency key.
+	GenerateKeyPair bool `json:"generate_key_pair,omitempty" yaml:"generate_key_pair,omitempty"`
 }
 
 type GCPReservationRequestPayload struct {
@@ -225,12 +306,76 @@ type GCPReservationRequestPayload struct {
 
 	// Immediately power off the system after initialization.
 	PowerOff bool `json:"poweroff" yaml:"poweroff"`
+
+	// Optional time to launch the instance(s) at, must be in the future. Launches immediately when not set.
+	LaunchAt *time.Time `json:"launch_at,omitempty" nullable:"true" yaml:"launch_at"`
+
+	// Optional cloud-config YAML starting with #cloud-config or shell script starting with #!,
+	// passed unchanged via user-data metadata, the generated startup script is passed separately.
+	// Up to 256 KB, requires cloud-init installed on the image.
+	UserData string `json:"user_data,omitempty" yaml:"user_data,omitempty"`
+
+	// Optional activation key to register the instance(s) with Red Hat Subscription Management
+	// and Insights via rhc at first boot. The key is stored encrypted and only until the launch.
+	RHCActivationKey string `json:"rhc_activation_key,omitempty" yaml:"rhc_activation_key,omitempty"`
+
+	// Organization ID of the activation key, defaults to the organization of the requester.
+	RHCOrgID string `json:"rhc_org_id,omitempty" yaml:"rhc_org_id,omitempty"`
+
+	// Optional IDs of additional pubkeys authorized on the instance(s).
+	PubkeyIDs []int64 `json:"pubkey_ids,omitempty" yaml:"pubkey_ids,omitempty"`
+
+	// Generate a new ed25519 key pair authorized on the instance(s). The private key is only
+	// returned in the response of this request, it cannot be used with an idempotency key.
+	GenerateKeyPair bool `json:"generate_key_pair,omitempty" yaml:"generate_key_pair,omitempty"`
+}
+
+type InstancePowerRequestPayload struct {
+	// Power action to perform on the instance: "start", "stop" or "reboot".
+	Action string `json:"action" yaml:"action"`
 }
 
 func (p *GenericReservationResponsePayload) Render(_ http.ResponseWriter, _ *http.Request) error {
 	return nil
 }
 
+type ReservationEventResponse struct {
+	// Textual status of the reservation after the change.
+	Status string `json:"status" yaml:"status"`
+
+	// Active job step of the reservation after the change.
+	Step int32 `json:"step" yaml:"step"`
+
+	// Time of the change.
+	CreatedAt time.Time `json:"created_at" yaml:"created_at"`
+
+	// Time spent in this state in milliseconds until the next change or until the reservation
+	// was finished, nil when the reservation is still in this state.
+	DurationMs *int64 `json:"duration_ms" nullable:"true" yaml:"duration_ms"`
+}
+
+type ReservationTimelineResponse struct {
+	// Reservation ID.
+	ReservationID int64 `json:"reservation_id" yaml:"reservation_id"`
+
+	// Time when reservation was made.
+	CreatedAt time.Time `json:"created_at" yaml:"created_at"`
+
+	// Time when reservation was finished or nil when it's still processing.
+	FinishedAt *time.Time `json:"finished_at" nullable:"true" yaml:"finished_at"`
+
+	// Status changes of the reservation ordered from the oldest.
+	Events []ReservationEventResponse `json:"events" yaml:"events"`
+}
+
+func (p *ReservationTimelineResponse) Render(_ http.ResponseWriter, _ *http.Request) error {
+	return nil
+}
+
+func (p *InstancePowerRequestPayload) Bind(_ *http.Request) error {
+	return nil
+}
+
 func (p *AWSReservationRequestPayload) Bind(_ *http.Request) error {
 	return nil
 }
@@ -259,11 +404,65 @@ func NewReservationResponse(reservation *models.Reservation) render.Renderer {
 	return reservationResponseMapper(reservation)
 }
 
+func NewReservationTimelineResponse(reservation *models.Reservation, events []*models.ReservationEvent) render.Renderer {
+	var finishedAt *time.Time
+	if reservation.FinishedAt.Valid {
+		finishedAt = &reservation.FinishedAt.Time
+	}
+
+	list := make([]ReservationEventResponse, len(events))
+	for i, event := range events {
+		list[i] = ReservationEventResponse{
+			Status:    event.Status,
+			Step:      event.Step,
+			CreatedAt: event.CreatedAt,
+		}
+
+		var end *time.Time
+		if i+1 < len(events) {
+			end = &events[i+1].CreatedAt
+		} else {
+			end = finishedAt
+		}
+		if end != nil {
+			duration := end.Sub(event.CreatedAt).Milliseconds()
+			list[i].DurationMs = &duration
+		}
+	}
+
+	return &ReservationTimelineResponse{
+		ReservationID: reservation.ID,
+		CreatedAt:     reservation.CreatedAt,
+		FinishedAt:    finishedAt,
+		Events:        list,
+	}
+}
+
 func (p *GCPReservationRequestPayload) Bind(_ *http.Request) error {
 	return nil
 }
 
+func NewReservationInstancesResponse(instances []*models.ReservationInstance) []InstanceResponse {
+	list := make([]InstanceResponse, len(instances))
+	for i, inst := range instances {
+		list[i] = InstanceResponse{InstanceID: inst.InstanceID, Detail: inst.Detail}
+	}
+	return list
+}
+
 func NewAWSReservationResponse(reservation *models.AWSReservation, instances []*models.ReservationInstance) render.Renderer {
+	return awsReservationResponse(reservation, instances)
+}
+
+// NewAWSReservationCreatedResponse returns response of a new reservation with the private key of
+// the generated key pair, if any.
+func NewAWSReservationCreatedResponse(reservation *models.AWSReservation, privateKey string) render.Renderer {
+	response := awsReservationResponse(reservation, nil)
+	response.PrivateKey = privateKey
+	return response
+}
+
+func awsReservationResponse(reservation *models.AWSReservation, instances []*models.ReservationInstance) *AWSReservationResponsePayload {
 	instancesResponse := make([]InstanceResponse, len(instances))
 	for iter, inst := range instances {
 		instancesResponse[iter] = InstanceResponse{InstanceID: inst.InstanceID, Detail: inst.Detail}
@@ -281,6 +480,7 @@ func NewAWSReservationResponse(reservation *models.AWSReservation, instances []*
 		PowerOff:         reservation.Detail.PowerOff,
 		Instances:        instancesResponse,
 		LaunchTemplateID: reservation.Detail.LaunchTemplateID,
+		PubkeyIDs:        reservation.Detail.PubkeyIDs,
 	}
 	if reservation.AWSReservationID != nil {
 		response.AWSReservationID = *reservation.AWSReservationID
@@ -289,6 +489,18 @@ func NewAWSReservationResponse(reservation *models.AWSReservation, instances []*
 }
 
 func NewAzureReservationResponse(reservation *models.AzureReservation, instances []*models.ReservationInstance) render.Renderer {
+	return azureReservationResponse(reservation, instances)
+}
+
+// NewAzureReservationCreatedResponse returns response of a new reservation with the private key of
+// the generated key pair, if any.
+func NewAzureReservationCreatedResponse(reservation *models.AzureReservation, privateKey string) render.Renderer {
+	response := azureReservationResponse(reservation, nil)
+	response.PrivateKey = privateKey
+	return response
+}
+
+func azureReservationResponse(reservation *models.AzureReservation, instances []*models.ReservationInstance) *AzureReservationResponsePayload {
 	instanceIds := make([]InstanceResponse, len(instances))
 	for iter, inst := range instances {
 		instanceIds[iter] = InstanceResponse{InstanceID: inst.InstanceID, Detail: inst.Detail}
@@ -305,11 +517,24 @@ func NewAzureReservationResponse(reservation *models.AzureReservation, instances
 		Name:         reservation.Detail.Name,
 		PowerOff:     reservation.Detail.PowerOff,
 		Instances:    instanceIds,
+		PubkeyIDs:    reservation.Detail.PubkeyIDs,
 	}
 	return &response
 }
 
 func NewGCPReservationResponse(reservation *models.GCPReservation, instances []*models.ReservationInstance) render.Renderer {
+	return gcpReservationResponse(reservation, instances)
+}
+
+// NewGCPReservationCreatedResponse returns response of a new reservation with the private key of
+// the generated key pair, if any.
+func NewGCPReservationCreatedResponse(reservation *models.GCPReservation, privateKey string) render.Renderer {
+	response := gcpReservationResponse(reservation, nil)
+	response.PrivateKey = privateKey
+	return response
+}
+
+func gcpReservationResponse(reservation *models.GCPReservation, instances []*models.ReservationInstance) *GCPReservationResponsePayload {
 	instanceIds := make([]InstanceResponse, len(instances))
 	for iter, inst := range instances {
 		instanceIds[iter] = InstanceResponse{
@@ -329,6 +554,7 @@ func NewGCPReservationResponse(reservation *models.GCPReservation, instances []*
 		ID:               reservation.ID,
 		PowerOff:         reservation.Detail.PowerOff,
 		Instances:        instanceIds,
+		PubkeyIDs:        reservation.Detail.PubkeyIDs,
 	}
 	return &response
 }
@@ -339,14 +565,6 @@ func NewNoopReservationResponse(reservation *models.NoopReservation) render.Rend
 	}
 }
 
-func NewReservationListResponse(reservations []*models.Reservation) []render.Renderer {
-	list := make([]render.Renderer, len(reservations))
-	for i, reservation := range reservations {
-		list[i] = reservationResponseMapper(reservation)
-	}
-	return list
-}
-
 func reservationResponseMapper(reservation *models.Reservation) *GenericReservationResponsePayload {
 	var finishedAt *time.Time
 	if reservation.FinishedAt.Valid {
@@ -356,16 +574,30 @@ func reservationResponseMapper(reservation *models.Reservation) *GenericReservat
 	if reservation.Success.Valid {
 		success = &reservation.Success.Bool
 	}
+	var launchAt *time.Time
+	if reservation.LaunchAt.Valid {
+		launchAt = &reservation.LaunchAt.Time
+	}
+	var parentID *int64
+	if reservation.ParentID.Valid {
+		parentID = &reservation.ParentID.Int64
+	}
 	return &GenericReservationResponsePayload{
-		ID:         reservation.ID,
-		Provider:   int(reservation.Provider),
-		CreatedAt:  reservation.CreatedAt,
-		FinishedAt: finishedAt,
-		Status:     reservation.Status,
-		Success:    success,
-		Steps:      reservation.Steps,
-		Step:       reservation.Step,
-		StepTitles: reservation.StepTitles,
-		Error:      reservation.Error,
+		ID:             reservation.ID,
+		Provider:       int(reservation.Provider),
+		CreatedAt:      reservation.CreatedAt,
+		FinishedAt:     finishedAt,
+		Status:         reservation.Status,
+		Success:        success,
+		Steps:          reservation.Steps,
+		Step:           reservation.Step,
+		StepTitles:     reservation.StepTitles,
+		Error:          reservation.Error,
+		Cancelled:      reservation.Cancelled,
+		LaunchAt:       launchAt,
+		ParentID:       parentID,
+		UserDataHash:   reservation.UserDataHash.String,
+		TeardownQueued: reservation.TeardownQueuedAt.Valid,
+		TeardownError:  reservation.TeardownError,
 	}
 }
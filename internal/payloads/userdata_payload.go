@@ -0,0 +1,36 @@
+package payloads
+
+import (
+	"net/http"
+
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/go-chi/render"
+)
+
+// UserDataPreviewResponse is user data rendered for a reservation request without launching it.
+type UserDataPreviewResponse struct {
+	// Provider type of the reservation request.
+	Provider string `json:"provider" yaml:"provider"`
+
+	// SHA-256 checksum of the user data, see user_data_hash of the reservation.
+	Hash string `json:"hash" yaml:"hash"`
+
+	// Rendered user data as sent to the provider. For GCP it is the user supplied user data.
+	UserData string `json:"user_data" yaml:"user_data"`
+
+	// Generated startup script, only for GCP which passes it separately from user data.
+	StartupScript string `json:"startup_script,omitempty" yaml:"startup_script,omitempty"`
+}
+
+func (p *UserDataPreviewResponse) Render(_ http.ResponseWriter, _ *http.Request) error {
+	return nil
+}
+
+func NewUserDataPreviewResponse(provider models.ProviderType, hash string, userData, startupScript []byte) render.Renderer {
+	return &UserDataPreviewResponse{
+		Provider:      provider.String(),
+		Hash:          hash,
+		UserData:      string(userData),
+		StartupScript: string(startupScript),
+	}
+}
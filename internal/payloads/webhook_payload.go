@@ -0,0 +1,111 @@
+package payloads
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/go-chi/render"
+)
+
+// See models.Webhook
+type WebhookRequest struct {
+	// Target HTTP or HTTPS URL, required on create.
+	URL string `json:"url" yaml:"url"`
+
+	// Optional secret for signature of requests, at least 16 characters. A random secret is
+	// generated on create when not provided, the secret is kept on update when not provided.
+	Secret string `json:"secret,omitempty" yaml:"secret,omitempty"`
+
+	// Disabled webhooks receive no notifications. Enabled by default on create, kept on update
+	// when not provided.
+	Enabled *bool `json:"enabled,omitempty" yaml:"enabled,omitempty" nullable:"true"`
+}
+
+// See models.Webhook
+type WebhookResponse struct {
+	ID        int64     `json:"id" yaml:"id"`
+	URL       string    `json:"url" yaml:"url"`
+	Enabled   bool      `json:"enabled" yaml:"enabled"`
+	CreatedAt time.Time `json:"created_at" yaml:"created_at"`
+
+	// Secret is only returned when the webhook is created or the secret is changed.
+	Secret string `json:"secret,omitempty" yaml:"secret,omitempty"`
+}
+
+// See models.WebhookDelivery
+type WebhookDeliveryResponse struct {
+	ID            int64     `json:"id" yaml:"id"`
+	ReservationID int64     `json:"reservation_id" yaml:"reservation_id"`
+	Event         string    `json:"event" yaml:"event"`
+	Attempt       int       `json:"attempt" yaml:"attempt"`
+	StatusCode    int       `json:"status_code" yaml:"status_code"`
+	Success       bool      `json:"success" yaml:"success"`
+	Error         string    `json:"error,omitempty" yaml:"error,omitempty"`
+	CreatedAt     time.Time `json:"created_at" yaml:"created_at"`
+}
+
+func (p *WebhookRequest) Bind(_ *http.Request) error {
+	return nil
+}
+
+func (p *WebhookResponse) Render(_ http.ResponseWriter, _ *http.Request) error {
+	return nil
+}
+
+// NewModel returns a new webhook, enabled unless disabled explicitly.
+func (p *WebhookRequest) NewModel() *models.Webhook {
+	enabled := true
+	if p.Enabled != nil {
+		enabled = *p.Enabled
+	}
+	return &models.Webhook{
+		URL:     p.URL,
+		Secret:  p.Secret,
+		Enabled: enabled,
+	}
+}
+
+// Apply updates the webhook with the provided fields.
+func (p *WebhookRequest) Apply(webhook *models.Webhook) {
+	if p.URL != "" {
+		webhook.URL = p.URL
+	}
+	if p.Secret != "" {
+		webhook.Secret = p.Secret
+	}
+	if p.Enabled != nil {
+		webhook.Enabled = *p.Enabled
+	}
+}
+
+// NewWebhookResponse returns webhook response, the secret is included only when withSecret is set.
+func NewWebhookResponse(webhook *models.Webhook, withSecret bool) render.Renderer {
+	response := webhookResponseMapper(webhook)
+	if withSecret {
+		response.Secret = webhook.Secret
+	}
+	return response
+}
+
+func webhookResponseMapper(webhook *models.Webhook) *WebhookResponse {
+	return &WebhookResponse{
+		ID:        webhook.ID,
+		URL:       webhook.URL,
+		Enabled:   webhook.Enabled,
+		CreatedAt: webhook.CreatedAt,
+	}
+}
+
+func webhookDeliveryResponseMapper(delivery *models.WebhookDelivery) *WebhookDeliveryResponse {
+	return &WebhookDeliveryResponse{
+		ID:            delivery.ID,
+		ReservationID: delivery.ReservationID,
+		Event:         delivery.Event,
+		Attempt:       delivery.Attempt,
+		StatusCode:    delivery.StatusCode,
+		Success:       delivery.Success,
+		Error:         delivery.Error,
+		CreatedAt:     delivery.CreatedAt,
+	}
+}
@@ -0,0 +1,85 @@
+// Package publicnet checks that hosts and addresses of outgoing connections are on the public
+// internet. It protects requests to user supplied URLs from reaching internal services.
+package publicnet
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+	"syscall"
+)
+
+var ErrNonPublicAddress = errors.New("address is not public")
+
+// nonPublicPrefixes are special-purpose ranges not covered by netip.Addr methods.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("fec0::/10"),
+}
+
+// internalHostSuffixes are domains which never resolve to public addresses.
+var internalHostSuffixes = []string{
+	".localhost",
+	".local",
+	".internal",
+	".localdomain",
+	".svc",
+}
+
+// IsPublicIP returns false for loopback, private, link-local, multicast and other special-purpose
+// addresses. IPv4-mapped IPv6 addresses are checked as IPv4.
+func IsPublicIP(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() ||
+		addr.IsUnspecified() ||
+		addr.IsLoopback() ||
+		addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() ||
+		addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsInternalHost returns true for IP literals which are not public and for hostnames which
+// obviously point into an internal network: localhost, single-label names resolved via search
+// domains and internal domains. Hostnames are not resolved, use Control to check addresses
+// of established connections.
+func IsInternalHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return !IsPublicIP(addr)
+	}
+	if host == "localhost" || !strings.Contains(host, ".") {
+		return true
+	}
+	for _, suffix := range internalHostSuffixes {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Control is a net.Dialer control function which refuses connections to non-public addresses.
+// It is called after DNS resolution, so hostnames resolving to internal addresses are refused too.
+func Control(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("cannot parse dial address %s: %w", address, err)
+	}
+	if !IsPublicIP(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrNonPublicAddress, addrPort.Addr())
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package publicnet_test
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/RHEnVision/provisioning-backend/internal/publicnet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	for _, addr := range []string{"1.1.1.1", "93.184.216.34", "2606:2800:220:1:248:1893:25c8:1946"} {
+		assert.True(t, publicnet.IsPublicIP(netip.MustParseAddr(addr)), "%s must be public", addr)
+	}
+
+	for _, addr := range []string{
+		"0.0.0.0",
+		"127.0.0.1",
+		"10.1.2.3",
+		"172.16.0.1",
+		"192.168.1.1",
+		"169.254.169.254",
+		"100.64.0.1",
+		"224.0.0.1",
+		"255.255.255.255",
+		"::",
+		"::1",
+		"fd00::1",
+		"fe80::1",
+		"::ffff:127.0.0.1",
+		"::ffff:169.254.169.254",
+	} {
+		assert.False(t, publicnet.IsPublicIP(netip.MustParseAddr(addr)), "%s must not be public", addr)
+	}
+}
+
+func TestIsInternalHost(t *testing.T) {
+	for _, host := range []string{"example.com", "hooks.example.com.", "1.1.1.1"} {
+		assert.False(t, publicnet.IsInternalHost(host), "%s must not be internal", host)
+	}
+
+	for _, host := range []string{
+		"localhost",
+		"LOCALHOST.",
+		"app.localhost",
+		"redis",
+		"printer.local",
+		"metadata.google.internal",
+		"sources-api.svc",
+		"10.0.0.1",
+		"::1",
+	} {
+		assert.True(t, publicnet.IsInternalHost(host), "%s must be internal", host)
+	}
+}
+
+func TestControl(t *testing.T) {
+	require.NoError(t, publicnet.Control("tcp", "1.1.1.1:443", nil))
+	require.NoError(t, publicnet.Control("tcp6", "[2606:4700:4700::1111]:443", nil))
+	require.ErrorIs(t, publicnet.Control("tcp", "127.0.0.1:80", nil), publicnet.ErrNonPublicAddress)
+	require.ErrorIs(t, publicnet.Control("tcp6", "[fe80::1%eth0]:80", nil), publicnet.ErrNonPublicAddress)
+	require.Error(t, publicnet.Control("tcp", "invalid", nil))
+}
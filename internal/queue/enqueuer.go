@@ -2,8 +2,52 @@ package queue
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
+	"github.com/RHEnVision/provisioning-backend/internal/models"
 	"github.com/RHEnVision/provisioning-backend/pkg/worker"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 var GetEnqueuer func(ctx context.Context) worker.JobEnqueuer
+
+var GetCanceller func(ctx context.Context) worker.JobCanceller
+
+// EnqueueTx enqueues the job within the database transaction and returns true. When the
+// configured queue is not transactional, the job is stored in the outbox within the transaction
+// and published to the queue by the outbox relay after the transaction is committed. When there
+// is no transaction, nothing is enqueued and false is returned, the caller must enqueue the job.
+func EnqueueTx(ctx context.Context, tx pgx.Tx, job *worker.Job) (bool, error) {
+	if tx == nil {
+		return false, nil
+	}
+
+	if txEnqueuer, ok := GetEnqueuer(ctx).(worker.TxJobEnqueuer); ok {
+		err := txEnqueuer.EnqueueTx(ctx, tx, job)
+		if err != nil {
+			return false, fmt.Errorf("unable to enqueue job in transaction: %w", err)
+		}
+		return true, nil
+	}
+
+	if job.ID == uuid.Nil {
+		job.ID = uuid.New()
+	}
+	payload, err := worker.EncodeJob(job)
+	if err != nil {
+		return false, fmt.Errorf("unable to encode outbox job: %w", err)
+	}
+
+	outboxJob := &models.OutboxJob{
+		JobID:   job.ID,
+		JobType: job.Type.String(),
+		Payload: payload,
+	}
+	err = dao.GetOutboxDao(ctx).CreateTx(ctx, tx, outboxJob)
+	if err != nil {
+		return false, fmt.Errorf("unable to store outbox job: %w", err)
+	}
+	return true, nil
+}
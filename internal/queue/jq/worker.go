@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/RHEnVision/provisioning-backend/internal/config"
+	"github.com/RHEnVision/provisioning-backend/internal/db"
 	"github.com/RHEnVision/provisioning-backend/internal/jobs"
 	"github.com/RHEnVision/provisioning-backend/internal/queue"
 	"github.com/RHEnVision/provisioning-backend/pkg/worker"
@@ -12,16 +13,27 @@ import (
 )
 
 var (
-	enqueuer worker.JobEnqueuer
-	workers  worker.JobWorker
+	enqueuer  worker.JobEnqueuer
+	canceller worker.JobCanceller
+	workers   worker.JobWorker
 )
 
+func init() {
+	// do not replace the job queue stub in tests which import this package indirectly
+	if queue.GetEnqueuer == nil {
+		queue.GetEnqueuer = getEnqueuer
+	}
+	if queue.GetCanceller == nil {
+		queue.GetCanceller = getCanceller
+	}
+}
+
 func getEnqueuer(_ context.Context) worker.JobEnqueuer {
 	return enqueuer
 }
 
-func init() {
-	queue.GetEnqueuer = getEnqueuer
+func getCanceller(_ context.Context) worker.JobCanceller {
+	return canceller
 }
 
 func RegisterJobs(logger *zerolog.Logger) {
@@ -30,6 +42,13 @@ func RegisterJobs(logger *zerolog.Logger) {
 	workers.RegisterHandler(jobs.TypeLaunchInstanceAws, jobs.HandleLaunchInstanceAWS, jobs.LaunchInstanceAWSTaskArgs{})
 	workers.RegisterHandler(jobs.TypeLaunchInstanceAzure, jobs.HandleLaunchInstanceAzure, jobs.LaunchInstanceAzureTaskArgs{})
 	workers.RegisterHandler(jobs.TypeLaunchInstanceGcp, jobs.HandleLaunchInstanceGCP, jobs.LaunchInstanceGCPTaskArgs{})
+	workers.RegisterHandler(jobs.TypeTerminateInstances, jobs.HandleTerminateInstances, jobs.TerminateInstancesTaskArgs{})
+	workers.RegisterHandler(jobs.TypeCleanupReservations, jobs.HandleCleanupReservations, jobs.CleanupReservationsTaskArgs{})
+	workers.RegisterHandler(jobs.TypeDeliverWebhook, jobs.HandleDeliverWebhook, jobs.DeliverWebhookTaskArgs{})
+
+	for jobType, policy := range jobs.RetryPolicies {
+		workers.RegisterRetryPolicy(jobType, policy)
+	}
 }
 
 func Initialize(_ context.Context, logger *zerolog.Logger) error {
@@ -39,16 +58,23 @@ func Initialize(_ context.Context, logger *zerolog.Logger) error {
 	case "memory":
 		wk := worker.NewMemoryClient()
 		enqueuer = wk
+		canceller = wk
 		workers = wk
 	case "redis":
 		wk, err := worker.NewRedisWorker(config.RedisHostAndPort(),
 			config.Application.Cache.Redis.User, config.Application.Cache.Redis.Password,
 			config.Application.Cache.Redis.DB, "provisioning-job-queue",
-			config.Worker.PollInterval, config.Worker.Concurrency)
+			config.Worker.PollInterval, config.Worker.HeartbeatInterval, config.Worker.Concurrency)
 		if err != nil {
 			return fmt.Errorf("cannot initialize redis worker queue: %w", err)
 		}
 		enqueuer = wk
+		canceller = wk
+		workers = wk
+	case "postgres":
+		wk := worker.NewPostgresWorker(db.Pool, config.Worker.PollInterval, config.Worker.Concurrency)
+		enqueuer = wk
+		canceller = wk
 		workers = wk
 	default:
 		panic("unknown WORKER_QUEUE setting, expected values: memory, redis, postgres")
@@ -9,6 +9,7 @@ import (
 
 	"github.com/RHEnVision/provisioning-backend/internal/queue"
 	"github.com/RHEnVision/provisioning-backend/pkg/worker"
+	"github.com/google/uuid"
 )
 
 type enqueueCtxKeyType string
@@ -20,11 +21,13 @@ var enqueueCtxKey enqueueCtxKeyType = "enqueuer-stub"
 type hollowEnqueuer struct{}
 
 type stubEnqueuer struct {
-	enqueued []*worker.Job
+	enqueued  []*worker.Job
+	cancelled []uuid.UUID
 }
 
 func init() {
 	queue.GetEnqueuer = getEnqueuer
+	queue.GetCanceller = getCanceller
 }
 
 // WithEnqueuer returns new context with Job enqueue struct that keeps the jobs
@@ -38,6 +41,19 @@ func EnqueuedJobs(ctx context.Context) []*worker.Job {
 	return enquer.enqueued
 }
 
+// CancelledJobs returns IDs of all jobs cancelled via the stub
+func CancelledJobs(ctx context.Context) []uuid.UUID {
+	enquer := getEnqueuerStub(ctx)
+	return enquer.cancelled
+}
+
+func getCanceller(ctx context.Context) worker.JobCanceller {
+	if enqueue := getEnqueuerStub(ctx); enqueue != nil {
+		return enqueue
+	}
+	return hollowEnqueuer{}
+}
+
 func getEnqueuer(ctx context.Context) worker.JobEnqueuer {
 	if enqueue := getEnqueuerStub(ctx); enqueue != nil {
 		return enqueue
@@ -61,3 +77,13 @@ func (s *stubEnqueuer) Enqueue(ctx context.Context, job *worker.Job) error {
 	s.enqueued = append(s.enqueued, job)
 	return nil
 }
+
+// Cancel of hollow - default - enqueuer just ignores all cancel requests.
+func (h hollowEnqueuer) Cancel(_ context.Context, _ uuid.UUID) error {
+	return nil
+}
+
+func (s *stubEnqueuer) Cancel(ctx context.Context, id uuid.UUID) error {
+	s.cancelled = append(s.cancelled, id)
+	return nil
+}
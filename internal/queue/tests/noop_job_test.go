@@ -56,7 +56,7 @@ func TestRedisNoopSuccess(t *testing.T) {
 			Status:     "Created",
 		},
 	}
-	err := reservationDao.CreateNoop(ctx, res)
+	err := reservationDao.CreateNoop(ctx, res, nil)
 	require.NoError(t, err)
 	require.NotZero(t, res.ID)
 
@@ -94,7 +94,7 @@ func TestRedisNoopFailure(t *testing.T) {
 			Status:     "Created",
 		},
 	}
-	err := reservationDao.CreateNoop(ctx, res)
+	err := reservationDao.CreateNoop(ctx, res, nil)
 	require.NoError(t, err)
 	require.NotZero(t, res.ID)
 
@@ -132,7 +132,7 @@ func TestRedisNoopTimeout(t *testing.T) {
 			Status:     "Created",
 		},
 	}
-	err := reservationDao.CreateNoop(ctx, res)
+	err := reservationDao.CreateNoop(ctx, res, nil)
 	require.NoError(t, err)
 	require.NotZero(t, res.ID)
 
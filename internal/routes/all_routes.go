@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/RHEnVision/provisioning-backend/api"
+	"github.com/RHEnVision/provisioning-backend/internal/cache"
 	"github.com/RHEnVision/provisioning-backend/internal/middleware"
 	"github.com/RHEnVision/provisioning-backend/internal/preload"
 	s "github.com/RHEnVision/provisioning-backend/internal/services"
@@ -56,6 +57,7 @@ func MountAPI(r *chi.Mux) {
 		r.Use(render.SetContentType(render.ContentTypeJSON))
 
 		r.Use(middleware.EnforceIdentity)
+		r.Use(middleware.RateLimitMiddleware(cache.TakeToken))
 		r.Use(middleware.AccountMiddleware)
 
 		// OpenAPI documented and supported routes
@@ -81,12 +83,25 @@ func MountAPI(r *chi.Mux) {
 			r.Get("/", s.ListPubkeys)
 			r.Route("/{ID}", func(r chi.Router) {
 				r.Get("/", s.GetPubkey)
+				r.Patch("/", s.UpdatePubkey)
 				r.Delete("/", s.DeletePubkey)
+				r.Get("/resources", s.ListPubkeyResources)
+			})
+		})
+
+		r.Route("/launch_profiles", func(r chi.Router) {
+			r.Post("/", s.CreateLaunchProfile)
+			r.Get("/", s.ListLaunchProfiles)
+			r.Route("/{ID}", func(r chi.Router) {
+				r.Get("/", s.GetLaunchProfile)
+				r.Patch("/", s.UpdateLaunchProfile)
+				r.Delete("/", s.DeleteLaunchProfile)
 			})
 		})
 
 		r.Route("/reservations", func(r chi.Router) {
 			r.Get("/", s.ListReservations)
+			r.Post("/from_profile/{ID}", s.CreateReservationFromProfile)
 			// Different types do have different payloads, therefore TYPE must be part of
 			// URL and not a URL (filter) parameter.
 			r.Route("/{TYPE}", func(r chi.Router) {
@@ -95,6 +110,24 @@ func MountAPI(r *chi.Mux) {
 			})
 			// Generic reservation detail request (no details provided)
 			r.Get("/{ID}", s.GetReservationDetail)
+			r.Get("/{ID}/events", s.StreamReservationEvents)
+			r.Get("/{ID}/timeline", s.GetReservationTimeline)
+			r.Delete("/{ID}/instances", s.DeleteReservationInstances)
+			r.Post("/{ID}/instances/{INSTANCE_ID}/power", s.PowerReservationInstance)
+			r.Post("/{ID}/cancel", s.CancelReservation)
+		})
+
+		r.Post("/userdata/preview/{TYPE}", s.PreviewUserData)
+
+		r.Route("/webhooks", func(r chi.Router) {
+			r.Post("/", s.CreateWebhook)
+			r.Get("/", s.ListWebhooks)
+			r.Route("/{ID}", func(r chi.Router) {
+				r.Get("/", s.GetWebhook)
+				r.Patch("/", s.UpdateWebhook)
+				r.Delete("/", s.DeleteWebhook)
+				r.Get("/deliveries", s.ListWebhookDeliveries)
+			})
 		})
 
 		r.Route("/availability_status", func(r chi.Router) {
@@ -144,4 +177,19 @@ func MountAPI(r *chi.Mux) {
 			r.Head("/", s.FeatureFlagService)
 		})
 	})
+
+	// Administration routes for Red Hat associates are not published through OpenAPI. There
+	// is no account of the caller, accounts are referenced by organization ID.
+	r.Group(func(r chi.Router) {
+		r.Use(render.SetContentType(render.ContentTypeJSON))
+
+		r.Use(middleware.EnforceIdentity)
+		r.Use(middleware.EnforceAdmin)
+
+		// Launch limits of an account, see QUOTA_* configuration for defaults.
+		r.Route("/admin/accounts/{ORG_ID}/quota", func(r chi.Router) {
+			r.Get("/", s.GetAccountQuota)
+			r.Put("/", s.UpdateAccountQuota)
+		})
+	})
 }
@@ -0,0 +1,79 @@
+// Package secrets encrypts secrets of users which are stored in the database until they are
+// needed, like rhc activation keys stored until a reservation is launched.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/RHEnVision/provisioning-backend/internal/config"
+)
+
+var (
+	ErrNotConfigured = errors.New("secrets key is not configured")
+	ErrInvalidKey    = errors.New("secrets key must be 32 bytes encoded in base64")
+	ErrDecrypt       = errors.New("cannot decrypt secret")
+)
+
+// Configured returns true when secrets can be encrypted.
+func Configured() bool {
+	return config.Application.SecretsKey != ""
+}
+
+func newAEAD() (cipher.AEAD, error) {
+	if !Configured() {
+		return nil, ErrNotConfigured
+	}
+	key, err := base64.StdEncoding.DecodeString(config.Application.SecretsKey)
+	if err != nil || len(key) != 32 {
+		return nil, ErrInvalidKey
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create cipher: %w", err)
+	}
+	return aead, nil
+}
+
+// Encrypt returns base64 encoded AES-256-GCM ciphertext of the secret prefixed with a random
+// nonce. Returns ErrNotConfigured when there is no secrets key.
+func Encrypt(secret string) (string, error) {
+	aead, err := newAEAD()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("cannot generate nonce: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(secret), nil)), nil
+}
+
+// Decrypt returns the secret of a ciphertext created by Encrypt. Returns ErrDecrypt when the
+// ciphertext is invalid or was encrypted with a different key.
+func Decrypt(ciphertext string) (string, error) {
+	aead, err := newAEAD()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil || len(data) < aead.NonceSize() {
+		return "", ErrDecrypt
+	}
+	secret, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	return string(secret), nil
+}
@@ -0,0 +1,51 @@
+package secrets_test
+
+import (
+	"testing"
+
+	"github.com/RHEnVision/provisioning-backend/internal/config"
+	"github.com/RHEnVision/provisioning-backend/internal/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func withKey(t *testing.T, key string) {
+	t.Helper()
+	previous := config.Application.SecretsKey
+	config.Application.SecretsKey = key
+	t.Cleanup(func() { config.Application.SecretsKey = previous })
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	withKey(t, "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+
+	ciphertext, err := secrets.Encrypt("lab-key")
+	require.NoError(t, err)
+	assert.NotContains(t, ciphertext, "lab-key")
+
+	again, err := secrets.Encrypt("lab-key")
+	require.NoError(t, err)
+	assert.NotEqual(t, ciphertext, again, "nonce must be random")
+
+	secret, err := secrets.Decrypt(ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, "lab-key", secret)
+
+	_, err = secrets.Decrypt("bGFiLWtleQ==")
+	require.ErrorIs(t, err, secrets.ErrDecrypt)
+
+	withKey(t, "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA=")
+	_, err = secrets.Decrypt(ciphertext)
+	require.ErrorIs(t, err, secrets.ErrDecrypt, "other key must not decrypt")
+}
+
+func TestEncryptNotConfigured(t *testing.T) {
+	withKey(t, "")
+	assert.False(t, secrets.Configured())
+	_, err := secrets.Encrypt("lab-key")
+	require.ErrorIs(t, err, secrets.ErrNotConfigured)
+
+	withKey(t, "c2hvcnQ=")
+	_, err = secrets.Encrypt("lab-key")
+	require.ErrorIs(t, err, secrets.ErrInvalidKey)
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"database/sql"
 	"fmt"
 	"net/http"
 	"strings"
@@ -15,8 +16,11 @@ import (
 	"github.com/RHEnVision/provisioning-backend/internal/payloads"
 	"github.com/RHEnVision/provisioning-backend/internal/preload"
 	"github.com/RHEnVision/provisioning-backend/internal/queue"
+	"github.com/RHEnVision/provisioning-backend/internal/userdata"
 	"github.com/RHEnVision/provisioning-backend/pkg/worker"
 	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/rs/zerolog"
 )
 
@@ -32,6 +36,12 @@ func CreateAWSReservation(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	launchAt, laErr := parseLaunchAt(payload.LaunchAt)
+	if laErr != nil {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "invalid launch time", laErr))
+		return
+	}
+
 	rDao := dao.GetReservationDao(r.Context())
 	pkDao := dao.GetPubkeyDao(r.Context())
 
@@ -83,9 +93,31 @@ func CreateAWSReservation(w http.ResponseWriter, r *http.Request) {
 	reservation.Provider = models.ProviderTypeAWS
 	reservation.Steps = 3
 	reservation.StepTitles = []string{"Ensure public key", "Launch instance(s)", "Fetch instance(s) description"}
+	reservation.JobID = uuid.NullUUID{UUID: uuid.New(), Valid: true}
+	reservation.LaunchAt = launchAt
+	reservation.IdempotencyKey = idempotencyKey(r)
+	reservation.ParentID = parentReservationID(r)
 	newName := config.Application.InstancePrefix + payload.Name
 	reservation.Detail.Name = &newName
 
+	keys, ok := additionalPubkeys(w, r, payload.PubkeyID, payload.PubkeyIDs, payload.GenerateKeyPair)
+	if !ok {
+		return
+	}
+	reservation.Detail.PubkeyIDs = keys.PubkeyIDs
+
+	input := awsUserData(payload, keys.Bodies)
+	userData, ok := generateUserData(w, r, input)
+	if !ok {
+		return
+	}
+	reservation.Detail.LaunchInput, ok = launchInput(w, r, input)
+	if !ok {
+		return
+	}
+	reservation.Detail.SSHKeys = keys.Bodies
+	reservation.UserDataHash = sql.NullString{String: userdata.Hash(userData), Valid: true}
+
 	// validate pubkey - must be always present because of data integrity (foreign keys)
 	logger.Debug().Msgf("Validating existence of pubkey %d for this account", reservation.PubkeyID)
 	pk, err := pkDao.GetById(r.Context(), reservation.PubkeyID)
@@ -96,14 +128,6 @@ func CreateAWSReservation(w http.ResponseWriter, r *http.Request) {
 	}
 	logger.Debug().Msgf("Found pubkey %d named '%s'", pk.ID, pk.Name)
 
-	// create reservation in the database
-	err = rDao.CreateAWS(r.Context(), reservation)
-	if err != nil {
-		renderError(w, r, payloads.NewDAOError(r.Context(), "create reservation", err))
-		return
-	}
-	logger.Debug().Msgf("Created a new reservation %d", reservation.ID)
-
 	// Get Sources client
 	sourcesClient, err := clients.GetSourcesClient(r.Context())
 	if err != nil {
@@ -144,31 +168,72 @@ func CreateAWSReservation(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	launchJob := worker.Job{
-		Type:      jobs.TypeLaunchInstanceAws,
-		Identity:  id,
-		AccountID: accountId,
-		Args: jobs.LaunchInstanceAWSTaskArgs{
-			ReservationID:    reservation.ID,
-			Region:           reservation.Detail.Region,
-			PubkeyID:         pk.ID,
-			SourceID:         reservation.SourceID,
-			Detail:           reservation.Detail,
-			AMI:              ami,
-			LaunchTemplateID: reservation.Detail.LaunchTemplateID,
-			ARN:              authentication,
-		},
+	if !enforceQuota(w, r, models.ProviderTypeAWS, int64(reservation.Detail.Amount)) {
+		return
 	}
 
-	err = queue.GetEnqueuer(r.Context()).Enqueue(r.Context(), &launchJob)
+	// launch input is read from the reservation by the job, secrets are not kept in job rows
+	argsDetail := *reservation.Detail
+	argsDetail.LaunchInput = models.LaunchInput{}
+
+	// create reservation in the database, transactional queues enqueue the job in the same transaction
+	var launchJob worker.Job
+	var enqueued bool
+	err = rDao.CreateAWS(r.Context(), reservation, func(tx pgx.Tx) error {
+		if txErr := checkQuotaTx(r.Context(), tx, models.ProviderTypeAWS); txErr != nil {
+			return txErr
+		}
+		launchJob = worker.Job{
+			ID:        reservation.JobID.UUID,
+			Type:      jobs.TypeLaunchInstanceAws,
+			Identity:  id,
+			AccountID: accountId,
+			RunAt:     reservation.LaunchAt.Time,
+			Args: jobs.LaunchInstanceAWSTaskArgs{
+				ReservationID:    reservation.ID,
+				Region:           reservation.Detail.Region,
+				PubkeyID:         pk.ID,
+				SourceID:         reservation.SourceID,
+				Detail:           &argsDetail,
+				AMI:              ami,
+				LaunchTemplateID: reservation.Detail.LaunchTemplateID,
+				ARN:              authentication,
+			},
+		}
+
+		var txErr error
+		enqueued, txErr = queue.EnqueueTx(r.Context(), tx, &launchJob)
+		return txErr
+	})
 	if err != nil {
-		renderError(w, r, payloads.NewEnqueueTaskError(r.Context(), "job enqueue error", err))
+		renderCreateReservationError(w, r, models.ProviderTypeAWS, err, "create reservation")
 		return
 	}
+	logger.Debug().Msgf("Created a new reservation %d", reservation.ID)
+
+	if !enqueued {
+		err = queue.GetEnqueuer(r.Context()).Enqueue(r.Context(), &launchJob)
+		if err != nil {
+			renderError(w, r, payloads.NewEnqueueTaskError(r.Context(), "job enqueue error", err))
+			return
+		}
+	}
 
 	// Return response payload
-	unused := make([]*models.ReservationInstance, 0, 0)
-	if err := render.Render(w, r, payloads.NewAWSReservationResponse(reservation, unused)); err != nil {
+	if err := render.Render(w, r, payloads.NewAWSReservationCreatedResponse(reservation, keys.PrivateKey)); err != nil {
 		renderError(w, r, payloads.NewRenderError(r.Context(), "unable to render AWS reservation", err))
 	}
 }
+
+// awsUserData returns user data input of AWS reservation request with additional pubkeys.
+func awsUserData(payload *payloads.AWSReservationRequestPayload, authorizedKeys []string) *userdata.UserData {
+	return &userdata.UserData{
+		Type:              models.ProviderTypeAWS,
+		PowerOff:          payload.PowerOff,
+		InsightsTags:      true,
+		Custom:            []byte(payload.UserData),
+		RHCActivationKey:  payload.RHCActivationKey,
+		RHCOrgID:          payload.RHCOrgID,
+		SSHAuthorizedKeys: authorizedKeys,
+	}
+}
@@ -6,16 +6,26 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	Clientstubs "github.com/RHEnVision/provisioning-backend/internal/clients/stubs"
+	"github.com/RHEnVision/provisioning-backend/internal/config"
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
 	"github.com/RHEnVision/provisioning-backend/internal/dao/stubs"
+	"github.com/RHEnVision/provisioning-backend/internal/jobs"
+	"github.com/RHEnVision/provisioning-backend/internal/payloads"
+	"github.com/RHEnVision/provisioning-backend/internal/queue/stub"
+	"github.com/RHEnVision/provisioning-backend/internal/secrets"
 	"github.com/RHEnVision/provisioning-backend/internal/services"
 	"github.com/RHEnVision/provisioning-backend/internal/testing/factories"
 	"github.com/RHEnVision/provisioning-backend/internal/testing/identity"
 	_ "github.com/RHEnVision/provisioning-backend/internal/testing/initialization"
+	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	cryptossh "golang.org/x/crypto/ssh"
 )
 
 func TestCreateAWSReservationHandler(t *testing.T) {
@@ -82,4 +92,347 @@ func TestCreateAWSReservationHandler(t *testing.T) {
 		assert.Contains(t, rr.Body.String(), "Unsupported region")
 		require.Equal(t, http.StatusBadRequest, rr.Code, "Handler returned wrong status code")
 	})
+
+	t.Run("scheduled reservation", func(t *testing.T) {
+		var err error
+		values := map[string]interface{}{
+			"source_id":     "1",
+			"image_id":      "2bc640f6-927a-404a-9594-5b2da7e06608",
+			"amount":        1,
+			"instance_type": "t1.micro",
+			"pubkey_id":     pk.ID,
+			"launch_at":     time.Now().Add(time.Hour),
+		}
+		if json_data, err = json.Marshal(values); err != nil {
+			t.Fatalf("unable to marshal values to json: %v", err)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "POST", "/api/provisioning/reservations/aws", bytes.NewBuffer(json_data))
+		require.NoError(t, err, "failed to create request")
+		req.Header.Add("Content-Type", "application/json")
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(services.CreateAWSReservation)
+		handler.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code, "Handler returned wrong status code")
+
+		stubCount := stubs.AWSReservationStubCount(ctx)
+		assert.Equal(t, 2, stubCount, "Reservation has not been created through DAO")
+	})
+
+	t.Run("failed reservation with launch time in the past", func(t *testing.T) {
+		var err error
+		values := map[string]interface{}{
+			"source_id":     "1",
+			"image_id":      "2bc640f6-927a-404a-9594-5b2da7e06608",
+			"amount":        1,
+			"instance_type": "t1.micro",
+			"pubkey_id":     pk.ID,
+			"launch_at":     time.Now().Add(-time.Hour),
+		}
+		if json_data, err = json.Marshal(values); err != nil {
+			t.Fatalf("unable to marshal values to json: %v", err)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "POST", "/api/provisioning/reservations/aws", bytes.NewBuffer(json_data))
+		require.NoError(t, err, "failed to create request")
+		req.Header.Add("Content-Type", "application/json")
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(services.CreateAWSReservation)
+		handler.ServeHTTP(rr, req)
+
+		assert.Contains(t, rr.Body.String(), "invalid launch time")
+		require.Equal(t, http.StatusBadRequest, rr.Code, "Handler returned wrong status code")
+	})
+
+	t.Run("reservation with user data", func(t *testing.T) {
+		var err error
+		values := map[string]interface{}{
+			"source_id":     "1",
+			"image_id":      "2bc640f6-927a-404a-9594-5b2da7e06608",
+			"amount":        1,
+			"instance_type": "t1.micro",
+			"pubkey_id":     pk.ID,
+			"user_data":     "#cloud-config\npackages:\n- vim\n",
+		}
+		if json_data, err = json.Marshal(values); err != nil {
+			t.Fatalf("unable to marshal values to json: %v", err)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "POST", "/api/provisioning/reservations/aws", bytes.NewBuffer(json_data))
+		require.NoError(t, err, "failed to create request")
+		req.Header.Add("Content-Type", "application/json")
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(services.CreateAWSReservation)
+		handler.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code, "Handler returned wrong status code")
+
+		var result payloads.AWSReservationResponsePayload
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&result), "failed to decode response body")
+		reservation, err := dao.GetReservationDao(ctx).GetAWSById(ctx, result.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "#cloud-config\npackages:\n- vim\n", string(reservation.Detail.UserData), "Rendered user data must not be stored")
+		assert.True(t, reservation.UserDataHash.Valid)
+	})
+
+	t.Run("failed reservation with invalid user data", func(t *testing.T) {
+		var err error
+		values := map[string]interface{}{
+			"source_id":     "1",
+			"image_id":      "2bc640f6-927a-404a-9594-5b2da7e06608",
+			"amount":        1,
+			"instance_type": "t1.micro",
+			"pubkey_id":     pk.ID,
+			"user_data":     "packages: [vim]",
+		}
+		if json_data, err = json.Marshal(values); err != nil {
+			t.Fatalf("unable to marshal values to json: %v", err)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "POST", "/api/provisioning/reservations/aws", bytes.NewBuffer(json_data))
+		require.NoError(t, err, "failed to create request")
+		req.Header.Add("Content-Type", "application/json")
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(services.CreateAWSReservation)
+		handler.ServeHTTP(rr, req)
+
+		assert.Contains(t, rr.Body.String(), "user data")
+		require.Equal(t, http.StatusBadRequest, rr.Code, "Handler returned wrong status code")
+	})
+}
+
+func TestCreateAWSReservationRHCRegistration(t *testing.T) {
+	ctx := stubs.WithAccountDaoOne(context.Background())
+	ctx = identity.WithTenant(t, ctx)
+	ctx = Clientstubs.WithSourcesClient(ctx)
+	ctx = Clientstubs.WithImageBuilderClient(ctx)
+	ctx = stubs.WithReservationDao(ctx)
+	ctx = stubs.WithPubkeyDao(ctx)
+	ctx = stub.WithEnqueuer(ctx)
+	pk := factories.NewPubkeyRSA()
+	err := stubs.AddPubkey(ctx, pk)
+	require.NoError(t, err, "failed to generate pubkey")
+	secretsKey := config.Application.SecretsKey
+	config.Application.SecretsKey = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	defer func() { config.Application.SecretsKey = secretsKey }()
+
+	createReservation := func(t *testing.T, activationKey, orgID string) *httptest.ResponseRecorder {
+		t.Helper()
+		values := map[string]interface{}{
+			"source_id":          "1",
+			"image_id":           "2bc640f6-927a-404a-9594-5b2da7e06608",
+			"amount":             1,
+			"instance_type":      "t1.micro",
+			"pubkey_id":          pk.ID,
+			"rhc_activation_key": activationKey,
+			"rhc_org_id":         orgID,
+		}
+		jsonData, err := json.Marshal(values)
+		require.NoError(t, err, "unable to marshal values to json")
+
+		req, err := http.NewRequestWithContext(ctx, "POST", "/api/provisioning/reservations/aws", bytes.NewBuffer(jsonData))
+		require.NoError(t, err, "failed to create request")
+		req.Header.Add("Content-Type", "application/json")
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(services.CreateAWSReservation)
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("defaults to organization of the requester", func(t *testing.T) {
+		rr := createReservation(t, "lab-key", "")
+		require.Equal(t, http.StatusOK, rr.Code, "Handler returned wrong status code")
+
+		var result payloads.AWSReservationResponsePayload
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&result), "failed to decode response body")
+		reservation, err := dao.GetReservationDao(ctx).GetAWSById(ctx, result.ID)
+		require.NoError(t, err)
+		assert.Equal(t, identity.DefaultOrgId, reservation.Detail.RHCOrgID)
+		assert.NotContains(t, reservation.Detail.RHCActivationKey, "lab-key", "activation key must be encrypted")
+		key, err := secrets.Decrypt(reservation.Detail.RHCActivationKey)
+		require.NoError(t, err)
+		assert.Equal(t, "lab-key", key)
+
+		enqueued := stub.EnqueuedJobs(ctx)
+		require.NotEmpty(t, enqueued)
+		args := enqueued[len(enqueued)-1].Args.(jobs.LaunchInstanceAWSTaskArgs)
+		assert.Empty(t, args.Detail.RHCActivationKey, "activation key must not be stored in the job")
+	})
+
+	t.Run("refuses registration without secrets key", func(t *testing.T) {
+		config.Application.SecretsKey = ""
+		defer func() { config.Application.SecretsKey = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=" }()
+
+		rr := createReservation(t, "lab-key", "")
+		require.Equal(t, http.StatusBadRequest, rr.Code, "Handler returned wrong status code")
+		assert.Contains(t, rr.Body.String(), "rhc registration")
+	})
+
+	t.Run("rejects invalid values", func(t *testing.T) {
+		for _, tc := range []struct{ key, org string }{
+			{"lab key", ""},
+			{`lab-key", "--force`, ""},
+			{"lab-key", "org-1"},
+			{"", "12345"},
+		} {
+			rr := createReservation(t, tc.key, tc.org)
+			require.Equal(t, http.StatusBadRequest, rr.Code, "Handler returned wrong status code for %q/%q", tc.key, tc.org)
+			assert.Contains(t, rr.Body.String(), "rhc registration")
+		}
+	})
+}
+
+func TestCreateAWSReservationAdditionalPubkeys(t *testing.T) {
+	ctx := stubs.WithAccountDaoOne(context.Background())
+	ctx = identity.WithTenant(t, ctx)
+	ctx = Clientstubs.WithSourcesClient(ctx)
+	ctx = Clientstubs.WithImageBuilderClient(ctx)
+	ctx = stubs.WithReservationDao(ctx)
+	ctx = stubs.WithPubkeyDao(ctx)
+	pk := factories.NewPubkeyRSA()
+	require.NoError(t, stubs.AddPubkey(ctx, pk), "failed to generate pubkey")
+	pk2 := factories.NewPubkeyED25519()
+	require.NoError(t, stubs.AddPubkey(ctx, pk2), "failed to generate pubkey")
+
+	createReservation := func(t *testing.T, pubkeyIDs []int64, generate bool, key string) *httptest.ResponseRecorder {
+		t.Helper()
+		values := map[string]interface{}{
+			"source_id":         "1",
+			"image_id":          "2bc640f6-927a-404a-9594-5b2da7e06608",
+			"amount":            1,
+			"instance_type":     "t1.micro",
+			"pubkey_id":         pk.ID,
+			"pubkey_ids":        pubkeyIDs,
+			"generate_key_pair": generate,
+		}
+		jsonData, err := json.Marshal(values)
+		require.NoError(t, err, "unable to marshal values to json")
+
+		req, err := http.NewRequestWithContext(ctx, "POST", "/api/provisioning/reservations/aws", bytes.NewBuffer(jsonData))
+		require.NoError(t, err, "failed to create request")
+		req.Header.Add("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Add(services.IdempotencyKeyHeader, key)
+		}
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(services.CreateAWSReservation)
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("authorizes additional and generated pubkeys", func(t *testing.T) {
+		rr := createReservation(t, []int64{pk2.ID, pk.ID, pk2.ID}, true, "")
+		require.Equal(t, http.StatusOK, rr.Code, "Handler returned wrong status code")
+
+		var result payloads.AWSReservationResponsePayload
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&result), "failed to decode response body")
+		assert.Equal(t, []int64{pk2.ID}, result.PubkeyIDs)
+		signer, err := cryptossh.ParsePrivateKey([]byte(result.PrivateKey))
+		require.NoError(t, err, "failed to parse generated private key")
+
+		reservation, err := dao.GetReservationDao(ctx).GetAWSById(ctx, result.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{pk2.ID}, reservation.Detail.PubkeyIDs)
+		generated := strings.TrimSpace(string(cryptossh.MarshalAuthorizedKey(signer.PublicKey())))
+		require.Len(t, reservation.Detail.SSHKeys, 2)
+		assert.Equal(t, pk2.Body, reservation.Detail.SSHKeys[0])
+		assert.True(t, strings.HasPrefix(reservation.Detail.SSHKeys[1], generated))
+	})
+
+	t.Run("rejects unknown pubkey", func(t *testing.T) {
+		rr := createReservation(t, []int64{pk2.ID, 987654}, false, "")
+		require.Equal(t, http.StatusNotFound, rr.Code, "Handler returned wrong status code")
+	})
+
+	t.Run("rejects generated key pair with idempotency key", func(t *testing.T) {
+		count := stubs.AWSReservationStubCount(ctx)
+		rr := createReservation(t, nil, true, "c91d2e44-request-1")
+		require.Equal(t, http.StatusBadRequest, rr.Code, "Handler returned wrong status code")
+		assert.Contains(t, rr.Body.String(), services.IdempotencyKeyKeyPairError.Error())
+		assert.Equal(t, count, stubs.AWSReservationStubCount(ctx), "Expected no reservation")
+	})
+}
+
+func TestCreateAWSReservationIdempotency(t *testing.T) {
+	ctx := stubs.WithAccountDaoOne(context.Background())
+	ctx = identity.WithTenant(t, ctx)
+	ctx = Clientstubs.WithSourcesClient(ctx)
+	ctx = Clientstubs.WithImageBuilderClient(ctx)
+	ctx = stubs.WithReservationDao(ctx)
+	ctx = stubs.WithPubkeyDao(ctx)
+	ctx = stub.WithEnqueuer(ctx)
+	pk := factories.NewPubkeyRSA()
+	err := stubs.AddPubkey(ctx, pk)
+	require.NoError(t, err, "failed to generate pubkey")
+
+	createRequest := func(t *testing.T, provider, key string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		values := map[string]interface{}{
+			"source_id":     "1",
+			"image_id":      "2bc640f6-927a-404a-9594-5b2da7e06608",
+			"amount":        1,
+			"instance_type": "t1.micro",
+			"pubkey_id":     pk.ID,
+		}
+		jsonData, err := json.Marshal(values)
+		require.NoError(t, err, "unable to marshal values to json")
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("TYPE", provider)
+		reqCtx := context.WithValue(ctx, chi.RouteCtxKey, rctx)
+		req, err := http.NewRequestWithContext(reqCtx, "POST", "/api/provisioning/reservations/"+provider, bytes.NewBuffer(jsonData))
+		require.NoError(t, err, "failed to create request")
+		req.Header.Add("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Add(services.IdempotencyKeyHeader, key)
+		}
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(services.CreateReservation)
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("replays reservation with the same key", func(t *testing.T) {
+		rr := createRequest(t, "aws", "f4b0a7e2-request-1")
+		require.Equal(t, http.StatusOK, rr.Code, "Handler returned wrong status code")
+		var created payloads.AWSReservationResponsePayload
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&created), "failed to decode response body")
+
+		rr = createRequest(t, "aws", "f4b0a7e2-request-1")
+		require.Equal(t, http.StatusOK, rr.Code, "Handler returned wrong status code")
+		assert.Equal(t, "true", rr.Header().Get(services.IdempotentReplayedHeader))
+		var replayed payloads.AWSReservationResponsePayload
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&replayed), "failed to decode response body")
+
+		assert.Equal(t, created.ID, replayed.ID, "Expected the original reservation")
+		assert.Equal(t, 1, stubs.AWSReservationStubCount(ctx), "Expected exactly one reservation")
+		assert.Equal(t, 1, len(stub.EnqueuedJobs(ctx)), "Expected exactly one job to be planned")
+	})
+
+	t.Run("creates reservation with a different key", func(t *testing.T) {
+		rr := createRequest(t, "aws", "f4b0a7e2-request-2")
+		require.Equal(t, http.StatusOK, rr.Code, "Handler returned wrong status code")
+		assert.Empty(t, rr.Header().Get(services.IdempotentReplayedHeader))
+		assert.Equal(t, 2, stubs.AWSReservationStubCount(ctx), "Expected a new reservation")
+	})
+
+	t.Run("rejects key used for a different provider", func(t *testing.T) {
+		rr := createRequest(t, "gcp", "f4b0a7e2-request-1")
+		require.Equal(t, http.StatusBadRequest, rr.Code, "Handler returned wrong status code")
+		assert.Equal(t, 0, stubs.GCPReservationStubCount(ctx), "Expected no GCP reservation")
+	})
+
+	t.Run("rejects too long key", func(t *testing.T) {
+		rr := createRequest(t, "aws", strings.Repeat("k", 256))
+		require.Equal(t, http.StatusBadRequest, rr.Code, "Handler returned wrong status code")
+	})
 }
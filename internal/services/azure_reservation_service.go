@@ -1,6 +1,7 @@
 package services
 
 import (
+	"database/sql"
 	"fmt"
 	"net/http"
 	"strings"
@@ -14,9 +15,11 @@ import (
 	"github.com/RHEnVision/provisioning-backend/internal/payloads"
 	"github.com/RHEnVision/provisioning-backend/internal/preload"
 	"github.com/RHEnVision/provisioning-backend/internal/queue"
+	"github.com/RHEnVision/provisioning-backend/internal/userdata"
 	"github.com/RHEnVision/provisioning-backend/pkg/worker"
 	"github.com/go-chi/render"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/rs/zerolog"
 )
 
@@ -29,6 +32,12 @@ func CreateAzureReservation(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	launchAt, laErr := parseLaunchAt(payload.LaunchAt)
+	if laErr != nil {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "invalid launch time", laErr))
+		return
+	}
+
 	pkDao := dao.GetPubkeyDao(r.Context())
 	rDao := dao.GetReservationDao(r.Context())
 
@@ -125,38 +134,89 @@ func CreateAzureReservation(w http.ResponseWriter, r *http.Request) {
 	}
 	reservation.Steps = int32(len(jobs.LaunchInstanceAzureSteps))
 	reservation.StepTitles = jobs.LaunchInstanceAzureSteps
+	reservation.JobID = uuid.NullUUID{UUID: uuid.New(), Valid: true}
+	reservation.LaunchAt = launchAt
+	reservation.IdempotencyKey = idempotencyKey(r)
+	reservation.ParentID = parentReservationID(r)
 
-	// create reservation in the database
-	err = rDao.CreateAzure(r.Context(), reservation)
-	if err != nil {
-		renderError(w, r, payloads.NewDAOError(r.Context(), "create Azure reservation", err))
+	keys, ok := additionalPubkeys(w, r, payload.PubkeyID, payload.PubkeyIDs, payload.GenerateKeyPair)
+	if !ok {
+		return
+	}
+	reservation.Detail.PubkeyIDs = keys.PubkeyIDs
+
+	input := azureUserData(payload, keys.Bodies)
+	userData, ok := generateUserData(w, r, input)
+	if !ok {
+		return
+	}
+	reservation.Detail.LaunchInput, ok = launchInput(w, r, input)
+	if !ok {
+		return
+	}
+	reservation.Detail.SSHKeys = keys.Bodies
+	reservation.UserDataHash = sql.NullString{String: userdata.Hash(userData), Valid: true}
+
+	if !enforceQuota(w, r, models.ProviderTypeAzure, reservation.Detail.Amount) {
 		return
 	}
-	logger.Debug().Msgf("Created a new reservation %d", reservation.ID)
 
-	launchJob := worker.Job{
-		Type:      jobs.TypeLaunchInstanceAzure,
-		Identity:  identity.Identity(r.Context()),
-		AccountID: identity.AccountId(r.Context()),
-		Args: jobs.LaunchInstanceAzureTaskArgs{
-			ReservationID: reservation.ID,
-			Location:      reservation.Detail.Location,
-			PubkeyID:      pk.ID,
-			SourceID:      reservation.SourceID,
-			AzureImageID:  azureImageName,
-			Subscription:  authentication,
-		},
-	}
-
-	err = queue.GetEnqueuer(r.Context()).Enqueue(r.Context(), &launchJob)
+	// create reservation in the database, transactional queues enqueue the job in the same transaction
+	var launchJob worker.Job
+	var enqueued bool
+	err = rDao.CreateAzure(r.Context(), reservation, func(tx pgx.Tx) error {
+		if txErr := checkQuotaTx(r.Context(), tx, models.ProviderTypeAzure); txErr != nil {
+			return txErr
+		}
+		launchJob = worker.Job{
+			ID:        reservation.JobID.UUID,
+			Type:      jobs.TypeLaunchInstanceAzure,
+			Identity:  identity.Identity(r.Context()),
+			AccountID: identity.AccountId(r.Context()),
+			RunAt:     reservation.LaunchAt.Time,
+			Args: jobs.LaunchInstanceAzureTaskArgs{
+				ReservationID: reservation.ID,
+				Location:      reservation.Detail.Location,
+				PubkeyID:      pk.ID,
+				SourceID:      reservation.SourceID,
+				AzureImageID:  azureImageName,
+				Subscription:  authentication,
+			},
+		}
+
+		var txErr error
+		enqueued, txErr = queue.EnqueueTx(r.Context(), tx, &launchJob)
+		return txErr
+	})
 	if err != nil {
-		renderError(w, r, payloads.NewEnqueueTaskError(r.Context(), "job enqueue error", err))
+		renderCreateReservationError(w, r, models.ProviderTypeAzure, err, "create Azure reservation")
 		return
 	}
+	logger.Debug().Msgf("Created a new reservation %d", reservation.ID)
+
+	if !enqueued {
+		err = queue.GetEnqueuer(r.Context()).Enqueue(r.Context(), &launchJob)
+		if err != nil {
+			renderError(w, r, payloads.NewEnqueueTaskError(r.Context(), "job enqueue error", err))
+			return
+		}
+	}
 
 	// Return response payload
-	unused := make([]*models.ReservationInstance, 0, 0)
-	if err = render.Render(w, r, payloads.NewAzureReservationResponse(reservation, unused)); err != nil {
+	if err = render.Render(w, r, payloads.NewAzureReservationCreatedResponse(reservation, keys.PrivateKey)); err != nil {
 		renderError(w, r, payloads.NewRenderError(r.Context(), "unable to render Azure reservation", err))
 	}
 }
+
+// azureUserData returns user data input of Azure reservation request with additional pubkeys.
+func azureUserData(payload *payloads.AzureReservationRequestPayload, authorizedKeys []string) *userdata.UserData {
+	return &userdata.UserData{
+		Type:              models.ProviderTypeAzure,
+		PowerOff:          payload.PowerOff,
+		InsightsTags:      true,
+		Custom:            []byte(payload.UserData),
+		RHCActivationKey:  payload.RHCActivationKey,
+		RHCOrgID:          payload.RHCOrgID,
+		SSHAuthorizedKeys: authorizedKeys,
+	}
+}
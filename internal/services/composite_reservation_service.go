@@ -0,0 +1,208 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
+	"github.com/RHEnVision/provisioning-backend/internal/identity"
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/RHEnVision/provisioning-backend/internal/payloads"
+	"github.com/RHEnVision/provisioning-backend/internal/queue"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/rs/zerolog"
+)
+
+type compositeCtxKeyType int
+
+const parentReservationCtxKey compositeCtxKeyType = iota
+
+// parentReservationID returns ID of the composite reservation for child reservation create
+// requests, or NULL.
+func parentReservationID(r *http.Request) sql.NullInt64 {
+	id, ok := r.Context().Value(parentReservationCtxKey).(int64)
+	return sql.NullInt64{Int64: id, Valid: ok}
+}
+
+// childResponseWriter buffers the response of a child reservation create request.
+type childResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (cw *childResponseWriter) Header() http.Header {
+	return cw.header
+}
+
+func (cw *childResponseWriter) Write(b []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	n, err := cw.body.Write(b)
+	if err != nil {
+		return n, fmt.Errorf("cannot buffer response: %w", err)
+	}
+	return n, nil
+}
+
+func (cw *childResponseWriter) WriteHeader(status int) {
+	if cw.status == 0 {
+		cw.status = status
+	}
+}
+
+// copyTo writes the buffered response into another response writer.
+func (cw *childResponseWriter) copyTo(w http.ResponseWriter) {
+	for key, values := range cw.header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(cw.status)
+	_, _ = w.Write(cw.body.Bytes())
+}
+
+// providerRequest returns a copy of the request with the provider type and the provider
+// specific reservation payload.
+func providerRequest(r *http.Request, provider models.ProviderType, body []byte) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("TYPE", provider.String())
+
+	result := r.Clone(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+	result.Body = io.NopCloser(bytes.NewReader(body))
+	result.ContentLength = int64(len(body))
+	return result
+}
+
+// childRequest returns a copy of the composite reservation request with the child payload and
+// provider type. The idempotency key belongs to the composite reservation only.
+func childRequest(r *http.Request, parentID int64, provider models.ProviderType, body []byte) *http.Request {
+	child := providerRequest(r.WithContext(context.WithValue(r.Context(), parentReservationCtxKey, parentID)), provider, body)
+	child.Header.Del(IdempotencyKeyHeader)
+	return child
+}
+
+// CreateCompositeReservation creates a composite reservation and a child reservation for each
+// provider specific request through the regular reservation create handlers. When a child
+// reservation cannot be created, the composite reservation is finished with an error, already
+// created children are cancelled and the error response of the child is returned.
+func CreateCompositeReservation(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	payload := &payloads.CompositeReservationRequestPayload{}
+	if err := render.Bind(r, payload); err != nil {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "composite reservation", err))
+		return
+	}
+
+	stepTitles := make([]string, len(payload.Reservations))
+	for i := range payload.Reservations {
+		stepTitles[i] = fmt.Sprintf("Launch %s reservation", payload.Reservations[i].Provider().String())
+	}
+	reservation := &models.CompositeReservation{
+		Reservation: models.Reservation{
+			Provider:       models.ProviderTypeComposite,
+			AccountID:      identity.AccountId(r.Context()),
+			Status:         "Created",
+			Steps:          int32(len(payload.Reservations)),
+			StepTitles:     stepTitles,
+			IdempotencyKey: idempotencyKey(r),
+		},
+	}
+
+	rDao := dao.GetReservationDao(r.Context())
+	err := rDao.CreateComposite(r.Context(), reservation)
+	if err != nil {
+		renderCreateReservationError(w, r, models.ProviderTypeComposite, err, "create composite reservation")
+		return
+	}
+	logger.Debug().Msgf("Created a new composite reservation %d", reservation.ID)
+
+	for i := range payload.Reservations {
+		child := &payload.Reservations[i]
+		body, mErr := json.Marshal(child.Payload())
+		if mErr != nil {
+			abortCompositeReservation(r.Context(), &reservation.Reservation, i)
+			renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "composite reservation", mErr))
+			return
+		}
+
+		cw := &childResponseWriter{header: make(http.Header)}
+		CreateReservation(cw, childRequest(r, reservation.ID, child.Provider(), body))
+		if cw.status != http.StatusOK {
+			logger.Warn().Msgf("Child reservation %d of composite reservation %d failed with status %d", i, reservation.ID, cw.status)
+			abortCompositeReservation(r.Context(), &reservation.Reservation, i)
+			cw.copyTo(w)
+			return
+		}
+	}
+
+	children, err := rDao.ListChildren(r.Context(), reservation.ID)
+	if err != nil {
+		renderError(w, r, payloads.NewDAOError(r.Context(), "list child reservations", err))
+		return
+	}
+
+	if err := render.Render(w, r, payloads.NewCompositeReservationResponse(&reservation.Reservation, children)); err != nil {
+		renderError(w, r, payloads.NewRenderError(r.Context(), "unable to render composite reservation", err))
+	}
+}
+
+// abortCompositeReservation finishes the composite reservation with an error and cancels child
+// reservations which were already created.
+func abortCompositeReservation(ctx context.Context, reservation *models.Reservation, failedIndex int) {
+	logger := zerolog.Ctx(ctx)
+
+	message := fmt.Sprintf("reservation %d of the composite reservation was not created", failedIndex)
+	err := dao.GetReservationDao(ctx).FinishWithError(ctx, reservation.ID, message)
+	if err != nil {
+		logger.Warn().Err(err).Msgf("Unable to finish composite reservation %d", reservation.ID)
+	}
+
+	err = cancelChildReservations(ctx, reservation.ID)
+	if err != nil {
+		logger.Warn().Err(err).Msgf("Unable to cancel child reservations of composite reservation %d", reservation.ID)
+	}
+}
+
+// cancelChildReservations marks unfinished children of a composite reservation as cancelled and
+// cancels their background jobs.
+func cancelChildReservations(ctx context.Context, parentID int64) error {
+	logger := zerolog.Ctx(ctx)
+	rDao := dao.GetReservationDao(ctx)
+
+	children, err := rDao.ListChildren(ctx, parentID)
+	if err != nil {
+		return fmt.Errorf("cannot list child reservations: %w", err)
+	}
+
+	for _, child := range children {
+		if child.FinishedAt.Valid {
+			continue
+		}
+
+		err = rDao.Cancel(ctx, child.ID)
+		if errors.Is(err, dao.ErrAffectedMismatch) {
+			// finished in the meantime
+			continue
+		} else if err != nil {
+			return fmt.Errorf("cannot cancel child reservation %d: %w", child.ID, err)
+		}
+
+		if child.JobID.Valid {
+			err = queue.GetCanceller(ctx).Cancel(ctx, child.JobID.UUID)
+			if err != nil {
+				return fmt.Errorf("cannot cancel job of child reservation %d: %w", child.ID, err)
+			}
+		}
+		logger.Debug().Msgf("Cancelled child reservation %d of composite reservation %d", child.ID, parentID)
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package services_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	Clientstubs "github.com/RHEnVision/provisioning-backend/internal/clients/stubs"
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
+	"github.com/RHEnVision/provisioning-backend/internal/dao/stubs"
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/RHEnVision/provisioning-backend/internal/payloads"
+	"github.com/RHEnVision/provisioning-backend/internal/queue/stub"
+	"github.com/RHEnVision/provisioning-backend/internal/services"
+	"github.com/RHEnVision/provisioning-backend/internal/testing/factories"
+	"github.com/RHEnVision/provisioning-backend/internal/testing/identity"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateCompositeReservation(t *testing.T) {
+	ctx := stubs.WithAccountDaoOne(context.Background())
+	ctx = identity.WithTenant(t, ctx)
+	ctx = Clientstubs.WithSourcesClient(ctx)
+	ctx = Clientstubs.WithImageBuilderClient(ctx)
+	ctx = stubs.WithReservationDao(ctx)
+	ctx = stubs.WithPubkeyDao(ctx)
+	ctx = stub.WithEnqueuer(ctx)
+	pk := factories.NewPubkeyRSA()
+	err := stubs.AddPubkey(ctx, pk)
+	require.NoError(t, err, "failed to generate pubkey")
+
+	awsChild := func(region string) map[string]interface{} {
+		return map[string]interface{}{
+			"aws": map[string]interface{}{
+				"source_id":     "1",
+				"image_id":      "ami-7846387643232",
+				"amount":        1,
+				"instance_type": "t1.micro",
+				"region":        region,
+				"pubkey_id":     pk.ID,
+			},
+		}
+	}
+
+	createRequest := func(t *testing.T, children ...map[string]interface{}) *httptest.ResponseRecorder {
+		t.Helper()
+
+		jsonData, err := json.Marshal(map[string]interface{}{"reservations": children})
+		require.NoError(t, err, "unable to marshal values to json")
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("TYPE", "composite")
+		reqCtx := context.WithValue(ctx, chi.RouteCtxKey, rctx)
+		req, err := http.NewRequestWithContext(reqCtx, "POST", "/api/provisioning/reservations/composite", bytes.NewBuffer(jsonData))
+		require.NoError(t, err, "failed to create request")
+		req.Header.Add("Content-Type", "application/json")
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(services.CreateReservation)
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("creates child reservations", func(t *testing.T) {
+		rr := createRequest(t, awsChild("us-east-1"), awsChild("eu-west-1"))
+		require.Equal(t, http.StatusOK, rr.Code, "Handler returned wrong status code: %s", rr.Body.String())
+
+		var result payloads.CompositeReservationResponsePayload
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&result), "failed to decode response body")
+		require.Len(t, result.Reservations, 2)
+		for _, child := range result.Reservations {
+			assert.Equal(t, int(models.ProviderTypeAWS), child.Provider)
+			require.NotNil(t, child.ParentID)
+			assert.Equal(t, result.ID, *child.ParentID)
+		}
+
+		parent, err := dao.GetReservationDao(ctx).GetById(ctx, result.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.ProviderTypeComposite, parent.Provider)
+		assert.Equal(t, int32(2), parent.Steps)
+		assert.False(t, parent.FinishedAt.Valid)
+
+		assert.Equal(t, 2, stubs.AWSReservationStubCount(ctx))
+		assert.Equal(t, 2, len(stub.EnqueuedJobs(ctx)), "Expected a job for each child")
+	})
+
+	t.Run("aborts when a child fails", func(t *testing.T) {
+		rr := createRequest(t, awsChild("us-east-1"), awsChild("blank"))
+		require.Equal(t, http.StatusBadRequest, rr.Code, "Handler returned wrong status code")
+		assert.Contains(t, rr.Body.String(), "Unsupported region")
+
+		assert.Equal(t, 3, stubs.AWSReservationStubCount(ctx), "Expected only the first child")
+		cancelled := stub.CancelledJobs(ctx)
+		require.Len(t, cancelled, 1, "Expected the first child to be cancelled")
+		assert.Equal(t, stub.EnqueuedJobs(ctx)[2].ID, cancelled[0])
+	})
+
+	t.Run("rejects empty request", func(t *testing.T) {
+		rr := createRequest(t)
+		require.Equal(t, http.StatusBadRequest, rr.Code, "Handler returned wrong status code")
+	})
+
+	t.Run("rejects child with two providers", func(t *testing.T) {
+		child := awsChild("us-east-1")
+		child["gcp"] = map[string]interface{}{"source_id": "1"}
+		rr := createRequest(t, child)
+		require.Equal(t, http.StatusBadRequest, rr.Code, "Handler returned wrong status code")
+	})
+}
@@ -1,11 +1,13 @@
 package services
 
 import (
+	"database/sql"
 	"fmt"
 	"net/http"
 
 	"github.com/RHEnVision/provisioning-backend/internal/preload"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/rs/zerolog"
 
 	"github.com/RHEnVision/provisioning-backend/internal/clients"
@@ -15,6 +17,7 @@ import (
 	"github.com/RHEnVision/provisioning-backend/internal/models"
 	"github.com/RHEnVision/provisioning-backend/internal/payloads"
 	"github.com/RHEnVision/provisioning-backend/internal/queue"
+	"github.com/RHEnVision/provisioning-backend/internal/userdata"
 	"github.com/RHEnVision/provisioning-backend/pkg/worker"
 	"github.com/go-chi/render"
 )
@@ -31,6 +34,12 @@ func CreateGCPReservation(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	launchAt, laErr := parseLaunchAt(payload.LaunchAt)
+	if laErr != nil {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "invalid launch time", laErr))
+		return
+	}
+
 	rDao := dao.GetReservationDao(r.Context())
 	pkDao := dao.GetPubkeyDao(r.Context())
 
@@ -59,6 +68,28 @@ func CreateGCPReservation(w http.ResponseWriter, r *http.Request) {
 	reservation.Status = "Created"
 	reservation.Provider = models.ProviderTypeGCP
 	reservation.Steps = 1
+	reservation.JobID = uuid.NullUUID{UUID: uuid.New(), Valid: true}
+	reservation.LaunchAt = launchAt
+	reservation.IdempotencyKey = idempotencyKey(r)
+	reservation.ParentID = parentReservationID(r)
+
+	keys, ok := additionalPubkeys(w, r, payload.PubkeyID, payload.PubkeyIDs, payload.GenerateKeyPair)
+	if !ok {
+		return
+	}
+	reservation.Detail.PubkeyIDs = keys.PubkeyIDs
+	reservation.Detail.SSHKeys = keys.Bodies
+
+	input := gcpUserData(payload, keys.Bodies)
+	userData, ok := generateUserData(w, r, input)
+	if !ok {
+		return
+	}
+	reservation.Detail.LaunchInput, ok = launchInput(w, r, input)
+	if !ok {
+		return
+	}
+	reservation.UserDataHash = sql.NullString{String: userdata.Hash(userData, input.Custom), Valid: true}
 
 	logger.Debug().Msgf("Validating existence of pubkey %d for this account", reservation.PubkeyID)
 	pk, err := pkDao.GetById(r.Context(), reservation.PubkeyID)
@@ -69,14 +100,6 @@ func CreateGCPReservation(w http.ResponseWriter, r *http.Request) {
 	}
 	logger.Debug().Msgf("Found pubkey %d named '%s'", pk.ID, pk.Name)
 
-	// create reservation in the database
-	err = rDao.CreateGCP(r.Context(), reservation)
-	if err != nil {
-		renderError(w, r, payloads.NewDAOError(r.Context(), "create reservation", err))
-		return
-	}
-	logger.Debug().Msgf("Created a new reservation %d", reservation.ID)
-
 	// Get Sources client
 	sourcesClient, err := clients.GetSourcesClient(r.Context())
 	if err != nil {
@@ -122,30 +145,71 @@ func CreateGCPReservation(w http.ResponseWriter, r *http.Request) {
 		name = payload.ImageID
 	}
 
-	launchJob := worker.Job{
-		Type:      jobs.TypeLaunchInstanceGcp,
-		AccountID: accountId,
-		Identity:  id,
-		Args: jobs.LaunchInstanceGCPTaskArgs{
-			ReservationID: reservation.ID,
-			Zone:          reservation.Detail.Zone,
-			PubkeyID:      reservation.PubkeyID,
-			Detail:        reservation.Detail,
-			ImageName:     name,
-			ProjectID:     authentication,
-		},
+	if !enforceQuota(w, r, models.ProviderTypeGCP, reservation.Detail.Amount) {
+		return
 	}
 
-	err = queue.GetEnqueuer(r.Context()).Enqueue(r.Context(), &launchJob)
+	// launch input is read from the reservation by the job, secrets are not kept in job rows
+	argsDetail := *reservation.Detail
+	argsDetail.LaunchInput = models.LaunchInput{}
+
+	// create reservation in the database, transactional queues enqueue the job in the same transaction
+	var launchJob worker.Job
+	var enqueued bool
+	err = rDao.CreateGCP(r.Context(), reservation, func(tx pgx.Tx) error {
+		if txErr := checkQuotaTx(r.Context(), tx, models.ProviderTypeGCP); txErr != nil {
+			return txErr
+		}
+		launchJob = worker.Job{
+			ID:        reservation.JobID.UUID,
+			Type:      jobs.TypeLaunchInstanceGcp,
+			AccountID: accountId,
+			RunAt:     reservation.LaunchAt.Time,
+			Identity:  id,
+			Args: jobs.LaunchInstanceGCPTaskArgs{
+				ReservationID: reservation.ID,
+				Zone:          reservation.Detail.Zone,
+				PubkeyID:      reservation.PubkeyID,
+				Detail:        &argsDetail,
+				ImageName:     name,
+				ProjectID:     authentication,
+			},
+		}
+
+		var txErr error
+		enqueued, txErr = queue.EnqueueTx(r.Context(), tx, &launchJob)
+		return txErr
+	})
 	if err != nil {
-		renderError(w, r, payloads.NewEnqueueTaskError(r.Context(), "job enqueue error", err))
+		renderCreateReservationError(w, r, models.ProviderTypeGCP, err, "create reservation")
 		return
 	}
+	logger.Debug().Msgf("Created a new reservation %d", reservation.ID)
+
+	if !enqueued {
+		err = queue.GetEnqueuer(r.Context()).Enqueue(r.Context(), &launchJob)
+		if err != nil {
+			renderError(w, r, payloads.NewEnqueueTaskError(r.Context(), "job enqueue error", err))
+			return
+		}
+	}
 
-	unused := make([]*models.ReservationInstance, 0, 0)
 	// Return response payload
-	if err := render.Render(w, r, payloads.NewGCPReservationResponse(reservation, unused)); err != nil {
+	if err := render.Render(w, r, payloads.NewGCPReservationCreatedResponse(reservation, keys.PrivateKey)); err != nil {
 		renderError(w, r, payloads.NewRenderError(r.Context(), "unable to render reservation", err))
 		return
 	}
 }
+
+// gcpUserData returns user data input of GCP reservation request with additional pubkeys.
+func gcpUserData(payload *payloads.GCPReservationRequestPayload, authorizedKeys []string) *userdata.UserData {
+	return &userdata.UserData{
+		Type:              models.ProviderTypeGCP,
+		PowerOff:          payload.PowerOff,
+		InsightsTags:      true,
+		Custom:            []byte(payload.UserData),
+		RHCActivationKey:  payload.RHCActivationKey,
+		RHCOrgID:          payload.RHCOrgID,
+		SSHAuthorizedKeys: authorizedKeys,
+	}
+}
@@ -9,8 +9,10 @@ import (
 	"testing"
 
 	Clientstubs "github.com/RHEnVision/provisioning-backend/internal/clients/stubs"
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
 	"github.com/RHEnVision/provisioning-backend/internal/dao/stubs"
 	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/RHEnVision/provisioning-backend/internal/payloads"
 	"github.com/RHEnVision/provisioning-backend/internal/services"
 	"github.com/RHEnVision/provisioning-backend/internal/testing/factories"
 	"github.com/RHEnVision/provisioning-backend/internal/testing/identity"
@@ -61,6 +63,38 @@ func TestCreateGCPReservationHandler(t *testing.T) {
 		assert.Equal(t, 1, stubCount, "Reservation has not been created through DAO")
 	})
 
+	t.Run("successful reservation with additional pubkeys", func(t *testing.T) {
+		pk2 := factories.NewPubkeyED25519()
+		require.NoError(t, stubs.AddPubkey(ctx, pk2), "failed to generate pubkey")
+		values := map[string]interface{}{
+			"source_id":    source.ID,
+			"image_id":     "80967e7f-efef-4eee-85b0-bd4cef4c455d",
+			"amount":       1,
+			"zone":         "us-central1-a",
+			"machine_type": "n1-standard-1",
+			"pubkey_id":    pk.ID,
+			"pubkey_ids":   []int64{pk2.ID},
+		}
+		jsonData, err := json.Marshal(values)
+		require.NoError(t, err, "unable to marshal values to json")
+
+		req, err := http.NewRequestWithContext(ctx, "POST", "/api/provisioning/reservations/gcp", bytes.NewBuffer(jsonData))
+		require.NoError(t, err, "failed to create request")
+		req.Header.Add("Content-Type", "application/json")
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(services.CreateGCPReservation)
+		handler.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code, "Handler returned wrong status code")
+
+		var result payloads.GCPReservationResponsePayload
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&result), "failed to decode response body")
+		reservation, err := dao.GetReservationDao(ctx).GetGCPById(ctx, result.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{pk2.Body}, reservation.Detail.SSHKeys)
+		assert.NotContains(t, string(reservation.Detail.UserData), pk2.Body)
+	})
+
 	t.Run("failed reservation with invalid zone", func(t *testing.T) {
 		var err error
 		values := map[string]interface{}{
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
+	"github.com/RHEnVision/provisioning-backend/internal/db"
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/RHEnVision/provisioning-backend/internal/payloads"
+	"github.com/go-chi/render"
+)
+
+// renderLaunchProfileDAOError renders duplicate name as unprocessable entity.
+func renderLaunchProfileDAOError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	if db.IsPostgresError(err, db.UniqueConstraintErrorCode) != nil {
+		renderError(w, r, payloads.LaunchProfileDuplicateError(r.Context(), "launch profile with such name already exists for this account", err))
+	} else {
+		renderNotFoundOrDAOError(w, r, err, message)
+	}
+}
+
+// validateLaunchProfile validates the profile and existence of its pubkey for the account,
+// renders an error and returns false when the profile is not valid.
+func validateLaunchProfile(w http.ResponseWriter, r *http.Request, profile *models.LaunchProfile) bool {
+	if vErr := models.Validate(r.Context(), profile); vErr != nil {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "launch profile validation", vErr))
+		return false
+	}
+
+	if _, err := dao.GetPubkeyDao(r.Context()).GetById(r.Context(), profile.PubkeyID); err != nil {
+		renderNotFoundOrDAOError(w, r, err, fmt.Sprintf("get pubkey with id %d", profile.PubkeyID))
+		return false
+	}
+
+	return true
+}
+
+func CreateLaunchProfile(w http.ResponseWriter, r *http.Request) {
+	payload := &payloads.LaunchProfileRequest{}
+	if err := render.Bind(r, payload); err != nil {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "create launch profile", err))
+		return
+	}
+
+	profile := payload.NewModel()
+	if !validateLaunchProfile(w, r, profile) {
+		return
+	}
+
+	err := dao.GetLaunchProfileDao(r.Context()).Create(r.Context(), profile)
+	if err != nil {
+		renderLaunchProfileDAOError(w, r, err, "create launch profile")
+		return
+	}
+
+	if err := render.Render(w, r, payloads.NewLaunchProfileResponse(profile)); err != nil {
+		renderError(w, r, payloads.NewRenderError(r.Context(), "unable to render launch profile", err))
+	}
+}
+
+func ListLaunchProfiles(w http.ResponseWriter, r *http.Request) {
+	params, err := parseListParams(r)
+	if err != nil {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "list parameters", err))
+		return
+	}
+
+	lpDao := dao.GetLaunchProfileDao(r.Context())
+
+	profiles, err := lpDao.List(r.Context(), fetchParams(params))
+	if err != nil {
+		renderError(w, r, payloads.NewDAOError(r.Context(), "list launch profiles", err))
+		return
+	}
+
+	total, err := lpDao.Count(r.Context())
+	if err != nil {
+		renderError(w, r, payloads.NewDAOError(r.Context(), "count launch profiles", err))
+		return
+	}
+
+	profiles, links := listPage(r, params, profiles, func(lp *models.LaunchProfile) int64 { return lp.ID })
+	if err := render.Render(w, r, payloads.NewLaunchProfileListResponse(profiles, total, links)); err != nil {
+		renderError(w, r, payloads.NewRenderError(r.Context(), "unable to render launch profiles list", err))
+		return
+	}
+}
+
+func GetLaunchProfile(w http.ResponseWriter, r *http.Request) {
+	id, err := ParseInt64(r, "ID")
+	if err != nil {
+		renderError(w, r, payloads.NewURLParsingError(r.Context(), "unable to parse ID parameter", err))
+		return
+	}
+
+	profile, err := dao.GetLaunchProfileDao(r.Context()).GetById(r.Context(), id)
+	if err != nil {
+		renderNotFoundOrDAOError(w, r, err, fmt.Sprintf("get launch profile with id %d", id))
+		return
+	}
+
+	if err := render.Render(w, r, payloads.NewLaunchProfileResponse(profile)); err != nil {
+		renderError(w, r, payloads.NewRenderError(r.Context(), "unable to render launch profile", err))
+	}
+}
+
+func UpdateLaunchProfile(w http.ResponseWriter, r *http.Request) {
+	id, err := ParseInt64(r, "ID")
+	if err != nil {
+		renderError(w, r, payloads.NewURLParsingError(r.Context(), "unable to parse ID parameter", err))
+		return
+	}
+
+	payload := &payloads.LaunchProfileRequest{}
+	if err = render.Bind(r, payload); err != nil {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "update launch profile", err))
+		return
+	}
+
+	lpDao := dao.GetLaunchProfileDao(r.Context())
+
+	profile, err := lpDao.GetById(r.Context(), id)
+	if err != nil {
+		renderNotFoundOrDAOError(w, r, err, fmt.Sprintf("get launch profile with id %d", id))
+		return
+	}
+
+	payload.Apply(profile)
+	if !validateLaunchProfile(w, r, profile) {
+		return
+	}
+
+	err = lpDao.Update(r.Context(), profile)
+	if err != nil {
+		renderLaunchProfileDAOError(w, r, err, fmt.Sprintf("update launch profile with id %d", id))
+		return
+	}
+
+	if err := render.Render(w, r, payloads.NewLaunchProfileResponse(profile)); err != nil {
+		renderError(w, r, payloads.NewRenderError(r.Context(), "unable to render launch profile", err))
+	}
+}
+
+func DeleteLaunchProfile(w http.ResponseWriter, r *http.Request) {
+	id, err := ParseInt64(r, "ID")
+	if err != nil {
+		renderError(w, r, payloads.NewURLParsingError(r.Context(), "unable to parse ID parameter", err))
+		return
+	}
+
+	err = dao.GetLaunchProfileDao(r.Context()).Delete(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, dao.ErrAffectedMismatch) {
+			renderError(w, r, payloads.NewNotFoundError(r.Context(), fmt.Sprintf("launch profile with id %d", id), err))
+		} else {
+			renderError(w, r, payloads.NewDAOError(r.Context(), fmt.Sprintf("delete launch profile with id %d", id), err))
+		}
+		return
+	}
+
+	render.NoContent(w, r)
+}
+
+// CreateReservationFromProfile expands a launch profile into a provider specific reservation
+// request and creates the reservation via the regular reservation create handler. The request
+// body with overrides is optional.
+func CreateReservationFromProfile(w http.ResponseWriter, r *http.Request) {
+	id, err := ParseInt64(r, "ID")
+	if err != nil {
+		renderError(w, r, payloads.NewURLParsingError(r.Context(), "unable to parse ID parameter", err))
+		return
+	}
+
+	payload := &payloads.LaunchProfileReservationRequest{}
+	if err = render.Bind(r, payload); err != nil && !errors.Is(err, io.EOF) {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "reservation from launch profile", err))
+		return
+	}
+
+	profile, err := dao.GetLaunchProfileDao(r.Context()).GetById(r.Context(), id)
+	if err != nil {
+		renderNotFoundOrDAOError(w, r, err, fmt.Sprintf("get launch profile with id %d", id))
+		return
+	}
+
+	body, err := json.Marshal(payload.ReservationPayload(profile))
+	if err != nil {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "reservation from launch profile", err))
+		return
+	}
+
+	CreateReservation(w, providerRequest(r, profile.Provider, body))
+}
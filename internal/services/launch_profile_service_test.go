@@ -0,0 +1,189 @@
+package services_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	Clientstubs "github.com/RHEnVision/provisioning-backend/internal/clients/stubs"
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
+	"github.com/RHEnVision/provisioning-backend/internal/dao/stubs"
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/RHEnVision/provisioning-backend/internal/payloads"
+	"github.com/RHEnVision/provisioning-backend/internal/queue/stub"
+	"github.com/RHEnVision/provisioning-backend/internal/services"
+	"github.com/RHEnVision/provisioning-backend/internal/testing/factories"
+	"github.com/RHEnVision/provisioning-backend/internal/testing/identity"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func prepareLaunchProfileContext(t *testing.T) (context.Context, *models.Pubkey) {
+	t.Helper()
+
+	ctx := stubs.WithAccountDaoOne(context.Background())
+	ctx = identity.WithTenant(t, ctx)
+	ctx = stubs.WithPubkeyDao(ctx)
+	ctx = stubs.WithLaunchProfileDao(ctx)
+	pk := factories.NewPubkeyRSA()
+	require.NoError(t, stubs.AddPubkey(ctx, pk), "failed to add pubkey")
+	return ctx, pk
+}
+
+func launchProfileRequest(t *testing.T, ctx context.Context, method, id string, body any, handler http.HandlerFunc) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		require.NoError(t, json.NewEncoder(&buf).Encode(body), "failed to encode request body")
+	}
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("ID", id)
+	reqCtx := context.WithValue(ctx, chi.RouteCtxKey, rctx)
+	req, err := http.NewRequestWithContext(reqCtx, method, "/api/provisioning/v1/launch_profiles", &buf)
+	require.NoError(t, err, "failed to create request")
+	req.Header.Add("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func newAWSLaunchProfile(pubkeyID int64) *models.LaunchProfile {
+	return &models.LaunchProfile{
+		Name:         "small",
+		Provider:     models.ProviderTypeAWS,
+		SourceID:     "1",
+		Region:       "us-east-1",
+		InstanceType: "t1.micro",
+		ImageID:      "ami-7846387643232",
+		PubkeyID:     pubkeyID,
+	}
+}
+
+func TestCreateLaunchProfileHandler(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		ctx, pk := prepareLaunchProfileContext(t)
+
+		request := payloads.LaunchProfileRequest{Name: "small", Provider: "aws", SourceID: "1", Region: "us-east-1", PubkeyID: pk.ID}
+		rr := launchProfileRequest(t, ctx, "POST", "", request, services.CreateLaunchProfile)
+		require.Equal(t, http.StatusOK, rr.Code, "Wrong status code: %s", rr.Body.String())
+
+		var profile payloads.LaunchProfileResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&profile), "failed to decode response body")
+		assert.Equal(t, "small", profile.Name)
+		assert.Equal(t, "aws", profile.Provider)
+		assert.Equal(t, "us-east-1", profile.Region)
+	})
+
+	t.Run("Unsupported provider", func(t *testing.T) {
+		ctx, pk := prepareLaunchProfileContext(t)
+
+		request := payloads.LaunchProfileRequest{Name: "noop", Provider: "noop", SourceID: "1", PubkeyID: pk.ID}
+		rr := launchProfileRequest(t, ctx, "POST", "", request, services.CreateLaunchProfile)
+		require.Equal(t, http.StatusBadRequest, rr.Code, "Wrong status code")
+	})
+
+	t.Run("Missing name", func(t *testing.T) {
+		ctx, pk := prepareLaunchProfileContext(t)
+
+		request := payloads.LaunchProfileRequest{Provider: "aws", SourceID: "1", PubkeyID: pk.ID}
+		rr := launchProfileRequest(t, ctx, "POST", "", request, services.CreateLaunchProfile)
+		require.Equal(t, http.StatusBadRequest, rr.Code, "Wrong status code")
+	})
+
+	t.Run("Unknown pubkey", func(t *testing.T) {
+		ctx, _ := prepareLaunchProfileContext(t)
+
+		request := payloads.LaunchProfileRequest{Name: "small", Provider: "aws", SourceID: "1", PubkeyID: 42}
+		rr := launchProfileRequest(t, ctx, "POST", "", request, services.CreateLaunchProfile)
+		require.Equal(t, http.StatusNotFound, rr.Code, "Wrong status code")
+	})
+}
+
+func TestUpdateLaunchProfileHandler(t *testing.T) {
+	ctx, pk := prepareLaunchProfileContext(t)
+	require.NoError(t, dao.GetLaunchProfileDao(ctx).Create(ctx, newAWSLaunchProfile(pk.ID)))
+
+	rr := launchProfileRequest(t, ctx, "PATCH", "1", payloads.LaunchProfileRequest{InstanceType: "t2.micro"}, services.UpdateLaunchProfile)
+	require.Equal(t, http.StatusOK, rr.Code, "Wrong status code")
+
+	var response payloads.LaunchProfileResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&response), "failed to decode response body")
+	assert.Equal(t, "t2.micro", response.InstanceType)
+	assert.Equal(t, "us-east-1", response.Region)
+
+	rr = launchProfileRequest(t, ctx, "PATCH", "42", payloads.LaunchProfileRequest{InstanceType: "t2.micro"}, services.UpdateLaunchProfile)
+	require.Equal(t, http.StatusNotFound, rr.Code, "Wrong status code")
+}
+
+func TestDeleteLaunchProfileHandler(t *testing.T) {
+	ctx, pk := prepareLaunchProfileContext(t)
+	require.NoError(t, dao.GetLaunchProfileDao(ctx).Create(ctx, newAWSLaunchProfile(pk.ID)))
+
+	rr := launchProfileRequest(t, ctx, "DELETE", "1", nil, services.DeleteLaunchProfile)
+	require.Equal(t, http.StatusNoContent, rr.Code, "Wrong status code")
+
+	rr = launchProfileRequest(t, ctx, "DELETE", "1", nil, services.DeleteLaunchProfile)
+	require.Equal(t, http.StatusNotFound, rr.Code, "Wrong status code")
+}
+
+func TestDeletePubkeyOfLaunchProfile(t *testing.T) {
+	ctx, pk := prepareLaunchProfileContext(t)
+	ctx = Clientstubs.WithSourcesClient(ctx)
+	require.NoError(t, dao.GetLaunchProfileDao(ctx).Create(ctx, newAWSLaunchProfile(pk.ID)))
+
+	rr := launchProfileRequest(t, ctx, "DELETE", "1", nil, services.DeletePubkey)
+	require.Equal(t, http.StatusUnprocessableEntity, rr.Code, "Wrong status code")
+	assert.Contains(t, rr.Body.String(), services.ErrPubkeyInUse.Error())
+	_, err := dao.GetPubkeyDao(ctx).GetById(ctx, pk.ID)
+	require.NoError(t, err, "pubkey must not be deleted")
+
+	rr = launchProfileRequest(t, ctx, "DELETE", "1", nil, services.DeleteLaunchProfile)
+	require.Equal(t, http.StatusNoContent, rr.Code, "Wrong status code")
+	rr = launchProfileRequest(t, ctx, "DELETE", "1", nil, services.DeletePubkey)
+	require.Equal(t, http.StatusNoContent, rr.Code, "Wrong status code")
+}
+
+func TestCreateReservationFromProfileHandler(t *testing.T) {
+	ctx, pk := prepareLaunchProfileContext(t)
+	ctx = Clientstubs.WithSourcesClient(ctx)
+	ctx = Clientstubs.WithImageBuilderClient(ctx)
+	ctx = stubs.WithReservationDao(ctx)
+	ctx = stub.WithEnqueuer(ctx)
+	require.NoError(t, dao.GetLaunchProfileDao(ctx).Create(ctx, newAWSLaunchProfile(pk.ID)))
+
+	t.Run("Without overrides", func(t *testing.T) {
+		rr := launchProfileRequest(t, ctx, "POST", "1", nil, services.CreateReservationFromProfile)
+		require.Equal(t, http.StatusOK, rr.Code, "Wrong status code: %s", rr.Body.String())
+
+		var result payloads.AWSReservationResponsePayload
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&result), "failed to decode response body")
+		assert.Equal(t, "us-east-1", result.Region)
+		assert.Equal(t, "t1.micro", result.InstanceType)
+		assert.Equal(t, int32(1), result.Amount)
+	})
+
+	t.Run("With overrides", func(t *testing.T) {
+		overrides := payloads.LaunchProfileReservationRequest{Amount: 2, Region: "eu-west-1"}
+		rr := launchProfileRequest(t, ctx, "POST", "1", overrides, services.CreateReservationFromProfile)
+		require.Equal(t, http.StatusOK, rr.Code, "Wrong status code: %s", rr.Body.String())
+
+		var result payloads.AWSReservationResponsePayload
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&result), "failed to decode response body")
+		assert.Equal(t, "eu-west-1", result.Region)
+		assert.Equal(t, int32(2), result.Amount)
+	})
+
+	t.Run("Unknown profile", func(t *testing.T) {
+		rr := launchProfileRequest(t, ctx, "POST", "42", nil, services.CreateReservationFromProfile)
+		require.Equal(t, http.StatusNotFound, rr.Code, "Wrong status code")
+	})
+
+	assert.Equal(t, 2, stubs.AWSReservationStubCount(ctx))
+}
@@ -11,6 +11,8 @@ import (
 	"github.com/RHEnVision/provisioning-backend/internal/queue"
 	"github.com/RHEnVision/provisioning-backend/pkg/worker"
 	"github.com/go-chi/render"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/rs/zerolog"
 )
 
@@ -23,36 +25,54 @@ func CreateNoopReservation(w http.ResponseWriter, r *http.Request) {
 	rDao := dao.GetReservationDao(r.Context())
 	reservation := &models.NoopReservation{
 		Reservation: models.Reservation{
-			Provider:   models.ProviderTypeNoop,
-			AccountID:  accountId,
-			Status:     "Created",
-			Steps:      1,
-			StepTitles: []string{"A test step"},
+			Provider:       models.ProviderTypeNoop,
+			AccountID:      accountId,
+			Status:         "Created",
+			Steps:          1,
+			StepTitles:     []string{"A test step"},
+			JobID:          uuid.NullUUID{UUID: uuid.New(), Valid: true},
+			IdempotencyKey: idempotencyKey(r),
 		},
 	}
 
-	// create reservation in the database
-	err := rDao.CreateNoop(r.Context(), reservation)
-	if err != nil {
-		renderError(w, r, payloads.NewDAOError(r.Context(), "create noop reservation", err))
+	if !enforceQuota(w, r, models.ProviderTypeNoop, 0) {
 		return
 	}
-	logger.Debug().Msgf("Created a new reservation %d", reservation.ID)
 
-	// create a new job
-	pj := worker.Job{
-		Type:      jobs.TypeNoop,
-		AccountID: accountId,
-		Identity:  identity,
-		Args: jobs.NoopJobArgs{
-			ReservationID: reservation.ID,
-		},
-	}
-	err = queue.GetEnqueuer(r.Context()).Enqueue(r.Context(), &pj)
+	// create reservation in the database, transactional queues enqueue the job in the same transaction
+	var pj worker.Job
+	var enqueued bool
+	err := rDao.CreateNoop(r.Context(), reservation, func(tx pgx.Tx) error {
+		if txErr := checkQuotaTx(r.Context(), tx, models.ProviderTypeNoop); txErr != nil {
+			return txErr
+		}
+		pj = worker.Job{
+			ID:        reservation.JobID.UUID,
+			Type:      jobs.TypeNoop,
+			AccountID: accountId,
+			Identity:  identity,
+			Args: jobs.NoopJobArgs{
+				ReservationID: reservation.ID,
+			},
+		}
+
+		var txErr error
+		enqueued, txErr = queue.EnqueueTx(r.Context(), tx, &pj)
+		return txErr
+	})
 	if err != nil {
-		renderError(w, r, payloads.NewEnqueueTaskError(r.Context(), "job enqueue error", err))
+		renderCreateReservationError(w, r, models.ProviderTypeNoop, err, "create noop reservation")
 		return
 	}
+	logger.Debug().Msgf("Created a new reservation %d", reservation.ID)
+
+	if !enqueued {
+		err = queue.GetEnqueuer(r.Context()).Enqueue(r.Context(), &pj)
+		if err != nil {
+			renderError(w, r, payloads.NewEnqueueTaskError(r.Context(), "job enqueue error", err))
+			return
+		}
+	}
 
 	if err := render.Render(w, r, payloads.NewNoopReservationResponse(reservation)); err != nil {
 		renderError(w, r, payloads.NewRenderError(r.Context(), "unable to render reservation", err))
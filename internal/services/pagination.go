@@ -0,0 +1,125 @@
+package services
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
+	"github.com/RHEnVision/provisioning-backend/internal/payloads"
+)
+
+const (
+	defaultListLimit = 100
+	maxListLimit     = 1000
+
+	cursorNext = "next"
+	cursorPrev = "prev"
+)
+
+var (
+	InvalidLimitError  = fmt.Errorf("limit must be a number between 1 and %d", maxListLimit)
+	InvalidCursorError = errors.New("invalid cursor")
+	UnknownSortError   = errors.New("unknown sort, expected values: id, -id")
+)
+
+// parseListParams parses "limit", "sort" and "cursor" URL query parameters. Cursors are opaque
+// values from links of list responses.
+func parseListParams(r *http.Request) (dao.ListParams, error) {
+	query := r.URL.Query()
+	params := dao.ListParams{Limit: defaultListLimit}
+
+	if str := query.Get("limit"); str != "" {
+		limit, err := strconv.ParseInt(str, 10, 64)
+		if err != nil || limit < 1 || limit > maxListLimit {
+			return params, InvalidLimitError
+		}
+		params.Limit = limit
+	}
+
+	switch query.Get("sort") {
+	case "", "id":
+	case "-id":
+		params.Descending = true
+	default:
+		return params, UnknownSortError
+	}
+
+	if str := query.Get("cursor"); str != "" {
+		direction, id, err := decodeCursor(str)
+		if err != nil {
+			return params, err
+		}
+		if direction == cursorNext {
+			params.After = id
+		} else {
+			params.Before = id
+		}
+	}
+
+	return params, nil
+}
+
+// fetchParams returns parameters for a DAO list call, one extra record is fetched
+// to find out if there are more records.
+func fetchParams(params dao.ListParams) dao.ListParams {
+	params.Limit++
+	return params
+}
+
+// listPage removes the extra record fetched with fetchParams and creates links to the
+// neighbouring pages.
+func listPage[T any](r *http.Request, params dao.ListParams, records []T, id func(T) int64) ([]T, payloads.ListLinks) {
+	more := int64(len(records)) > params.Limit
+	if more {
+		if params.Backward() {
+			records = records[1:]
+		} else {
+			records = records[:params.Limit]
+		}
+	}
+
+	var links payloads.ListLinks
+	if len(records) == 0 {
+		return records, links
+	}
+	if more || params.Backward() {
+		links.Next = pageLink(r, encodeCursor(cursorNext, id(records[len(records)-1])))
+	}
+	if (more && params.Backward()) || params.After != 0 {
+		links.Previous = pageLink(r, encodeCursor(cursorPrev, id(records[0])))
+	}
+	return records, links
+}
+
+func pageLink(r *http.Request, cursor string) string {
+	u := *r.URL
+	query := u.Query()
+	query.Set("cursor", cursor)
+	u.RawQuery = query.Encode()
+	return u.RequestURI()
+}
+
+func encodeCursor(direction string, id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%d", direction, id)))
+}
+
+func decodeCursor(cursor string) (string, int64, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", 0, fmt.Errorf("%w: %s", InvalidCursorError, err.Error())
+	}
+
+	direction, str, found := strings.Cut(string(buf), ":")
+	if !found || (direction != cursorNext && direction != cursorPrev) {
+		return "", 0, InvalidCursorError
+	}
+	id, err := strconv.ParseInt(str, 10, 64)
+	if err != nil || id < 1 {
+		return "", 0, InvalidCursorError
+	}
+	return direction, id, nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -39,3 +40,15 @@ func ParseBool(str string) (*bool, error) {
 	}
 	return &b, nil
 }
+
+// parseTime converts string in RFC 3339 format into UTC time. Returns zero time when string is empty.
+func parseTime(str string) (time.Time, error) {
+	if str == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, str)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error parsing '%s' to time: %w", str, err)
+	}
+	return t.UTC(), nil
+}
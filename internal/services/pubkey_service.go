@@ -15,7 +15,10 @@ import (
 	"github.com/rs/zerolog"
 )
 
-var ErrMissingNameOrBody = errors.New("name or body missing")
+var (
+	ErrMissingNameOrBody = errors.New("name or body missing")
+	ErrPubkeyInUse       = errors.New("pubkey is used by a launch profile")
+)
 
 func CreatePubkey(w http.ResponseWriter, r *http.Request) {
 	payload := &payloads.PubkeyRequest{}
@@ -47,15 +50,28 @@ func CreatePubkey(w http.ResponseWriter, r *http.Request) {
 }
 
 func ListPubkeys(w http.ResponseWriter, r *http.Request) {
+	params, err := parseListParams(r)
+	if err != nil {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "list parameters", err))
+		return
+	}
+
 	pubkeyDao := dao.GetPubkeyDao(r.Context())
 
-	pubkeys, err := pubkeyDao.List(r.Context(), 100, 0)
+	pubkeys, err := pubkeyDao.List(r.Context(), fetchParams(params))
 	if err != nil {
 		renderError(w, r, payloads.NewDAOError(r.Context(), "list pubkeys", err))
 		return
 	}
 
-	if err := render.RenderList(w, r, payloads.NewPubkeyListResponse(pubkeys)); err != nil {
+	total, err := pubkeyDao.Count(r.Context())
+	if err != nil {
+		renderError(w, r, payloads.NewDAOError(r.Context(), "count pubkeys", err))
+		return
+	}
+
+	pubkeys, links := listPage(r, params, pubkeys, func(pk *models.Pubkey) int64 { return pk.ID })
+	if err := render.Render(w, r, payloads.NewPubkeyListResponse(pubkeys, total, links)); err != nil {
 		renderError(w, r, payloads.NewRenderError(r.Context(), "unable to render pubkeys list", err))
 		return
 	}
@@ -82,6 +98,101 @@ func GetPubkey(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// UpdatePubkey renames a pubkey, the pubkey is validated again before it is saved.
+func UpdatePubkey(w http.ResponseWriter, r *http.Request) {
+	id, err := ParseInt64(r, "ID")
+	if err != nil {
+		renderError(w, r, payloads.NewURLParsingError(r.Context(), "unable to parse ID parameter", err))
+		return
+	}
+
+	payload := &payloads.PubkeyUpdateRequest{}
+	if err = render.Bind(r, payload); err != nil {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "update pubkey", err))
+		return
+	}
+
+	pubkeyDao := dao.GetPubkeyDao(r.Context())
+
+	pubkey, err := pubkeyDao.GetById(r.Context(), id)
+	if err != nil {
+		message := fmt.Sprintf("get pubkey with id %d", id)
+		renderNotFoundOrDAOError(w, r, err, message)
+		return
+	}
+
+	payload.Apply(pubkey)
+	if vErr := models.Validate(r.Context(), pubkey); vErr != nil {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "update pubkey", vErr))
+		return
+	}
+
+	err = pubkeyDao.Update(r.Context(), pubkey)
+	if err != nil {
+		if db.IsPostgresError(err, db.UniqueConstraintErrorCode) != nil {
+			renderError(w, r, payloads.PubkeyDuplicateError(r.Context(), "pubkey with such name already exists for this account", err))
+		} else {
+			renderError(w, r, payloads.NewDAOError(r.Context(), "update pubkey", err))
+		}
+		return
+	}
+
+	if err := render.Render(w, r, payloads.NewPubkeyResponse(pubkey)); err != nil {
+		renderError(w, r, payloads.NewRenderError(r.Context(), "unable to render pubkey", err))
+	}
+}
+
+// ListPubkeyResources returns uploads of a pubkey to cloud providers and a page of reservations
+// which authorized the pubkey.
+func ListPubkeyResources(w http.ResponseWriter, r *http.Request) {
+	id, err := ParseInt64(r, "ID")
+	if err != nil {
+		renderError(w, r, payloads.NewURLParsingError(r.Context(), "unable to parse ID parameter", err))
+		return
+	}
+
+	params, err := parseListParams(r)
+	if err != nil {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "list parameters", err))
+		return
+	}
+
+	pubkeyDao := dao.GetPubkeyDao(r.Context())
+
+	// resources are not scoped by account, check the pubkey belongs to the account first
+	pubkey, err := pubkeyDao.GetById(r.Context(), id)
+	if err != nil {
+		message := fmt.Sprintf("get pubkey with id %d", id)
+		renderNotFoundOrDAOError(w, r, err, message)
+		return
+	}
+
+	resources, err := pubkeyDao.UnscopedListResourcesByPubkeyId(r.Context(), pubkey.ID)
+	if err != nil {
+		renderError(w, r, payloads.NewDAOError(r.Context(), "list pubkey resources", err))
+		return
+	}
+
+	rDao := dao.GetReservationDao(r.Context())
+	filter := dao.ReservationFilter{PubkeyID: pubkey.ID}
+	reservations, err := rDao.List(r.Context(), fetchParams(params), filter)
+	if err != nil {
+		renderError(w, r, payloads.NewDAOError(r.Context(), "list pubkey reservations", err))
+		return
+	}
+
+	total, err := rDao.Count(r.Context(), filter)
+	if err != nil {
+		renderError(w, r, payloads.NewDAOError(r.Context(), "count pubkey reservations", err))
+		return
+	}
+
+	reservations, links := listPage(r, params, reservations, func(res *models.Reservation) int64 { return res.ID })
+	if err := render.Render(w, r, payloads.NewPubkeyResourcesResponse(resources, reservations, total, links)); err != nil {
+		renderError(w, r, payloads.NewRenderError(r.Context(), "unable to render pubkey resources", err))
+	}
+}
+
 func DeletePubkey(w http.ResponseWriter, r *http.Request) {
 	logger := zerolog.Ctx(r.Context())
 	sourcesClient, err := clients.GetSourcesClient(r.Context())
@@ -105,6 +216,17 @@ func DeletePubkey(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// profiles are checked before keys are deleted from clouds, the database refuses the delete too
+	profiles, err := dao.GetLaunchProfileDao(r.Context()).CountByPubkeyId(r.Context(), pubkey.ID)
+	if err != nil {
+		renderError(w, r, payloads.NewDAOError(r.Context(), "count launch profiles by pubkey", err))
+		return
+	}
+	if profiles > 0 {
+		renderError(w, r, payloads.PubkeyInUseError(r.Context(), "delete pubkey", ErrPubkeyInUse))
+		return
+	}
+
 	resources, err := pubkeyDao.UnscopedListResourcesByPubkeyId(r.Context(), pubkey.ID)
 	if err != nil {
 		message := fmt.Sprintf("list resources by pubkey id %d", pubkey.ID)
@@ -143,6 +265,10 @@ func DeletePubkey(w http.ResponseWriter, r *http.Request) {
 	}
 
 	err = pubkeyDao.Delete(r.Context(), id)
+	if db.IsPostgresError(err, db.ForeignKeyConstraintErrorCode) != nil {
+		renderError(w, r, payloads.PubkeyInUseError(r.Context(), "delete pubkey", ErrPubkeyInUse))
+		return
+	}
 	if err != nil {
 		message := fmt.Sprintf("pubkey with id %d", id)
 		renderNotFoundOrDAOError(w, r, err, message)
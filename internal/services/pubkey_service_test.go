@@ -6,16 +6,22 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strconv"
 	"testing"
 
 	"github.com/RHEnVision/provisioning-backend/internal/services"
 	_ "github.com/RHEnVision/provisioning-backend/internal/testing/initialization"
 	"github.com/stretchr/testify/require"
 
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
 	"github.com/RHEnVision/provisioning-backend/internal/dao/stubs"
+	identity2 "github.com/RHEnVision/provisioning-backend/internal/identity"
 	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/RHEnVision/provisioning-backend/internal/payloads"
 	"github.com/RHEnVision/provisioning-backend/internal/testing/factories"
 	"github.com/RHEnVision/provisioning-backend/internal/testing/identity"
+	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -43,12 +49,13 @@ func TestListPubkeysHandler(t *testing.T) {
 
 	require.Equal(t, http.StatusOK, rr.Code, "Wrong status code")
 
-	var pubkeys []models.Pubkey
+	var pubkeys payloads.PubkeyListResponse
 
 	err = json.NewDecoder(rr.Body).Decode(&pubkeys)
 	require.NoError(t, err, "failed to decode response body")
 
-	assert.Equal(t, 2, len(pubkeys), "expected two pubkeys in response json")
+	assert.Equal(t, 2, len(pubkeys.Data), "expected two pubkeys in response json")
+	assert.Equal(t, int64(2), pubkeys.Metadata.Total, "expected total of two pubkeys in response json")
 }
 
 func TestCreatePubkeyHandler(t *testing.T) {
@@ -80,3 +87,138 @@ func TestCreatePubkeyHandler(t *testing.T) {
 	stubCount := stubs.PubkeyStubCount(ctx)
 	assert.Equal(t, 1, stubCount, "Pubkey has not been Created through DAO")
 }
+
+func TestUpdatePubkeyHandler(t *testing.T) {
+	ctx := stubs.WithAccountDaoOne(context.Background())
+	ctx = identity.WithTenant(t, ctx)
+	ctx = stubs.WithPubkeyDao(ctx)
+	pk := factories.NewPubkeyED25519()
+	require.NoError(t, stubs.AddPubkey(ctx, pk), "failed to add stubbed key")
+
+	updatePubkey := func(t *testing.T, id string, name string) *httptest.ResponseRecorder {
+		t.Helper()
+		jsonData, err := json.Marshal(map[string]interface{}{"name": name})
+		require.NoError(t, err, "unable to marshal values to json")
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("ID", id)
+		reqCtx := context.WithValue(ctx, chi.RouteCtxKey, rctx)
+		req, err := http.NewRequestWithContext(reqCtx, "PATCH", "/api/provisioning/pubkeys/"+id, bytes.NewBuffer(jsonData))
+		require.NoError(t, err, "failed to create request")
+		req.Header.Add("Content-Type", "application/json")
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(services.UpdatePubkey)
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("renames pubkey", func(t *testing.T) {
+		rr := updatePubkey(t, strconv.FormatInt(pk.ID, 10), "renamed key")
+		require.Equal(t, http.StatusOK, rr.Code, "Handler returned wrong status code")
+
+		var result payloads.PubkeyResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&result), "failed to decode response body")
+		assert.Equal(t, "renamed key", result.Name)
+		assert.Equal(t, pk.Body, result.Body)
+		assert.NotEmpty(t, result.Fingerprint)
+	})
+
+	t.Run("rejects empty name", func(t *testing.T) {
+		rr := updatePubkey(t, strconv.FormatInt(pk.ID, 10), "")
+		assert.Equal(t, http.StatusBadRequest, rr.Code, "Handler returned wrong status code")
+	})
+
+	t.Run("unknown pubkey", func(t *testing.T) {
+		rr := updatePubkey(t, "987654", "renamed key")
+		assert.Equal(t, http.StatusNotFound, rr.Code, "Handler returned wrong status code")
+	})
+}
+
+func TestListPubkeyResourcesHandler(t *testing.T) {
+	ctx := stubs.WithAccountDaoOne(context.Background())
+	ctx = identity.WithTenant(t, ctx)
+	ctx = stubs.WithPubkeyDao(ctx)
+	ctx = stubs.WithReservationDao(ctx)
+	pk := factories.NewPubkeyRSA()
+	require.NoError(t, stubs.AddPubkey(ctx, pk), "failed to add stubbed key")
+	pk2 := factories.NewPubkeyED25519()
+	require.NoError(t, stubs.AddPubkey(ctx, pk2), "failed to add stubbed key")
+
+	require.NoError(t, dao.GetPubkeyDao(ctx).UnscopedCreateResource(ctx, &models.PubkeyResource{
+		PubkeyID: pk.ID,
+		Provider: models.ProviderTypeAWS,
+		SourceID: "1",
+		Handle:   "key-0f1d2e3c4b5a69788",
+		Region:   "us-east-1",
+	}), "failed to add stubbed resource")
+
+	addReservation := func(t *testing.T, pubkeyID int64, pubkeyIDs []int64) *models.AWSReservation {
+		t.Helper()
+		reservation := &models.AWSReservation{
+			PubkeyID: pubkeyID,
+			SourceID: "1",
+			ImageID:  "ami-random",
+			Detail: &models.AWSDetail{
+				Region:       "us-east-1",
+				InstanceType: "t1.micro",
+				Amount:       1,
+				PubkeyIDs:    pubkeyIDs,
+			},
+		}
+		reservation.AccountID = identity2.AccountId(ctx)
+		reservation.Provider = models.ProviderTypeAWS
+		reservation.Steps = 2
+		require.NoError(t, stubs.AddAWSReservation(ctx, reservation), "failed to add stubbed reservation")
+		return reservation
+	}
+	primary := addReservation(t, pk.ID, nil)
+	additional := addReservation(t, pk2.ID, []int64{pk.ID})
+	addReservation(t, pk2.ID, nil)
+
+	listResources := func(t *testing.T, query string) payloads.PubkeyResourcesResponse {
+		t.Helper()
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("ID", strconv.FormatInt(pk.ID, 10))
+		reqCtx := context.WithValue(ctx, chi.RouteCtxKey, rctx)
+		req, err := http.NewRequestWithContext(reqCtx, "GET", "/api/provisioning/pubkeys/1/resources"+query, nil)
+		require.NoError(t, err, "failed to create request")
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(services.ListPubkeyResources)
+		handler.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code, "Handler returned wrong status code")
+
+		var result payloads.PubkeyResourcesResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&result), "failed to decode response body")
+		return result
+	}
+
+	t.Run("all", func(t *testing.T) {
+		result := listResources(t, "?sort=-id")
+		require.Len(t, result.Resources, 1)
+		assert.Equal(t, "aws", result.Resources[0].Provider)
+		assert.Equal(t, "key-0f1d2e3c4b5a69788", result.Resources[0].Handle)
+		require.Len(t, result.Reservations, 2)
+		assert.Equal(t, additional.ID, result.Reservations[0].ID)
+		assert.Equal(t, primary.ID, result.Reservations[1].ID)
+		assert.Equal(t, int64(2), result.Metadata.Total)
+		assert.Empty(t, result.Links.Next)
+	})
+
+	t.Run("paged", func(t *testing.T) {
+		result := listResources(t, "?limit=1")
+		require.Len(t, result.Resources, 1)
+		require.Len(t, result.Reservations, 1)
+		assert.Equal(t, primary.ID, result.Reservations[0].ID)
+		assert.Equal(t, int64(2), result.Metadata.Total)
+		require.NotEmpty(t, result.Links.Next)
+
+		next, err := url.Parse(result.Links.Next)
+		require.NoError(t, err)
+		result = listResources(t, "?"+next.RawQuery)
+		require.Len(t, result.Reservations, 1)
+		assert.Equal(t, additional.ID, result.Reservations[0].ID)
+		assert.Empty(t, result.Links.Next)
+	})
+}
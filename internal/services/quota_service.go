@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/RHEnVision/provisioning-backend/internal/config"
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
+	"github.com/RHEnVision/provisioning-backend/internal/identity"
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/RHEnVision/provisioning-backend/internal/payloads"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	QuotaInflightReservationsError    = errors.New("maximum of reservations in progress reached")
+	QuotaInstancesPerReservationError = errors.New("amount of instances is over the maximum per reservation")
+	QuotaInstancesPerDayError         = errors.New("maximum of instances launched in the last 24 hours reached")
+)
+
+// quotaDefaults returns launch limits from the application configuration.
+func quotaDefaults() models.QuotaLimits {
+	return models.QuotaLimits{
+		MaxInflightReservations:    &config.Quota.MaxInflightReservations,
+		MaxInstancesPerReservation: &config.Quota.MaxInstancesPerReservation,
+		MaxInstancesPerDay:         &config.Quota.MaxInstancesPerDay,
+	}
+}
+
+// overLimit returns true when the value is over the limit, zero or nil limit means unlimited.
+func overLimit(limit *int32, value int64) bool {
+	return limit != nil && *limit > 0 && value > int64(*limit)
+}
+
+// checkQuota returns an error when a new reservation of the provider type launching amount
+// of instances would exceed limits of the current account. It is a check before a reservation is
+// created, concurrent requests are only checked reliably by checkQuotaTx.
+func checkQuota(ctx context.Context, provider models.ProviderType, amount int64) error {
+	limits, err := quotaLimits(ctx, provider)
+	if err != nil {
+		return err
+	}
+
+	if overLimit(limits.MaxInstancesPerReservation, amount) {
+		return fmt.Errorf("%w: %d", QuotaInstancesPerReservationError, *limits.MaxInstancesPerReservation)
+	}
+
+	rDao := dao.GetReservationDao(ctx)
+	inflight, err := rDao.CountInflight(ctx, provider)
+	if err != nil {
+		return fmt.Errorf("count reservations in progress: %w", err)
+	}
+	launched, err := rDao.SumInstancesSince(ctx, provider, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		return fmt.Errorf("sum launched instances: %w", err)
+	}
+
+	return checkUsage(limits, dao.QuotaUsage{Inflight: inflight + 1, Instances: launched + amount})
+}
+
+// checkQuotaTx checks limits of the current account again in the transaction creating a new
+// reservation of the provider type, after the reservation was inserted. Quota of the account
+// is locked until the transaction ends, so concurrent requests cannot exceed it together.
+func checkQuotaTx(ctx context.Context, tx pgx.Tx, provider models.ProviderType) error {
+	usage, err := dao.GetReservationDao(ctx).QuotaUsageTx(ctx, tx, provider, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		return fmt.Errorf("lock quota: %w", err)
+	}
+
+	limits, err := quotaLimits(ctx, provider)
+	if err != nil {
+		return err
+	}
+	return checkUsage(limits, *usage)
+}
+
+func quotaLimits(ctx context.Context, provider models.ProviderType) (models.QuotaLimits, error) {
+	account, err := dao.GetAccountDao(ctx).GetById(ctx, identity.AccountId(ctx))
+	if err != nil {
+		return models.QuotaLimits{}, fmt.Errorf("get account: %w", err)
+	}
+	return account.Quota.Effective(provider, quotaDefaults()), nil
+}
+
+// checkUsage returns an error when usage including a new reservation is over the limits.
+func checkUsage(limits models.QuotaLimits, usage dao.QuotaUsage) error {
+	if overLimit(limits.MaxInflightReservations, usage.Inflight) {
+		return fmt.Errorf("%w: %d", QuotaInflightReservationsError, *limits.MaxInflightReservations)
+	}
+	if overLimit(limits.MaxInstancesPerDay, usage.Instances) {
+		return fmt.Errorf("%w: %d", QuotaInstancesPerDayError, *limits.MaxInstancesPerDay)
+	}
+	return nil
+}
+
+// enforceQuota checks limits of the current account before a reservation is created and renders
+// an error when a limit would be exceeded. Returns false when the request must not continue.
+func enforceQuota(w http.ResponseWriter, r *http.Request, provider models.ProviderType, amount int64) bool {
+	err := checkQuota(r.Context(), provider, amount)
+	if err == nil {
+		return true
+	}
+	renderQuotaError(w, r, err)
+	return false
+}
+
+// renderQuotaError renders an error returned by checkQuota or checkQuotaTx.
+func renderQuotaError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, QuotaInstancesPerReservationError):
+		renderError(w, r, payloads.QuotaForbiddenError(r.Context(), "quota exceeded", err))
+	case errors.Is(err, QuotaInflightReservationsError), errors.Is(err, QuotaInstancesPerDayError):
+		renderError(w, r, payloads.QuotaExceededError(r.Context(), "quota exceeded", err))
+	default:
+		renderError(w, r, payloads.NewDAOError(r.Context(), "check quota", err))
+	}
+}
+
+// GetAccountQuota returns launch limits of an account, admin only.
+func GetAccountQuota(w http.ResponseWriter, r *http.Request) {
+	orgId := chi.URLParam(r, "ORG_ID")
+
+	account, err := dao.GetAccountDao(r.Context()).GetByOrgId(r.Context(), orgId)
+	if err != nil {
+		renderNotFoundOrDAOError(w, r, err, fmt.Sprintf("get account with org id %s", orgId))
+		return
+	}
+
+	if err := render.Render(w, r, payloads.NewAccountQuotaResponse(account, quotaDefaults())); err != nil {
+		renderError(w, r, payloads.NewRenderError(r.Context(), "unable to render account quota", err))
+	}
+}
+
+// UpdateAccountQuota replaces launch limits of an account, admin only.
+func UpdateAccountQuota(w http.ResponseWriter, r *http.Request) {
+	orgId := chi.URLParam(r, "ORG_ID")
+
+	payload := &payloads.AccountQuotaRequest{}
+	if err := render.Bind(r, payload); err != nil {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "account quota", err))
+		return
+	}
+
+	accDao := dao.GetAccountDao(r.Context())
+	account, err := accDao.GetByOrgId(r.Context(), orgId)
+	if err != nil {
+		renderNotFoundOrDAOError(w, r, err, fmt.Sprintf("get account with org id %s", orgId))
+		return
+	}
+
+	account.Quota = *payload.NewModel()
+	err = accDao.UpdateQuota(r.Context(), account.ID, &account.Quota)
+	if err != nil {
+		renderError(w, r, payloads.NewDAOError(r.Context(), "update account quota", err))
+		return
+	}
+
+	if err := render.Render(w, r, payloads.NewAccountQuotaResponse(account, quotaDefaults())); err != nil {
+		renderError(w, r, payloads.NewRenderError(r.Context(), "unable to render account quota", err))
+	}
+}
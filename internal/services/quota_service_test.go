@@ -0,0 +1,193 @@
+package services_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	Clientstubs "github.com/RHEnVision/provisioning-backend/internal/clients/stubs"
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
+	"github.com/RHEnVision/provisioning-backend/internal/dao/stubs"
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/RHEnVision/provisioning-backend/internal/payloads"
+	"github.com/RHEnVision/provisioning-backend/internal/ptr"
+	"github.com/RHEnVision/provisioning-backend/internal/queue/stub"
+	"github.com/RHEnVision/provisioning-backend/internal/services"
+	"github.com/RHEnVision/provisioning-backend/internal/testing/factories"
+	"github.com/RHEnVision/provisioning-backend/internal/testing/identity"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func prepareQuotaContext(t *testing.T, quota models.AccountQuota) (context.Context, int64) {
+	t.Helper()
+
+	ctx := stubs.WithAccountDaoOne(context.Background())
+	ctx = identity.WithTenant(t, ctx)
+	ctx = Clientstubs.WithSourcesClient(ctx)
+	ctx = Clientstubs.WithImageBuilderClient(ctx)
+	ctx = stubs.WithReservationDao(ctx)
+	ctx = stubs.WithPubkeyDao(ctx)
+	ctx = stub.WithEnqueuer(ctx)
+
+	err := dao.GetAccountDao(ctx).UpdateQuota(ctx, 1, &quota)
+	require.NoError(t, err, "failed to set quota")
+
+	pk := factories.NewPubkeyRSA()
+	err = stubs.AddPubkey(ctx, pk)
+	require.NoError(t, err, "failed to generate pubkey")
+	return ctx, pk.ID
+}
+
+func createAWSReservation(t *testing.T, ctx context.Context, pubkeyId int64, amount int) *httptest.ResponseRecorder {
+	t.Helper()
+
+	values := map[string]interface{}{
+		"source_id":     "1",
+		"image_id":      "ami-random",
+		"amount":        amount,
+		"instance_type": "t1.micro",
+		"pubkey_id":     pubkeyId,
+	}
+	jsonData, err := json.Marshal(values)
+	require.NoError(t, err, "unable to marshal values to json")
+
+	req, err := http.NewRequestWithContext(ctx, "POST", "/api/provisioning/reservations/aws", bytes.NewBuffer(jsonData))
+	require.NoError(t, err, "failed to create request")
+	req.Header.Add("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+	handler := http.HandlerFunc(services.CreateAWSReservation)
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestCreateReservationQuota(t *testing.T) {
+	t.Run("instances per reservation", func(t *testing.T) {
+		quota := models.AccountQuota{QuotaLimits: models.QuotaLimits{MaxInstancesPerReservation: ptr.To(int32(2))}}
+		ctx, pkId := prepareQuotaContext(t, quota)
+
+		rr := createAWSReservation(t, ctx, pkId, 2)
+		require.Equal(t, http.StatusOK, rr.Code, "Handler returned wrong status code")
+
+		rr = createAWSReservation(t, ctx, pkId, 3)
+		require.Equal(t, http.StatusForbidden, rr.Code, "Handler returned wrong status code")
+		assert.Equal(t, 1, stubs.AWSReservationStubCount(ctx), "Expected no reservation over quota")
+	})
+
+	t.Run("reservations in progress", func(t *testing.T) {
+		quota := models.AccountQuota{QuotaLimits: models.QuotaLimits{MaxInflightReservations: ptr.To(int32(1))}}
+		ctx, pkId := prepareQuotaContext(t, quota)
+
+		rr := createAWSReservation(t, ctx, pkId, 1)
+		require.Equal(t, http.StatusOK, rr.Code, "Handler returned wrong status code")
+
+		rr = createAWSReservation(t, ctx, pkId, 1)
+		require.Equal(t, http.StatusTooManyRequests, rr.Code, "Handler returned wrong status code")
+
+		// finished reservations are not in progress
+		reservation, err := dao.GetReservationDao(ctx).GetAWSById(ctx, 1)
+		require.NoError(t, err)
+		reservation.FinishedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+		rr = createAWSReservation(t, ctx, pkId, 1)
+		require.Equal(t, http.StatusOK, rr.Code, "Handler returned wrong status code")
+	})
+
+	t.Run("instances per day", func(t *testing.T) {
+		quota := models.AccountQuota{QuotaLimits: models.QuotaLimits{MaxInstancesPerDay: ptr.To(int32(3))}}
+		ctx, pkId := prepareQuotaContext(t, quota)
+
+		rr := createAWSReservation(t, ctx, pkId, 2)
+		require.Equal(t, http.StatusOK, rr.Code, "Handler returned wrong status code")
+
+		rr = createAWSReservation(t, ctx, pkId, 2)
+		require.Equal(t, http.StatusTooManyRequests, rr.Code, "Handler returned wrong status code")
+
+		rr = createAWSReservation(t, ctx, pkId, 1)
+		require.Equal(t, http.StatusOK, rr.Code, "Handler returned wrong status code")
+	})
+
+	t.Run("provider override", func(t *testing.T) {
+		quota := models.AccountQuota{
+			QuotaLimits: models.QuotaLimits{MaxInstancesPerReservation: ptr.To(int32(1))},
+			Providers: map[string]models.QuotaLimits{
+				"aws": {MaxInstancesPerReservation: ptr.To(int32(0))},
+			},
+		}
+		ctx, pkId := prepareQuotaContext(t, quota)
+
+		rr := createAWSReservation(t, ctx, pkId, 5)
+		require.Equal(t, http.StatusOK, rr.Code, "Handler returned wrong status code")
+	})
+}
+
+func TestAccountQuota(t *testing.T) {
+	quotaRequest := func(t *testing.T, method, orgId, body string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		ctx := stubs.WithAccountDaoOne(context.Background())
+		ctx = identity.WithAssociateIdentity(t, ctx)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("ORG_ID", orgId)
+		ctx = context.WithValue(ctx, chi.RouteCtxKey, rctx)
+
+		req, err := http.NewRequestWithContext(ctx, method, "/api/provisioning/v1/admin/accounts/"+orgId+"/quota", strings.NewReader(body))
+		require.NoError(t, err, "failed to create request")
+		req.Header.Add("Content-Type", "application/json")
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(services.GetAccountQuota)
+		if method == "PUT" {
+			handler = services.UpdateAccountQuota
+		}
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("get defaults", func(t *testing.T) {
+		rr := quotaRequest(t, "GET", identity.DefaultOrgId, "")
+		require.Equal(t, http.StatusOK, rr.Code, "Handler returned wrong status code")
+
+		var result payloads.AccountQuotaResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&result), "failed to decode response body")
+		assert.Equal(t, identity.DefaultOrgId, result.OrgID)
+		assert.Nil(t, result.MaxInstancesPerDay)
+		require.Contains(t, result.Effective, "aws")
+		assert.NotNil(t, result.Effective["aws"].MaxInstancesPerDay)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		rr := quotaRequest(t, "PUT", identity.DefaultOrgId, `{"max_instances_per_day": 10, "providers": {"AWS": {"max_instances_per_day": 5}}}`)
+		require.Equal(t, http.StatusOK, rr.Code, "Handler returned wrong status code")
+
+		var result payloads.AccountQuotaResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&result), "failed to decode response body")
+		assert.Equal(t, int32(10), *result.MaxInstancesPerDay)
+		assert.Equal(t, int32(5), *result.Providers["aws"].MaxInstancesPerDay)
+		assert.Equal(t, int32(5), *result.Effective["aws"].MaxInstancesPerDay)
+		assert.Equal(t, int32(10), *result.Effective["gcp"].MaxInstancesPerDay)
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		rr := quotaRequest(t, "PUT", identity.DefaultOrgId, `{"providers": {"openstack": {"max_instances_per_day": 5}}}`)
+		require.Equal(t, http.StatusBadRequest, rr.Code, "Handler returned wrong status code")
+	})
+
+	t.Run("negative limit", func(t *testing.T) {
+		rr := quotaRequest(t, "PUT", identity.DefaultOrgId, `{"max_inflight_reservations": -1}`)
+		require.Equal(t, http.StatusBadRequest, rr.Code, "Handler returned wrong status code")
+	})
+
+	t.Run("unknown account", func(t *testing.T) {
+		rr := quotaRequest(t, "GET", "999", "")
+		require.Equal(t, http.StatusNotFound, rr.Code, "Handler returned wrong status code")
+	})
+}
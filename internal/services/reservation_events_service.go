@@ -0,0 +1,137 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/RHEnVision/provisioning-backend/internal/background"
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/RHEnVision/provisioning-backend/internal/payloads"
+	"github.com/rs/zerolog"
+)
+
+// Interval of keep-alive comments which prevent proxies from closing idle streams. The
+// reservation is also reloaded in case a change notification was lost.
+const eventStreamKeepAlive = 15 * time.Second
+
+var StreamingNotSupportedError = errors.New("response streaming not supported")
+
+// eventStream writes server-sent events, events with unchanged data are not sent again.
+type eventStream struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	last    map[string][]byte
+}
+
+func (s *eventStream) send(event string, data any) error {
+	buf, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("unable to marshal event %s: %w", event, err)
+	}
+	if bytes.Equal(s.last[event], buf) {
+		return nil
+	}
+	s.last[event] = buf
+
+	if _, err = fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, buf); err != nil {
+		return fmt.Errorf("unable to write event %s: %w", event, err)
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+func (s *eventStream) comment(text string) error {
+	if _, err := fmt.Fprintf(s.w, ": %s\n\n", text); err != nil {
+		return fmt.Errorf("unable to write comment: %w", err)
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+// sendReservation sends "reservation" event with the generic reservation and "instances" event
+// with the list of instances.
+func (s *eventStream) sendReservation(ctx context.Context, rDao dao.ReservationDao, reservation *models.Reservation) error {
+	instances, err := rDao.ListInstances(ctx, reservation.ID)
+	if err != nil {
+		return fmt.Errorf("unable to list instances: %w", err)
+	}
+
+	if err = s.send("reservation", payloads.NewReservationResponse(reservation)); err != nil {
+		return err
+	}
+	return s.send("instances", payloads.NewReservationInstancesResponse(instances))
+}
+
+// StreamReservationEvents streams reservation changes as server-sent events until the
+// reservation is finished or the client disconnects.
+func StreamReservationEvents(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	id, err := ParseInt64(r, "ID")
+	if err != nil {
+		renderError(w, r, payloads.NewURLParsingError(r.Context(), "unable to parse ID parameter", err))
+		return
+	}
+
+	// subscribe before the first read so no change is missed
+	changes, unsubscribe := background.SubscribeReservationChanges(id)
+	defer unsubscribe()
+
+	rDao := dao.GetReservationDao(r.Context())
+	reservation, err := rDao.GetById(r.Context(), id)
+	if err != nil {
+		renderNotFoundOrDAOError(w, r, err, "get reservation detail")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		renderError(w, r, payloads.NewRenderError(r.Context(), "unable to stream reservation events", StreamingNotSupportedError))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	stream := &eventStream{w: w, flusher: flusher, last: make(map[string][]byte)}
+	ticker := time.NewTicker(eventStreamKeepAlive)
+	defer ticker.Stop()
+
+	for {
+		if err = stream.sendReservation(r.Context(), rDao, reservation); err != nil {
+			logger.Warn().Err(err).Msgf("Unable to send events of reservation %d", id)
+			return
+		}
+		if reservation.FinishedAt.Valid {
+			return
+		}
+
+		select {
+		case _, open := <-changes:
+			if !open {
+				return
+			}
+		case <-ticker.C:
+			if err = stream.comment("keep-alive"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+
+		reservation, err = rDao.GetById(r.Context(), id)
+		if err != nil {
+			logger.Warn().Err(err).Msgf("Unable to reload reservation %d", id)
+			return
+		}
+	}
+}
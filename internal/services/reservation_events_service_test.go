@@ -0,0 +1,55 @@
+package services_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RHEnVision/provisioning-backend/internal/dao/stubs"
+	"github.com/RHEnVision/provisioning-backend/internal/services"
+	"github.com/RHEnVision/provisioning-backend/internal/testing/identity"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamReservationEvents(t *testing.T) {
+	eventsRequest := func(t *testing.T, ctx context.Context, id string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("ID", id)
+		reqCtx := context.WithValue(ctx, chi.RouteCtxKey, rctx)
+		req, err := http.NewRequestWithContext(reqCtx, "GET", "/api/provisioning/v1/reservations/"+id+"/events", nil)
+		require.NoError(t, err, "failed to create request")
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(services.StreamReservationEvents)
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Finished reservation", func(t *testing.T) {
+		ctx := stubs.WithAccountDaoOne(context.Background())
+		ctx = identity.WithTenant(t, ctx)
+		ctx = stubs.WithPubkeyDao(ctx)
+		ctx = stubs.WithReservationDao(ctx)
+		prepareFinishedAWSReservation(t, ctx, true)
+
+		rr := eventsRequest(t, ctx, "1")
+		require.Equal(t, http.StatusOK, rr.Code, "Wrong status code")
+		assert.Equal(t, "text/event-stream", rr.Header().Get("Content-Type"))
+		assert.Contains(t, rr.Body.String(), "event: reservation\ndata: {\"id\":1,")
+		assert.Contains(t, rr.Body.String(), "event: instances\ndata: []\n\n")
+	})
+
+	t.Run("Unknown reservation", func(t *testing.T) {
+		ctx := stubs.WithAccountDaoOne(context.Background())
+		ctx = identity.WithTenant(t, ctx)
+		ctx = stubs.WithReservationDao(ctx)
+
+		rr := eventsRequest(t, ctx, "42")
+		require.Equal(t, http.StatusNotFound, rr.Code, "Wrong status code")
+	})
+}
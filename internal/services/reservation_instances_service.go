@@ -0,0 +1,279 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/RHEnVision/provisioning-backend/internal/clients"
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
+	"github.com/RHEnVision/provisioning-backend/internal/identity"
+	"github.com/RHEnVision/provisioning-backend/internal/jobs"
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/RHEnVision/provisioning-backend/internal/payloads"
+	"github.com/RHEnVision/provisioning-backend/internal/queue"
+	"github.com/RHEnVision/provisioning-backend/pkg/worker"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/rs/zerolog"
+)
+
+// DeleteReservationInstances enqueues a job which terminates all instances of a finished reservation.
+func DeleteReservationInstances(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	id, err := ParseInt64(r, "ID")
+	if err != nil {
+		renderError(w, r, payloads.NewURLParsingError(r.Context(), "unable to parse ID parameter", err))
+		return
+	}
+
+	rDao := dao.GetReservationDao(r.Context())
+	reservation, err := rDao.GetById(r.Context(), id)
+	if err != nil {
+		renderNotFoundOrDAOError(w, r, err, "get reservation detail")
+		return
+	}
+
+	if !reservation.FinishedAt.Valid {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "reservation is still in progress", ReservationInProgressError))
+		return
+	}
+	if reservation.TeardownQueuedAt.Valid {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "instances termination is already queued", TeardownQueuedError))
+		return
+	}
+
+	authentication, region, ok := reservationAuthentication(w, r, reservation)
+	if !ok {
+		return
+	}
+
+	terminateJob := worker.Job{
+		Type:      jobs.TypeTerminateInstances,
+		Identity:  identity.Identity(r.Context()),
+		AccountID: identity.AccountId(r.Context()),
+		Args: jobs.TerminateInstancesTaskArgs{
+			ReservationID:  reservation.ID,
+			Provider:       reservation.Provider,
+			Region:         region,
+			Authentication: authentication,
+		},
+	}
+
+	// concurrent requests must not queue more teardown jobs
+	err = rDao.QueueTeardown(r.Context(), reservation.ID)
+	if errors.Is(err, dao.ErrAffectedMismatch) {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "instances termination is already queued", TeardownQueuedError))
+		return
+	} else if err != nil {
+		renderError(w, r, payloads.NewDAOError(r.Context(), "queue instances termination", err))
+		return
+	}
+	reservation.TeardownQueuedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	reservation.TeardownError = ""
+
+	err = queue.GetEnqueuer(r.Context()).Enqueue(r.Context(), &terminateJob)
+	if err != nil {
+		if finishErr := rDao.FinishTeardown(r.Context(), reservation.ID, reservation.Status, err.Error()); finishErr != nil {
+			logger.Warn().Err(finishErr).Msg("unable to clear queued teardown")
+		}
+		renderError(w, r, payloads.NewEnqueueTaskError(r.Context(), "job enqueue error", err))
+		return
+	}
+	logger.Debug().Msgf("Enqueued instance termination of reservation %d", reservation.ID)
+
+	render.Status(r, http.StatusAccepted)
+	if err := render.Render(w, r, payloads.NewReservationResponse(reservation)); err != nil {
+		renderError(w, r, payloads.NewRenderError(r.Context(), "unable to render reservation", err))
+	}
+}
+
+// PowerReservationInstance starts, stops or reboots an instance of a reservation. The operation
+// is only initiated, it does not wait until the instance changes its state.
+func PowerReservationInstance(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	id, err := ParseInt64(r, "ID")
+	if err != nil {
+		renderError(w, r, payloads.NewURLParsingError(r.Context(), "unable to parse ID parameter", err))
+		return
+	}
+	// Azure instance IDs contain slashes and must be URL-encoded
+	instanceID, err := url.PathUnescape(chi.URLParam(r, "INSTANCE_ID"))
+	if err != nil {
+		renderError(w, r, payloads.NewURLParsingError(r.Context(), "unable to parse INSTANCE_ID parameter", err))
+		return
+	}
+
+	payload := &payloads.InstancePowerRequestPayload{}
+	if err := render.Bind(r, payload); err != nil {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "instance power", err))
+		return
+	}
+	if payload.Action != "start" && payload.Action != "stop" && payload.Action != "reboot" {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "unknown action", UnknownPowerActionError))
+		return
+	}
+
+	rDao := dao.GetReservationDao(r.Context())
+	reservation, err := rDao.GetById(r.Context(), id)
+	if err != nil {
+		renderNotFoundOrDAOError(w, r, err, "get reservation detail")
+		return
+	}
+
+	instances, err := rDao.ListInstances(r.Context(), reservation.ID)
+	if err != nil {
+		message := fmt.Sprintf("get reservation instances with id %d", reservation.ID)
+		renderNotFoundOrDAOError(w, r, err, message)
+		return
+	}
+	found := false
+	for _, instance := range instances {
+		if instance.InstanceID == instanceID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		renderError(w, r, payloads.NewNotFoundError(r.Context(), "instance", InstanceNotFoundError))
+		return
+	}
+
+	authentication, region, ok := reservationAuthentication(w, r, reservation)
+	if !ok {
+		return
+	}
+
+	logger.Debug().Msgf("Performing power action '%s' on instance %s", payload.Action, instanceID)
+	switch reservation.Provider {
+	case models.ProviderTypeAWS:
+		ec2Client, clientErr := clients.GetEC2Client(r.Context(), authentication, region)
+		if clientErr != nil {
+			renderError(w, r, payloads.NewAWSError(r.Context(), "unable to get AWS client", clientErr))
+			return
+		}
+		switch payload.Action {
+		case "start":
+			err = ec2Client.StartInstances(r.Context(), []string{instanceID})
+		case "stop":
+			err = ec2Client.StopInstances(r.Context(), []string{instanceID})
+		case "reboot":
+			err = ec2Client.RebootInstances(r.Context(), []string{instanceID})
+		}
+		if err != nil {
+			renderError(w, r, payloads.NewAWSError(r.Context(), "unable to change instance power state", err))
+			return
+		}
+	case models.ProviderTypeAzure:
+		azureClient, clientErr := clients.GetAzureClient(r.Context(), authentication)
+		if clientErr != nil {
+			renderError(w, r, payloads.NewAzureError(r.Context(), "unable to get Azure client", clientErr))
+			return
+		}
+		switch payload.Action {
+		case "start":
+			err = azureClient.StartVM(r.Context(), instanceID)
+		case "stop":
+			err = azureClient.DeallocateVM(r.Context(), instanceID)
+		case "reboot":
+			err = azureClient.RestartVM(r.Context(), instanceID)
+		}
+		if err != nil {
+			renderError(w, r, payloads.NewAzureError(r.Context(), "unable to change instance power state", err))
+			return
+		}
+	case models.ProviderTypeGCP:
+		gcpClient, clientErr := clients.GetGCPClient(r.Context(), authentication)
+		if clientErr != nil {
+			renderError(w, r, payloads.NewGCPError(r.Context(), "unable to get GCP client", clientErr))
+			return
+		}
+		switch payload.Action {
+		case "start":
+			err = gcpClient.StartInstance(r.Context(), region, instanceID)
+		case "stop":
+			err = gcpClient.StopInstance(r.Context(), region, instanceID)
+		case "reboot":
+			err = gcpClient.ResetInstance(r.Context(), region, instanceID)
+		}
+		if err != nil {
+			renderError(w, r, payloads.NewGCPError(r.Context(), "unable to change instance power state", err))
+			return
+		}
+	case models.ProviderTypeNoop, models.ProviderTypeComposite, models.ProviderTypeUnknown:
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "provider is not supported", ProviderTypeNotImplementedError))
+		return
+	default:
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "provider is not supported", ProviderTypeNotImplementedError))
+		return
+	}
+
+	render.NoContent(w, r)
+}
+
+// reservationAuthentication returns authentication of the source and region (or zone) of
+// a provider-specific reservation. Errors are rendered, returns false when the request must
+// not continue.
+func reservationAuthentication(w http.ResponseWriter, r *http.Request, reservation *models.Reservation) (*clients.Authentication, string, bool) {
+	sourceID, region, err := reservationSourceAndRegion(r, reservation)
+	if errors.Is(err, ProviderTypeNotImplementedError) {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "provider is not supported", err))
+		return nil, "", false
+	} else if err != nil {
+		message := fmt.Sprintf("get reservation details with id %d", reservation.ID)
+		renderNotFoundOrDAOError(w, r, err, message)
+		return nil, "", false
+	}
+
+	sourcesClient, err := clients.GetSourcesClient(r.Context())
+	if err != nil {
+		renderError(w, r, payloads.NewClientError(r.Context(), err))
+		return nil, "", false
+	}
+
+	authentication, err := sourcesClient.GetAuthentication(r.Context(), sourceID)
+	if err != nil {
+		renderError(w, r, payloads.NewClientError(r.Context(), err))
+		return nil, "", false
+	}
+
+	if typeErr := authentication.MustBe(reservation.Provider); typeErr != nil {
+		renderError(w, r, payloads.NewClientError(r.Context(), typeErr))
+		return nil, "", false
+	}
+	return authentication, region, true
+}
+
+// reservationSourceAndRegion returns source ID and region (or zone) of a provider-specific reservation.
+func reservationSourceAndRegion(r *http.Request, reservation *models.Reservation) (string, string, error) {
+	rDao := dao.GetReservationDao(r.Context())
+	switch reservation.Provider {
+	case models.ProviderTypeAWS:
+		reservationAws, err := rDao.GetAWSById(r.Context(), reservation.ID)
+		if err != nil {
+			return "", "", fmt.Errorf("cannot get AWS reservation: %w", err)
+		}
+		return reservationAws.SourceID, reservationAws.Detail.Region, nil
+	case models.ProviderTypeAzure:
+		reservationAzure, err := rDao.GetAzureById(r.Context(), reservation.ID)
+		if err != nil {
+			return "", "", fmt.Errorf("cannot get Azure reservation: %w", err)
+		}
+		return reservationAzure.SourceID, reservationAzure.Detail.Location, nil
+	case models.ProviderTypeGCP:
+		reservationGCP, err := rDao.GetGCPById(r.Context(), reservation.ID)
+		if err != nil {
+			return "", "", fmt.Errorf("cannot get GCP reservation: %w", err)
+		}
+		return reservationGCP.SourceID, reservationGCP.Detail.Zone, nil
+	case models.ProviderTypeNoop, models.ProviderTypeComposite, models.ProviderTypeUnknown:
+		return "", "", ProviderTypeNotImplementedError
+	default:
+		return "", "", ProviderTypeNotImplementedError
+	}
+}
@@ -0,0 +1,164 @@
+package services_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	Clientstubs "github.com/RHEnVision/provisioning-backend/internal/clients/stubs"
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
+	"github.com/RHEnVision/provisioning-backend/internal/dao/stubs"
+	identity2 "github.com/RHEnVision/provisioning-backend/internal/identity"
+	"github.com/RHEnVision/provisioning-backend/internal/jobs"
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/RHEnVision/provisioning-backend/internal/queue/stub"
+	"github.com/RHEnVision/provisioning-backend/internal/services"
+	"github.com/RHEnVision/provisioning-backend/internal/testing/factories"
+	"github.com/RHEnVision/provisioning-backend/internal/testing/identity"
+	_ "github.com/RHEnVision/provisioning-backend/internal/testing/initialization"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func prepareFinishedAWSReservation(t *testing.T, ctx context.Context, finished bool) *models.AWSReservation {
+	t.Helper()
+
+	pk := factories.NewPubkeyRSA()
+	err := stubs.AddPubkey(ctx, pk)
+	require.NoError(t, err, "failed to add stubbed key")
+
+	reservation := &models.AWSReservation{
+		PubkeyID: pk.ID,
+		SourceID: "1",
+		ImageID:  "ami-random",
+		Detail: &models.AWSDetail{
+			Region:       "us-east-1",
+			InstanceType: "t1.micro",
+			Amount:       1,
+		},
+	}
+	reservation.AccountID = identity2.AccountId(ctx)
+	reservation.Provider = models.ProviderTypeAWS
+	reservation.Steps = 2
+	if finished {
+		reservation.FinishedAt = sql.NullTime{Time: time.Now(), Valid: true}
+		reservation.Success = sql.NullBool{Bool: true, Valid: true}
+	}
+
+	err = stubs.AddAWSReservation(ctx, reservation)
+	require.NoError(t, err, "failed to create stub reservation")
+	return reservation
+}
+
+func TestDeleteReservationInstances(t *testing.T) {
+	t.Run("Finished reservation", func(t *testing.T) {
+		ctx := stubs.WithAccountDaoOne(context.Background())
+		ctx = identity.WithTenant(t, ctx)
+		ctx = Clientstubs.WithSourcesClient(ctx)
+		ctx = stubs.WithPubkeyDao(ctx)
+		ctx = stubs.WithReservationDao(ctx)
+		ctx = stub.WithEnqueuer(ctx)
+		reservation := prepareFinishedAWSReservation(t, ctx, true)
+
+		rctx := chi.NewRouteContext()
+		ctx = context.WithValue(ctx, chi.RouteCtxKey, rctx)
+		rctx.URLParams.Add("ID", "1")
+		req, err := http.NewRequestWithContext(ctx, "DELETE", "/api/provisioning/v1/reservations/1/instances", nil)
+		require.NoError(t, err, "failed to create request")
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(services.DeleteReservationInstances)
+		handler.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusAccepted, rr.Code, "Wrong status code")
+		require.Equal(t, 1, len(stub.EnqueuedJobs(ctx)), "Expected exactly one job to be planned")
+		assert.Equal(t, jobs.TypeTerminateInstances, stub.EnqueuedJobs(ctx)[0].Type)
+		jobArgs, ok := stub.EnqueuedJobs(ctx)[0].Args.(jobs.TerminateInstancesTaskArgs)
+		require.True(t, ok, "Unexpected type of arguments for the planned job")
+		assert.Equal(t, reservation.ID, jobArgs.ReservationID)
+		assert.Equal(t, models.ProviderTypeAWS, jobArgs.Provider)
+		assert.Equal(t, "us-east-1", jobArgs.Region)
+		assert.True(t, reservation.TeardownQueuedAt.Valid)
+
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusBadRequest, rr.Code, "Second teardown must be refused")
+		assert.Equal(t, 1, len(stub.EnqueuedJobs(ctx)), "Expected no other job to be planned")
+	})
+
+	t.Run("Reservation in progress", func(t *testing.T) {
+		ctx := stubs.WithAccountDaoOne(context.Background())
+		ctx = identity.WithTenant(t, ctx)
+		ctx = Clientstubs.WithSourcesClient(ctx)
+		ctx = stubs.WithPubkeyDao(ctx)
+		ctx = stubs.WithReservationDao(ctx)
+		ctx = stub.WithEnqueuer(ctx)
+		prepareFinishedAWSReservation(t, ctx, false)
+
+		rctx := chi.NewRouteContext()
+		ctx = context.WithValue(ctx, chi.RouteCtxKey, rctx)
+		rctx.URLParams.Add("ID", "1")
+		req, err := http.NewRequestWithContext(ctx, "DELETE", "/api/provisioning/v1/reservations/1/instances", nil)
+		require.NoError(t, err, "failed to create request")
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(services.DeleteReservationInstances)
+		handler.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusBadRequest, rr.Code, "Wrong status code")
+		assert.Empty(t, stub.EnqueuedJobs(ctx), "Expected no job to be planned")
+	})
+}
+
+func TestPowerReservationInstance(t *testing.T) {
+	ctx := stubs.WithAccountDaoOne(context.Background())
+	ctx = identity.WithTenant(t, ctx)
+	ctx = Clientstubs.WithSourcesClient(ctx)
+	ctx = Clientstubs.WithEC2Client(ctx)
+	ctx = stubs.WithPubkeyDao(ctx)
+	ctx = stubs.WithReservationDao(ctx)
+	reservation := prepareFinishedAWSReservation(t, ctx, true)
+	err := dao.GetReservationDao(ctx).CreateInstance(ctx, &models.ReservationInstance{
+		ReservationID: reservation.ID,
+		InstanceID:    "i-0a4caa2cf5b097ce1",
+	})
+	require.NoError(t, err, "failed to create stub instance")
+
+	powerRequest := func(t *testing.T, instanceID string, body string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("ID", "1")
+		rctx.URLParams.Add("INSTANCE_ID", instanceID)
+		reqCtx := context.WithValue(ctx, chi.RouteCtxKey, rctx)
+		req, err := http.NewRequestWithContext(reqCtx, "POST", "/api/provisioning/v1/reservations/1/instances/"+instanceID+"/power", bytes.NewBufferString(body))
+		require.NoError(t, err, "failed to create request")
+		req.Header.Add("Content-Type", "application/json")
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(services.PowerReservationInstance)
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Reboot", func(t *testing.T) {
+		rr := powerRequest(t, "i-0a4caa2cf5b097ce1", `{"action": "reboot"}`)
+		require.Equal(t, http.StatusNoContent, rr.Code, "Wrong status code")
+	})
+
+	t.Run("Unknown action", func(t *testing.T) {
+		rr := powerRequest(t, "i-0a4caa2cf5b097ce1", `{"action": "hibernate"}`)
+		require.Equal(t, http.StatusBadRequest, rr.Code, "Wrong status code")
+	})
+
+	t.Run("Unknown instance", func(t *testing.T) {
+		rr := powerRequest(t, "i-unknown", `{"action": "start"}`)
+		require.Equal(t, http.StatusNotFound, rr.Code, "Wrong status code")
+	})
+}
@@ -1,16 +1,26 @@
 package services
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"net/http"
+	"regexp"
+	"time"
 
 	"github.com/RHEnVision/provisioning-backend/internal/config"
 	"github.com/RHEnVision/provisioning-backend/internal/dao"
+	"github.com/RHEnVision/provisioning-backend/internal/db"
+	"github.com/RHEnVision/provisioning-backend/internal/identity"
 	"github.com/RHEnVision/provisioning-backend/internal/models"
 	"github.com/RHEnVision/provisioning-backend/internal/payloads"
+	"github.com/RHEnVision/provisioning-backend/internal/queue"
+	"github.com/RHEnVision/provisioning-backend/internal/secrets"
+	"github.com/RHEnVision/provisioning-backend/internal/ssh"
+	"github.com/RHEnVision/provisioning-backend/internal/userdata"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
+	"github.com/rs/zerolog"
 )
 
 var (
@@ -21,8 +31,221 @@ var (
 	ArchitectureMismatch            = errors.New("instance type and image architecture mismatch")
 	BothTypeAndTemplateMissingError = errors.New("instance type or launch template not set")
 	UnsupportedRegionError          = errors.New("unknown region/location/zone")
+	ReservationInProgressError      = errors.New("reservation is still in progress")
+	TeardownQueuedError             = errors.New("instances termination is already queued")
+	UnknownPowerActionError         = errors.New("unknown power action, expected values: start, stop, reboot")
+	RHCActivationKeyError           = errors.New("activation key must contain only letters, numbers, hyphens and underscores")
+	RHCActivationKeyMissingError    = errors.New("organization ID set without activation key")
+	RHCOrgIDError                   = errors.New("organization ID must be numeric")
+	RHCNotConfiguredError           = errors.New("rhc registration is not configured on the server")
+	InstanceNotFoundError           = errors.New("instance not found in reservation")
+	ReservationFinishedError        = errors.New("reservation is already finished")
+	LaunchAtInPastError             = errors.New("launch time must be in the future")
+	LaunchAtTooFarError             = errors.New("launch time must be within 7 days")
+	IdempotencyKeyTooLongError      = errors.New("idempotency key is too long")
+	IdempotencyKeyMismatchError     = errors.New("idempotency key was used for a different provider type")
+	IdempotencyKeyKeyPairError      = errors.New("generated key pair cannot be replayed, idempotency key must not be used")
 )
 
+// Reservation create requests with the same idempotency key header return the original reservation.
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+// maxLaunchDelay is the maximum time a reservation can be scheduled ahead, jobs and their
+// cancellation markers are not kept in the queue for much longer.
+const maxLaunchDelay = 7 * 24 * time.Hour
+
+// idempotencyKey returns the idempotency key of a reservation create request.
+func idempotencyKey(r *http.Request) sql.NullString {
+	key := r.Header.Get(IdempotencyKeyHeader)
+	return sql.NullString{String: key, Valid: key != ""}
+}
+
+// replayReservation renders the reservation created with the idempotency key of the request
+// and returns true, or returns false when there is no such reservation.
+func replayReservation(w http.ResponseWriter, r *http.Request, providerType models.ProviderType) bool {
+	key := idempotencyKey(r)
+	if !key.Valid {
+		return false
+	}
+
+	reservation, err := dao.GetReservationDao(r.Context()).GetByIdempotencyKey(r.Context(), key.String)
+	if errors.Is(err, dao.ErrNoRows) {
+		return false
+	} else if err != nil {
+		renderError(w, r, payloads.NewDAOError(r.Context(), "get reservation by idempotency key", err))
+		return true
+	}
+
+	if reservation.Provider != providerType {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "idempotency key", IdempotencyKeyMismatchError))
+		return true
+	}
+
+	zerolog.Ctx(r.Context()).Info().Msgf("Replaying reservation %d for idempotency key", reservation.ID)
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	renderReservationDetail(w, r, reservation, providerType)
+	return true
+}
+
+// renderCreateReservationError renders error of a reservation create operation. When a concurrent
+// request with the same idempotency key created the reservation first, it is replayed instead.
+func renderCreateReservationError(w http.ResponseWriter, r *http.Request, providerType models.ProviderType, err error, message string) {
+	if db.IsPostgresError(err, db.UniqueConstraintErrorCode) != nil && replayReservation(w, r, providerType) {
+		return
+	}
+	if errors.Is(err, QuotaInflightReservationsError) || errors.Is(err, QuotaInstancesPerDayError) {
+		renderQuotaError(w, r, err)
+		return
+	}
+	renderError(w, r, payloads.NewDAOError(r.Context(), message, err))
+}
+
+// parseLaunchAt validates optional launch time from a reservation request.
+func parseLaunchAt(launchAt *time.Time) (sql.NullTime, error) {
+	if launchAt == nil {
+		return sql.NullTime{}, nil
+	}
+
+	now := time.Now()
+	if !launchAt.After(now) {
+		return sql.NullTime{}, LaunchAtInPastError
+	}
+	if launchAt.After(now.Add(maxLaunchDelay)) {
+		return sql.NullTime{}, LaunchAtTooFarError
+	}
+	return sql.NullTime{Time: launchAt.UTC(), Valid: true}, nil
+}
+
+var (
+	rhcActivationKeyRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,255}$`)
+	rhcOrgIDRegexp         = regexp.MustCompile(`^[0-9]{1,32}$`)
+)
+
+// validateRHCRegistration validates optional rhc registration of user data, the organization
+// ID defaults to the organization of the requester. Values are rendered into user data without
+// escaping.
+func validateRHCRegistration(r *http.Request, input *userdata.UserData) error {
+	if input.RHCActivationKey == "" {
+		if input.RHCOrgID != "" {
+			return RHCActivationKeyMissingError
+		}
+		return nil
+	}
+	if !rhcActivationKeyRegexp.MatchString(input.RHCActivationKey) {
+		return RHCActivationKeyError
+	}
+
+	if input.RHCOrgID == "" {
+		input.RHCOrgID = identity.Identity(r.Context()).Identity.OrgID
+	}
+	if !rhcOrgIDRegexp.MatchString(input.RHCOrgID) {
+		return RHCOrgIDError
+	}
+	return nil
+}
+
+// generateUserData renders user data of a reservation including user supplied user data and
+// renders an error when it is not valid. Returns false when the request must not continue.
+func generateUserData(w http.ResponseWriter, r *http.Request, input *userdata.UserData) ([]byte, bool) {
+	if err := validateRHCRegistration(r, input); err != nil {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "rhc registration", err))
+		return nil, false
+	}
+
+	data, err := userdata.GenerateUserData(input)
+	switch {
+	case err == nil:
+		return data, true
+	case errors.Is(err, userdata.ErrUnsupportedUserData), errors.Is(err, userdata.ErrUserDataTooLarge):
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "user data", err))
+	default:
+		renderError(w, r, payloads.NewRenderError(r.Context(), "unable to generate user data", err))
+	}
+	return nil, false
+}
+
+// launchInput returns user data input stored in the reservation detail, launch jobs render
+// the same user data from it. The activation key is encrypted, renders an error and returns
+// false when it cannot be.
+func launchInput(w http.ResponseWriter, r *http.Request, input *userdata.UserData) (models.LaunchInput, bool) {
+	result := models.LaunchInput{
+		UserData: input.Custom,
+		RHCOrgID: input.RHCOrgID,
+	}
+	if input.RHCActivationKey == "" {
+		return result, true
+	}
+
+	var err error
+	result.RHCActivationKey, err = secrets.Encrypt(input.RHCActivationKey)
+	switch {
+	case err == nil:
+		return result, true
+	case errors.Is(err, secrets.ErrNotConfigured):
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "rhc registration", RHCNotConfiguredError))
+	default:
+		renderError(w, r, payloads.NewRenderError(r.Context(), "unable to encrypt activation key", err))
+	}
+	return result, false
+}
+
+// Comment of public keys generated for reservations.
+const generatedKeyComment = "provisioning-generated"
+
+// authorizedKeys are public keys authorized on reservation instances in addition to the pubkey
+// of the reservation.
+type authorizedKeys struct {
+	// IDs of additional pubkeys without the reservation pubkey and duplicates.
+	PubkeyIDs []int64
+
+	// Bodies of additional pubkeys followed by the generated public key.
+	Bodies []string
+
+	// Private key of the generated key pair or empty string.
+	PrivateKey string
+}
+
+// additionalPubkeys loads additional pubkeys of a reservation request and generates a new key
+// pair when requested. Renders an error and returns false when the request must not continue.
+func additionalPubkeys(w http.ResponseWriter, r *http.Request, pubkeyID int64, pubkeyIDs []int64, generate bool) (*authorizedKeys, bool) {
+	keys := &authorizedKeys{}
+	seen := map[int64]bool{pubkeyID: true}
+	for _, id := range pubkeyIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		pk, err := dao.GetPubkeyDao(r.Context()).GetById(r.Context(), id)
+		if err != nil {
+			renderNotFoundOrDAOError(w, r, err, fmt.Sprintf("get pubkey with id %d", id))
+			return nil, false
+		}
+		keys.PubkeyIDs = append(keys.PubkeyIDs, pk.ID)
+		keys.Bodies = append(keys.Bodies, pk.Body)
+	}
+
+	if generate {
+		// replayed reservations do not contain the private key, it is not stored
+		if idempotencyKey(r).Valid {
+			renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "generate key pair", IdempotencyKeyKeyPairError))
+			return nil, false
+		}
+		keyPair, err := ssh.GenerateED25519KeyPair(generatedKeyComment)
+		if err != nil {
+			renderError(w, r, payloads.NewResponseError(r.Context(), http.StatusInternalServerError, "unable to generate key pair", err))
+			return nil, false
+		}
+		keys.Bodies = append(keys.Bodies, keyPair.PublicKey)
+		keys.PrivateKey = keyPair.PrivateKey
+	}
+	return keys, true
+}
+
 // CreateReservation dispatches requests to type provider specific handlers
 func CreateReservation(w http.ResponseWriter, r *http.Request) {
 	if !config.LaunchEnabled(r.Context()) {
@@ -30,6 +253,14 @@ func CreateReservation(w http.ResponseWriter, r *http.Request) {
 	}
 
 	pType := models.ProviderTypeFromString(chi.URLParam(r, "TYPE"))
+	if len(r.Header.Get(IdempotencyKeyHeader)) > maxIdempotencyKeyLength {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "idempotency key", IdempotencyKeyTooLongError))
+		return
+	}
+	if replayReservation(w, r, pType) {
+		return
+	}
+
 	switch pType {
 	case models.ProviderTypeNoop:
 		CreateNoopReservation(w, r)
@@ -43,6 +274,8 @@ func CreateReservation(w http.ResponseWriter, r *http.Request) {
 		}
 	case models.ProviderTypeGCP:
 		CreateGCPReservation(w, r)
+	case models.ProviderTypeComposite:
+		CreateCompositeReservation(w, r)
 	case models.ProviderTypeUnknown:
 		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "provider is not supported", UnknownProviderTypeError))
 	default:
@@ -51,20 +284,70 @@ func CreateReservation(w http.ResponseWriter, r *http.Request) {
 }
 
 func ListReservations(w http.ResponseWriter, r *http.Request) {
-	rDao := dao.GetReservationDao(r.Context())
+	params, err := parseListParams(r)
+	if err != nil {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "list parameters", err))
+		return
+	}
 
-	reservations, err := rDao.List(r.Context(), 100, 0)
+	filter, err := parseReservationFilter(r)
+	if err != nil {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "reservation filter", err))
+		return
+	}
+
+	rDao := dao.GetReservationDao(r.Context())
+	reservations, err := rDao.List(r.Context(), fetchParams(params), filter)
 	if err != nil {
 		renderError(w, r, payloads.NewDAOError(r.Context(), "list reservations", err))
 		return
 	}
 
-	if err := render.RenderList(w, r, payloads.NewReservationListResponse(reservations)); err != nil {
+	total, err := rDao.Count(r.Context(), filter)
+	if err != nil {
+		renderError(w, r, payloads.NewDAOError(r.Context(), "count reservations", err))
+		return
+	}
+
+	reservations, links := listPage(r, params, reservations, func(res *models.Reservation) int64 { return res.ID })
+	if err := render.Render(w, r, payloads.NewReservationListResponse(reservations, total, links)); err != nil {
 		renderError(w, r, payloads.NewRenderError(r.Context(), "unable to render reservations list", err))
 		return
 	}
 }
 
+// parseReservationFilter parses "provider", "status", "success", "source_id", "created_after"
+// and "created_before" URL query parameters. Times are expected in RFC 3339 format.
+func parseReservationFilter(r *http.Request) (dao.ReservationFilter, error) {
+	query := r.URL.Query()
+	filter := dao.ReservationFilter{
+		Status:   query.Get("status"),
+		SourceID: query.Get("source_id"),
+	}
+
+	if str := query.Get("provider"); str != "" {
+		filter.Provider = models.ProviderTypeFromString(str)
+		if filter.Provider == models.ProviderTypeUnknown {
+			return filter, UnknownProviderTypeError
+		}
+	}
+
+	success, err := ParseBool(query.Get("success"))
+	if err != nil {
+		return filter, err
+	}
+	filter.Success = success
+
+	if filter.CreatedAfter, err = parseTime(query.Get("created_after")); err != nil {
+		return filter, err
+	}
+	if filter.CreatedBefore, err = parseTime(query.Get("created_before")); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
 func GetReservationDetail(w http.ResponseWriter, r *http.Request) {
 	provider := chi.URLParam(r, "TYPE")
 	id, err := ParseInt64(r, "ID")
@@ -87,6 +370,15 @@ func GetReservationDetail(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	renderReservationDetail(w, r, reservation, providerType)
+}
+
+// renderReservationDetail renders provider specific reservation detail with instances, or generic
+// reservation detail for unknown provider type.
+func renderReservationDetail(w http.ResponseWriter, r *http.Request, reservation *models.Reservation, providerType models.ProviderType) {
+	id := reservation.ID
+	rDao := dao.GetReservationDao(r.Context())
+
 	switch providerType {
 	// Generic reservation request will have provider == "" and thus render this
 	case models.ProviderTypeUnknown, models.ProviderTypeNoop:
@@ -147,7 +439,100 @@ func GetReservationDetail(w http.ResponseWriter, r *http.Request) {
 		if err := render.Render(w, r, payloads.NewGCPReservationResponse(reservationGCP, instances)); err != nil {
 			renderError(w, r, payloads.NewRenderError(r.Context(), "unable to render reservation", err))
 		}
+	case models.ProviderTypeComposite:
+		children, err := rDao.ListChildren(r.Context(), id)
+		if err != nil {
+			renderError(w, r, payloads.NewDAOError(r.Context(), "list child reservations", err))
+			return
+		}
+
+		if err := render.Render(w, r, payloads.NewCompositeReservationResponse(reservation, children)); err != nil {
+			renderError(w, r, payloads.NewRenderError(r.Context(), "unable to render reservation", err))
+		}
 	default:
 		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "provider is not supported", ProviderTypeNotImplementedError))
 	}
 }
+
+// GetReservationTimeline returns all status changes of a reservation with time spent in each state.
+func GetReservationTimeline(w http.ResponseWriter, r *http.Request) {
+	id, err := ParseInt64(r, "ID")
+	if err != nil {
+		renderError(w, r, payloads.NewURLParsingError(r.Context(), "unable to parse ID parameter", err))
+		return
+	}
+
+	rDao := dao.GetReservationDao(r.Context())
+	reservation, err := rDao.GetById(r.Context(), id)
+	if err != nil {
+		renderNotFoundOrDAOError(w, r, err, "get reservation detail")
+		return
+	}
+
+	events, err := rDao.ListEvents(r.Context(), id)
+	if err != nil {
+		renderError(w, r, payloads.NewDAOError(r.Context(), "list reservation events", err))
+		return
+	}
+
+	if err := render.Render(w, r, payloads.NewReservationTimelineResponse(reservation, events)); err != nil {
+		renderError(w, r, payloads.NewRenderError(r.Context(), "unable to render reservation timeline", err))
+	}
+}
+
+// CancelReservation marks a reservation which is still in progress as cancelled and cancels its
+// background job. The job finishes the reservation with an error and "Cancelled" status.
+func CancelReservation(w http.ResponseWriter, r *http.Request) {
+	logger := zerolog.Ctx(r.Context())
+
+	id, err := ParseInt64(r, "ID")
+	if err != nil {
+		renderError(w, r, payloads.NewURLParsingError(r.Context(), "unable to parse ID parameter", err))
+		return
+	}
+
+	rDao := dao.GetReservationDao(r.Context())
+	reservation, err := rDao.GetById(r.Context(), id)
+	if err != nil {
+		renderNotFoundOrDAOError(w, r, err, "get reservation detail")
+		return
+	}
+
+	if reservation.FinishedAt.Valid {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "reservation is already finished", ReservationFinishedError))
+		return
+	}
+
+	err = rDao.Cancel(r.Context(), reservation.ID)
+	if errors.Is(err, dao.ErrAffectedMismatch) {
+		// finished in the meantime
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "reservation is already finished", ReservationFinishedError))
+		return
+	} else if err != nil {
+		renderError(w, r, payloads.NewDAOError(r.Context(), "cancel reservation", err))
+		return
+	}
+	reservation.Cancelled = true
+
+	if reservation.JobID.Valid {
+		err = queue.GetCanceller(r.Context()).Cancel(r.Context(), reservation.JobID.UUID)
+		if err != nil {
+			renderError(w, r, payloads.NewCancelTaskError(r.Context(), "job cancel error", err))
+			return
+		}
+		logger.Debug().Msgf("Cancelled job %s of reservation %d", reservation.JobID.UUID, reservation.ID)
+	} else if reservation.Provider == models.ProviderTypeComposite {
+		err = cancelChildReservations(r.Context(), reservation.ID)
+		if err != nil {
+			renderError(w, r, payloads.NewCancelTaskError(r.Context(), "child reservations cancel error", err))
+			return
+		}
+		logger.Debug().Msgf("Cancelled child reservations of composite reservation %d", reservation.ID)
+	} else {
+		logger.Warn().Msgf("Reservation %d has no associated job to cancel", reservation.ID)
+	}
+
+	if err := render.Render(w, r, payloads.NewReservationResponse(reservation)); err != nil {
+		renderError(w, r, payloads.NewRenderError(r.Context(), "unable to render reservation", err))
+	}
+}
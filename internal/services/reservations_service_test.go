@@ -7,14 +7,17 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
 	"github.com/RHEnVision/provisioning-backend/internal/dao/stubs"
 	identity2 "github.com/RHEnVision/provisioning-backend/internal/identity"
 	"github.com/RHEnVision/provisioning-backend/internal/models"
 	"github.com/RHEnVision/provisioning-backend/internal/payloads"
+	"github.com/RHEnVision/provisioning-backend/internal/queue/stub"
 	"github.com/RHEnVision/provisioning-backend/internal/services"
 	"github.com/RHEnVision/provisioning-backend/internal/testing/factories"
 	"github.com/RHEnVision/provisioning-backend/internal/testing/identity"
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -72,3 +75,215 @@ func TestGetReservationDetail(t *testing.T) {
 		assert.Equal(t, int(models.ProviderTypeAWS), response.Provider, "expected provider to be AWS in parsed json")
 	})
 }
+
+func TestListReservations(t *testing.T) {
+	ctx := stubs.WithAccountDaoOne(context.Background())
+	ctx = identity.WithTenant(t, ctx)
+	ctx = stubs.WithPubkeyDao(ctx)
+	ctx = stubs.WithReservationDao(ctx)
+	prepareFinishedAWSReservation(t, ctx, true)
+	prepareFinishedAWSReservation(t, ctx, true)
+	prepareFinishedAWSReservation(t, ctx, false)
+
+	listRequest := func(t *testing.T, url string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		require.NoError(t, err, "failed to create request")
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(services.ListReservations)
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Pages", func(t *testing.T) {
+		rr := listRequest(t, "/api/provisioning/v1/reservations?limit=2")
+		require.Equal(t, http.StatusOK, rr.Code, "Wrong status code")
+
+		var result payloads.ReservationListResponse
+		err := json.NewDecoder(rr.Body).Decode(&result)
+		require.NoError(t, err, "failed to decode response body")
+		assert.Equal(t, 2, len(result.Data))
+		assert.Equal(t, int64(3), result.Metadata.Total)
+		assert.Empty(t, result.Links.Previous)
+		require.NotEmpty(t, result.Links.Next)
+
+		rr = listRequest(t, result.Links.Next)
+		require.Equal(t, http.StatusOK, rr.Code, "Wrong status code")
+
+		result = payloads.ReservationListResponse{}
+		err = json.NewDecoder(rr.Body).Decode(&result)
+		require.NoError(t, err, "failed to decode response body")
+		require.Equal(t, 1, len(result.Data))
+		assert.Equal(t, int64(3), result.Data[0].ID)
+		assert.Empty(t, result.Links.Next)
+		require.NotEmpty(t, result.Links.Previous)
+
+		rr = listRequest(t, result.Links.Previous)
+		require.Equal(t, http.StatusOK, rr.Code, "Wrong status code")
+
+		result = payloads.ReservationListResponse{}
+		err = json.NewDecoder(rr.Body).Decode(&result)
+		require.NoError(t, err, "failed to decode response body")
+		require.Equal(t, 2, len(result.Data))
+		assert.Equal(t, int64(1), result.Data[0].ID)
+		assert.Empty(t, result.Links.Previous)
+		assert.NotEmpty(t, result.Links.Next)
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		rr := listRequest(t, "/api/provisioning/v1/reservations?provider=aws&success=true&sort=-id")
+		require.Equal(t, http.StatusOK, rr.Code, "Wrong status code")
+
+		var result payloads.ReservationListResponse
+		err := json.NewDecoder(rr.Body).Decode(&result)
+		require.NoError(t, err, "failed to decode response body")
+		require.Equal(t, 2, len(result.Data))
+		assert.Equal(t, int64(2), result.Data[0].ID)
+		assert.Equal(t, int64(2), result.Metadata.Total)
+	})
+
+	t.Run("Invalid parameters", func(t *testing.T) {
+		rr := listRequest(t, "/api/provisioning/v1/reservations?limit=0")
+		assert.Equal(t, http.StatusBadRequest, rr.Code, "Wrong status code")
+
+		rr = listRequest(t, "/api/provisioning/v1/reservations?cursor=invalid")
+		assert.Equal(t, http.StatusBadRequest, rr.Code, "Wrong status code")
+
+		rr = listRequest(t, "/api/provisioning/v1/reservations?provider=unknown")
+		assert.Equal(t, http.StatusBadRequest, rr.Code, "Wrong status code")
+
+		rr = listRequest(t, "/api/provisioning/v1/reservations?sort=created_at")
+		assert.Equal(t, http.StatusBadRequest, rr.Code, "Wrong status code")
+	})
+}
+
+func TestCancelReservation(t *testing.T) {
+	cancelRequest := func(t *testing.T, ctx context.Context) *httptest.ResponseRecorder {
+		t.Helper()
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("ID", "1")
+		reqCtx := context.WithValue(ctx, chi.RouteCtxKey, rctx)
+		req, err := http.NewRequestWithContext(reqCtx, "POST", "/api/provisioning/v1/reservations/1/cancel", nil)
+		require.NoError(t, err, "failed to create request")
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(services.CancelReservation)
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Reservation in progress", func(t *testing.T) {
+		ctx := stubs.WithAccountDaoOne(context.Background())
+		ctx = identity.WithTenant(t, ctx)
+		ctx = stubs.WithPubkeyDao(ctx)
+		ctx = stubs.WithReservationDao(ctx)
+		ctx = stub.WithEnqueuer(ctx)
+		reservation := prepareFinishedAWSReservation(t, ctx, false)
+		reservation.JobID = uuid.NullUUID{UUID: uuid.New(), Valid: true}
+
+		rr := cancelRequest(t, ctx)
+		require.Equal(t, http.StatusOK, rr.Code, "Wrong status code")
+
+		var result payloads.GenericReservationResponsePayload
+		err := json.NewDecoder(rr.Body).Decode(&result)
+		require.NoError(t, err, "failed to decode response body")
+		assert.True(t, result.Cancelled)
+		assert.True(t, reservation.Cancelled)
+		require.Equal(t, 1, len(stub.CancelledJobs(ctx)), "Expected exactly one job to be cancelled")
+		assert.Equal(t, reservation.JobID.UUID, stub.CancelledJobs(ctx)[0])
+	})
+
+	t.Run("Finished reservation", func(t *testing.T) {
+		ctx := stubs.WithAccountDaoOne(context.Background())
+		ctx = identity.WithTenant(t, ctx)
+		ctx = stubs.WithPubkeyDao(ctx)
+		ctx = stubs.WithReservationDao(ctx)
+		ctx = stub.WithEnqueuer(ctx)
+		reservation := prepareFinishedAWSReservation(t, ctx, true)
+
+		rr := cancelRequest(t, ctx)
+		require.Equal(t, http.StatusBadRequest, rr.Code, "Wrong status code")
+		assert.False(t, reservation.Cancelled)
+		assert.Empty(t, stub.CancelledJobs(ctx), "Expected no job to be cancelled")
+	})
+}
+
+func TestGetReservationTimeline(t *testing.T) {
+	timelineRequest := func(t *testing.T, ctx context.Context, id string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("ID", id)
+		reqCtx := context.WithValue(ctx, chi.RouteCtxKey, rctx)
+		req, err := http.NewRequestWithContext(reqCtx, "GET", "/api/provisioning/v1/reservations/"+id+"/timeline", nil)
+		require.NoError(t, err, "failed to create request")
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(services.GetReservationTimeline)
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Reservation with events", func(t *testing.T) {
+		ctx := stubs.WithAccountDaoOne(context.Background())
+		ctx = identity.WithTenant(t, ctx)
+		ctx = stubs.WithPubkeyDao(ctx)
+		ctx = stubs.WithReservationDao(ctx)
+		reservation := prepareFinishedAWSReservation(t, ctx, true)
+
+		rDao := dao.GetReservationDao(ctx)
+		require.NoError(t, rDao.UpdateStatus(ctx, reservation.ID, "Uploading key", 0))
+		require.NoError(t, rDao.UpdateStatus(ctx, reservation.ID, "Key uploaded", 1))
+		require.NoError(t, rDao.UpdateStatus(ctx, reservation.ID, "Launching instances", 0))
+
+		rr := timelineRequest(t, ctx, "1")
+		require.Equal(t, http.StatusOK, rr.Code, "Wrong status code")
+
+		var result payloads.ReservationTimelineResponse
+		err := json.NewDecoder(rr.Body).Decode(&result)
+		require.NoError(t, err, "failed to decode response body")
+		assert.Equal(t, reservation.ID, result.ReservationID)
+		require.Len(t, result.Events, 3)
+		assert.Equal(t, "Uploading key", result.Events[0].Status)
+		assert.Equal(t, int32(0), result.Events[0].Step)
+		assert.Equal(t, "Key uploaded", result.Events[1].Status)
+		assert.Equal(t, int32(1), result.Events[1].Step)
+		assert.Equal(t, int32(1), result.Events[2].Step)
+		for _, event := range result.Events {
+			assert.NotNil(t, event.DurationMs, "expected duration of a finished reservation event")
+		}
+	})
+
+	t.Run("Unfinished reservation", func(t *testing.T) {
+		ctx := stubs.WithAccountDaoOne(context.Background())
+		ctx = identity.WithTenant(t, ctx)
+		ctx = stubs.WithPubkeyDao(ctx)
+		ctx = stubs.WithReservationDao(ctx)
+		reservation := prepareFinishedAWSReservation(t, ctx, false)
+
+		rDao := dao.GetReservationDao(ctx)
+		require.NoError(t, rDao.UpdateStatus(ctx, reservation.ID, "Uploading key", 0))
+
+		rr := timelineRequest(t, ctx, "1")
+		require.Equal(t, http.StatusOK, rr.Code, "Wrong status code")
+
+		var result payloads.ReservationTimelineResponse
+		err := json.NewDecoder(rr.Body).Decode(&result)
+		require.NoError(t, err, "failed to decode response body")
+		require.Len(t, result.Events, 1)
+		assert.Nil(t, result.FinishedAt)
+		assert.Nil(t, result.Events[0].DurationMs, "expected no duration of the current state")
+	})
+
+	t.Run("Unknown reservation", func(t *testing.T) {
+		ctx := stubs.WithAccountDaoOne(context.Background())
+		ctx = identity.WithTenant(t, ctx)
+		ctx = stubs.WithReservationDao(ctx)
+
+		rr := timelineRequest(t, ctx, "42")
+		require.Equal(t, http.StatusNotFound, rr.Code, "Wrong status code")
+	})
+}
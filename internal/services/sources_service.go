@@ -106,7 +106,7 @@ func GetSourceUploadInfo(w http.ResponseWriter, r *http.Request) {
 			renderError(w, r, payloads.NewAzureError(r.Context(), "unable to fetch Azure upload info", err))
 			return
 		}
-	case models.ProviderTypeGCP, models.ProviderTypeNoop, models.ProviderTypeUnknown:
+	case models.ProviderTypeGCP, models.ProviderTypeNoop, models.ProviderTypeComposite, models.ProviderTypeUnknown:
 		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "provider is not supported", ProviderTypeNotImplementedError))
 		return
 	}
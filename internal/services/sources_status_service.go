@@ -52,6 +52,7 @@ func SourcesStatus(w stdhttp.ResponseWriter, r *stdhttp.Request) {
 			return
 		}
 	case models.ProviderTypeNoop:
+	case models.ProviderTypeComposite:
 	case models.ProviderTypeUnknown:
 	default:
 		renderError(w, r, payloads.NewStatusError(r.Context(), "unknown sources provider", UnknownProviderFromSourcesErr))
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"net/http"
+
+	"github.com/RHEnVision/provisioning-backend/internal/config"
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/RHEnVision/provisioning-backend/internal/payloads"
+	"github.com/RHEnVision/provisioning-backend/internal/userdata"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+)
+
+// maskedActivationKey replaces the activation key in user data previews.
+const maskedActivationKey = "********"
+
+// PreviewUserData renders user data of a provider specific reservation request exactly as it
+// would be sent to the provider except the activation key which is masked. Nothing is validated
+// against the provider and launched, key pair is never generated.
+func PreviewUserData(w http.ResponseWriter, r *http.Request) {
+	var input *userdata.UserData
+	pType := models.ProviderTypeFromString(chi.URLParam(r, "TYPE"))
+	switch pType {
+	case models.ProviderTypeAWS:
+		payload := &payloads.AWSReservationRequestPayload{}
+		if err := render.Bind(r, payload); err != nil {
+			renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "AWS reservation", err))
+			return
+		}
+		keys, ok := additionalPubkeys(w, r, payload.PubkeyID, payload.PubkeyIDs, false)
+		if !ok {
+			return
+		}
+		input = awsUserData(payload, keys.Bodies)
+	case models.ProviderTypeAzure:
+		if !config.FeatureEnabled(r.Context(), "azure") {
+			renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "azure reservation is not implemented", ProviderTypeNotImplementedError))
+			return
+		}
+		payload := &payloads.AzureReservationRequestPayload{}
+		if err := render.Bind(r, payload); err != nil {
+			renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "Azure reservation", err))
+			return
+		}
+		keys, ok := additionalPubkeys(w, r, payload.PubkeyID, payload.PubkeyIDs, false)
+		if !ok {
+			return
+		}
+		input = azureUserData(payload, keys.Bodies)
+	case models.ProviderTypeGCP:
+		payload := &payloads.GCPReservationRequestPayload{}
+		if err := render.Bind(r, payload); err != nil {
+			renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "GCP reservation", err))
+			return
+		}
+		keys, ok := additionalPubkeys(w, r, payload.PubkeyID, payload.PubkeyIDs, false)
+		if !ok {
+			return
+		}
+		input = gcpUserData(payload, keys.Bodies)
+	case models.ProviderTypeNoop, models.ProviderTypeComposite, models.ProviderTypeUnknown:
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "provider has no user data", ProviderTypeNotImplementedError))
+		return
+	default:
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "provider is not supported", UnknownProviderTypeError))
+		return
+	}
+
+	userData, ok := generateUserData(w, r, input)
+	if !ok {
+		return
+	}
+	hash := userdata.Hash(userData)
+	if pType == models.ProviderTypeGCP {
+		hash = userdata.Hash(userData, input.Custom)
+	}
+
+	// the activation key is a secret, it is masked in the preview but the hash includes it
+	if input.RHCActivationKey != "" {
+		masked := *input
+		masked.RHCActivationKey = maskedActivationKey
+		var err error
+		if userData, err = userdata.GenerateUserData(&masked); err != nil {
+			renderError(w, r, payloads.NewRenderError(r.Context(), "unable to generate user data", err))
+			return
+		}
+	}
+
+	// GCP runs the generated script as startup script, custom user data is passed unchanged
+	var startupScript []byte
+	if pType == models.ProviderTypeGCP {
+		startupScript, userData = userData, input.Custom
+	}
+
+	if err := render.Render(w, r, payloads.NewUserDataPreviewResponse(pType, hash, userData, startupScript)); err != nil {
+		renderError(w, r, payloads.NewRenderError(r.Context(), "unable to render user data preview", err))
+	}
+}
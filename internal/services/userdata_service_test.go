@@ -0,0 +1,87 @@
+package services_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RHEnVision/provisioning-backend/internal/dao/stubs"
+	"github.com/RHEnVision/provisioning-backend/internal/payloads"
+	"github.com/RHEnVision/provisioning-backend/internal/services"
+	"github.com/RHEnVision/provisioning-backend/internal/testing/identity"
+	_ "github.com/RHEnVision/provisioning-backend/internal/testing/initialization"
+	"github.com/RHEnVision/provisioning-backend/internal/userdata"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewUserData(t *testing.T) {
+	ctx := stubs.WithAccountDaoOne(context.Background())
+	ctx = identity.WithTenant(t, ctx)
+
+	previewRequest := func(t *testing.T, provider string, values map[string]interface{}) *httptest.ResponseRecorder {
+		t.Helper()
+		jsonData, err := json.Marshal(values)
+		require.NoError(t, err, "unable to marshal values to json")
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("TYPE", provider)
+		reqCtx := context.WithValue(ctx, chi.RouteCtxKey, rctx)
+		req, err := http.NewRequestWithContext(reqCtx, "POST", "/api/provisioning/userdata/preview/"+provider, bytes.NewBuffer(jsonData))
+		require.NoError(t, err, "failed to create request")
+		req.Header.Add("Content-Type", "application/json")
+
+		rr := httptest.NewRecorder()
+		handler := http.HandlerFunc(services.PreviewUserData)
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("renders user data of AWS reservation", func(t *testing.T) {
+		rr := previewRequest(t, "aws", map[string]interface{}{
+			"pubkey_id":          1,
+			"rhc_activation_key": "lab-key",
+			"user_data":          "#!/bin/sh\necho hello\n",
+		})
+		require.Equal(t, http.StatusOK, rr.Code, "Handler returned wrong status code")
+
+		var result payloads.UserDataPreviewResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&result), "failed to decode response body")
+		assert.Equal(t, "aws", result.Provider)
+		assert.True(t, userdata.IsMultipart([]byte(result.UserData)))
+		assert.Contains(t, result.UserData, `"--activation-key", "********"`)
+		assert.NotContains(t, result.UserData, "lab-key")
+		assert.NotEqual(t, userdata.Hash([]byte(result.UserData)), result.Hash, "hash must include the activation key")
+		assert.Contains(t, result.UserData, "echo hello")
+	})
+
+	t.Run("renders script of GCP reservation", func(t *testing.T) {
+		rr := previewRequest(t, "gcp", map[string]interface{}{
+			"poweroff":  true,
+			"user_data": "#cloud-config\npackages:\n- vim\n",
+		})
+		require.Equal(t, http.StatusOK, rr.Code, "Handler returned wrong status code")
+
+		var result payloads.UserDataPreviewResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&result), "failed to decode response body")
+		assert.Equal(t, "gcp", result.Provider)
+		assert.True(t, strings.HasPrefix(result.StartupScript, "#!"))
+		assert.Equal(t, "#cloud-config\npackages:\n- vim\n", result.UserData)
+		assert.Equal(t, userdata.Hash([]byte(result.StartupScript), []byte(result.UserData)), result.Hash)
+	})
+
+	t.Run("rejects invalid user data", func(t *testing.T) {
+		rr := previewRequest(t, "aws", map[string]interface{}{"user_data": "echo hello"})
+		assert.Equal(t, http.StatusBadRequest, rr.Code, "Handler returned wrong status code")
+	})
+
+	t.Run("rejects provider without user data", func(t *testing.T) {
+		rr := previewRequest(t, "noop", map[string]interface{}{})
+		assert.Equal(t, http.StatusBadRequest, rr.Code, "Handler returned wrong status code")
+	})
+}
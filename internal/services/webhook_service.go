@@ -0,0 +1,258 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/RHEnVision/provisioning-backend/internal/config"
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
+	"github.com/RHEnVision/provisioning-backend/internal/db"
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/RHEnVision/provisioning-backend/internal/payloads"
+	"github.com/RHEnVision/provisioning-backend/internal/publicnet"
+	"github.com/go-chi/render"
+)
+
+// Length of generated webhook secrets in bytes, secrets are hex encoded.
+const webhookSecretLength = 32
+
+var (
+	ErrMissingWebhookURL  = errors.New("url missing")
+	ErrInternalWebhookURL = errors.New("url points into internal network")
+)
+
+// generateWebhookSecret returns a random hex encoded secret.
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, webhookSecretLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("unable to generate secret: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// checkWebhookURL rejects URLs with hosts which obviously point into internal network. Hostnames
+// are resolved during delivery when connections to non-public addresses are refused as well.
+func checkWebhookURL(rawURL string) error {
+	if config.Webhook.AllowPrivate {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("unable to parse url: %w", err)
+	}
+	if publicnet.IsInternalHost(u.Hostname()) {
+		return fmt.Errorf("%w: %s", ErrInternalWebhookURL, u.Hostname())
+	}
+	return nil
+}
+
+// renderWebhookDAOError renders duplicate URL as unprocessable entity.
+func renderWebhookDAOError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	if db.IsPostgresError(err, db.UniqueConstraintErrorCode) != nil {
+		renderError(w, r, payloads.WebhookDuplicateError(r.Context(), "webhook with such url already exists for this account", err))
+	} else {
+		renderNotFoundOrDAOError(w, r, err, message)
+	}
+}
+
+func CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	payload := &payloads.WebhookRequest{}
+	if err := render.Bind(r, payload); err != nil {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "create webhook", err))
+		return
+	}
+
+	if payload.URL == "" {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), ErrMissingWebhookURL.Error(), ErrMissingWebhookURL))
+		return
+	}
+
+	webhook := payload.NewModel()
+	if webhook.Secret == "" {
+		secret, err := generateWebhookSecret()
+		if err != nil {
+			renderError(w, r, payloads.NewRenderError(r.Context(), "create webhook", err))
+			return
+		}
+		webhook.Secret = secret
+	}
+	if vErr := models.Validate(r.Context(), webhook); vErr != nil {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "webhook validation", vErr))
+		return
+	}
+	if uErr := checkWebhookURL(webhook.URL); uErr != nil {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "webhook validation", uErr))
+		return
+	}
+
+	err := dao.GetWebhookDao(r.Context()).Create(r.Context(), webhook)
+	if err != nil {
+		renderWebhookDAOError(w, r, err, "create webhook")
+		return
+	}
+
+	if err := render.Render(w, r, payloads.NewWebhookResponse(webhook, true)); err != nil {
+		renderError(w, r, payloads.NewRenderError(r.Context(), "unable to render webhook", err))
+	}
+}
+
+func ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	params, err := parseListParams(r)
+	if err != nil {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "list parameters", err))
+		return
+	}
+
+	whDao := dao.GetWebhookDao(r.Context())
+
+	webhooks, err := whDao.List(r.Context(), fetchParams(params))
+	if err != nil {
+		renderError(w, r, payloads.NewDAOError(r.Context(), "list webhooks", err))
+		return
+	}
+
+	total, err := whDao.Count(r.Context())
+	if err != nil {
+		renderError(w, r, payloads.NewDAOError(r.Context(), "count webhooks", err))
+		return
+	}
+
+	webhooks, links := listPage(r, params, webhooks, func(wh *models.Webhook) int64 { return wh.ID })
+	if err := render.Render(w, r, payloads.NewWebhookListResponse(webhooks, total, links)); err != nil {
+		renderError(w, r, payloads.NewRenderError(r.Context(), "unable to render webhooks list", err))
+		return
+	}
+}
+
+func GetWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := ParseInt64(r, "ID")
+	if err != nil {
+		renderError(w, r, payloads.NewURLParsingError(r.Context(), "unable to parse ID parameter", err))
+		return
+	}
+
+	webhook, err := dao.GetWebhookDao(r.Context()).GetById(r.Context(), id)
+	if err != nil {
+		renderNotFoundOrDAOError(w, r, err, fmt.Sprintf("get webhook with id %d", id))
+		return
+	}
+
+	if err := render.Render(w, r, payloads.NewWebhookResponse(webhook, false)); err != nil {
+		renderError(w, r, payloads.NewRenderError(r.Context(), "unable to render webhook", err))
+	}
+}
+
+func UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := ParseInt64(r, "ID")
+	if err != nil {
+		renderError(w, r, payloads.NewURLParsingError(r.Context(), "unable to parse ID parameter", err))
+		return
+	}
+
+	payload := &payloads.WebhookRequest{}
+	if err = render.Bind(r, payload); err != nil {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "update webhook", err))
+		return
+	}
+
+	whDao := dao.GetWebhookDao(r.Context())
+
+	webhook, err := whDao.GetById(r.Context(), id)
+	if err != nil {
+		renderNotFoundOrDAOError(w, r, err, fmt.Sprintf("get webhook with id %d", id))
+		return
+	}
+
+	payload.Apply(webhook)
+	if vErr := models.Validate(r.Context(), webhook); vErr != nil {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "webhook validation", vErr))
+		return
+	}
+	if uErr := checkWebhookURL(webhook.URL); uErr != nil {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "webhook validation", uErr))
+		return
+	}
+
+	err = whDao.Update(r.Context(), webhook)
+	if err != nil {
+		renderWebhookDAOError(w, r, err, fmt.Sprintf("update webhook with id %d", id))
+		return
+	}
+
+	if err := render.Render(w, r, payloads.NewWebhookResponse(webhook, payload.Secret != "")); err != nil {
+		renderError(w, r, payloads.NewRenderError(r.Context(), "unable to render webhook", err))
+	}
+}
+
+func DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := ParseInt64(r, "ID")
+	if err != nil {
+		renderError(w, r, payloads.NewURLParsingError(r.Context(), "unable to parse ID parameter", err))
+		return
+	}
+
+	err = dao.GetWebhookDao(r.Context()).Delete(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, dao.ErrAffectedMismatch) {
+			renderError(w, r, payloads.NewNotFoundError(r.Context(), fmt.Sprintf("webhook with id %d", id), err))
+		} else {
+			renderError(w, r, payloads.NewDAOError(r.Context(), fmt.Sprintf("delete webhook with id %d", id), err))
+		}
+		return
+	}
+
+	render.NoContent(w, r)
+}
+
+// ListWebhookDeliveries returns the delivery log of a webhook, failed deliveries can be listed
+// via "success=false" URL query parameter.
+func ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, err := ParseInt64(r, "ID")
+	if err != nil {
+		renderError(w, r, payloads.NewURLParsingError(r.Context(), "unable to parse ID parameter", err))
+		return
+	}
+
+	params, err := parseListParams(r)
+	if err != nil {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "list parameters", err))
+		return
+	}
+
+	success, err := ParseBool(r.URL.Query().Get("success"))
+	if err != nil {
+		renderError(w, r, payloads.NewInvalidRequestError(r.Context(), "list filter", err))
+		return
+	}
+	filter := dao.WebhookDeliveryFilter{Success: success}
+
+	whDao := dao.GetWebhookDao(r.Context())
+
+	// check the webhook exists to distinguish missing webhook from an empty log
+	if _, err = whDao.GetById(r.Context(), id); err != nil {
+		renderNotFoundOrDAOError(w, r, err, fmt.Sprintf("get webhook with id %d", id))
+		return
+	}
+
+	deliveries, err := whDao.ListDeliveries(r.Context(), id, fetchParams(params), filter)
+	if err != nil {
+		renderError(w, r, payloads.NewDAOError(r.Context(), "list webhook deliveries", err))
+		return
+	}
+
+	total, err := whDao.CountDeliveries(r.Context(), id, filter)
+	if err != nil {
+		renderError(w, r, payloads.NewDAOError(r.Context(), "count webhook deliveries", err))
+		return
+	}
+
+	deliveries, links := listPage(r, params, deliveries, func(d *models.WebhookDelivery) int64 { return d.ID })
+	if err := render.Render(w, r, payloads.NewWebhookDeliveryListResponse(deliveries, total, links)); err != nil {
+		renderError(w, r, payloads.NewRenderError(r.Context(), "unable to render webhook deliveries list", err))
+		return
+	}
+}
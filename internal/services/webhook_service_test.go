@@ -0,0 +1,160 @@
+package services_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RHEnVision/provisioning-backend/internal/dao"
+	"github.com/RHEnVision/provisioning-backend/internal/dao/stubs"
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+	"github.com/RHEnVision/provisioning-backend/internal/payloads"
+	"github.com/RHEnVision/provisioning-backend/internal/services"
+	"github.com/RHEnVision/provisioning-backend/internal/testing/identity"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func prepareWebhookContext(t *testing.T) context.Context {
+	t.Helper()
+
+	ctx := stubs.WithAccountDaoOne(context.Background())
+	ctx = identity.WithTenant(t, ctx)
+	ctx = stubs.WithWebhookDao(ctx)
+	return ctx
+}
+
+func webhookRequest(t *testing.T, ctx context.Context, method, id string, body any, handler http.HandlerFunc) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		require.NoError(t, json.NewEncoder(&buf).Encode(body), "failed to encode request body")
+	}
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("ID", id)
+	reqCtx := context.WithValue(ctx, chi.RouteCtxKey, rctx)
+	req, err := http.NewRequestWithContext(reqCtx, method, "/api/provisioning/v1/webhooks", &buf)
+	require.NoError(t, err, "failed to create request")
+	req.Header.Add("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestCreateWebhookHandler(t *testing.T) {
+	t.Run("Generated secret", func(t *testing.T) {
+		ctx := prepareWebhookContext(t)
+
+		rr := webhookRequest(t, ctx, "POST", "", payloads.WebhookRequest{URL: "https://example.com/hook"}, services.CreateWebhook)
+		require.Equal(t, http.StatusOK, rr.Code, "Wrong status code")
+
+		var webhook payloads.WebhookResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&webhook), "failed to decode response body")
+		assert.Equal(t, "https://example.com/hook", webhook.URL)
+		assert.True(t, webhook.Enabled)
+		assert.Len(t, webhook.Secret, 64)
+	})
+
+	t.Run("Invalid URL", func(t *testing.T) {
+		ctx := prepareWebhookContext(t)
+
+		rr := webhookRequest(t, ctx, "POST", "", payloads.WebhookRequest{URL: "ftp://example.com"}, services.CreateWebhook)
+		require.Equal(t, http.StatusBadRequest, rr.Code, "Wrong status code")
+	})
+
+	t.Run("Short secret", func(t *testing.T) {
+		ctx := prepareWebhookContext(t)
+
+		rr := webhookRequest(t, ctx, "POST", "", payloads.WebhookRequest{URL: "https://example.com/hook", Secret: "short"}, services.CreateWebhook)
+		require.Equal(t, http.StatusBadRequest, rr.Code, "Wrong status code")
+	})
+
+	t.Run("Internal URL", func(t *testing.T) {
+		for _, url := range []string{
+			"http://localhost:8000/hook",
+			"http://127.0.0.1/hook",
+			"http://169.254.169.254/latest/meta-data/",
+			"http://10.0.0.1/hook",
+			"http://[::1]/hook",
+			"http://redis:6379/",
+			"http://sources-api.svc/hook",
+		} {
+			ctx := prepareWebhookContext(t)
+
+			rr := webhookRequest(t, ctx, "POST", "", payloads.WebhookRequest{URL: url}, services.CreateWebhook)
+			require.Equal(t, http.StatusBadRequest, rr.Code, "Wrong status code for %s", url)
+		}
+	})
+}
+
+func TestUpdateWebhookHandler(t *testing.T) {
+	ctx := prepareWebhookContext(t)
+	webhook := &models.Webhook{URL: "https://example.com/hook", Secret: "0123456789abcdef", Enabled: true}
+	require.NoError(t, dao.GetWebhookDao(ctx).Create(ctx, webhook))
+
+	disabled := false
+	rr := webhookRequest(t, ctx, "PATCH", "1", payloads.WebhookRequest{Enabled: &disabled}, services.UpdateWebhook)
+	require.Equal(t, http.StatusOK, rr.Code, "Wrong status code")
+
+	var response payloads.WebhookResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&response), "failed to decode response body")
+	assert.False(t, response.Enabled)
+	assert.Equal(t, "https://example.com/hook", response.URL)
+	assert.Empty(t, response.Secret, "unchanged secret must not be returned")
+
+	rr = webhookRequest(t, ctx, "PATCH", "42", payloads.WebhookRequest{Enabled: &disabled}, services.UpdateWebhook)
+	require.Equal(t, http.StatusNotFound, rr.Code, "Wrong status code")
+
+	rr = webhookRequest(t, ctx, "PATCH", "1", payloads.WebhookRequest{URL: "http://192.168.1.1/hook"}, services.UpdateWebhook)
+	require.Equal(t, http.StatusBadRequest, rr.Code, "Wrong status code")
+}
+
+func TestDeleteWebhookHandler(t *testing.T) {
+	ctx := prepareWebhookContext(t)
+	webhook := &models.Webhook{URL: "https://example.com/hook", Secret: "0123456789abcdef", Enabled: true}
+	require.NoError(t, dao.GetWebhookDao(ctx).Create(ctx, webhook))
+
+	rr := webhookRequest(t, ctx, "DELETE", "1", nil, services.DeleteWebhook)
+	require.Equal(t, http.StatusNoContent, rr.Code, "Wrong status code")
+
+	rr = webhookRequest(t, ctx, "DELETE", "1", nil, services.DeleteWebhook)
+	require.Equal(t, http.StatusNotFound, rr.Code, "Wrong status code")
+}
+
+func TestListWebhookDeliveriesHandler(t *testing.T) {
+	ctx := prepareWebhookContext(t)
+	whDao := dao.GetWebhookDao(ctx)
+	webhook := &models.Webhook{URL: "https://example.com/hook", Secret: "0123456789abcdef", Enabled: true}
+	require.NoError(t, whDao.Create(ctx, webhook))
+	require.NoError(t, whDao.UnscopedCreateDelivery(ctx, &models.WebhookDelivery{WebhookID: webhook.ID, ReservationID: 1, Event: "finished", Attempt: 1, StatusCode: 500}))
+	require.NoError(t, whDao.UnscopedCreateDelivery(ctx, &models.WebhookDelivery{WebhookID: webhook.ID, ReservationID: 1, Event: "finished", Attempt: 2, StatusCode: 200, Success: true}))
+
+	t.Run("Failed deliveries", func(t *testing.T) {
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("ID", "1")
+		req, err := http.NewRequestWithContext(context.WithValue(ctx, chi.RouteCtxKey, rctx), "GET", "/api/provisioning/v1/webhooks/1/deliveries?success=false", nil)
+		require.NoError(t, err, "failed to create request")
+
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(services.ListWebhookDeliveries).ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code, "Wrong status code")
+
+		var deliveries payloads.WebhookDeliveryListResponse
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&deliveries), "failed to decode response body")
+		require.Len(t, deliveries.Data, 1)
+		assert.Equal(t, 500, deliveries.Data[0].StatusCode)
+		assert.Equal(t, int64(1), deliveries.Metadata.Total)
+	})
+
+	t.Run("Unknown webhook", func(t *testing.T) {
+		rr := webhookRequest(t, ctx, "GET", "42", nil, services.ListWebhookDeliveries)
+		require.Equal(t, http.StatusNotFound, rr.Code, "Wrong status code")
+	})
+}
@@ -0,0 +1,95 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// KeyPair is a generated SSH key pair. The public key is in the authorized_keys format, the
+// private key in the OpenSSH PEM format as written by ssh-keygen.
+type KeyPair struct {
+	PublicKey  string
+	PrivateKey string
+}
+
+const openSSHMagic = "openssh-key-v1\x00"
+
+// openSSHKey is the unencrypted OpenSSH private key container, see PROTOCOL.key in OpenSSH.
+type openSSHKey struct {
+	CipherName   string
+	KdfName      string
+	KdfOpts      string
+	NumKeys      uint32
+	PubKey       []byte
+	PrivKeyBlock []byte
+}
+
+type openSSHEd25519Key struct {
+	Check1  uint32
+	Check2  uint32
+	Keytype string
+	Pub     []byte
+	Priv    []byte
+	Comment string
+	Pad     []byte `ssh:"rest"`
+}
+
+// GenerateED25519KeyPair generates a new ed25519 key pair with the comment.
+func GenerateED25519KeyPair(comment string) (*KeyPair, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate ed25519 key: %w", err)
+	}
+
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return nil, fmt.Errorf("unable to convert ed25519 public key: %w", err)
+	}
+
+	var check [4]byte
+	if _, err = rand.Read(check[:]); err != nil {
+		return nil, fmt.Errorf("unable to generate check bytes: %w", err)
+	}
+	checkInt := binary.BigEndian.Uint32(check[:])
+
+	block := openSSHEd25519Key{
+		Check1:  checkInt,
+		Check2:  checkInt,
+		Keytype: ssh.KeyAlgoED25519,
+		Pub:     pub,
+		Priv:    priv,
+		Comment: comment,
+	}
+	// unencrypted keys are padded to the block size of 8 with bytes 1, 2, 3...
+	for i := 1; (len(ssh.Marshal(block)))%8 != 0; i++ {
+		block.Pad = append(block.Pad, byte(i))
+	}
+
+	key := openSSHKey{
+		CipherName:   "none",
+		KdfName:      "none",
+		NumKeys:      1,
+		PubKey:       sshPub.Marshal(),
+		PrivKeyBlock: ssh.Marshal(block),
+	}
+	privateKey := pem.EncodeToMemory(&pem.Block{
+		Type:  "OPENSSH PRIVATE KEY",
+		Bytes: append([]byte(openSSHMagic), ssh.Marshal(key)...),
+	})
+
+	publicKey := strings.TrimSuffix(string(ssh.MarshalAuthorizedKey(sshPub)), "\n")
+	if comment != "" {
+		publicKey += " " + comment
+	}
+
+	return &KeyPair{
+		PublicKey:  publicKey,
+		PrivateKey: string(privateKey),
+	}, nil
+}
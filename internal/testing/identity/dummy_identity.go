@@ -35,6 +35,16 @@ func WithCustomIdentity(t *testing.T, ctx context.Context, orgId string, account
 	return context.WithValue(ctx, rhidentity.Key, newIdentity(orgId, accountNumber))
 }
 
+// WithAssociateIdentity returns context with identity of a Red Hat associate (admin).
+func WithAssociateIdentity(t *testing.T, ctx context.Context) context.Context {
+	id := rhidentity.XRHID{
+		Identity: rhidentity.Identity{
+			Type: "Associate",
+		},
+	}
+	return context.WithValue(ctx, rhidentity.Key, id)
+}
+
 func WithTenant(t *testing.T, ctx context.Context) context.Context {
 	ctx = WithIdentity(t, ctx)
 	accDao := dao.GetAccountDao(ctx)
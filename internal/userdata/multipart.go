@@ -0,0 +1,119 @@
+package userdata
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+
+	"github.com/RHEnVision/provisioning-backend/internal/models"
+)
+
+var (
+	ErrUnsupportedUserData = errors.New("user data must be a cloud-config starting with #cloud-config or a script starting with #!")
+	ErrUserDataTooLarge    = errors.New("user data too large")
+)
+
+const (
+	cloudConfigType = "text/cloud-config"
+	shellScriptType = "text/x-shellscript"
+
+	// Custom cloud-config lists (e.g. runcmd or write_files) are appended to the generated
+	// ones instead of replacing them.
+	customMergeType = "list(append)+dict(recurse_array)+str()"
+
+	multipartPrefix = "Content-Type: multipart/"
+)
+
+// MaxSize returns the maximum size of user data in bytes accepted by the provider or zero
+// when there is no limit.
+func MaxSize(provider models.ProviderType) int {
+	switch provider {
+	case models.ProviderTypeAWS:
+		// 16 KB before base64 encoding
+		return 16 * 1024
+	case models.ProviderTypeAzure:
+		// 64 KB after base64 encoding
+		return 64 * 1024 / 4 * 3
+	case models.ProviderTypeGCP:
+		// 256 KB metadata value
+		return 256 * 1024
+	case models.ProviderTypeNoop, models.ProviderTypeComposite, models.ProviderTypeUnknown:
+		return 0
+	default:
+		return 0
+	}
+}
+
+// IsMultipart returns true for user data merged into a multipart archive.
+func IsMultipart(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(multipartPrefix))
+}
+
+// customContentType returns content type of user supplied user data.
+func customContentType(data []byte) (string, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte("#cloud-config")):
+		return cloudConfigType, nil
+	case bytes.HasPrefix(data, []byte("#!")):
+		return shellScriptType, nil
+	default:
+		return "", ErrUnsupportedUserData
+	}
+}
+
+// mergeMultipart creates a cloud-init multipart MIME archive from generated and custom user
+// data. The boundary is derived from the content so the same input renders the same output.
+func mergeMultipart(generated []byte, generatedType string, custom []byte) ([]byte, error) {
+	customType, err := customContentType(custom)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.New()
+	hash.Write(generated)
+	hash.Write(custom)
+	boundary := "==" + hex.EncodeToString(hash.Sum(nil))[:32] + "=="
+
+	var buffer bytes.Buffer
+	fmt.Fprintf(&buffer, "%smixed; boundary=\"%s\"\nMIME-Version: 1.0\n\n", multipartPrefix, boundary)
+
+	writer := multipart.NewWriter(&buffer)
+	if err = writer.SetBoundary(boundary); err != nil {
+		return nil, fmt.Errorf("cannot set multipart boundary: %w", err)
+	}
+
+	parts := []struct {
+		contentType string
+		filename    string
+		mergeType   string
+		body        []byte
+	}{
+		{generatedType, "provisioning", "", generated},
+		{customType, "user-data", customMergeType, custom},
+	}
+	for _, part := range parts {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", part.contentType+"; charset=\"utf-8\"")
+		header.Set("Content-Disposition", "attachment; filename=\""+part.filename+"\"")
+		if part.mergeType != "" {
+			header.Set("Merge-Type", part.mergeType)
+		}
+
+		pw, pErr := writer.CreatePart(header)
+		if pErr != nil {
+			return nil, fmt.Errorf("cannot create multipart part: %w", pErr)
+		}
+		if _, pErr = pw.Write(part.body); pErr != nil {
+			return nil, fmt.Errorf("cannot write multipart part: %w", pErr)
+		}
+	}
+
+	if err = writer.Close(); err != nil {
+		return nil, fmt.Errorf("cannot close multipart archive: %w", err)
+	}
+	return buffer.Bytes(), nil
+}
@@ -4,7 +4,9 @@ package userdata
 
 import (
 	"bytes"
+	"crypto/sha256"
 	_ "embed"
+	"encoding/hex"
 	"fmt"
 	"text/template"
 
@@ -31,6 +33,22 @@ type UserData struct {
 
 	// InsightsTags renders a first-boot script which populates /etc/insights-client/tags.yaml
 	InsightsTags bool
+
+	// RHCActivationKey enables registration with Red Hat Subscription Management and Insights
+	// via "rhc connect" at first boot. The key and organization ID must be validated by the
+	// caller, they are rendered without escaping.
+	RHCActivationKey string
+
+	// RHCOrgID is the organization ID the activation key belongs to.
+	RHCOrgID string
+
+	// SSHAuthorizedKeys are additional public keys in the authorized_keys format added to the
+	// default user via cloud-config. Not rendered for GCP, keys are passed via metadata there.
+	SSHAuthorizedKeys []string
+
+	// Custom is an optional user supplied cloud-config or shell script. When set, it is
+	// merged with the generated user data into a cloud-init multipart MIME archive.
+	Custom []byte
 }
 
 func (ud UserData) IsAWS() bool {
@@ -45,6 +63,10 @@ func (ud UserData) IsGCP() bool {
 	return ud.Type == models.ProviderTypeGCP
 }
 
+func (ud UserData) RHCConnect() bool {
+	return ud.RHCActivationKey != ""
+}
+
 //go:embed cloud-init.goyaml
 var cloudinitBuffer []byte
 var cloudinitTemplate *template.Template
@@ -65,7 +87,13 @@ func init() {
 	}
 }
 
-// GenerateUserData creates a cloud-init user-data from a build-in template.
+// GenerateUserData creates a cloud-init user-data from a build-in template. Custom user data
+// is merged with the template into a multipart archive. Returns ErrUnsupportedUserData or
+// ErrUserDataTooLarge for invalid custom user data.
+//
+// GCP is different, the generated script is passed via startup-script metadata which does not
+// need cloud-init. Custom user data is only validated and passed unchanged via user-data metadata
+// processed by cloud-init, so the script is returned without it.
 func GenerateUserData(userData *UserData) ([]byte, error) {
 	if userData.PowerOffDelayMin < 1 {
 		userData.PowerOffDelayMin = 1
@@ -75,15 +103,53 @@ func GenerateUserData(userData *UserData) ([]byte, error) {
 	}
 
 	var buffer bytes.Buffer
-	var err error
 	if userData.Type == models.ProviderTypeGCP {
-		err = scriptTemplate.Execute(&buffer, userData)
-	} else {
-		err = cloudinitTemplate.Execute(&buffer, userData)
+		if err := scriptTemplate.Execute(&buffer, userData); err != nil {
+			return nil, fmt.Errorf("cannot generate user data: %w", err)
+		}
+		if len(userData.Custom) == 0 {
+			return buffer.Bytes(), nil
+		}
+		if _, err := customContentType(userData.Custom); err != nil {
+			return nil, err
+		}
+		if err := checkSize(userData.Type, userData.Custom); err != nil {
+			return nil, err
+		}
+		return buffer.Bytes(), nil
 	}
-	if err != nil {
+
+	if err := cloudinitTemplate.Execute(&buffer, userData); err != nil {
 		return nil, fmt.Errorf("cannot generate user data: %w", err)
 	}
+	if len(userData.Custom) == 0 {
+		return buffer.Bytes(), nil
+	}
 
-	return buffer.Bytes(), nil
+	result, err := mergeMultipart(buffer.Bytes(), cloudConfigType, userData.Custom)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkSize(userData.Type, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func checkSize(provider models.ProviderType, data []byte) error {
+	if limit := MaxSize(provider); limit > 0 && len(data) > limit {
+		return fmt.Errorf("%w: %d bytes, %s limit is %d bytes", ErrUserDataTooLarge, len(data), provider.String(), limit)
+	}
+	return nil
+}
+
+// Hash returns hex encoded SHA-256 checksum of rendered user data. Data passed to the provider
+// in several values (GCP startup script and custom user data) are hashed in the given order.
+func Hash(data ...[]byte) string {
+	hash := sha256.New()
+	for _, d := range data {
+		hash.Write(d)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
 }
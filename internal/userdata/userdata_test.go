@@ -1,7 +1,13 @@
 package userdata
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
 	"regexp"
 	"strings"
 	"testing"
@@ -140,3 +146,177 @@ power_state:
 	assert.NoError(t, validateYAML(userData))
 	assert.Equal(t, expected, strings.Trim(trimRe.ReplaceAllString(string(userData), "\n"), "\n"))
 }
+
+func TestGenerateRHCConnect(t *testing.T) {
+	userDataInput := UserData{
+		Type:             models.ProviderTypeAWS,
+		InsightsTags:     true,
+		RHCActivationKey: "lab-key",
+		RHCOrgID:         "12345",
+	}
+	userData, err := GenerateUserData(&userDataInput)
+	require.NoError(t, err)
+
+	assert.NoError(t, validateYAML(userData))
+	assert.Contains(t, string(userData), `runcmd:
+- [ "/bin/sh", "-xc", "/etc/insights-client/tags-generate.sh" ]
+- [ "rhc", "connect", "--activation-key", "lab-key", "--organization", "12345" ]`)
+}
+
+func TestGenerateRHCConnectWithoutTags(t *testing.T) {
+	userDataInput := UserData{
+		Type:             models.ProviderTypeAzure,
+		RHCActivationKey: "lab-key",
+		RHCOrgID:         "12345",
+	}
+	userData, err := GenerateUserData(&userDataInput)
+	require.NoError(t, err)
+	expected := `#cloud-config
+runcmd:
+- [ "rhc", "connect", "--activation-key", "lab-key", "--organization", "12345" ]`
+
+	assert.NoError(t, validateYAML(userData))
+	assert.Equal(t, expected, strings.Trim(trimRe.ReplaceAllString(string(userData), "\n"), "\n"))
+}
+
+func TestGenerateSSHAuthorizedKeys(t *testing.T) {
+	userDataInput := UserData{
+		Type: models.ProviderTypeAWS,
+		SSHAuthorizedKeys: []string{
+			"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIEhnn80ZywmjeBFFOGm+cm+5HUwm62qTVnjKlOdYFLHN lzap",
+			`ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIEhnn80ZywmjeBFFOGm+cm+5HUwm62qTVnjKlOdYFLHN "quoted"`,
+		},
+	}
+	userData, err := GenerateUserData(&userDataInput)
+	require.NoError(t, err)
+	expected := `#cloud-config
+ssh_authorized_keys:
+- "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIEhnn80ZywmjeBFFOGm+cm+5HUwm62qTVnjKlOdYFLHN lzap"
+- "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIEhnn80ZywmjeBFFOGm+cm+5HUwm62qTVnjKlOdYFLHN \"quoted\""`
+
+	assert.NoError(t, validateYAML(userData))
+	assert.Equal(t, expected, strings.Trim(trimRe.ReplaceAllString(string(userData), "\n"), "\n"))
+}
+
+func TestGenerateGCPSSHAuthorizedKeys(t *testing.T) {
+	userDataInput := UserData{
+		Type:              models.ProviderTypeGCP,
+		SSHAuthorizedKeys: []string{"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIEhnn80ZywmjeBFFOGm+cm+5HUwm62qTVnjKlOdYFLHN lzap"},
+	}
+	userData, err := GenerateUserData(&userDataInput)
+	require.NoError(t, err)
+	assert.NotContains(t, string(userData), "ssh-ed25519")
+}
+
+func TestGenerateGCPRHCConnect(t *testing.T) {
+	userDataInput := UserData{
+		Type:             models.ProviderTypeGCP,
+		RHCActivationKey: "lab-key",
+		RHCOrgID:         "12345",
+	}
+	userData, err := GenerateUserData(&userDataInput)
+	require.NoError(t, err)
+	expected := `#! /bin/bash
+rhc connect --activation-key "lab-key" --organization "12345"
+exit 0`
+
+	assert.Equal(t, expected, strings.Trim(trimRe.ReplaceAllString(string(userData), "\n"), "\n"))
+}
+
+type userDataPart struct {
+	header textproto.MIMEHeader
+	body   string
+}
+
+func parseMultipart(t *testing.T, data []byte) []userDataPart {
+	t.Helper()
+
+	header, err := textproto.NewReader(bufio.NewReader(bytes.NewReader(data))).ReadMIMEHeader()
+	require.NoError(t, err)
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/mixed", mediaType)
+
+	var result []userDataPart
+	reader := multipart.NewReader(bytes.NewReader(data), params["boundary"])
+	for {
+		part, pErr := reader.NextPart()
+		if pErr == io.EOF {
+			break
+		}
+		require.NoError(t, pErr)
+		body, rErr := io.ReadAll(part)
+		require.NoError(t, rErr)
+		result = append(result, userDataPart{header: part.Header, body: string(body)})
+	}
+	return result
+}
+
+func TestGenerateCustomCloudConfig(t *testing.T) {
+	custom := "#cloud-config\npackages:\n- vim\n"
+	userDataInput := UserData{
+		Type:     models.ProviderTypeAWS,
+		PowerOff: true,
+		Custom:   []byte(custom),
+	}
+	userData, err := GenerateUserData(&userDataInput)
+	require.NoError(t, err)
+	assert.True(t, IsMultipart(userData))
+
+	parts := parseMultipart(t, userData)
+	require.Len(t, parts, 2)
+	assert.Equal(t, `text/cloud-config; charset="utf-8"`, parts[0].header.Get("Content-Type"))
+	assert.Contains(t, parts[0].body, "power_state:")
+	assert.Empty(t, parts[0].header.Get("Merge-Type"))
+	assert.Equal(t, `text/cloud-config; charset="utf-8"`, parts[1].header.Get("Content-Type"))
+	assert.Equal(t, "list(append)+dict(recurse_array)+str()", parts[1].header.Get("Merge-Type"))
+	assert.Equal(t, custom, parts[1].body)
+
+	again, err := GenerateUserData(&userDataInput)
+	require.NoError(t, err)
+	assert.Equal(t, userData, again, "output must be stable")
+}
+
+func TestGenerateCustomScriptGCP(t *testing.T) {
+	userDataInput := UserData{
+		Type:         models.ProviderTypeGCP,
+		InsightsTags: true,
+		Custom:       []byte("#!/bin/sh\necho hello\n"),
+	}
+	userData, err := GenerateUserData(&userDataInput)
+	require.NoError(t, err)
+
+	// custom user data is passed separately via cloud-init, startup script is not merged
+	assert.False(t, IsMultipart(userData))
+	assert.True(t, strings.HasPrefix(string(userData), "#! /bin/bash"))
+	assert.Contains(t, string(userData), "tags.yaml")
+	assert.NotContains(t, string(userData), "echo hello")
+
+	_, err = GenerateUserData(&UserData{Type: models.ProviderTypeGCP, Custom: []byte("echo hello")})
+	require.ErrorIs(t, err, ErrUnsupportedUserData)
+}
+
+func TestHash(t *testing.T) {
+	assert.Equal(t, Hash([]byte("script")), Hash([]byte("script"), nil))
+	assert.Equal(t, Hash([]byte("scriptdata")), Hash([]byte("script"), []byte("data")))
+}
+
+func TestGenerateCustomInvalid(t *testing.T) {
+	t.Run("unsupported format", func(t *testing.T) {
+		_, err := GenerateUserData(&UserData{Type: models.ProviderTypeAWS, Custom: []byte("packages: [vim]")})
+		require.ErrorIs(t, err, ErrUnsupportedUserData)
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		custom := "#!/bin/sh\n" + strings.Repeat("#", MaxSize(models.ProviderTypeAWS))
+		_, err := GenerateUserData(&UserData{Type: models.ProviderTypeAWS, Custom: []byte(custom)})
+		require.ErrorIs(t, err, ErrUserDataTooLarge)
+
+		_, err = GenerateUserData(&UserData{Type: models.ProviderTypeGCP, Custom: []byte(custom)})
+		require.NoError(t, err, "GCP limit is higher")
+
+		custom = "#!/bin/sh\n" + strings.Repeat("#", MaxSize(models.ProviderTypeGCP))
+		_, err = GenerateUserData(&UserData{Type: models.ProviderTypeGCP, Custom: []byte(custom)})
+		require.ErrorIs(t, err, ErrUserDataTooLarge)
+	})
+}
@@ -0,0 +1,56 @@
+package worker
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// cancelRegistry keeps cancel functions of jobs which are currently being processed. It is safe
+// for concurrent use.
+type cancelRegistry struct {
+	mu    sync.Mutex
+	funcs map[uuid.UUID]context.CancelFunc
+}
+
+func newCancelRegistry() *cancelRegistry {
+	return &cancelRegistry{
+		funcs: make(map[uuid.UUID]context.CancelFunc),
+	}
+}
+
+func (r *cancelRegistry) add(id uuid.UUID, cFunc context.CancelFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.funcs[id] = cFunc
+}
+
+func (r *cancelRegistry) remove(id uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.funcs, id)
+}
+
+// cancel calls the cancel function of a job and returns true, or returns false when the job
+// is not being processed.
+func (r *cancelRegistry) cancel(id uuid.UUID) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cFunc, ok := r.funcs[id]; ok {
+		cFunc()
+		return true
+	}
+	return false
+}
+
+// ids returns IDs of all jobs which are being processed.
+func (r *cancelRegistry) ids() []uuid.UUID {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := make([]uuid.UUID, 0, len(r.funcs))
+	for id := range r.funcs {
+		result = append(result, id)
+	}
+	return result
+}
@@ -1,13 +1,18 @@
 package worker
 
 import (
+	"bytes"
 	"context"
+	"encoding/gob"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/RHEnVision/provisioning-backend/internal/identity"
 	"github.com/rs/zerolog"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 func init() {
@@ -17,7 +22,9 @@ func init() {
 
 type JobType string
 
-type JobHandler func(ctx context.Context, job *Job)
+// JobHandler processes a job. When an error is returned, the job is retried according to the retry
+// policy of the job type. Jobs which fail all attempts are moved into the dead-letter queue.
+type JobHandler func(ctx context.Context, job *Job) error
 
 type Job struct {
 	// Random UUID for logging and tracing. It is generated randomly by Enqueue function when blank.
@@ -34,6 +41,47 @@ type Job struct {
 
 	// Job arguments.
 	Args any
+
+	// Attempt number starting from 1. It is increased by the worker before each attempt.
+	Attempt int
+
+	// Optional time when the job is due. Jobs are not dispatched before this time, zero value
+	// or time in the past means the job is dispatched immediately.
+	RunAt time.Time
+}
+
+// delay returns duration until the job is due or zero when it is due already.
+func (j *Job) delay() time.Duration {
+	if j.RunAt.IsZero() {
+		return 0
+	}
+	if d := time.Until(j.RunAt); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// RetryPolicy configures how failed jobs of a particular type are retried. The zero value means
+// failed jobs are never retried.
+type RetryPolicy struct {
+	// Maximum number of attempts including the first one.
+	MaxAttempts int
+
+	// Delay before the first retry, it doubles with each next attempt.
+	Backoff time.Duration
+}
+
+// Retry returns true when a job which failed the given attempt should be retried.
+func (p RetryPolicy) Retry(attempt int) bool {
+	return attempt < p.MaxAttempts
+}
+
+// Delay returns the backoff delay before the next attempt of a job which failed the given attempt.
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	if attempt < 1 {
+		return p.Backoff
+	}
+	return p.Backoff * time.Duration(1<<(attempt-1))
 }
 
 var HandlerNotFoundErr = errors.New("handler not registered")
@@ -44,11 +92,28 @@ type JobEnqueuer interface {
 	Enqueue(context.Context, *Job) error
 }
 
+// JobCanceller cancels jobs which are enqueued or being processed.
+type JobCanceller interface {
+	// Cancel cancels context of a job which is being processed. When the job has not been dequeued yet,
+	// it is processed with a cancelled context so the handler can record the outcome.
+	Cancel(context.Context, uuid.UUID) error
+}
+
+// TxJobEnqueuer sends Job messages into worker queue within a database transaction, the job
+// is only dispatched when the transaction is committed.
+type TxJobEnqueuer interface {
+	// EnqueueTx delivers a job to one of the backend workers within the transaction.
+	EnqueueTx(context.Context, pgx.Tx, *Job) error
+}
+
 // JobWorker receives and handles Job messages.
 type JobWorker interface {
 	// RegisterHandler registers an event listener for a particular type with an associated handler.
 	RegisterHandler(JobType, JobHandler, any)
 
+	// RegisterRetryPolicy sets retry policy for a particular type. Jobs are not retried by default.
+	RegisterRetryPolicy(JobType, RetryPolicy)
+
 	// DequeueLoop starts one or more goroutines to dispatch incoming jobs.
 	DequeueLoop(ctx context.Context)
 
@@ -70,6 +135,70 @@ type Stats struct {
 
 	// Number of jobs currently being processed. Local value - each client has its own number.
 	InFlight int64
+
+	// Number of failed jobs waiting for a retry. This is a global value.
+	RetryingJobs uint64
+
+	// Number of delayed jobs which are not due yet. This is a global value.
+	ScheduledJobs uint64
+
+	// Number of jobs in the dead-letter queue which failed all attempts, could not be decoded or
+	// have no handler. This is a global value.
+	DeadLetterJobs uint64
+}
+
+// permanentError is a job error which must not be retried, see Permanent.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent wraps a job handler error, so the job is not retried regardless of its retry policy.
+// It is meant for handlers which already performed steps that must not be repeated.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// attemptResult returns whether a job attempt failed and whether it can be retried. Cancelled jobs
+// are not considered failed, expired jobs and permanent errors are never retried.
+func attemptResult(ctx context.Context, err error) (bool, bool) {
+	if err == nil || errors.Is(ctx.Err(), context.Canceled) {
+		return false, false
+	}
+	var permanent *permanentError
+	return true, ctx.Err() == nil && !errors.As(err, &permanent)
+}
+
+// EncodeJob encodes a job into binary form, argument types must be registered via RegisterHandler.
+func EncodeJob(job *Job) ([]byte, error) {
+	var buffer bytes.Buffer
+	enc := gob.NewEncoder(&buffer)
+	err := enc.Encode(&job)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode args: %w", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+// DecodeJob decodes a job encoded via EncodeJob. The returned job is never nil.
+func DecodeJob(payload []byte) (*Job, error) {
+	var job Job
+	dec := gob.NewDecoder(bytes.NewReader(payload))
+	err := dec.Decode(&job)
+	if err != nil {
+		return &job, fmt.Errorf("unable to decode job: %w", err)
+	}
+	return &job, nil
 }
 
 func contextLogger(ctx context.Context, job *Job) context.Context {
@@ -0,0 +1,63 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, Backoff: 10 * time.Second}
+
+	assert.True(t, policy.Retry(1))
+	assert.True(t, policy.Retry(2))
+	assert.False(t, policy.Retry(3))
+
+	assert.Equal(t, 10*time.Second, policy.Delay(1))
+	assert.Equal(t, 20*time.Second, policy.Delay(2))
+	assert.Equal(t, 40*time.Second, policy.Delay(3))
+}
+
+func TestRetryPolicyZero(t *testing.T) {
+	var policy RetryPolicy
+
+	assert.False(t, policy.Retry(1))
+}
+
+func TestJobDelay(t *testing.T) {
+	assert.Zero(t, (&Job{}).delay())
+	assert.Zero(t, (&Job{RunAt: time.Now().Add(-time.Minute)}).delay())
+
+	delay := (&Job{RunAt: time.Now().Add(time.Hour)}).delay()
+	assert.Greater(t, delay, 59*time.Minute)
+	assert.LessOrEqual(t, delay, time.Hour)
+}
+
+func TestAttemptResult(t *testing.T) {
+	ctx := context.Background()
+	errFailure := errors.New("failure")
+
+	failed, retryable := attemptResult(ctx, nil)
+	assert.False(t, failed)
+	assert.False(t, retryable)
+
+	failed, retryable = attemptResult(ctx, errFailure)
+	assert.True(t, failed)
+	assert.True(t, retryable)
+
+	err := fmt.Errorf("wrapped: %w", Permanent(errFailure))
+	failed, retryable = attemptResult(ctx, err)
+	assert.True(t, failed)
+	assert.False(t, retryable)
+	assert.ErrorIs(t, err, errFailure)
+
+	cCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	failed, retryable = attemptResult(cCtx, errFailure)
+	assert.False(t, failed)
+	assert.False(t, retryable)
+}
@@ -2,7 +2,11 @@ package worker
 
 import (
 	"context"
+	"encoding/gob"
 	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/RHEnVision/provisioning-backend/internal/config"
 	"github.com/google/uuid"
@@ -11,18 +15,43 @@ import (
 
 type MemoryWorker struct {
 	handlers map[JobType]JobHandler
+	policies map[JobType]RetryPolicy
 	todo     chan *Job
+
+	// number of scheduled, retrying and failed jobs (must be use via atomic functions)
+	scheduled int64
+	retrying  int64
+	dead      int64
+
+	// jobs being processed and enqueued jobs which were not processed yet (true when cancelled)
+	running  *cancelRegistry
+	queuedMu sync.Mutex
+	queued   map[uuid.UUID]bool
 }
 
+var (
+	_ JobWorker    = &MemoryWorker{}
+	_ JobCanceller = &MemoryWorker{}
+)
+
 func NewMemoryClient() *MemoryWorker {
 	return &MemoryWorker{
 		handlers: make(map[JobType]JobHandler),
+		policies: make(map[JobType]RetryPolicy),
 		todo:     make(chan *Job),
+		running:  newCancelRegistry(),
+		queued:   make(map[uuid.UUID]bool),
 	}
 }
 
-func (w *MemoryWorker) RegisterHandler(jtype JobType, handler JobHandler, _ any) {
+func (w *MemoryWorker) RegisterHandler(jtype JobType, handler JobHandler, args any) {
 	w.handlers[jtype] = handler
+	// jobs are not encoded by the memory worker, but they can be stored in the outbox
+	gob.Register(args)
+}
+
+func (w *MemoryWorker) RegisterRetryPolicy(jtype JobType, policy RetryPolicy) {
+	w.policies[jtype] = policy
 }
 
 func (w *MemoryWorker) Enqueue(ctx context.Context, job *Job) error {
@@ -35,7 +64,43 @@ func (w *MemoryWorker) Enqueue(ctx context.Context, job *Job) error {
 		}
 	}
 
+	w.queuedMu.Lock()
+	w.queued[job.ID] = false
+	w.queuedMu.Unlock()
+
+	if delay := job.delay(); delay > 0 {
+		zerolog.Ctx(ctx).Info().Str("job_id", job.ID.String()).Msgf("Scheduling job type %s in %s", job.Type, delay)
+		atomic.AddInt64(&w.scheduled, 1)
+		time.AfterFunc(delay, func() {
+			atomic.AddInt64(&w.scheduled, -1)
+			w.dispatch(job)
+		})
+		return nil
+	}
+
+	// job handlers enqueue jobs too, sending from the dequeue goroutine would block it forever
+	go w.dispatch(job)
+	return nil
+}
+
+// dispatch blocks until the job is picked by the dequeue loop.
+func (w *MemoryWorker) dispatch(job *Job) {
+	// pending jobs are lost when the worker is stopped like all other in-memory jobs
+	defer func() { _ = recover() }()
 	w.todo <- job
+}
+
+func (w *MemoryWorker) Cancel(_ context.Context, id uuid.UUID) error {
+	if w.running.cancel(id) {
+		return nil
+	}
+
+	// cancel requests of unknown or already processed jobs are not kept
+	w.queuedMu.Lock()
+	defer w.queuedMu.Unlock()
+	if _, ok := w.queued[id]; ok {
+		w.queued[id] = true
+	}
 	return nil
 }
 
@@ -55,16 +120,64 @@ func (w *MemoryWorker) dequeueLoop(ctx context.Context) {
 }
 
 func (w *MemoryWorker) processJob(ctx context.Context, job *Job) {
-	if h, ok := w.handlers[job.Type]; ok {
-		ctx = contextLogger(ctx, job)
-		cCtx, cFunc := context.WithTimeout(ctx, config.Worker.Timeout)
-		defer cFunc()
-		h(cCtx, job)
-	} else {
+	h, ok := w.handlers[job.Type]
+	if !ok {
 		zerolog.Ctx(ctx).Warn().Msgf("Memory worker handler not found for job type: %s", job.Type)
+		w.forget(job.ID)
+		return
+	}
+
+	ctx = contextLogger(ctx, job)
+	logger := zerolog.Ctx(ctx)
+	policy := w.policies[job.Type]
+	failed, retryable := w.processAttempt(ctx, job, h)
+	if failed && retryable && policy.Retry(job.Attempt) {
+		delay := policy.Delay(job.Attempt)
+		logger.Info().Msgf("Job attempt %d failed, retrying in %s", job.Attempt, delay)
+		atomic.AddInt64(&w.retrying, 1)
+		// other jobs are processed while the job is waiting for the next attempt
+		time.AfterFunc(delay, func() {
+			atomic.AddInt64(&w.retrying, -1)
+			w.dispatch(job)
+		})
+		return
+	}
+
+	w.forget(job.ID)
+	if failed {
+		logger.Warn().Msgf("Job failed %d attempt(s), giving up", job.Attempt)
+		atomic.AddInt64(&w.dead, 1)
 	}
 }
 
+// forget drops the job from queued jobs once it will not be processed again.
+func (w *MemoryWorker) forget(id uuid.UUID) {
+	w.queuedMu.Lock()
+	defer w.queuedMu.Unlock()
+	delete(w.queued, id)
+}
+
+// processAttempt runs the job handler, see attemptResult for the returned values.
+func (w *MemoryWorker) processAttempt(ctx context.Context, job *Job, h JobHandler) (bool, bool) {
+	job.Attempt++
+	cCtx, cFunc := context.WithTimeout(ctx, config.Worker.Timeout)
+	defer cFunc()
+	w.running.add(job.ID, cFunc)
+	defer w.running.remove(job.ID)
+	w.queuedMu.Lock()
+	if w.queued[job.ID] {
+		cFunc()
+	}
+	w.queuedMu.Unlock()
+
+	err := h(cCtx, job)
+	return attemptResult(cCtx, err)
+}
+
 func (w *MemoryWorker) Stats(_ context.Context) (Stats, error) {
-	return Stats{}, nil
+	return Stats{
+		ScheduledJobs:  uint64(atomic.LoadInt64(&w.scheduled)),
+		RetryingJobs:   uint64(atomic.LoadInt64(&w.retrying)),
+		DeadLetterJobs: uint64(atomic.LoadInt64(&w.dead)),
+	}, nil
 }
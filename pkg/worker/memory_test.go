@@ -0,0 +1,114 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/RHEnVision/provisioning-backend/internal/config"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryWorkerCancel(t *testing.T) {
+	timeout := config.Worker.Timeout
+	config.Worker.Timeout = time.Minute
+	defer func() { config.Worker.Timeout = timeout }()
+
+	ctx := context.Background()
+	w := NewMemoryClient()
+	var handlerErr error
+	w.RegisterHandler("test", func(ctx context.Context, job *Job) error {
+		handlerErr = ctx.Err()
+		return nil
+	}, struct{ ID int }{})
+
+	t.Run("Queued", func(t *testing.T) {
+		job := &Job{Type: "test"}
+		go func() { _ = w.Enqueue(ctx, job) }()
+		job = <-w.todo
+		require.NoError(t, w.Cancel(ctx, job.ID))
+
+		w.processJob(ctx, job)
+		assert.ErrorIs(t, handlerErr, context.Canceled)
+		assert.Empty(t, w.queued)
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		require.NoError(t, w.Cancel(ctx, uuid.New()))
+		assert.Empty(t, w.queued)
+	})
+
+	t.Run("Handler not found", func(t *testing.T) {
+		job := &Job{Type: "missing"}
+		go func() { _ = w.Enqueue(ctx, job) }()
+		job = <-w.todo
+		require.NoError(t, w.Cancel(ctx, job.ID))
+
+		w.processJob(ctx, job)
+		assert.Empty(t, w.queued)
+	})
+}
+
+func TestMemoryWorkerEnqueueFromHandler(t *testing.T) {
+	timeout := config.Worker.Timeout
+	config.Worker.Timeout = time.Minute
+	defer func() { config.Worker.Timeout = timeout }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := NewMemoryClient()
+	defer w.Stop(ctx)
+	done := make(chan struct{})
+	w.RegisterHandler("parent", func(ctx context.Context, job *Job) error {
+		return w.Enqueue(ctx, &Job{Type: "child"})
+	}, struct{ Parent int }{})
+	w.RegisterHandler("child", func(ctx context.Context, job *Job) error {
+		close(done)
+		return nil
+	}, struct{ Child int }{})
+	w.DequeueLoop(ctx)
+
+	require.NoError(t, w.Enqueue(ctx, &Job{Type: "parent"}))
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job enqueued by a job handler was not processed")
+	}
+}
+
+func TestMemoryWorkerRetryDoesNotBlock(t *testing.T) {
+	timeout := config.Worker.Timeout
+	config.Worker.Timeout = time.Minute
+	defer func() { config.Worker.Timeout = timeout }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := NewMemoryClient()
+	defer w.Stop(ctx)
+	done := make(chan struct{})
+	w.RegisterHandler("failing", func(ctx context.Context, job *Job) error {
+		return errors.New("failure")
+	}, struct{ Failing int }{})
+	w.RegisterRetryPolicy("failing", RetryPolicy{MaxAttempts: 2, Backoff: time.Hour})
+	w.RegisterHandler("other", func(ctx context.Context, job *Job) error {
+		close(done)
+		return nil
+	}, struct{ Other int }{})
+	w.DequeueLoop(ctx)
+
+	require.NoError(t, w.Enqueue(ctx, &Job{Type: "failing"}))
+	require.Eventually(t, func() bool {
+		stats, _ := w.Stats(ctx)
+		return stats.RetryingJobs == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, w.Enqueue(ctx, &Job{Type: "other"}))
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job was not processed while another job was waiting for a retry")
+	}
+}
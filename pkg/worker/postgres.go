@@ -0,0 +1,360 @@
+package worker
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/RHEnVision/provisioning-backend/internal/config"
+	"github.com/RHEnVision/provisioning-backend/internal/metrics"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+)
+
+type PostgresWorker struct {
+	// the connection pool - safe for concurrent use
+	pool *pgxpool.Pool
+
+	// handler functions and retry policies
+	handlers map[JobType]JobHandler
+	policies map[JobType]RetryPolicy
+
+	// close channel
+	closeCh chan interface{}
+
+	// polling and wait groups
+	pollInterval time.Duration
+	concurrency  int
+	loopWG       sync.WaitGroup
+
+	// number of in-flight jobs (must be use via atomic functions)
+	inFlight int64
+
+	// cancel functions of jobs being processed by this worker
+	running *cancelRegistry
+}
+
+var (
+	_ JobWorker     = &PostgresWorker{}
+	_ JobCanceller  = &PostgresWorker{}
+	_ TxJobEnqueuer = &PostgresWorker{}
+)
+
+// lockMargin is added to the job timeout when a job is locked, jobs of workers which died are
+// processed again once the lock expires.
+const lockMargin = time.Minute
+
+// pgxExecutor is implemented by both the connection pool and a transaction.
+type pgxExecutor interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+// NewPostgresWorker creates new worker that keeps all jobs in the jobs table, starts N polling
+// goroutines which claim jobs via SELECT FOR UPDATE SKIP LOCKED and process them in the same
+// goroutine. Jobs can be enqueued in the same transaction as other database changes via EnqueueTx.
+func NewPostgresWorker(pool *pgxpool.Pool, pollInterval time.Duration, concurrency int) *PostgresWorker {
+	return &PostgresWorker{
+		pool:         pool,
+		handlers:     make(map[JobType]JobHandler),
+		policies:     make(map[JobType]RetryPolicy),
+		pollInterval: pollInterval,
+		concurrency:  concurrency,
+		closeCh:      make(chan interface{}),
+		running:      newCancelRegistry(),
+	}
+}
+
+func (w *PostgresWorker) RegisterHandler(jtype JobType, handler JobHandler, args any) {
+	w.handlers[jtype] = handler
+	gob.Register(args)
+}
+
+func (w *PostgresWorker) RegisterRetryPolicy(jtype JobType, policy RetryPolicy) {
+	w.policies[jtype] = policy
+}
+
+func (w *PostgresWorker) Enqueue(ctx context.Context, job *Job) error {
+	return w.enqueue(ctx, w.pool, job)
+}
+
+func (w *PostgresWorker) EnqueueTx(ctx context.Context, tx pgx.Tx, job *Job) error {
+	return w.enqueue(ctx, tx, job)
+}
+
+func (w *PostgresWorker) enqueue(ctx context.Context, executor pgxExecutor, job *Job) error {
+	var err error
+	if job.ID == uuid.Nil {
+		job.ID, err = uuid.NewRandom()
+		if err != nil {
+			return fmt.Errorf("unable to generate UUID: %w", err)
+		}
+	}
+
+	logger := loggerWithJob(ctx, job)
+	logger.Info().Msgf("Enqueuing job type %s via Postgres", job.Type)
+
+	payload, err := EncodeJob(job)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO jobs (id, type, payload, run_at)
+		VALUES ($1, $2, $3, now() + $4 * interval '1 millisecond')`
+	_, err = executor.Exec(ctx, query, job.ID, job.Type.String(), payload, job.delay().Milliseconds())
+	if err != nil {
+		logger.Error().Err(err).Msg("Unable to insert job into Postgres")
+		return fmt.Errorf("unable to insert job into Postgres: %w", err)
+	}
+
+	logger.Info().Msg("Inserted job successfully")
+	return nil
+}
+
+// Cancel marks the job as cancelled. Workers periodically check cancel flags of jobs they
+// process, jobs which were not dequeued yet are processed with a cancelled context.
+func (w *PostgresWorker) Cancel(ctx context.Context, id uuid.UUID) error {
+	zerolog.Ctx(ctx).Info().Str("job_id", id.String()).Msg("Cancelling job via Postgres")
+
+	query := `UPDATE jobs SET cancelled = true WHERE id = $1`
+	_, err := w.pool.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("unable to cancel job: %w", err)
+	}
+	return nil
+}
+
+func (w *PostgresWorker) Stop(ctx context.Context) {
+	logger := zerolog.Ctx(ctx)
+	close(w.closeCh)
+	logger.Info().Msg("Waiting for all workers to finish")
+	w.loopWG.Wait()
+	logger.Info().Msg("Done waiting for all workers to finish")
+}
+
+func (w *PostgresWorker) DequeueLoop(ctx context.Context) {
+	logger := zerolog.Ctx(ctx)
+	logger.Info().Msgf("Starting Postgres dequeuer with %d polling goroutines", w.concurrency)
+	for i := 1; i <= w.concurrency; i++ {
+		w.loopWG.Add(1)
+		go w.dequeueLoop(ctx, i, w.concurrency)
+	}
+	w.loopWG.Add(1)
+	go w.cancelLoop(ctx)
+}
+
+func (w *PostgresWorker) dequeueLoop(ctx context.Context, i, total int) {
+	defer w.loopWG.Done()
+	logger := zerolog.Ctx(ctx)
+
+	// do not crash the program on fatal errors
+	debug.SetPanicOnFault(true)
+
+	// spread polling intervals
+	delayMs := (int(w.pollInterval.Milliseconds()) / total) * (i - 1)
+	logger.Debug().Msgf("Worker start delay %dms", delayMs)
+	time.Sleep(time.Duration(delayMs) * time.Millisecond)
+
+	for {
+		// poll again immediately when a job was processed
+		found := w.fetchJob(ctx)
+		if found {
+			continue
+		}
+
+		select {
+		case <-w.closeCh:
+			logger.Info().Msg("Shutting down a Postgres poller (stop)")
+			return
+		case <-ctx.Done():
+			logger.Info().Msg("Shutting down a Postgres poller (cancel)")
+			return
+		case <-time.After(w.pollInterval):
+		}
+	}
+}
+
+// cancelLoop periodically checks cancel flags of jobs which are being processed by this worker.
+func (w *PostgresWorker) cancelLoop(ctx context.Context) {
+	defer w.loopWG.Done()
+	logger := zerolog.Ctx(ctx)
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.closeCh:
+			logger.Info().Msg("Shutting down a Postgres cancel poller (stop)")
+			return
+		case <-ctx.Done():
+			logger.Info().Msg("Shutting down a Postgres cancel poller (cancel)")
+			return
+		case <-ticker.C:
+			ids := w.running.ids()
+			if len(ids) == 0 {
+				continue
+			}
+
+			var cancelled []uuid.UUID
+			query := `SELECT id FROM jobs WHERE cancelled AND id = ANY($1)`
+			rows, err := w.pool.Query(ctx, query, ids)
+			if err != nil {
+				logger.Error().Err(err).Msg("Unable to check cancelled jobs in Postgres")
+				continue
+			}
+			cancelled, err = pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+			if err != nil {
+				logger.Error().Err(err).Msg("Unable to read cancelled jobs from Postgres")
+				continue
+			}
+
+			for _, id := range cancelled {
+				if w.running.cancel(id) {
+					logger.Info().Str("job_id", id.String()).Msg("Cancelled running job")
+				}
+			}
+		}
+	}
+}
+
+// fetchJob claims one job and processes it, it returns false when no job was available.
+func (w *PostgresWorker) fetchJob(ctx context.Context) bool {
+	defer recoverAndLog(ctx)
+
+	lockSeconds := int64((config.Worker.Timeout + lockMargin).Seconds())
+	query := `UPDATE jobs SET attempt = attempt + 1, locked_until = now() + $1 * interval '1 second'
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE NOT dead AND run_at <= now() AND (locked_until IS NULL OR locked_until < now())
+			ORDER BY run_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED)
+		RETURNING id, payload, attempt, cancelled`
+
+	var id uuid.UUID
+	var payload []byte
+	var attempt int
+	var cancelled bool
+	err := w.pool.QueryRow(ctx, query, lockSeconds).Scan(&id, &payload, &attempt, &cancelled)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false
+	} else if err != nil {
+		logger := zerolog.Ctx(ctx)
+		logger.Error().Err(err).Msg("Error consuming from Postgres queue")
+		return false
+	}
+
+	job, err := DecodeJob(payload)
+	if err != nil {
+		job.ID = id
+		logger := loggerWithJob(ctx, job)
+		logger.Error().Err(err).Msg("Unable to unmarshal job payload, moving to dead-letter queue")
+		// the row would be claimed again when the lock expires
+		deadQuery := `UPDATE jobs SET locked_until = NULL, error = $2, dead = true WHERE id = $1`
+		if _, dErr := w.pool.Exec(ctx, deadQuery, id, err.Error()); dErr != nil {
+			logger.Error().Err(dErr).Msg("Unable to update job in Postgres")
+		}
+		return true
+	}
+	// attempts are counted by the database, the encoded value is not updated on retries
+	job.Attempt = attempt - 1
+
+	atomic.AddInt64(&w.inFlight, 1)
+	w.processJob(ctx, job, cancelled)
+	return true
+}
+
+func (w *PostgresWorker) processJob(ctx context.Context, job *Job, cancelled bool) {
+	defer recoverAndLog(ctx)
+
+	defer atomic.AddInt64(&w.inFlight, -1)
+	logger := loggerWithJob(ctx, job)
+
+	logger.Info().Msg("Dequeued job from Postgres")
+	ctx = contextLogger(ctx, job)
+	h, ok := w.handlers[job.Type]
+	if !ok {
+		// handler not found
+		zerolog.Ctx(ctx).Warn().Msgf("Postgres worker handler not found for job type: %s", job.Type)
+		w.finishJob(ctx, job, HandlerNotFoundErr, false)
+		return
+	}
+
+	cCtx, cFunc := context.WithTimeout(ctx, config.Worker.Timeout)
+	defer cFunc()
+	w.running.add(job.ID, cFunc)
+	defer w.running.remove(job.ID)
+	if cancelled {
+		logger.Info().Msg("Job was cancelled before it was dequeued")
+		cFunc()
+	}
+
+	job.Attempt++
+	var err error
+	metrics.ObserveBackgroundJobDuration(job.Type.String(), func() {
+		err = h(cCtx, job)
+	})
+	failed, retryable := attemptResult(cCtx, err)
+	if !failed {
+		w.finishJob(ctx, job, nil, false)
+		return
+	}
+
+	w.finishJob(ctx, job, err, retryable)
+}
+
+// finishJob deletes a processed job, schedules a retry of a failed job or marks it dead when it
+// failed all attempts.
+func (w *PostgresWorker) finishJob(ctx context.Context, job *Job, jobErr error, retryable bool) {
+	logger := loggerWithJob(ctx, job)
+
+	var err error
+	policy := w.policies[job.Type]
+	if jobErr == nil {
+		query := `DELETE FROM jobs WHERE id = $1`
+		_, err = w.pool.Exec(ctx, query, job.ID)
+	} else if retryable && policy.Retry(job.Attempt) {
+		delay := policy.Delay(job.Attempt)
+		logger.Info().Msgf("Job attempt %d failed, retrying in %s", job.Attempt, delay)
+		query := `UPDATE jobs SET locked_until = NULL, error = $2, run_at = now() + $3 * interval '1 millisecond'
+			WHERE id = $1`
+		_, err = w.pool.Exec(ctx, query, job.ID, jobErr.Error(), delay.Milliseconds())
+	} else {
+		logger.Warn().Err(jobErr).Msgf("Job failed %d attempt(s), moving to dead-letter queue", job.Attempt)
+		query := `UPDATE jobs SET locked_until = NULL, error = $2, dead = true WHERE id = $1`
+		_, err = w.pool.Exec(ctx, query, job.ID, jobErr.Error())
+	}
+	if err != nil {
+		logger.Error().Err(err).Msg("Unable to update job in Postgres")
+	}
+}
+
+func (w *PostgresWorker) Stats(ctx context.Context) (Stats, error) {
+	query := `SELECT
+		count(*) FILTER (WHERE NOT dead AND attempt = 0 AND run_at <= now()),
+		count(*) FILTER (WHERE NOT dead AND attempt = 0 AND run_at > now()),
+		count(*) FILTER (WHERE NOT dead AND attempt > 0 AND (locked_until IS NULL OR locked_until < now())),
+		count(*) FILTER (WHERE dead)
+		FROM jobs`
+
+	var enqueued, scheduled, retrying, dead int64
+	err := w.pool.QueryRow(ctx, query).Scan(&enqueued, &scheduled, &retrying, &dead)
+	if err != nil {
+		return Stats{}, fmt.Errorf("unable to get queue stats: %w", err)
+	}
+
+	return Stats{
+		EnqueuedJobs:   uint64(enqueued),
+		InFlight:       atomic.LoadInt64(&w.inFlight),
+		ScheduledJobs:  uint64(scheduled),
+		RetryingJobs:   uint64(retrying),
+		DeadLetterJobs: uint64(dead),
+	}, nil
+}
@@ -7,7 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"runtime/debug"
-	"strings"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -23,44 +23,83 @@ type RedisWorker struct {
 	// the main client for enqueue and dequeue workers - safe for concurrent use
 	client *redis.Client
 
-	// handler functions
+	// handler functions and retry policies
 	handlers map[JobType]JobHandler
+	policies map[JobType]RetryPolicy
 
 	// queue for all jobs
 	queueName string
 
-	// close channel
-	closeCh chan interface{}
+	// unique identifier of this worker, dequeued jobs are kept in its processing list
+	workerID string
+
+	// close channels
+	closeCh     chan interface{}
+	heartbeatCh chan interface{}
 
 	// polling and wait groups
-	pollInterval time.Duration
-	concurrency  int
-	loopWG       sync.WaitGroup
+	pollInterval      time.Duration
+	heartbeatInterval time.Duration
+	concurrency       int
+	loopWG            sync.WaitGroup
+	heartbeatWG       sync.WaitGroup
 
 	// number of in-flight jobs (must be use via atomic functions)
 	inFlight int64
+
+	// cancel functions of jobs being processed by this worker
+	running *cancelRegistry
 }
 
-var _ JobWorker = &RedisWorker{}
+var (
+	_ JobWorker    = &RedisWorker{}
+	_ JobCanceller = &RedisWorker{}
+)
+
+const (
+	// cancelExpiration is how long a cancel request is kept for jobs which were not dequeued yet,
+	// it must be longer than the longest delay of scheduled jobs.
+	cancelExpiration = 8 * 24 * time.Hour
+
+	// deadLetterSize is the maximum number of jobs kept in the dead-letter list, older jobs are dropped.
+	deadLetterSize = 1000
+
+	// promoteBatchSize is the maximum number of retried or scheduled jobs promoted into the queue in one poll.
+	promoteBatchSize = 100
+
+	// heartbeatExpiration is the number of heartbeat intervals after which a worker is considered dead.
+	heartbeatExpiration = 3
+)
 
 // NewRedisWorker creates new worker that keeps all jobs in a single queue (list), starts N polling
-// goroutines which fetch jobs from the queue and process them in the same goroutine. Use the
-// Stats function to track number of in-flight jobs.
-func NewRedisWorker(address, username, password string, db int, queueName string, pollInterval time.Duration, concurrency int) (*RedisWorker, error) {
+// goroutines which fetch jobs from the queue and process them in the same goroutine. Dequeued jobs
+// are atomically moved into a processing list of the worker, workers send heartbeats and jobs of
+// workers which stopped sending heartbeats are moved back into the queue. Use the Stats function
+// to track number of in-flight jobs.
+func NewRedisWorker(address, username, password string, db int, queueName string, pollInterval, heartbeatInterval time.Duration, concurrency int) (*RedisWorker, error) {
 	rdb := redis.NewClient(&redis.Options{
 		Addr:     address,
 		Username: username,
 		Password: password,
 		DB:       db,
-		PoolSize: concurrency + 2, // number of polling goroutines + room for Stats call
+		PoolSize: concurrency + 5, // number of polling goroutines + cancel subscription + retry and heartbeat pollers + room for Stats call
 	})
+	workerID, err := uuid.NewRandom()
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate worker UUID: %w", err)
+	}
 	return &RedisWorker{
-		handlers:     make(map[JobType]JobHandler),
-		client:       rdb,
-		queueName:    queueName,
-		pollInterval: pollInterval,
-		concurrency:  concurrency,
-		closeCh:      make(chan interface{}),
+		handlers:          make(map[JobType]JobHandler),
+		policies:          make(map[JobType]RetryPolicy),
+		client:            rdb,
+		queueName:         queueName,
+		workerID:          workerID.String(),
+		pollInterval:      pollInterval,
+		heartbeatInterval: heartbeatInterval,
+		concurrency:       concurrency,
+		closeCh:           make(chan interface{}),
+		heartbeatCh:       make(chan interface{}),
+		running:           newCancelRegistry(),
 	}, nil
 }
 
@@ -69,6 +108,10 @@ func (w *RedisWorker) RegisterHandler(jtype JobType, handler JobHandler, args an
 	gob.Register(args)
 }
 
+func (w *RedisWorker) RegisterRetryPolicy(jtype JobType, policy RetryPolicy) {
+	w.policies[jtype] = policy
+}
+
 func loggerWithJob(ctx context.Context, job *Job) *zerolog.Logger {
 	logger := zerolog.Ctx(ctx).With().
 		Str("job_id", job.ID.String()).
@@ -89,14 +132,25 @@ func (w *RedisWorker) Enqueue(ctx context.Context, job *Job) error {
 	logger := loggerWithJob(ctx, job)
 	logger.Info().Msgf("Enqueuing job type %s via Redis", job.Type)
 
-	var buffer bytes.Buffer
-	enc := gob.NewEncoder(&buffer)
-	err = enc.Encode(&job)
+	payload, err := EncodeJob(job)
 	if err != nil {
-		return fmt.Errorf("unable to encode args: %w", err)
+		return err
+	}
+
+	if job.delay() > 0 {
+		err = w.client.ZAdd(ctx, w.scheduledKey(), redis.Z{
+			Score:  float64(job.RunAt.UnixMilli()),
+			Member: payload,
+		}).Err()
+		if err != nil {
+			logger.Error().Err(err).Msg("Unable to schedule job in Redis")
+			return fmt.Errorf("unable to schedule job in Redis: %w", err)
+		}
+		logger.Info().Msgf("Scheduled job successfully at %s", job.RunAt)
+		return nil
 	}
 
-	cmd := w.client.LPush(ctx, w.queueName, buffer.Bytes())
+	cmd := w.client.LPush(ctx, w.queueName, payload)
 	if cmd.Err() != nil {
 		logger.Error().Err(err).Msg("Unable to push job into Redis")
 		return fmt.Errorf("unable to push job into Redis: %w", cmd.Err())
@@ -110,21 +164,259 @@ func (w *RedisWorker) Enqueue(ctx context.Context, job *Job) error {
 	return nil
 }
 
+func (w *RedisWorker) retryKey() string {
+	return w.queueName + ":retry"
+}
+
+func (w *RedisWorker) scheduledKey() string {
+	return w.queueName + ":scheduled"
+}
+
+func (w *RedisWorker) deadLetterKey() string {
+	return w.queueName + ":dead"
+}
+
+func (w *RedisWorker) processingKey(workerID string) string {
+	return w.queueName + ":processing:" + workerID
+}
+
+func (w *RedisWorker) heartbeatKey(workerID string) string {
+	return w.queueName + ":heartbeat:" + workerID
+}
+
+func (w *RedisWorker) workersKey() string {
+	return w.queueName + ":workers"
+}
+
+func (w *RedisWorker) cancelKey(id uuid.UUID) string {
+	return w.queueName + ":cancel:" + id.String()
+}
+
+func (w *RedisWorker) cancelChannel() string {
+	return w.queueName + ":cancel"
+}
+
+// Cancel stores a cancel request for jobs which were not dequeued yet and notifies all workers
+// via pub/sub so the worker processing the job can cancel it.
+func (w *RedisWorker) Cancel(ctx context.Context, id uuid.UUID) error {
+	logger := zerolog.Ctx(ctx).With().Str("job_id", id.String()).Logger()
+	logger.Info().Msg("Cancelling job via Redis")
+
+	err := w.client.Set(ctx, w.cancelKey(id), 1, cancelExpiration).Err()
+	if err != nil {
+		return fmt.Errorf("unable to store cancel request: %w", err)
+	}
+
+	err = w.client.Publish(ctx, w.cancelChannel(), id.String()).Err()
+	if err != nil {
+		return fmt.Errorf("unable to publish cancel request: %w", err)
+	}
+	return nil
+}
+
 func (w *RedisWorker) Stop(ctx context.Context) {
 	logger := zerolog.Ctx(ctx)
 	close(w.closeCh)
 	logger.Info().Msg("Waiting for all workers to finish")
 	w.loopWG.Wait()
 	logger.Info().Msg("Done waiting for all workers to finish")
+
+	// heartbeats are sent until all jobs are finished
+	close(w.heartbeatCh)
+	w.heartbeatWG.Wait()
+	w.reapWorker(ctx, w.workerID)
 }
 
 func (w *RedisWorker) DequeueLoop(ctx context.Context) {
 	logger := zerolog.Ctx(ctx)
-	logger.Info().Msgf("Starting Redis dequeuer with %d polling goroutines", w.concurrency)
+	logger.Info().Msgf("Starting Redis dequeuer %s with %d polling goroutines", w.workerID, w.concurrency)
+	w.heartbeat(ctx)
+	w.heartbeatWG.Add(1)
+	go w.heartbeatLoop(ctx)
+
 	for i := 1; i <= w.concurrency; i++ {
 		w.loopWG.Add(1)
 		go w.dequeueLoop(ctx, i, w.concurrency)
 	}
+	w.loopWG.Add(2)
+	go w.cancelLoop(ctx)
+	go w.promoteLoop(ctx)
+}
+
+// heartbeatLoop periodically refreshes heartbeat of this worker and moves jobs of dead workers
+// back into the queue.
+func (w *RedisWorker) heartbeatLoop(ctx context.Context) {
+	defer w.heartbeatWG.Done()
+	logger := zerolog.Ctx(ctx)
+	ticker := time.NewTicker(w.heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.heartbeatCh:
+			logger.Info().Msg("Shutting down a Redis heartbeat (stop)")
+			return
+		case <-ctx.Done():
+			logger.Info().Msg("Shutting down a Redis heartbeat (cancel)")
+			return
+		case <-ticker.C:
+			w.heartbeat(ctx)
+			w.reapDeadWorkers(ctx)
+		}
+	}
+}
+
+func (w *RedisWorker) heartbeat(ctx context.Context) {
+	_, err := w.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SAdd(ctx, w.workersKey(), w.workerID)
+		pipe.Set(ctx, w.heartbeatKey(w.workerID), time.Now().Unix(), heartbeatExpiration*w.heartbeatInterval)
+		return nil
+	})
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("Unable to send heartbeat to Redis")
+	}
+}
+
+// reapDeadWorkers finds workers without heartbeat and moves their jobs back into the queue.
+func (w *RedisWorker) reapDeadWorkers(ctx context.Context) {
+	logger := zerolog.Ctx(ctx)
+
+	workerIDs, err := w.client.SMembers(ctx, w.workersKey()).Result()
+	if err != nil {
+		logger.Error().Err(err).Msg("Unable to list workers from Redis")
+		return
+	}
+
+	for _, workerID := range workerIDs {
+		if workerID == w.workerID {
+			continue
+		}
+
+		alive, existsErr := w.client.Exists(ctx, w.heartbeatKey(workerID)).Result()
+		if existsErr != nil {
+			logger.Error().Err(existsErr).Msg("Unable to check worker heartbeat in Redis")
+			continue
+		}
+		if alive == 0 {
+			logger.Warn().Msgf("Worker %s did not send heartbeat, re-queuing its jobs", workerID)
+			w.reapWorker(ctx, workerID)
+		}
+	}
+}
+
+// reapWorker moves all jobs from the processing list of a worker back into the queue and
+// unregisters the worker.
+func (w *RedisWorker) reapWorker(ctx context.Context, workerID string) {
+	logger := zerolog.Ctx(ctx)
+
+	count := 0
+	for {
+		err := w.client.LMove(ctx, w.processingKey(workerID), w.queueName, "RIGHT", "LEFT").Err()
+		if errors.Is(err, redis.Nil) {
+			break
+		} else if err != nil {
+			logger.Error().Err(err).Msgf("Unable to re-queue jobs of worker %s", workerID)
+			return
+		}
+		count++
+	}
+	if count > 0 {
+		logger.Warn().Msgf("Re-queued %d job(s) of worker %s", count, workerID)
+	}
+
+	_, err := w.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SRem(ctx, w.workersKey(), workerID)
+		pipe.Del(ctx, w.heartbeatKey(workerID))
+		return nil
+	})
+	if err != nil {
+		logger.Error().Err(err).Msgf("Unable to unregister worker %s", workerID)
+	}
+}
+
+// promoteLoop periodically moves failed jobs which are due for a retry and scheduled jobs
+// which are due back into the queue.
+func (w *RedisWorker) promoteLoop(ctx context.Context) {
+	defer w.loopWG.Done()
+	logger := zerolog.Ctx(ctx)
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.closeCh:
+			logger.Info().Msg("Shutting down a Redis retry poller (stop)")
+			return
+		case <-ctx.Done():
+			logger.Info().Msg("Shutting down a Redis retry poller (cancel)")
+			return
+		case <-ticker.C:
+			w.promoteDue(ctx, w.retryKey())
+			w.promoteDue(ctx, w.scheduledKey())
+		}
+	}
+}
+
+// promoteDue moves jobs from a sorted set scored by due time into the queue.
+func (w *RedisWorker) promoteDue(ctx context.Context, key string) {
+	logger := zerolog.Ctx(ctx).With().Str("key", key).Logger()
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+
+	members, err := w.client.ZRangeByScore(ctx, key, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   now,
+		Count: promoteBatchSize,
+	}).Result()
+	if err != nil {
+		logger.Error().Err(err).Msg("Unable to read due jobs from Redis")
+		return
+	}
+
+	for _, member := range members {
+		// only the worker which removed the job from the set pushes it into the queue
+		removed, remErr := w.client.ZRem(ctx, key, member).Result()
+		if remErr != nil {
+			logger.Error().Err(remErr).Msg("Unable to remove due job from Redis")
+			continue
+		}
+		if removed == 0 {
+			continue
+		}
+
+		pushErr := w.client.LPush(ctx, w.queueName, member).Err()
+		if pushErr != nil {
+			logger.Error().Err(pushErr).Msg("Unable to push due job into Redis")
+		}
+	}
+}
+
+func (w *RedisWorker) cancelLoop(ctx context.Context) {
+	defer w.loopWG.Done()
+	logger := zerolog.Ctx(ctx)
+
+	pubsub := w.client.Subscribe(ctx, w.cancelChannel())
+	defer pubsub.Close()
+	ch := pubsub.Channel()
+
+	for {
+		select {
+		case <-w.closeCh:
+			logger.Info().Msg("Shutting down a Redis cancel subscriber (stop)")
+			return
+		case <-ctx.Done():
+			logger.Info().Msg("Shutting down a Redis cancel subscriber (cancel)")
+			return
+		case msg := <-ch:
+			id, err := uuid.Parse(msg.Payload)
+			if err != nil {
+				logger.Warn().Err(err).Msgf("Unable to parse cancelled job id: %s", msg.Payload)
+				continue
+			}
+			if w.running.cancel(id) {
+				logger.Info().Str("job_id", id.String()).Msg("Cancelled running job")
+			}
+		}
+	}
 }
 
 func (w *RedisWorker) dequeueLoop(ctx context.Context, i, total int) {
@@ -168,7 +460,7 @@ func recoverAndLog(ctx context.Context) {
 func (w *RedisWorker) fetchJob(ctx context.Context) {
 	defer recoverAndLog(ctx)
 
-	res, err := w.client.BLPop(ctx, w.pollInterval, w.queueName).Result()
+	res, err := w.client.BLMove(ctx, w.queueName, w.processingKey(w.workerID), "LEFT", "LEFT", w.pollInterval).Result()
 
 	if errors.Is(err, redis.Nil) {
 		// timeout occurred
@@ -178,17 +470,27 @@ func (w *RedisWorker) fetchJob(ctx context.Context) {
 		logger.Error().Err(err).Msg("Error consuming from Redis queue")
 		return
 	}
+	// the job is removed from the processing list once it is processed, jobs left in the list
+	// are re-queued when the worker dies
+	defer w.ackJob(ctx, res)
 
-	var job Job
-	dec := gob.NewDecoder(strings.NewReader(res[1]))
-	err = dec.Decode(&job)
-	logger := loggerWithJob(ctx, &job)
+	job, err := DecodeJob([]byte(res))
+	logger := loggerWithJob(ctx, job)
 	if err != nil {
-		logger.Error().Err(err).Msg("Unable to unmarshal job payload, skipping")
+		logger.Error().Err(err).Msg("Unable to unmarshal job payload, moving to dead-letter queue")
+		w.pushDeadLetter(ctx, logger, []byte(res), err)
+		return
 	}
 
 	atomic.AddInt64(&w.inFlight, 1)
-	w.processJob(ctx, &job)
+	w.processJob(ctx, job)
+}
+
+func (w *RedisWorker) ackJob(ctx context.Context, payload string) {
+	err := w.client.LRem(ctx, w.processingKey(w.workerID), 1, payload).Err()
+	if err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("Unable to remove job from Redis processing list")
+	}
 }
 
 func (w *RedisWorker) processJob(ctx context.Context, job *Job) {
@@ -202,12 +504,103 @@ func (w *RedisWorker) processJob(ctx context.Context, job *Job) {
 	if h, ok := w.handlers[job.Type]; ok {
 		cCtx, cFunc := context.WithTimeout(ctx, config.Worker.Timeout)
 		defer cFunc()
+		w.running.add(job.ID, cFunc)
+		defer w.running.remove(job.ID)
+		if w.client.Exists(ctx, w.cancelKey(job.ID)).Val() > 0 {
+			logger.Info().Msg("Job was cancelled before it was dequeued")
+			cFunc()
+		}
+		job.Attempt++
+		var err error
 		metrics.ObserveBackgroundJobDuration(job.Type.String(), func() {
-			h(cCtx, job)
+			err = h(cCtx, job)
 		})
+		failed, retryable := attemptResult(cCtx, err)
+		if !failed {
+			return
+		}
+
+		policy := w.policies[job.Type]
+		if retryable && policy.Retry(job.Attempt) {
+			w.retryJob(ctx, job, policy.Delay(job.Attempt))
+		} else {
+			w.deadLetterJob(ctx, job, err)
+		}
 	} else {
 		// handler not found
 		zerolog.Ctx(ctx).Warn().Msgf("Redis worker handler not found for job type: %s", job.Type)
+		w.deadLetterJob(ctx, job, HandlerNotFoundErr)
+	}
+}
+
+// retryJob schedules a failed job to be pushed into the queue again after the given delay.
+func (w *RedisWorker) retryJob(ctx context.Context, job *Job, delay time.Duration) {
+	logger := loggerWithJob(ctx, job)
+	logger.Info().Msgf("Job attempt %d failed, retrying in %s", job.Attempt, delay)
+
+	payload, err := EncodeJob(job)
+	if err != nil {
+		logger.Error().Err(err).Msg("Unable to encode job to retry")
+		return
+	}
+
+	err = w.client.ZAdd(ctx, w.retryKey(), redis.Z{
+		Score:  float64(time.Now().Add(delay).UnixMilli()),
+		Member: payload,
+	}).Err()
+	if err != nil {
+		logger.Error().Err(err).Msg("Unable to schedule job retry in Redis")
+	}
+}
+
+// deadLetter is an entry of the Redis dead-letter list. The job is kept in the encoded form because
+// payloads which could not be decoded are dead-lettered too.
+type deadLetter struct {
+	// Encoded job as it was dequeued or after the last attempt.
+	Payload []byte
+
+	// Error of the last attempt or the decode error.
+	Error string
+
+	// Time when the job was moved into the dead-letter list.
+	FailedAt time.Time
+}
+
+// deadLetterJob pushes a job which failed all attempts or has no handler into the dead-letter list.
+func (w *RedisWorker) deadLetterJob(ctx context.Context, job *Job, jobErr error) {
+	logger := loggerWithJob(ctx, job)
+	logger.Warn().Err(jobErr).Msgf("Job failed %d attempt(s), moving to dead-letter queue", job.Attempt)
+
+	payload, err := EncodeJob(job)
+	if err != nil {
+		logger.Error().Err(err).Msg("Unable to encode failed job")
+		return
+	}
+
+	w.pushDeadLetter(ctx, logger, payload, jobErr)
+}
+
+// pushDeadLetter pushes an encoded job with the error into the dead-letter list.
+func (w *RedisWorker) pushDeadLetter(ctx context.Context, logger *zerolog.Logger, payload []byte, jobErr error) {
+	var buffer bytes.Buffer
+	entry := deadLetter{
+		Payload:  payload,
+		Error:    jobErr.Error(),
+		FailedAt: time.Now(),
+	}
+	err := gob.NewEncoder(&buffer).Encode(&entry)
+	if err != nil {
+		logger.Error().Err(err).Msg("Unable to encode dead-letter entry")
+		return
+	}
+
+	_, err = w.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LPush(ctx, w.deadLetterKey(), buffer.Bytes())
+		pipe.LTrim(ctx, w.deadLetterKey(), 0, deadLetterSize-1)
+		return nil
+	})
+	if err != nil {
+		logger.Error().Err(err).Msg("Unable to push job into Redis dead-letter queue")
 	}
 }
 
@@ -217,8 +610,26 @@ func (w *RedisWorker) Stats(ctx context.Context) (Stats, error) {
 		return Stats{}, fmt.Errorf("unable to get queue len: %w", err)
 	}
 
+	retrying, err := w.client.ZCard(ctx, w.retryKey()).Result()
+	if err != nil {
+		return Stats{}, fmt.Errorf("unable to get retry set len: %w", err)
+	}
+
+	scheduled, err := w.client.ZCard(ctx, w.scheduledKey()).Result()
+	if err != nil {
+		return Stats{}, fmt.Errorf("unable to get scheduled set len: %w", err)
+	}
+
+	dead, err := w.client.LLen(ctx, w.deadLetterKey()).Result()
+	if err != nil {
+		return Stats{}, fmt.Errorf("unable to get dead-letter queue len: %w", err)
+	}
+
 	return Stats{
-		EnqueuedJobs: uint64(count),
-		InFlight:     atomic.LoadInt64(&w.inFlight),
+		EnqueuedJobs:   uint64(count),
+		InFlight:       atomic.LoadInt64(&w.inFlight),
+		ScheduledJobs:  uint64(scheduled),
+		RetryingJobs:   uint64(retrying),
+		DeadLetterJobs: uint64(dead),
 	}, nil
 }